@@ -14,6 +14,20 @@ func main() {
 		&models.Tag{},
 		&models.VideoModel{},
 		&models.JobModel{},
+		&models.VideoVersion{},
+		&models.EncodingProfile{},
+		&models.Watermark{},
+		&models.OutboxMessage{},
+		&models.Worker{},
+		&models.WebhookSubscription{},
+		&models.WebhookDelivery{},
+		&models.Chapter{},
+		&models.AudioTrack{},
+		&models.VideoKey{},
+		&models.Session{},
+		&models.RevokedToken{},
+		&models.APIKey{},
+		&models.EmailToken{},
 	)
 	if err != nil {
 		io.WriteString(os.Stderr, err.Error())
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"flag"
+	"log/slog"
+	"os"
+
+	"github.com/joho/godotenv"
+	"github.com/unbot2313/go-streaming-service/internal/logger"
+	"github.com/unbot2313/go-streaming-service/internal/services"
+)
+
+// Convierte en administrador a un usuario ya registrado. Solo funciona mientras no
+// haya ningún administrador; los siguientes se asignan con PUT /admin/users/:id/role.
+func main() {
+	// Cargar .env
+	godotenv.Load()
+
+	// Configurar logger
+	logger.Setup()
+
+	username := flag.String("username", "", "usuario registrado que pasa a ser administrador")
+	flag.Parse()
+
+	if *username == "" {
+		slog.Error("usage: go run ./cmd/bootstrap-admin -username <username>")
+		os.Exit(2)
+	}
+
+	user, err := services.NewModerationService().BootstrapAdmin(*username)
+	if err != nil {
+		slog.Error("failed to bootstrap admin", slog.String("username", *username), slog.Any("error", err))
+		os.Exit(1)
+	}
+
+	slog.Info("user promoted to admin", slog.String("user_id", user.Id), slog.String("username", user.Username))
+}
@@ -2,30 +2,16 @@ package main
 
 import (
 	"context"
-	"encoding/json"
 	"log/slog"
 	"os"
-	"time"
+	"os/signal"
+	"syscall"
 
 	"github.com/joho/godotenv"
 	"github.com/unbot2313/go-streaming-service/config"
 	"github.com/unbot2313/go-streaming-service/internal/logger"
-	"github.com/unbot2313/go-streaming-service/internal/models"
 	"github.com/unbot2313/go-streaming-service/internal/services"
-	"github.com/unbot2313/go-streaming-service/internal/services/storage"
-)
-
-const (
-	// ProcessingTimeout es el tiempo máximo para procesar un video completo
-	ProcessingTimeout = 30 * time.Minute
-)
-
-// Servicios globales para el worker
-var (
-	jobService           services.JobService
-	videoService         services.VideoService
-	databaseVideoService services.DatabaseVideoService
-	filesService         services.FilesService
+	"github.com/unbot2313/go-streaming-service/internal/worker"
 )
 
 func main() {
@@ -38,8 +24,15 @@ func main() {
 	// Obtener configuración
 	cfg := config.GetConfig()
 
-	// Inicializar servicios
-	initServices()
+	// La cola en memoria solo existe dentro del proceso de la API
+	if cfg.QueueType == "memory" {
+		slog.Error("QUEUE_TYPE=memory runs the worker inside the API process (EMBEDDED_WORKER=true), the standalone worker needs RabbitMQ")
+		os.Exit(1)
+	}
+
+	// El contexto se cancela con SIGINT/SIGTERM para iniciar el apagado ordenado
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
 
 	// Crear servicio RabbitMQ
 	rabbitService := services.NewRabbitMQService()
@@ -52,114 +45,18 @@ func main() {
 	}
 	defer rabbitService.Close()
 
-	// Consumir mensajes de la cola de video
-	err = rabbitService.Consume(cfg.RabbitMQVideoQueue, processVideoTask)
-	if err != nil {
+	// WORKER_MODE elige la cola: transcodificación de video o generación de thumbnails
+	w := worker.New(rabbitService, cfg.WorkerMode)
+	if err := w.Start(); err != nil {
 		slog.Error("failed to start consumer", slog.Any("error", err))
 		os.Exit(1)
 	}
 
-	slog.Info("worker listening", slog.String("queue", cfg.RabbitMQVideoQueue))
-	select {} // Bloquea indefinidamente
-}
-
-// initServices inicializa los servicios necesarios para procesar videos
-func initServices() {
-	jobService = services.NewJobService()
-	filesService = services.NewFilesService()
-	storageService := storage.NewStorageService()
-	ffmpegService := services.NewFFmpegService()
-	videoService = services.NewVideoService(storageService, filesService, ffmpegService)
-	databaseVideoService = services.NewDatabaseVideoService()
-
-	slog.Info("services initialized")
-}
-
-// processVideoTask procesa una tarea de video recibida de RabbitMQ
-func processVideoTask(message []byte) error {
-	// Crear contexto con timeout para todo el procesamiento
-	ctx, cancel := context.WithTimeout(context.Background(), ProcessingTimeout)
-	defer cancel()
-
-	// 1. Parsear el mensaje JSON
-	var task models.VideoTask
-	if err := json.Unmarshal(message, &task); err != nil {
-		slog.Error("error parsing message", slog.Any("error", err))
-		return err
-	}
-
-	slog.Info("processing job",
-		slog.String("job_id", task.JobID),
-		slog.String("file", task.UniqueName),
-	)
-
-	// 2. Actualizar job a "processing"
-	if err := jobService.UpdateJobStatus(task.JobID, "processing", ""); err != nil {
-		slog.Error("error updating job to processing", slog.String("job_id", task.JobID), slog.Any("error", err))
-		return err
-	}
-
-	// 3. Convertir video a HLS (ffmpeg)
-	slog.Info("converting to HLS", slog.String("file", task.UniqueName))
-	filesPath, err := videoService.FormatVideo(ctx, task.UniqueName)
-	if err != nil {
-		slog.Error("error in FormatVideo", slog.String("job_id", task.JobID), slog.Any("error", err))
-		jobService.UpdateJobStatus(task.JobID, "failed", "Error convirtiendo video: "+err.Error())
-		return err
-	}
-
-	// 4. Generar thumbnail
-	slog.Info("generating thumbnail", slog.String("job_id", task.JobID))
-	_, err = videoService.GenerateThumbnail(ctx, task.LocalPath, filesPath)
-	if err != nil {
-		slog.Error("error in GenerateThumbnail", slog.String("job_id", task.JobID), slog.Any("error", err))
-		jobService.UpdateJobStatus(task.JobID, "failed", "Error generando thumbnail: "+err.Error())
-		filesService.RemoveFolder(filesPath)
-		return err
-	}
-
-	// 5. Subir a storage (S3 o MinIO según configuración)
-	slog.Info("uploading to storage", slog.String("job_id", task.JobID))
-	uploadResult, err := videoService.UploadFolder(ctx, filesPath)
-	if err != nil {
-		slog.Error("error uploading to storage", slog.String("job_id", task.JobID), slog.Any("error", err))
-		jobService.UpdateJobStatus(task.JobID, "failed", "Error subiendo a storage: "+err.Error())
-		filesService.RemoveFolder(filesPath)
-		return err
-	}
-
-	// 6. Guardar video en base de datos
-	slog.Info("saving to database", slog.String("job_id", task.JobID))
-	videoData := &models.Video{
-		Id:           task.JobID, // Usamos el mismo ID del job para el video
-		Title:        task.Title,
-		Description:  task.Description,
-		Duration:     task.Duration,
-		M3u8FileURL:  uploadResult.M3u8FileURL,
-		ThumbnailURL: uploadResult.ThumbnailURL,
-	}
-
-	_, err = databaseVideoService.CreateVideo(videoData, task.UserID)
-	if err != nil {
-		slog.Error("error saving to database", slog.String("job_id", task.JobID), slog.Any("error", err))
-		jobService.UpdateJobStatus(task.JobID, "failed", "Error guardando en DB: "+err.Error())
-		// Borrar de storage si falla
-		videoService.DeleteFolder(ctx, uploadResult.BaseFolder+"/")
-		filesService.RemoveFolder(filesPath)
-		return err
-	}
-
-	// 7. Actualizar job a "completed"
-	if err := jobService.UpdateJobCompleted(task.JobID, task.JobID); err != nil {
-		slog.Error("error updating job to completed", slog.String("job_id", task.JobID), slog.Any("error", err))
-		return err
-	}
-
-	// 8. Cleanup - Borrar archivos locales
-	slog.Info("cleaning up local files", slog.String("job_id", task.JobID))
-	filesService.RemoveFile(task.LocalPath) // Video original
-	filesService.RemoveFolder(filesPath)    // Carpeta con .ts y .m3u8
+	// Esperar la señal de apagado
+	<-ctx.Done()
+	stop()
 
-	slog.Info("job completed", slog.String("job_id", task.JobID))
-	return nil
+	// Dejar de consumir y esperar a los jobs en curso
+	slog.Info("shutdown signal received")
+	w.Stop(cfg.WorkerShutdownTimeout)
 }
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"flag"
+	"log/slog"
+	"os"
+
+	"github.com/joho/godotenv"
+	"github.com/unbot2313/go-streaming-service/config"
+	"github.com/unbot2313/go-streaming-service/internal/logger"
+	"github.com/unbot2313/go-streaming-service/internal/services"
+)
+
+// Genera una clave de firma nueva en JWT_KEYS_DIR y borra las reemplazadas hace más de
+// -retain. Las claves anteriores siguen validando tokens hasta que vencen todos los que firmaron.
+func main() {
+	// Cargar .env
+	godotenv.Load()
+
+	// Configurar logger
+	logger.Setup()
+
+	cfg := config.GetConfig()
+
+	algorithm := flag.String("alg", cfg.JWTSigningAlgorithm, "algoritmo de la clave nueva: RS256 o EdDSA")
+	dir := flag.String("dir", cfg.JWTKeysDir, "directorio de las claves")
+	// Los refresh tokens son los que más duran: una clave tiene que validar hasta que vence el último
+	retain := flag.Duration("retain", services.RefreshTokenTTL, "tiempo que se conserva una clave después de ser reemplazada")
+	flag.Parse()
+
+	if *algorithm != "RS256" && *algorithm != "EdDSA" {
+		slog.Error("usage: go run ./cmd/rotate-jwt-key -alg RS256|EdDSA (or set JWT_SIGNING_ALGORITHM)")
+		os.Exit(2)
+	}
+
+	keyId, err := services.GenerateJWTKey(*dir, *algorithm)
+	if err != nil {
+		slog.Error("failed to generate JWT key", slog.String("dir", *dir), slog.Any("error", err))
+		os.Exit(1)
+	}
+	slog.Info("JWT signing key generated", slog.String("kid", keyId), slog.String("alg", *algorithm), slog.String("dir", *dir))
+
+	removed, err := services.PruneJWTKeys(*dir, *retain)
+	if err != nil {
+		slog.Error("failed to prune JWT keys", slog.String("dir", *dir), slog.Any("error", err))
+		os.Exit(1)
+	}
+	for _, keyId := range removed {
+		slog.Info("expired JWT key removed", slog.String("kid", keyId))
+	}
+}
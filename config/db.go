@@ -32,7 +32,10 @@ func GetDB() (*gorm.DB, error) {
 	var err error
 	once.Do(func() {
 		dsn := getDsn()
-		dbInstance, err = gorm.Open(postgres.Open(dsn), &gorm.Config{})
+		dbInstance, err = gorm.Open(postgres.Open(dsn), &gorm.Config{
+			// Traduce los errores de Postgres (ej: clave duplicada) a los errores de GORM
+			TranslateError: true,
+		})
 	})
 	if err != nil {
 		return nil, err
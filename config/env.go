@@ -1,11 +1,15 @@
 package config
 
 import (
+	"encoding/base64"
 	"fmt"
 	"log/slog"
 	"os"
+	"runtime"
 	"strconv"
+	"strings"
 	"sync"
+	"time"
 
 	"github.com/joho/godotenv"
 )
@@ -14,6 +18,14 @@ type Config struct {
 	Port         string
 	DatabaseURL  string
 	JWTSecretKey string
+	// JWTSigningAlgorithm es el algoritmo con el que se firman los tokens: HS256, RS256 o EdDSA
+	JWTSigningAlgorithm string
+	// JWTKeysDir es el directorio con las claves privadas (<kid>.pem) de RS256/EdDSA
+	JWTKeysDir string
+	// AccessTokenTTL es la duración de los access tokens; se renuevan con el refresh token
+	AccessTokenTTL time.Duration
+	// TokenRevocationCacheTTL es cuánto se recuerda que un token no está revocado antes de volver a consultar Postgres
+	TokenRevocationCacheTTL time.Duration
 	AWSRegion	 string
 	AWSBucketName string
 	AWSAccessKey string
@@ -33,8 +45,48 @@ type Config struct {
 	RabbitMQVideoQueue     string
 	RabbitMQThumbnailQueue string
 
+	QueueType      string
+	EmbeddedWorker bool
+
+	WorkerMode              string
+	WorkerHeartbeatInterval time.Duration
+	WorkerHeartbeatTTL      time.Duration
+	WorkerMaxJobAttempts    int
+	WorkerConcurrency       int
+	WorkerShutdownTimeout   time.Duration
+	FFmpegThreads           int
+
+	WebhookMaxAttempts int
+	WebhookTimeout     time.Duration
+	WebhookAllowHTTP   bool
+
+	SourceVersionRetention time.Duration
+
+	// PublicAPIURL es la URL pública de la API, usada en las URIs de EXT-X-KEY
+	PublicAPIURL           string
+	// HLSKeyEncryptionKey cifra en Postgres las claves AES-128 de los videos (32 bytes en base64)
+	HLSKeyEncryptionKey    string
+	// HLSKeyRotationSegments rota la clave cada N segmentos; 0 usa una sola clave por video
+	HLSKeyRotationSegments int
+
 	CORSAllowedOrigins string
 
+	// AppURL es la URL del frontend; los emails llevan links a <AppURL>/verify-email y /reset-password
+	AppURL string
+	// MailerType elige cómo se envían los emails: smtp, log (solo los loguea) o file (.eml en MailFileDir)
+	MailerType   string
+	MailFrom     string
+	MailFileDir  string
+	SMTPHost     string
+	SMTPPort     int
+	SMTPUser     string
+	SMTPPassword string
+	// EmailVerificationTTL y PasswordResetTTL son la vigencia de los links de los emails
+	EmailVerificationTTL time.Duration
+	PasswordResetTTL     time.Duration
+	// RequireVerifiedEmail impide subir videos hasta verificar el email
+	RequireVerifiedEmail bool
+
 	StorageType     string
 	MinIOEndpoint   string
 	MinIOBucketName string
@@ -61,9 +113,15 @@ func GetConfig() *Config {
 			panic(fmt.Sprintf("Error al cargar el archivo .env: %v", err))
 		}
 
+		workerConcurrency := getEnvAsInt("WORKER_CONCURRENCY", 1)
+
 		config = &Config{
 			Port:         getEnv("PORT", "8080"),
 			JWTSecretKey: getEnv("JWT_SECRET_KEY", ""),
+			JWTSigningAlgorithm:     getEnv("JWT_SIGNING_ALGORITHM", "HS256"),
+			JWTKeysDir:              getEnv("JWT_KEYS_DIR", "./keys/jwt"),
+			AccessTokenTTL:          getEnvAsDuration("ACCESS_TOKEN_TTL", 15*time.Minute),
+			TokenRevocationCacheTTL: getEnvAsDuration("TOKEN_REVOCATION_CACHE_TTL", 30*time.Second),
 			LocalStoragePath: getEnv("LOCAL_STORAGE_PATH", "videos"),
 			AWSRegion:    getEnv("AWS_REGION", ""),
 			AWSBucketName: getEnv("AWS_BUCKET_NAME", ""),
@@ -83,8 +141,41 @@ func GetConfig() *Config {
 			RabbitMQVideoQueue:     getEnv("RABBITMQ_VIDEO_QUEUE", "video_processing"),
 			RabbitMQThumbnailQueue: getEnv("RABBITMQ_THUMBNAIL_QUEUE", "thumbnail_generation"),
 
+			QueueType:      getEnv("QUEUE_TYPE", "rabbitmq"),
+			EmbeddedWorker: getEnvAsBool("EMBEDDED_WORKER", false),
+
+			WorkerMode:              getEnv("WORKER_MODE", "video"),
+			WorkerHeartbeatInterval: getEnvAsDuration("WORKER_HEARTBEAT_INTERVAL", 10*time.Second),
+			WorkerHeartbeatTTL:      getEnvAsDuration("WORKER_HEARTBEAT_TTL", 45*time.Second),
+			WorkerMaxJobAttempts:    getEnvAsInt("WORKER_MAX_JOB_ATTEMPTS", 3),
+			WorkerConcurrency:       workerConcurrency,
+			WorkerShutdownTimeout:   getEnvAsDuration("WORKER_SHUTDOWN_TIMEOUT", 5*time.Minute),
+			FFmpegThreads:           getEnvAsInt("FFMPEG_THREADS", defaultFFmpegThreads(workerConcurrency)),
+
+			WebhookMaxAttempts: getEnvAsInt("WEBHOOK_MAX_ATTEMPTS", 8),
+			WebhookTimeout:     getEnvAsDuration("WEBHOOK_TIMEOUT", 10*time.Second),
+			WebhookAllowHTTP:   getEnvAsBool("WEBHOOK_ALLOW_HTTP", false),
+
+			SourceVersionRetention: getEnvAsDuration("SOURCE_VERSION_RETENTION", 7*24*time.Hour),
+
+			PublicAPIURL:           strings.TrimSuffix(getEnv("PUBLIC_API_URL", "http://localhost:3003"), "/"),
+			HLSKeyEncryptionKey:    getEnv("HLS_KEY_ENCRYPTION_KEY", ""),
+			HLSKeyRotationSegments: getEnvAsInt("HLS_KEY_ROTATION_SEGMENTS", 0),
+
 			CORSAllowedOrigins: getEnv("CORS_ALLOWED_ORIGINS", "http://localhost:3000"),
 
+			AppURL:               strings.TrimSuffix(getEnv("APP_URL", "http://localhost:3000"), "/"),
+			MailerType:           getEnv("MAILER_TYPE", "log"),
+			MailFrom:             getEnv("MAIL_FROM", "no-reply@localhost"),
+			MailFileDir:          getEnv("MAIL_FILE_DIR", "./tmp/mail"),
+			SMTPHost:             getEnv("SMTP_HOST", ""),
+			SMTPPort:             getEnvAsInt("SMTP_PORT", 587),
+			SMTPUser:             getEnv("SMTP_USER", ""),
+			SMTPPassword:         getEnv("SMTP_PASSWORD", ""),
+			EmailVerificationTTL: getEnvAsDuration("EMAIL_VERIFICATION_TTL", 48*time.Hour),
+			PasswordResetTTL:     getEnvAsDuration("PASSWORD_RESET_TTL", time.Hour),
+			RequireVerifiedEmail: getEnvAsBool("REQUIRE_VERIFIED_EMAIL", false),
+
 			StorageType:     getEnv("STORAGE_TYPE", "minio"),
 			MinIOEndpoint:   getEnv("MINIO_ENDPOINT", "localhost:9000"),
 			MinIOBucketName: getEnv("MINIO_BUCKET_NAME", "streaming-videos"),
@@ -99,21 +190,87 @@ func GetConfig() *Config {
 }
 
 func validateConfig(cfg *Config) {
-	if cfg.JWTSecretKey == "" {
+	if cfg.JWTSigningAlgorithm != "HS256" && cfg.JWTSigningAlgorithm != "RS256" && cfg.JWTSigningAlgorithm != "EdDSA" {
+		panic("JWT_SIGNING_ALGORITHM must be 'HS256', 'RS256' or 'EdDSA'")
+	}
+	// Con RS256/EdDSA el secreto es opcional: solo sirve para validar los tokens HS256 emitidos antes del cambio
+	if cfg.JWTSecretKey == "" && cfg.JWTSigningAlgorithm == "HS256" {
 		panic("JWT_SECRET_KEY environment variable is required")
 	}
-	if len(cfg.JWTSecretKey) < 32 {
+	if cfg.JWTSecretKey != "" && len(cfg.JWTSecretKey) < 32 {
 		panic("JWT_SECRET_KEY must be at least 32 characters long")
 	}
+	if cfg.AccessTokenTTL <= 0 {
+		panic("ACCESS_TOKEN_TTL must be greater than 0")
+	}
+	if cfg.TokenRevocationCacheTTL < 0 {
+		panic("TOKEN_REVOCATION_CACHE_TTL must be 0 or greater")
+	}
+
+	if cfg.QueueType != "rabbitmq" && cfg.QueueType != "memory" {
+		panic("QUEUE_TYPE must be either 'rabbitmq' or 'memory'")
+	}
+	if cfg.QueueType == "memory" && !cfg.EmbeddedWorker {
+		// La cola en memoria no se comparte entre procesos: solo la puede consumir la propia API
+		panic("QUEUE_TYPE=memory requires EMBEDDED_WORKER=true")
+	}
+
+	if cfg.WorkerMode != "video" && cfg.WorkerMode != "thumbnail" {
+		panic("WORKER_MODE must be either 'video' or 'thumbnail'")
+	}
+	if cfg.WorkerConcurrency < 1 {
+		panic("WORKER_CONCURRENCY must be at least 1")
+	}
+	if cfg.FFmpegThreads < 1 {
+		panic("FFMPEG_THREADS must be at least 1")
+	}
+	if cfg.WebhookMaxAttempts < 1 {
+		panic("WEBHOOK_MAX_ATTEMPTS must be at least 1")
+	}
+	if cfg.SourceVersionRetention <= 0 {
+		panic("SOURCE_VERSION_RETENTION must be greater than 0")
+	}
+	if cfg.MailerType != "smtp" && cfg.MailerType != "log" && cfg.MailerType != "file" {
+		panic("MAILER_TYPE must be 'smtp', 'log' or 'file'")
+	}
+	if cfg.MailerType == "smtp" && cfg.SMTPHost == "" {
+		panic("SMTP_HOST is required when MAILER_TYPE=smtp")
+	}
+	if cfg.EmailVerificationTTL <= 0 || cfg.PasswordResetTTL <= 0 {
+		panic("EMAIL_VERIFICATION_TTL and PASSWORD_RESET_TTL must be greater than 0")
+	}
+	if cfg.HLSKeyRotationSegments < 0 {
+		panic("HLS_KEY_ROTATION_SEGMENTS must be 0 or greater")
+	}
+	if cfg.HLSKeyEncryptionKey != "" {
+		if key, err := base64.StdEncoding.DecodeString(cfg.HLSKeyEncryptionKey); err != nil || len(key) != 32 {
+			panic("HLS_KEY_ENCRYPTION_KEY must be 32 bytes encoded in base64")
+		}
+	}
+	if cfg.WorkerHeartbeatTTL <= cfg.WorkerHeartbeatInterval {
+		panic("WORKER_HEARTBEAT_TTL must be greater than WORKER_HEARTBEAT_INTERVAL")
+	}
 
 	if cfg.PostgresPassword == "postgres" {
 		slog.Warn("using default PostgreSQL password, set POSTGRES_PASSWORD in .env")
 	}
-	if cfg.RabbitMQPassword == "guest" {
+	if cfg.QueueType == "rabbitmq" && cfg.RabbitMQPassword == "guest" {
 		slog.Warn("using default RabbitMQ password, set RABBITMQ_PASSWORD in .env")
 	}
 }
 
+// defaultFFmpegThreads reparte los CPUs disponibles entre los jobs que corren en paralelo
+func defaultFFmpegThreads(concurrency int) int {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	threads := runtime.NumCPU() / concurrency
+	if threads < 1 {
+		return 1
+	}
+	return threads
+}
+
 func loadEnv() error {
 	err := godotenv.Load()
 	if err != nil {
@@ -133,6 +290,24 @@ func getEnvAsBool(key string, defaultValue bool) bool {
 	return defaultValue
 }
 
+// getEnvAsInt obtiene una variable de entorno como entero o retorna un valor por defecto.
+func getEnvAsInt(key string, defaultValue int) int {
+	valStr := getEnv(key, "")
+	if val, err := strconv.Atoi(valStr); err == nil {
+		return val
+	}
+	return defaultValue
+}
+
+// getEnvAsDuration obtiene una variable de entorno como duración (ej: "30s", "5m") o retorna un valor por defecto.
+func getEnvAsDuration(key string, defaultValue time.Duration) time.Duration {
+	valStr := getEnv(key, "")
+	if val, err := time.ParseDuration(valStr); err == nil {
+		return val
+	}
+	return defaultValue
+}
+
 func getEnv(key, defaultValue string) string {
 	value, exists := os.LookupEnv(key)
 	if !exists {
@@ -0,0 +1,17 @@
+package app
+
+import (
+	"github.com/unbot2313/go-streaming-service/internal/services"
+	"github.com/unbot2313/go-streaming-service/internal/worker"
+)
+
+// startEmbeddedWorker corre el worker de video y thumbnails dentro del proceso de la API
+// (EMBEDDED_WORKER=true). Se drena en Shutdown junto con el resto de las tareas de fondo.
+func startEmbeddedWorker(queueService services.RabbitMQService) *worker.Worker {
+	w := worker.New(queueService, worker.ModeVideo, worker.ModeThumbnail)
+	if err := w.Start(); err != nil {
+		panic("Could not start embedded worker: " + err.Error())
+	}
+
+	return w
+}
@@ -1,20 +1,33 @@
 package app
 
 import (
+	"context"
+
+	"github.com/unbot2313/go-streaming-service/config"
 	"github.com/unbot2313/go-streaming-service/internal/controllers"
+	"github.com/unbot2313/go-streaming-service/internal/routes"
 	"github.com/unbot2313/go-streaming-service/internal/services"
+	"github.com/unbot2313/go-streaming-service/internal/services/mailer"
 	"github.com/unbot2313/go-streaming-service/internal/services/storage"
 )
 
-// InitializeComponents crea las instancias de los servicios y controladores
-func InitializeComponents() (controllers.UserController, controllers.AuthController, controllers.VideoController, controllers.JobController, controllers.TagController, services.AuthService) {
+// Components son los controladores y servicios que necesitan las rutas
+type Components struct {
+	Controllers routes.Controllers
+	Services    routes.Services
+}
+
+// InitializeComponents crea las instancias de los servicios y controladores.
+// Las tareas de fondo corren hasta que se cancele ctx; después hay que llamar a Shutdown.
+func InitializeComponents(ctx context.Context) Components {
 	// Inicializa los servicios base
 	userService := services.NewUserService()
 	authService := services.NewAuthService()
 
 	// Inicializa los controladores de usuario y auth
 	userController := controllers.NewUserController(userService)
-	authController := controllers.NewAuthController(authService, userService)
+	accountEmailService := services.NewAccountEmailService(mailer.NewMailer())
+	authController := controllers.NewAuthController(authService, userService, accountEmailService)
 
 	// Inicializa servicios de video con StorageService genérico
 	storageService := storage.NewStorageService()
@@ -23,20 +36,69 @@ func InitializeComponents() (controllers.UserController, controllers.AuthControl
 	videoService := services.NewVideoService(storageService, filesService, ffmpegService)
 	databaseVideoService := services.NewDatabaseVideoService()
 
-	// Inicializa servicios de jobs y RabbitMQ (conexión persistente)
+	// Inicializa servicios de jobs y la cola (RabbitMQ con conexión persistente o en memoria)
 	jobService := services.NewJobService()
-	rabbitMQService := services.NewRabbitMQService()
-	if err := rabbitMQService.Connect(); err != nil {
+	queueService := services.NewQueueService()
+	if err := queueService.Connect(); err != nil {
 		panic("Could not connect to RabbitMQ: " + err.Error())
 	}
 
+	// El relay publica en la cola las tareas guardadas en el outbox
+	outboxRelay := services.NewOutboxRelay(services.NewOutboxService(), queueService)
+	background.queueService = queueService
+	background.relay.Add(1)
+	go func() {
+		defer background.relay.Done()
+		outboxRelay.Start(ctx)
+	}()
+
+	// En modo embebido la API también procesa las colas, sin un worker aparte
+	if config.GetConfig().EmbeddedWorker {
+		background.embeddedWorker = startEmbeddedWorker(queueService)
+	}
+
 	// Inicializa servicios de tags
 	tagService := services.NewTagService()
 
 	// Inicializa controladores
-	videoController := controllers.NewVideoController(videoService, databaseVideoService, jobService, rabbitMQService)
+	hlsKeyService := services.NewHLSKeyService()
+	thumbnailService := services.NewThumbnailService(storageService, ffmpegService, filesService, hlsKeyService)
+	videoVersionService := services.NewVideoVersionService(storageService)
+	videoController := controllers.NewVideoController(videoService, databaseVideoService, jobService, thumbnailService, videoVersionService)
 	jobController := controllers.NewJobController(jobService)
 	tagController := controllers.NewTagController(tagService, databaseVideoService)
+	adminController := controllers.NewAdminController(services.NewWorkerService(), jobService, services.NewQueueStatsService(queueService, jobService), services.NewEncodingProfileService())
+	webhookController := controllers.NewWebhookController(services.NewWebhookService())
+	podcastController := controllers.NewPodcastController(services.NewPodcastService())
+	watermarkController := controllers.NewWatermarkController(services.NewWatermarkService(storageService, filesService))
+	chapterController := controllers.NewChapterController(services.NewChapterService(), databaseVideoService)
+	audioTrackController := controllers.NewAudioTrackController(services.NewAudioTrackService(storageService, filesService, ffmpegService, hlsKeyService), databaseVideoService)
+	keyController := controllers.NewKeyController(hlsKeyService, databaseVideoService)
+	moderationController := controllers.NewModerationController(services.NewModerationService(), userService, databaseVideoService)
+	apiKeyService := services.NewAPIKeyService()
+	apiKeyController := controllers.NewAPIKeyController(apiKeyService)
 
-	return userController, authController, videoController, jobController, tagController, authService
+	return Components{
+		Controllers: routes.Controllers{
+			User:       userController,
+			Auth:       authController,
+			Video:      videoController,
+			Job:        jobController,
+			Tag:        tagController,
+			Admin:      adminController,
+			Webhook:    webhookController,
+			Podcast:    podcastController,
+			Watermark:  watermarkController,
+			Chapter:    chapterController,
+			AudioTrack: audioTrackController,
+			Key:        keyController,
+			Moderation: moderationController,
+			APIKey:     apiKeyController,
+		},
+		Services: routes.Services{
+			Auth:         authService,
+			APIKey:       apiKeyService,
+			AccountEmail: accountEmailService,
+		},
+	}
 }
@@ -0,0 +1,42 @@
+package app
+
+import (
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/unbot2313/go-streaming-service/internal/services"
+	"github.com/unbot2313/go-streaming-service/internal/worker"
+)
+
+// background agrupa las tareas de fondo de la API que hay que drenar antes de cerrar la cola
+var background struct {
+	relay          sync.WaitGroup
+	embeddedWorker *worker.Worker
+	queueService   services.RabbitMQService
+}
+
+// Shutdown drena el worker embebido, espera a que el relay del outbox termine la publicación
+// en curso y recién entonces cierra la conexión con la cola. El contexto pasado a
+// InitializeComponents tiene que estar cancelado, si no el relay no se detiene.
+func Shutdown(timeout time.Duration) {
+	if background.embeddedWorker != nil {
+		background.embeddedWorker.Stop(timeout)
+	}
+
+	relayDone := make(chan struct{})
+	go func() {
+		background.relay.Wait()
+		close(relayDone)
+	}()
+
+	select {
+	case <-relayDone:
+	case <-time.After(timeout):
+		slog.Warn("outbox relay did not stop before the shutdown timeout")
+	}
+
+	if background.queueService != nil {
+		background.queueService.Close()
+	}
+}
@@ -0,0 +1,194 @@
+package controllers
+
+import (
+	"net/http"
+	"regexp"
+
+	"github.com/gin-gonic/gin"
+	"github.com/unbot2313/go-streaming-service/internal/helpers"
+	"github.com/unbot2313/go-streaming-service/internal/models"
+	"github.com/unbot2313/go-streaming-service/internal/services"
+)
+
+type AdminController interface {
+	GetWorkers(c *gin.Context)
+	GetQueues(c *gin.Context)
+	SetJobPriority(c *gin.Context)
+	GetEncodingProfiles(c *gin.Context)
+	SaveEncodingProfile(c *gin.Context)
+}
+
+type AdminControllerImpl struct {
+	workerService          services.WorkerService
+	jobService             services.JobService
+	queueStatsService      services.QueueStatsService
+	encodingProfileService services.EncodingProfileService
+}
+
+func NewAdminController(workerService services.WorkerService, jobService services.JobService, queueStatsService services.QueueStatsService, encodingProfileService services.EncodingProfileService) AdminController {
+	return &AdminControllerImpl{
+		workerService:          workerService,
+		jobService:             jobService,
+		queueStatsService:      queueStatsService,
+		encodingProfileService: encodingProfileService,
+	}
+}
+
+// SetJobPriorityRequest valida la prioridad que asigna un administrador
+type SetJobPriorityRequest struct {
+	Priority *int `json:"priority" binding:"required,min=0,max=10"`
+}
+
+// SaveEncodingProfileRequest valida las opciones de un perfil de codificación
+type SaveEncodingProfileRequest struct {
+	Description string `json:"description" binding:"max=255"`
+	Preview     *bool  `json:"preview" binding:"required"`
+	// Loudnorm es opcional; si no se envía el perfil no normaliza el volumen
+	Loudnorm bool `json:"loudnorm"`
+}
+
+// encodingProfileNamePattern son los nombres de perfil válidos (se usan en el formulario de upload)
+var encodingProfileNamePattern = regexp.MustCompile(`^[a-z0-9_-]{1,50}$`)
+
+// GetWorkers godoc
+// @Summary		List processing workers
+// @Description	List the video workers with their last heartbeat, current job and whether they are alive. Requires the admin role.
+// @Tags		admin
+// @Produce		json
+// @Security	BearerAuth
+// @Success		200 {object} helpers.APIResponse{data=[]models.WorkerSwagger}
+// @Failure		401 {object} helpers.APIResponse{error=helpers.APIError}
+// @Failure		403 {object} helpers.APIResponse{error=helpers.APIError}
+// @Failure		500 {object} helpers.APIResponse{error=helpers.APIError}
+// @Router		/admin/workers [get]
+func (ac *AdminControllerImpl) GetWorkers(c *gin.Context) {
+	workers, err := ac.workerService.ListWorkers()
+	if err != nil {
+		helpers.HandleError(c, http.StatusInternalServerError, "Could not retrieve workers", err)
+		return
+	}
+
+	helpers.Success(c, http.StatusOK, workers)
+}
+
+// GetQueues godoc
+// @Summary		List processing queues
+// @Description	Waiting messages and consumers of the video and thumbnail queues, with the depth per priority level (highest first). With RabbitMQ the video queue depth per priority is the number of pending jobs of each priority. Requires the admin role.
+// @Tags		admin
+// @Produce		json
+// @Security	BearerAuth
+// @Success		200 {object} helpers.APIResponse{data=[]models.QueueStats}
+// @Failure		401 {object} helpers.APIResponse{error=helpers.APIError}
+// @Failure		403 {object} helpers.APIResponse{error=helpers.APIError}
+// @Failure		500 {object} helpers.APIResponse{error=helpers.APIError}
+// @Router		/admin/queues [get]
+func (ac *AdminControllerImpl) GetQueues(c *gin.Context) {
+	queues, err := ac.queueStatsService.ListQueues()
+	if err != nil {
+		helpers.HandleError(c, http.StatusInternalServerError, "Could not retrieve queues", err)
+		return
+	}
+
+	helpers.Success(c, http.StatusOK, queues)
+}
+
+// SetJobPriority godoc
+// @Summary		Override a job's priority
+// @Description	Set the priority of a job in the video queue (0 to 10, higher is processed first). A pending job is republished with the new priority. Requires the admin role.
+// @Tags		admin
+// @Accept		json
+// @Produce		json
+// @Security	BearerAuth
+// @Param		jobid path string true "Job ID"
+// @Param		body body SetJobPriorityRequest true "New priority"
+// @Success		200 {object} helpers.APIResponse{data=models.JobSwagger}
+// @Failure		400 {object} helpers.APIResponse{error=helpers.APIError}
+// @Failure		401 {object} helpers.APIResponse{error=helpers.APIError}
+// @Failure		403 {object} helpers.APIResponse{error=helpers.APIError}
+// @Failure		404 {object} helpers.APIResponse{error=helpers.APIError}
+// @Failure		500 {object} helpers.APIResponse{error=helpers.APIError}
+// @Router		/admin/jobs/{jobid}/priority [patch]
+func (ac *AdminControllerImpl) SetJobPriority(c *gin.Context) {
+	var req SetJobPriorityRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		helpers.HandleError(c, http.StatusBadRequest, "priority must be between 0 and 10", err)
+		return
+	}
+
+	jobId := c.Param("jobid")
+	if _, err := ac.jobService.GetJobByID(jobId); err != nil {
+		helpers.HandleError(c, http.StatusNotFound, "Job not found", err)
+		return
+	}
+
+	job, err := ac.jobService.SetJobPriority(jobId, *req.Priority)
+	if err != nil {
+		helpers.HandleError(c, http.StatusInternalServerError, "Could not update job priority", err)
+		return
+	}
+
+	helpers.Success(c, http.StatusOK, job)
+}
+
+// GetEncodingProfiles godoc
+// @Summary		List encoding profiles
+// @Description	List the encoding profiles that uploads can choose with the encoding_profile field. Requires the admin role.
+// @Tags		admin
+// @Produce		json
+// @Security	BearerAuth
+// @Success		200 {object} helpers.APIResponse{data=[]models.EncodingProfileSwagger}
+// @Failure		401 {object} helpers.APIResponse{error=helpers.APIError}
+// @Failure		403 {object} helpers.APIResponse{error=helpers.APIError}
+// @Failure		500 {object} helpers.APIResponse{error=helpers.APIError}
+// @Router		/admin/encoding-profiles [get]
+func (ac *AdminControllerImpl) GetEncodingProfiles(c *gin.Context) {
+	profiles, err := ac.encodingProfileService.ListProfiles()
+	if err != nil {
+		helpers.HandleError(c, http.StatusInternalServerError, "Could not retrieve encoding profiles", err)
+		return
+	}
+
+	helpers.Success(c, http.StatusOK, profiles)
+}
+
+// SaveEncodingProfile godoc
+// @Summary		Create or update an encoding profile
+// @Description	Create an encoding profile or update its options. Changes apply to the next uploads; videos already processed are not reprocessed. Requires the admin role.
+// @Tags		admin
+// @Accept		json
+// @Produce		json
+// @Security	BearerAuth
+// @Param		name path string true "Profile name (lowercase letters, digits, - and _)"
+// @Param		body body SaveEncodingProfileRequest true "Profile options"
+// @Success		200 {object} helpers.APIResponse{data=models.EncodingProfileSwagger}
+// @Failure		400 {object} helpers.APIResponse{error=helpers.APIError}
+// @Failure		401 {object} helpers.APIResponse{error=helpers.APIError}
+// @Failure		403 {object} helpers.APIResponse{error=helpers.APIError}
+// @Failure		500 {object} helpers.APIResponse{error=helpers.APIError}
+// @Router		/admin/encoding-profiles/{name} [put]
+func (ac *AdminControllerImpl) SaveEncodingProfile(c *gin.Context) {
+	name := c.Param("name")
+	if !encodingProfileNamePattern.MatchString(name) {
+		helpers.HandleError(c, http.StatusBadRequest, "Profile name must be 1 to 50 lowercase letters, digits, - or _", nil)
+		return
+	}
+
+	var req SaveEncodingProfileRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		helpers.HandleError(c, http.StatusBadRequest, "preview is required and description must be at most 255 characters", err)
+		return
+	}
+
+	profile, err := ac.encodingProfileService.SaveProfile(&models.EncodingProfile{
+		Name:        name,
+		Description: req.Description,
+		Preview:     *req.Preview,
+		Loudnorm:    req.Loudnorm,
+	})
+	if err != nil {
+		helpers.HandleError(c, http.StatusInternalServerError, "Could not save encoding profile", err)
+		return
+	}
+
+	helpers.Success(c, http.StatusOK, profile)
+}
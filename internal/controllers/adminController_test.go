@@ -0,0 +1,262 @@
+package controllers
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/unbot2313/go-streaming-service/internal/mocks"
+	"github.com/unbot2313/go-streaming-service/internal/models"
+)
+
+func setupAdminRouter(controller AdminController) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/admin/workers", controller.GetWorkers)
+	r.GET("/admin/queues", controller.GetQueues)
+	r.PATCH("/admin/jobs/:jobid/priority", controller.SetJobPriority)
+	r.GET("/admin/encoding-profiles", controller.GetEncodingProfiles)
+	r.PUT("/admin/encoding-profiles/:name", controller.SaveEncodingProfile)
+	return r
+}
+
+func TestGetWorkers_Success(t *testing.T) {
+	mockWorker := &mocks.MockWorkerService{
+		ListWorkersFn: func() ([]models.Worker, error) {
+			return []models.Worker{
+				{Id: "worker-1", Hostname: "host-1", Concurrency: 2, CurrentJobs: []string{"job-123"}, LastHeartbeatAt: time.Now(), Alive: true},
+				{Id: "worker-2", Hostname: "host-2", LastHeartbeatAt: time.Now().Add(-time.Hour), Alive: false},
+			}, nil
+		},
+	}
+
+	controller := NewAdminController(mockWorker, nil, nil, nil)
+	router := setupAdminRouter(controller)
+
+	req, _ := http.NewRequest("GET", "/admin/workers", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("expected status %d, got %d", http.StatusOK, w.Code)
+	}
+
+	var response map[string]interface{}
+	json.Unmarshal(w.Body.Bytes(), &response)
+
+	data := response["data"].([]interface{})
+	if len(data) != 2 {
+		t.Fatalf("expected 2 workers, got %d", len(data))
+	}
+
+	first := data[0].(map[string]interface{})
+	currentJobs := first["current_jobs"].([]interface{})
+	if len(currentJobs) != 1 || currentJobs[0] != "job-123" || first["alive"] != true {
+		t.Errorf("unexpected worker data: %v", first)
+	}
+}
+
+func TestGetWorkers_Error(t *testing.T) {
+	mockWorker := &mocks.MockWorkerService{
+		ListWorkersFn: func() ([]models.Worker, error) {
+			return nil, errors.New("database error")
+		},
+	}
+
+	controller := NewAdminController(mockWorker, nil, nil, nil)
+	router := setupAdminRouter(controller)
+
+	req, _ := http.NewRequest("GET", "/admin/workers", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf("expected status %d, got %d", http.StatusInternalServerError, w.Code)
+	}
+}
+
+func TestGetQueues_Success(t *testing.T) {
+	mockQueueStats := &mocks.MockQueueStatsService{
+		ListQueuesFn: func() ([]models.QueueStats, error) {
+			return []models.QueueStats{
+				{Queue: "video_processing", Messages: 3, Consumers: 2, Priorities: []models.QueuePriorityDepth{{Priority: 6, Messages: 1}, {Priority: 1, Messages: 2}}},
+				{Queue: "thumbnail_generation", Priorities: []models.QueuePriorityDepth{}},
+			}, nil
+		},
+	}
+
+	controller := NewAdminController(nil, nil, mockQueueStats, nil)
+	router := setupAdminRouter(controller)
+
+	req, _ := http.NewRequest("GET", "/admin/queues", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("expected status %d, got %d", http.StatusOK, w.Code)
+	}
+
+	var response map[string]interface{}
+	json.Unmarshal(w.Body.Bytes(), &response)
+
+	data := response["data"].([]interface{})
+	video := data[0].(map[string]interface{})
+	priorities := video["priorities"].([]interface{})
+	if len(priorities) != 2 || priorities[0].(map[string]interface{})["priority"] != float64(6) {
+		t.Errorf("unexpected priorities: %v", priorities)
+	}
+}
+
+func TestSetJobPriority_Success(t *testing.T) {
+	var receivedPriority int
+
+	mockJob := &mocks.MockJobService{
+		GetJobByIDFn: func(jobId string) (*models.JobModel, error) {
+			return &models.JobModel{Job: models.Job{Id: jobId, Status: "pending", Priority: 2}}, nil
+		},
+		SetJobPriorityFn: func(jobId string, priority int) (*models.JobModel, error) {
+			receivedPriority = priority
+			return &models.JobModel{Job: models.Job{Id: jobId, Status: "pending", Priority: priority}}, nil
+		},
+	}
+
+	controller := NewAdminController(nil, mockJob, nil, nil)
+	router := setupAdminRouter(controller)
+
+	body, _ := json.Marshal(map[string]int{"priority": 10})
+	req, _ := http.NewRequest("PATCH", "/admin/jobs/job-123/priority", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("expected status %d, got %d", http.StatusOK, w.Code)
+	}
+
+	if receivedPriority != 10 {
+		t.Errorf("expected priority 10, got %d", receivedPriority)
+	}
+}
+
+func TestSetJobPriority_InvalidPriority(t *testing.T) {
+	controller := NewAdminController(nil, &mocks.MockJobService{}, nil, nil)
+	router := setupAdminRouter(controller)
+
+	for _, body := range []string{`{"priority": 11}`, `{"priority": -1}`, `{}`} {
+		req, _ := http.NewRequest("PATCH", "/admin/jobs/job-123/priority", bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("body %s: expected status %d, got %d", body, http.StatusBadRequest, w.Code)
+		}
+	}
+}
+
+func TestSetJobPriority_NotFound(t *testing.T) {
+	mockJob := &mocks.MockJobService{
+		GetJobByIDFn: func(jobId string) (*models.JobModel, error) {
+			return nil, errors.New("not found")
+		},
+	}
+
+	controller := NewAdminController(nil, mockJob, nil, nil)
+	router := setupAdminRouter(controller)
+
+	req, _ := http.NewRequest("PATCH", "/admin/jobs/job-123/priority", bytes.NewBufferString(`{"priority": 5}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusNotFound {
+		t.Errorf("expected status %d, got %d", http.StatusNotFound, w.Code)
+	}
+}
+
+func TestSaveEncodingProfile_Success(t *testing.T) {
+	var received *models.EncodingProfile
+
+	mockProfiles := &mocks.MockEncodingProfileService{
+		SaveProfileFn: func(profile *models.EncodingProfile) (*models.EncodingProfile, error) {
+			received = profile
+			return profile, nil
+		},
+	}
+
+	controller := NewAdminController(nil, nil, nil, mockProfiles)
+	router := setupAdminRouter(controller)
+
+	req, _ := http.NewRequest("PUT", "/admin/encoding-profiles/previews", bytes.NewBufferString(`{"description": "Con vista previa", "preview": true}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("expected status %d, got %d", http.StatusOK, w.Code)
+	}
+
+	if received == nil || received.Name != "previews" || !received.Preview {
+		t.Errorf("unexpected profile saved: %+v", received)
+	}
+}
+
+func TestSaveEncodingProfile_Loudnorm(t *testing.T) {
+	var received *models.EncodingProfile
+
+	mockProfiles := &mocks.MockEncodingProfileService{
+		SaveProfileFn: func(profile *models.EncodingProfile) (*models.EncodingProfile, error) {
+			received = profile
+			return profile, nil
+		},
+	}
+
+	controller := NewAdminController(nil, nil, nil, mockProfiles)
+	router := setupAdminRouter(controller)
+
+	req, _ := http.NewRequest("PUT", "/admin/encoding-profiles/podcast", bytes.NewBufferString(`{"preview": false, "loudnorm": true}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("expected status %d, got %d", http.StatusOK, w.Code)
+	}
+
+	if received == nil || !received.Loudnorm || received.Preview {
+		t.Errorf("unexpected profile saved: %+v", received)
+	}
+}
+
+func TestSaveEncodingProfile_InvalidName(t *testing.T) {
+	controller := NewAdminController(nil, nil, nil, &mocks.MockEncodingProfileService{})
+	router := setupAdminRouter(controller)
+
+	req, _ := http.NewRequest("PUT", "/admin/encoding-profiles/Not%20Valid", bytes.NewBufferString(`{"preview": true}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected status %d, got %d", http.StatusBadRequest, w.Code)
+	}
+}
+
+func TestSaveEncodingProfile_MissingPreview(t *testing.T) {
+	controller := NewAdminController(nil, nil, nil, &mocks.MockEncodingProfileService{})
+	router := setupAdminRouter(controller)
+
+	req, _ := http.NewRequest("PUT", "/admin/encoding-profiles/default", bytes.NewBufferString(`{"description": "sin preview"}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected status %d, got %d", http.StatusBadRequest, w.Code)
+	}
+}
@@ -0,0 +1,146 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/unbot2313/go-streaming-service/internal/helpers"
+	"github.com/unbot2313/go-streaming-service/internal/models"
+	"github.com/unbot2313/go-streaming-service/internal/services"
+)
+
+// defaultAPIKeyExpirationDays es la vigencia de una API key si no se indica otra
+const defaultAPIKeyExpirationDays = 90
+
+type APIKeyController interface {
+	CreateAPIKey(c *gin.Context)
+	GetAPIKeys(c *gin.Context)
+	DeleteAPIKey(c *gin.Context)
+}
+
+type APIKeyControllerImpl struct {
+	apiKeyService services.APIKeyService
+}
+
+func NewAPIKeyController(apiKeyService services.APIKeyService) APIKeyController {
+	return &APIKeyControllerImpl{
+		apiKeyService: apiKeyService,
+	}
+}
+
+// CreateAPIKeyRequest valida los datos para crear una API key
+type CreateAPIKeyRequest struct {
+	Name          string   `json:"name" binding:"required,max=100"`
+	Scopes        []string `json:"scopes" binding:"required,min=1,dive,oneof=videos:write videos:read jobs:read"`
+	ExpiresInDays int      `json:"expires_in_days" binding:"omitempty,min=1,max=365" example:"90"`
+}
+
+// CreateAPIKey godoc
+// @Summary		Create an API key
+// @Description	Create a long-lived key for automation (CI pipelines, scripts) with the selected scopes: `videos:write` (upload and edit videos), `videos:read` (versions and encryption keys of own videos), `jobs:read` (job status). Send it as the `X-API-Key` header or as `Authorization: Bearer sk_...`. The key is only shown once; it expires after `expires_in_days` (default 90, max 365). API keys cannot be used to manage the account, sessions or other API keys.
+// @Tags		users
+// @Accept		json
+// @Produce		json
+// @Security	BearerAuth
+// @Param		body body CreateAPIKeyRequest true "API key data"
+// @Success		201 {object} helpers.APIResponse{data=models.APIKeySwagger}
+// @Failure		400 {object} helpers.APIResponse{error=helpers.APIError}
+// @Failure		401 {object} helpers.APIResponse{error=helpers.APIError}
+// @Failure		403 {object} helpers.APIResponse{error=helpers.APIError}
+// @Failure		409 {object} helpers.APIResponse{error=helpers.APIError}
+// @Failure		500 {object} helpers.APIResponse{error=helpers.APIError}
+// @Router		/users/me/api-keys [post]
+func (ac *APIKeyControllerImpl) CreateAPIKey(c *gin.Context) {
+	user, exists := c.Get("user")
+	if !exists {
+		helpers.HandleError(c, http.StatusInternalServerError, "User not found in context", nil)
+		return
+	}
+	authenticatedUser := user.(*models.User)
+
+	var req CreateAPIKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		helpers.HandleError(c, http.StatusBadRequest, "Invalid input", err)
+		return
+	}
+
+	expiresInDays := req.ExpiresInDays
+	if expiresInDays == 0 {
+		expiresInDays = defaultAPIKeyExpirationDays
+	}
+
+	apiKey, err := ac.apiKeyService.CreateAPIKey(authenticatedUser.Id, req.Name, req.Scopes, time.Now().AddDate(0, 0, expiresInDays))
+	if err != nil {
+		if errors.Is(err, services.ErrTooManyAPIKeys) {
+			helpers.HandleError(c, http.StatusConflict, "Too many API keys, delete an unused one first", err)
+			return
+		}
+		helpers.HandleError(c, http.StatusInternalServerError, "Could not create API key", err)
+		return
+	}
+
+	helpers.Success(c, http.StatusCreated, apiKey)
+}
+
+// GetAPIKeys godoc
+// @Summary		List API keys
+// @Description	List the API keys of the authenticated user, including expired ones, with their scopes and last use. The keys themselves are not returned, only their prefix.
+// @Tags		users
+// @Produce		json
+// @Security	BearerAuth
+// @Success		200 {object} helpers.APIResponse{data=[]models.APIKeySwagger}
+// @Failure		401 {object} helpers.APIResponse{error=helpers.APIError}
+// @Failure		403 {object} helpers.APIResponse{error=helpers.APIError}
+// @Failure		500 {object} helpers.APIResponse{error=helpers.APIError}
+// @Router		/users/me/api-keys [get]
+func (ac *APIKeyControllerImpl) GetAPIKeys(c *gin.Context) {
+	user, exists := c.Get("user")
+	if !exists {
+		helpers.HandleError(c, http.StatusInternalServerError, "User not found in context", nil)
+		return
+	}
+	authenticatedUser := user.(*models.User)
+
+	apiKeys, err := ac.apiKeyService.ListAPIKeys(authenticatedUser.Id)
+	if err != nil {
+		helpers.HandleError(c, http.StatusInternalServerError, "Could not retrieve API keys", err)
+		return
+	}
+
+	helpers.Success(c, http.StatusOK, apiKeys)
+}
+
+// DeleteAPIKey godoc
+// @Summary		Delete an API key
+// @Description	Revoke an API key of the authenticated user. Requests that use it fail right away.
+// @Tags		users
+// @Produce		json
+// @Security	BearerAuth
+// @Param		id path string true "API key ID"
+// @Success		200 {object} helpers.APIResponse{data=object{message=string}}
+// @Failure		401 {object} helpers.APIResponse{error=helpers.APIError}
+// @Failure		403 {object} helpers.APIResponse{error=helpers.APIError}
+// @Failure		404 {object} helpers.APIResponse{error=helpers.APIError}
+// @Failure		500 {object} helpers.APIResponse{error=helpers.APIError}
+// @Router		/users/me/api-keys/{id} [delete]
+func (ac *APIKeyControllerImpl) DeleteAPIKey(c *gin.Context) {
+	user, exists := c.Get("user")
+	if !exists {
+		helpers.HandleError(c, http.StatusInternalServerError, "User not found in context", nil)
+		return
+	}
+	authenticatedUser := user.(*models.User)
+
+	if err := ac.apiKeyService.DeleteAPIKey(authenticatedUser.Id, c.Param("id")); err != nil {
+		if errors.Is(err, services.ErrAPIKeyNotFound) {
+			helpers.HandleError(c, http.StatusNotFound, "API key not found", err)
+			return
+		}
+		helpers.HandleError(c, http.StatusInternalServerError, "Could not delete API key", err)
+		return
+	}
+
+	helpers.Success(c, http.StatusOK, gin.H{"message": "API key deleted"})
+}
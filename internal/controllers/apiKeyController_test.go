@@ -0,0 +1,260 @@
+package controllers
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/unbot2313/go-streaming-service/internal/middlewares"
+	"github.com/unbot2313/go-streaming-service/internal/mocks"
+	"github.com/unbot2313/go-streaming-service/internal/models"
+	"github.com/unbot2313/go-streaming-service/internal/services"
+)
+
+func setupAPIKeyRouter(controller APIKeyController) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+
+	// Rutas protegidas con usuario simulado
+	protected := r.Group("")
+	protected.Use(func(c *gin.Context) {
+		c.Set("user", &models.User{Id: "user-123", Username: "testuser"})
+		c.Next()
+	})
+	protected.POST("/users/me/api-keys", controller.CreateAPIKey)
+	protected.GET("/users/me/api-keys", controller.GetAPIKeys)
+	protected.DELETE("/users/me/api-keys/:id", controller.DeleteAPIKey)
+	return r
+}
+
+func TestCreateAPIKey_Success(t *testing.T) {
+	var receivedExpiresAt time.Time
+
+	mockAPIKey := &mocks.MockAPIKeyService{
+		CreateAPIKeyFn: func(userId, name string, scopes []string, expiresAt time.Time) (*models.APIKey, error) {
+			receivedExpiresAt = expiresAt
+			return &models.APIKey{Id: "key-1", UserID: userId, Name: name, Scopes: scopes, ExpiresAt: expiresAt, Key: "sk_secret"}, nil
+		},
+	}
+
+	controller := NewAPIKeyController(mockAPIKey)
+	router := setupAPIKeyRouter(controller)
+
+	body, _ := json.Marshal(map[string]interface{}{
+		"name":   "CI",
+		"scopes": []string{"videos:write", "jobs:read"},
+	})
+	req, _ := http.NewRequest("POST", "/users/me/api-keys", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected status %d, got %d", http.StatusCreated, w.Code)
+	}
+
+	// Sin expires_in_days la key dura 90 días
+	expected := time.Now().AddDate(0, 0, 90)
+	if receivedExpiresAt.Sub(expected) > time.Minute || expected.Sub(receivedExpiresAt) > time.Minute {
+		t.Errorf("expected expiry around %v, got %v", expected, receivedExpiresAt)
+	}
+
+	var response struct {
+		Data models.APIKey `json:"data"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &response)
+
+	if response.Data.Key != "sk_secret" {
+		t.Errorf("expected the key in the response, got %q", response.Data.Key)
+	}
+}
+
+func TestCreateAPIKey_InvalidScope(t *testing.T) {
+	mockAPIKey := &mocks.MockAPIKeyService{}
+
+	controller := NewAPIKeyController(mockAPIKey)
+	router := setupAPIKeyRouter(controller)
+
+	body, _ := json.Marshal(map[string]interface{}{
+		"name":   "CI",
+		"scopes": []string{"users:write"},
+	})
+	req, _ := http.NewRequest("POST", "/users/me/api-keys", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected status %d, got %d", http.StatusBadRequest, w.Code)
+	}
+}
+
+func TestCreateAPIKey_TooMany(t *testing.T) {
+	mockAPIKey := &mocks.MockAPIKeyService{
+		CreateAPIKeyFn: func(userId, name string, scopes []string, expiresAt time.Time) (*models.APIKey, error) {
+			return nil, services.ErrTooManyAPIKeys
+		},
+	}
+
+	controller := NewAPIKeyController(mockAPIKey)
+	router := setupAPIKeyRouter(controller)
+
+	body, _ := json.Marshal(map[string]interface{}{
+		"name":   "CI",
+		"scopes": []string{"videos:read"},
+	})
+	req, _ := http.NewRequest("POST", "/users/me/api-keys", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusConflict {
+		t.Errorf("expected status %d, got %d", http.StatusConflict, w.Code)
+	}
+}
+
+func TestGetAPIKeys_Success(t *testing.T) {
+	mockAPIKey := &mocks.MockAPIKeyService{
+		ListAPIKeysFn: func(userId string) ([]models.APIKey, error) {
+			return []models.APIKey{{Id: "key-1", UserID: userId, Name: "CI", Prefix: "sk_abcdefgh"}}, nil
+		},
+	}
+
+	controller := NewAPIKeyController(mockAPIKey)
+	router := setupAPIKeyRouter(controller)
+
+	req, _ := http.NewRequest("GET", "/users/me/api-keys", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("expected status %d, got %d", http.StatusOK, w.Code)
+	}
+}
+
+func TestDeleteAPIKey_NotFound(t *testing.T) {
+	mockAPIKey := &mocks.MockAPIKeyService{
+		DeleteAPIKeyFn: func(userId, keyId string) error {
+			return services.ErrAPIKeyNotFound
+		},
+	}
+
+	controller := NewAPIKeyController(mockAPIKey)
+	router := setupAPIKeyRouter(controller)
+
+	req, _ := http.NewRequest("DELETE", "/users/me/api-keys/other-key", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusNotFound {
+		t.Errorf("expected status %d, got %d", http.StatusNotFound, w.Code)
+	}
+}
+
+// setupScopedRouter monta el AuthMiddleware real con una ruta solo para sesiones y otra con scope
+func setupScopedRouter(mockAPIKey *mocks.MockAPIKeyService) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	mockAuth := &mocks.MockAuthService{}
+	ok := func(c *gin.Context) { c.Status(http.StatusOK) }
+
+	r.GET("/account", middlewares.AuthMiddleware(mockAuth, mockAPIKey), ok)
+	r.GET("/jobs/:jobid", middlewares.AuthMiddleware(mockAuth, mockAPIKey, models.APIKeyScopeJobsRead), ok)
+	return r
+}
+
+func TestAuthMiddleware_APIKeyScopes(t *testing.T) {
+	var receivedKey string
+	mockAPIKey := &mocks.MockAPIKeyService{
+		AuthenticateFn: func(rawKey string) (*models.User, *models.APIKey, error) {
+			receivedKey = rawKey
+			return &models.User{Id: "user-123"}, &models.APIKey{Id: "key-1", Scopes: []string{"videos:write"}}, nil
+		},
+	}
+	router := setupScopedRouter(mockAPIKey)
+
+	tests := []struct {
+		name   string
+		path   string
+		header string
+		value  string
+		status int
+	}{
+		{"session-only route", "/account", "X-API-Key", "sk_abc", http.StatusForbidden},
+		{"missing scope", "/jobs/job-1", "X-API-Key", "sk_abc", http.StatusForbidden},
+		{"bearer api key", "/jobs/job-1", "Authorization", "Bearer sk_abc", http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		req, _ := http.NewRequest("GET", tt.path, nil)
+		req.Header.Set(tt.header, tt.value)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		if w.Code != tt.status {
+			t.Errorf("%s: expected status %d, got %d", tt.name, tt.status, w.Code)
+		}
+		if receivedKey != "sk_abc" {
+			t.Errorf("%s: expected the key to be checked, got %q", tt.name, receivedKey)
+		}
+	}
+}
+
+func TestAuthMiddleware_APIKeyWithScope(t *testing.T) {
+	mockAPIKey := &mocks.MockAPIKeyService{
+		AuthenticateFn: func(rawKey string) (*models.User, *models.APIKey, error) {
+			return &models.User{Id: "user-123"}, &models.APIKey{Id: "key-1", Scopes: []string{"jobs:read"}}, nil
+		},
+	}
+	router := setupScopedRouter(mockAPIKey)
+
+	req, _ := http.NewRequest("GET", "/jobs/job-1", nil)
+	req.Header.Set("Authorization", "Bearer sk_abc")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("expected status %d, got %d", http.StatusOK, w.Code)
+	}
+}
+
+func TestAuthMiddleware_InvalidAPIKey(t *testing.T) {
+	mockAPIKey := &mocks.MockAPIKeyService{
+		AuthenticateFn: func(rawKey string) (*models.User, *models.APIKey, error) {
+			return nil, nil, services.ErrInvalidAPIKey
+		},
+	}
+	router := setupScopedRouter(mockAPIKey)
+
+	req, _ := http.NewRequest("GET", "/jobs/job-1", nil)
+	req.Header.Set("X-API-Key", "sk_expired")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("expected status %d, got %d", http.StatusUnauthorized, w.Code)
+	}
+}
+
+func TestAuthMiddleware_APIKeyError(t *testing.T) {
+	mockAPIKey := &mocks.MockAPIKeyService{
+		AuthenticateFn: func(rawKey string) (*models.User, *models.APIKey, error) {
+			return nil, nil, errors.New("db down")
+		},
+	}
+	router := setupScopedRouter(mockAPIKey)
+
+	req, _ := http.NewRequest("GET", "/jobs/job-1", nil)
+	req.Header.Set("X-API-Key", "sk_abc")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf("expected status %d, got %d", http.StatusInternalServerError, w.Code)
+	}
+}
@@ -0,0 +1,181 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/unbot2313/go-streaming-service/internal/helpers"
+	"github.com/unbot2313/go-streaming-service/internal/models"
+	"github.com/unbot2313/go-streaming-service/internal/services"
+)
+
+type AudioTrackController interface {
+	GetAudioTracks(c *gin.Context)
+	AddDub(c *gin.Context)
+	DeleteDub(c *gin.Context)
+}
+
+type AudioTrackControllerImpl struct {
+	audioTrackService    services.AudioTrackService
+	databaseVideoService services.DatabaseVideoService
+}
+
+func NewAudioTrackController(audioTrackService services.AudioTrackService, databaseVideoService services.DatabaseVideoService) AudioTrackController {
+	return &AudioTrackControllerImpl{
+		audioTrackService:    audioTrackService,
+		databaseVideoService: databaseVideoService,
+	}
+}
+
+// GetAudioTracks godoc
+// @Summary		List a video's audio tracks
+// @Description	List the audio tracks of a video: the ones detected in the source file (`embedded`) and the dubbed ones uploaded by the owner (`dub`). The first one is the default track of the master playlist. Scheduled or suspended videos return 404 except to the owner.
+// @Tags		audio-tracks
+// @Produce		json
+// @Param		videoid path string true "Video ID"
+// @Success		200 {object} helpers.APIResponse{data=[]models.AudioTrackSwagger}
+// @Failure		404 {object} helpers.APIResponse{error=helpers.APIError}
+// @Failure		500 {object} helpers.APIResponse{error=helpers.APIError}
+// @Router		/streaming/{videoid}/audio-tracks [get]
+func (ac *AudioTrackControllerImpl) GetAudioTracks(c *gin.Context) {
+	video, err := ac.databaseVideoService.FindVideoByID(c.Param("videoid"))
+	if err != nil || !canViewVideo(c, video) {
+		helpers.HandleError(c, http.StatusNotFound, "Video not found", err)
+		return
+	}
+
+	tracks, err := ac.audioTrackService.ListAudioTracks(video.Id)
+	if err != nil {
+		helpers.HandleError(c, http.StatusInternalServerError, "Could not retrieve audio tracks", err)
+		return
+	}
+
+	helpers.Success(c, http.StatusOK, tracks)
+}
+
+// AddDub godoc
+// @Summary		Upload a dubbed audio track
+// @Description	Upload an audio file (mp3, m4a, wav or flac, max 500MB) as an alternate audio track. It is transcoded to AAC HLS and added to the video's master playlist as an `#EXT-X-MEDIA:TYPE=AUDIO`; the video URL changes to the master playlist. Only the owner can add tracks, and audio-only uploads do not support them.
+// @Tags		audio-tracks
+// @Accept		multipart/form-data
+// @Produce		json
+// @Security	BearerAuth
+// @Security	APIKeyAuth
+// @Param		videoid path string true "Video ID"
+// @Param		audio formData file true "Dubbed audio file"
+// @Param		language formData string true "BCP 47 language tag (es, pt-BR)"
+// @Param		name formData string false "Name shown in the player (defaults to the language)"
+// @Success		201 {object} helpers.APIResponse{data=models.AudioTrackSwagger}
+// @Failure		400 {object} helpers.APIResponse{error=helpers.APIError}
+// @Failure		403 {object} helpers.APIResponse{error=helpers.APIError}
+// @Failure		404 {object} helpers.APIResponse{error=helpers.APIError}
+// @Failure		500 {object} helpers.APIResponse{error=helpers.APIError}
+// @Router		/streaming/{videoid}/audio-tracks [post]
+func (ac *AudioTrackControllerImpl) AddDub(c *gin.Context) {
+	video, ok := ac.findOwnVideo(c)
+	if !ok {
+		return
+	}
+
+	header, err := c.FormFile("audio")
+	if err != nil {
+		helpers.HandleError(c, http.StatusBadRequest, "audio file is required", err)
+		return
+	}
+	if header.Size > services.MaxDubUploadSize {
+		helpers.HandleError(c, http.StatusBadRequest, "El archivo excede el limite de tamaño permitido", nil)
+		return
+	}
+
+	file, err := header.Open()
+	if err != nil {
+		helpers.HandleError(c, http.StatusBadRequest, "Could not read audio file", err)
+		return
+	}
+	defer file.Close()
+
+	track, err := ac.audioTrackService.AddDub(c.Request.Context(), video, services.DubUpload{
+		Filename: header.Filename,
+		File:     file,
+		Language: c.PostForm("language"),
+		Name:     c.PostForm("name"),
+	})
+	if err != nil {
+		handleAudioTrackError(c, err, "Could not add audio track")
+		return
+	}
+
+	helpers.Success(c, http.StatusCreated, track)
+}
+
+// DeleteDub godoc
+// @Summary		Delete a dubbed audio track
+// @Description	Remove a dubbed audio track from the master playlist and delete its files. Tracks from the source file cannot be deleted.
+// @Tags		audio-tracks
+// @Produce		json
+// @Security	BearerAuth
+// @Security	APIKeyAuth
+// @Param		videoid path string true "Video ID"
+// @Param		trackid path string true "Audio track ID"
+// @Success		200 {object} helpers.APIResponse{data=object{message=string}}
+// @Failure		400 {object} helpers.APIResponse{error=helpers.APIError}
+// @Failure		403 {object} helpers.APIResponse{error=helpers.APIError}
+// @Failure		404 {object} helpers.APIResponse{error=helpers.APIError}
+// @Failure		500 {object} helpers.APIResponse{error=helpers.APIError}
+// @Router		/streaming/{videoid}/audio-tracks/{trackid} [delete]
+func (ac *AudioTrackControllerImpl) DeleteDub(c *gin.Context) {
+	video, ok := ac.findOwnVideo(c)
+	if !ok {
+		return
+	}
+
+	track, err := ac.audioTrackService.FindAudioTrackByID(c.Param("trackid"))
+	if err != nil || track.VideoID != video.Id {
+		helpers.HandleError(c, http.StatusNotFound, "Audio track not found", err)
+		return
+	}
+
+	if err := ac.audioTrackService.DeleteDub(c.Request.Context(), video, track); err != nil {
+		handleAudioTrackError(c, err, "Could not delete audio track")
+		return
+	}
+
+	helpers.Success(c, http.StatusOK, gin.H{"message": "Audio track deleted successfully"})
+}
+
+// findOwnVideo busca el video de la ruta y verifica que sea del usuario autenticado.
+// Si falla responde el error y retorna false.
+func (ac *AudioTrackControllerImpl) findOwnVideo(c *gin.Context) (*models.VideoModel, bool) {
+	user, exists := c.Get("user")
+	if !exists {
+		helpers.HandleError(c, http.StatusInternalServerError, "User not found in context", nil)
+		return nil, false
+	}
+	authenticatedUser := user.(*models.User)
+
+	video, err := ac.databaseVideoService.FindVideoByID(c.Param("videoid"))
+	if err != nil {
+		helpers.HandleError(c, http.StatusNotFound, "Video not found", err)
+		return nil, false
+	}
+
+	if video.UserID != authenticatedUser.Id {
+		helpers.HandleError(c, http.StatusForbidden, "You are not the owner of this video", nil)
+		return nil, false
+	}
+
+	return video, true
+}
+
+// handleAudioTrackError responde los errores de negocio de pistas de audio con su status
+func handleAudioTrackError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, services.ErrInvalidAudioTrack):
+		helpers.HandleError(c, http.StatusBadRequest, err.Error(), err)
+	case errors.Is(err, services.ErrTooManyAudioTracks):
+		helpers.HandleError(c, http.StatusBadRequest, "The video reached the maximum number of audio tracks", err)
+	default:
+		helpers.HandleError(c, http.StatusInternalServerError, message, err)
+	}
+}
@@ -0,0 +1,220 @@
+package controllers
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/unbot2313/go-streaming-service/internal/mocks"
+	"github.com/unbot2313/go-streaming-service/internal/models"
+	"github.com/unbot2313/go-streaming-service/internal/services"
+)
+
+func setupAudioTrackRouter(controller AudioTrackController) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+
+	// Rutas públicas
+	r.GET("/streaming/:videoid/audio-tracks", controller.GetAudioTracks)
+
+	// Rutas protegidas con usuario simulado
+	protected := r.Group("")
+	protected.Use(func(c *gin.Context) {
+		c.Set("user", &models.User{Id: "user-123", Username: "testuser"})
+		c.Next()
+	})
+	protected.POST("/streaming/:videoid/audio-tracks", controller.AddDub)
+	protected.DELETE("/streaming/:videoid/audio-tracks/:trackid", controller.DeleteDub)
+
+	return r
+}
+
+func newDubRequest(t *testing.T, language string) *http.Request {
+	t.Helper()
+
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	writer.WriteField("language", language)
+	writer.WriteField("name", "Español")
+	part, _ := writer.CreateFormFile("audio", "dub.m4a")
+	part.Write([]byte("fake audio"))
+	writer.Close()
+
+	req, _ := http.NewRequest("POST", "/streaming/video-1/audio-tracks", body)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	return req
+}
+
+func TestGetAudioTracks_Success(t *testing.T) {
+	mockTracks := &mocks.MockAudioTrackService{
+		ListAudioTracksFn: func(videoId string) ([]models.AudioTrack, error) {
+			return []models.AudioTrack{
+				{Id: "t1", VideoID: videoId, Language: "eng", Name: "English", Source: models.AudioTrackSourceEmbedded},
+				{Id: "t2", VideoID: videoId, Language: "es", Name: "Español", Source: models.AudioTrackSourceDub, Position: 1},
+			}, nil
+		},
+	}
+
+	controller := NewAudioTrackController(mockTracks, newPublishedVideoService("user-123"))
+	router := setupAudioTrackRouter(controller)
+
+	req, _ := http.NewRequest("GET", "/streaming/video-1/audio-tracks", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("expected status %d, got %d", http.StatusOK, w.Code)
+	}
+}
+
+func TestGetAudioTracks_Scheduled(t *testing.T) {
+	mockTracks := &mocks.MockAudioTrackService{
+		ListAudioTracksFn: func(videoId string) ([]models.AudioTrack, error) {
+			t.Error("the tracks must not be listed")
+			return nil, nil
+		},
+	}
+
+	controller := NewAudioTrackController(mockTracks, newVideoService(newScheduledVideo("owner-1")))
+	router := setupAudioTrackRouter(controller)
+
+	req, _ := http.NewRequest("GET", "/streaming/video-1/audio-tracks", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusNotFound {
+		t.Errorf("expected status %d, got %d", http.StatusNotFound, w.Code)
+	}
+}
+
+func TestGetAudioTracks_Suspended(t *testing.T) {
+	mockTracks := &mocks.MockAudioTrackService{
+		ListAudioTracksFn: func(videoId string) ([]models.AudioTrack, error) {
+			t.Error("the tracks must not be listed")
+			return nil, nil
+		},
+	}
+
+	controller := NewAudioTrackController(mockTracks, newVideoService(newSuspendedVideo("owner-1")))
+	router := setupAudioTrackRouter(controller)
+
+	req, _ := http.NewRequest("GET", "/streaming/video-1/audio-tracks", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusNotFound {
+		t.Errorf("expected status %d, got %d", http.StatusNotFound, w.Code)
+	}
+}
+
+func TestGetAudioTracks_SuspendedOwner(t *testing.T) {
+	mockTracks := &mocks.MockAudioTrackService{
+		ListAudioTracksFn: func(videoId string) ([]models.AudioTrack, error) {
+			return []models.AudioTrack{{Id: "t1", VideoID: videoId, Language: "eng", Source: models.AudioTrackSourceEmbedded}}, nil
+		},
+	}
+
+	controller := NewAudioTrackController(mockTracks, newVideoService(newSuspendedVideo("user-123")))
+
+	// La ruta es pública: el dueño llega con el usuario que deja la autenticación opcional
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/streaming/:videoid/audio-tracks", func(c *gin.Context) {
+		c.Set("user", &models.User{Id: "user-123", Username: "testuser"})
+		c.Next()
+	}, controller.GetAudioTracks)
+
+	req, _ := http.NewRequest("GET", "/streaming/video-1/audio-tracks", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("expected status %d, got %d", http.StatusOK, w.Code)
+	}
+}
+
+func TestAddDub_Success(t *testing.T) {
+	var received services.DubUpload
+	mockTracks := &mocks.MockAudioTrackService{
+		AddDubFn: func(ctx context.Context, video *models.VideoModel, dub services.DubUpload) (*models.AudioTrack, error) {
+			received = dub
+			content, _ := io.ReadAll(dub.File)
+			if string(content) != "fake audio" {
+				t.Errorf("unexpected dub content %q", content)
+			}
+			return &models.AudioTrack{Id: "t2", VideoID: video.Id, Language: dub.Language, Name: dub.Name, Source: models.AudioTrackSourceDub}, nil
+		},
+	}
+
+	controller := NewAudioTrackController(mockTracks, newOwnedVideoService("user-123"))
+	router := setupAudioTrackRouter(controller)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, newDubRequest(t, "es"))
+
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusCreated, w.Code, w.Body.String())
+	}
+	if received.Filename != "dub.m4a" || received.Language != "es" || received.Name != "Español" {
+		t.Errorf("unexpected dub upload: %+v", received)
+	}
+}
+
+func TestAddDub_NotOwner(t *testing.T) {
+	controller := NewAudioTrackController(&mocks.MockAudioTrackService{}, newOwnedVideoService("other-user"))
+	router := setupAudioTrackRouter(controller)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, newDubRequest(t, "es"))
+
+	if w.Code != http.StatusForbidden {
+		t.Errorf("expected status %d, got %d", http.StatusForbidden, w.Code)
+	}
+}
+
+func TestAddDub_InvalidLanguage(t *testing.T) {
+	mockTracks := &mocks.MockAudioTrackService{
+		AddDubFn: func(ctx context.Context, video *models.VideoModel, dub services.DubUpload) (*models.AudioTrack, error) {
+			return nil, fmt.Errorf("%w: idioma inválido", services.ErrInvalidAudioTrack)
+		},
+	}
+
+	controller := NewAudioTrackController(mockTracks, newOwnedVideoService("user-123"))
+	router := setupAudioTrackRouter(controller)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, newDubRequest(t, "not a language"))
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected status %d, got %d", http.StatusBadRequest, w.Code)
+	}
+}
+
+func TestDeleteDub_OtherVideo(t *testing.T) {
+	mockTracks := &mocks.MockAudioTrackService{
+		FindAudioTrackByIDFn: func(trackId string) (*models.AudioTrack, error) {
+			return &models.AudioTrack{Id: trackId, VideoID: "video-2", Source: models.AudioTrackSourceDub}, nil
+		},
+		DeleteDubFn: func(ctx context.Context, video *models.VideoModel, track *models.AudioTrack) error {
+			t.Error("DeleteDub should not be called for another video's track")
+			return nil
+		},
+	}
+
+	controller := NewAudioTrackController(mockTracks, newOwnedVideoService("user-123"))
+	router := setupAudioTrackRouter(controller)
+
+	req, _ := http.NewRequest("DELETE", "/streaming/video-1/audio-tracks/t2", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusNotFound {
+		t.Errorf("expected status %d, got %d", http.StatusNotFound, w.Code)
+	}
+}
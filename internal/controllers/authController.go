@@ -1,6 +1,8 @@
 package controllers
 
 import (
+	"errors"
+	"log/slog"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
@@ -14,11 +16,18 @@ type AuthController interface {
 	Register(c *gin.Context)
 	RefreshToken(c *gin.Context)
 	Logout(c *gin.Context)
+	GetSessions(c *gin.Context)
+	DeleteSession(c *gin.Context)
+	GetJWKS(c *gin.Context)
+	VerifyEmail(c *gin.Context)
+	ResendVerificationEmail(c *gin.Context)
+	RequestPasswordReset(c *gin.Context)
+	ConfirmPasswordReset(c *gin.Context)
 }
 
 // Login godoc
 // @Summary		Log in user
-// @Description	Authenticate with username and password to get access and refresh tokens
+// @Description	Authenticate with username and password to get access and refresh tokens. Each login opens a new session; sessions on other devices stay active.
 // @Tags		Auth
 // @Accept		json
 // @Produce		json
@@ -26,6 +35,7 @@ type AuthController interface {
 // @Success		200 {object} helpers.APIResponse{data=object{access_token=string,refresh_token=string,user=string}}
 // @Failure		400 {object} helpers.APIResponse{error=helpers.APIError}
 // @Failure		401 {object} helpers.APIResponse{error=helpers.APIError}
+// @Failure		403 {object} helpers.APIResponse{error=helpers.APIError}
 // @Router		/auth/login [post]
 func (controller *AuthControllerImp) Login(c *gin.Context) {
 	
@@ -36,7 +46,12 @@ func (controller *AuthControllerImp) Login(c *gin.Context) {
 		return
 	}
 
-	tokens, err := controller.authService.Login(userLogin.Username, userLogin.Password)
+	tokens, err := controller.authService.Login(userLogin.Username, userLogin.Password, sessionDevice(c, userLogin.DeviceName))
+
+	if errors.Is(err, services.ErrUserSuspended) {
+		helpers.HandleError(c, http.StatusForbidden, "Account suspended", err)
+		return
+	}
 
 	if err != nil {
 		helpers.HandleError(c, http.StatusUnauthorized, "Invalid credentials", err)
@@ -81,26 +96,20 @@ func (controller *AuthControllerImp) Register(c *gin.Context) {
 		return
 	}
 
-	accessToken, err := controller.authService.GenerateToken(createdUser)
+	tokens, err := controller.authService.CreateSession(createdUser, sessionDevice(c, req.DeviceName))
 	if err != nil {
-		helpers.HandleError(c, http.StatusInternalServerError, "Could not generate token", err)
+		helpers.HandleError(c, http.StatusInternalServerError, "Could not create session", err)
 		return
 	}
 
-	refreshToken, err := controller.authService.GenerateRefreshToken(createdUser)
-	if err != nil {
-		helpers.HandleError(c, http.StatusInternalServerError, "Could not generate refresh token", err)
-		return
-	}
-
-	if err := controller.authService.SaveRefreshToken(createdUser.Id, refreshToken); err != nil {
-		helpers.HandleError(c, http.StatusInternalServerError, "Could not save refresh token", err)
-		return
+	// La cuenta ya existe: si el email no sale, el usuario puede pedirlo de nuevo
+	if err := controller.accountEmailService.SendVerificationEmail(createdUser.Id); err != nil {
+		slog.Warn("failed to send verification email", slog.String("user_id", createdUser.Id), slog.Any("error", err))
 	}
 
 	helpers.Success(c, http.StatusCreated, gin.H{
-		"access_token":  accessToken,
-		"refresh_token": refreshToken,
+		"access_token":  tokens.AccessToken,
+		"refresh_token": tokens.RefreshToken,
 		"user":          createdUser,
 	})
 }
@@ -127,7 +136,7 @@ func (controller *AuthControllerImp) RefreshToken(c *gin.Context) {
 		return
 	}
 
-	tokens, err := controller.authService.RefreshTokens(req.RefreshToken)
+	tokens, err := controller.authService.RefreshTokens(req.RefreshToken, sessionDevice(c, ""))
 	if err != nil {
 		helpers.HandleError(c, http.StatusUnauthorized, "Invalid or expired refresh token", err)
 		return
@@ -141,7 +150,7 @@ func (controller *AuthControllerImp) RefreshToken(c *gin.Context) {
 
 // Logout godoc
 // @Summary		Logout user
-// @Description	Revoke the refresh token for the authenticated user
+// @Description	Close the session of the access token: its refresh token and the access token itself stop working. Other devices stay logged in.
 // @Tags		Auth
 // @Produce		json
 // @Security	BearerAuth
@@ -157,8 +166,22 @@ func (controller *AuthControllerImp) Logout(c *gin.Context) {
 	}
 
 	authenticatedUser := user.(*models.User)
+	claims := requestClaims(c)
 
-	if err := controller.authService.ClearRefreshToken(authenticatedUser.Id); err != nil {
+	// Los access tokens anteriores a las sesiones no traen sid: se cierran todas
+	var err error
+	if claims.SessionID != "" {
+		err = controller.authService.RevokeSession(authenticatedUser.Id, claims.SessionID)
+	} else {
+		err = controller.authService.RevokeUserSessions(authenticatedUser.Id)
+	}
+	if err != nil && !errors.Is(err, services.ErrSessionNotFound) {
+		helpers.HandleError(c, http.StatusInternalServerError, "Could not logout", err)
+		return
+	}
+
+	// El access token de la petición deja de servir aunque todavía no haya vencido
+	if err := controller.authService.RevokeAccessToken(authenticatedUser, claims); err != nil {
 		helpers.HandleError(c, http.StatusInternalServerError, "Could not logout", err)
 		return
 	}
@@ -166,12 +189,241 @@ func (controller *AuthControllerImp) Logout(c *gin.Context) {
 	helpers.Success(c, http.StatusOK, gin.H{"message": "Logged out successfully"})
 }
 
+// GetSessions godoc
+// @Summary		List active sessions
+// @Description	List the active sessions of the authenticated user, most recently used first. `current` marks the session of the access token used for the request.
+// @Tags		Auth
+// @Produce		json
+// @Security	BearerAuth
+// @Success		200 {object} helpers.APIResponse{data=[]models.SessionSwagger}
+// @Failure		401 {object} helpers.APIResponse{error=helpers.APIError}
+// @Failure		500 {object} helpers.APIResponse{error=helpers.APIError}
+// @Router		/auth/sessions [get]
+func (controller *AuthControllerImp) GetSessions(c *gin.Context) {
+	user, exists := c.Get("user")
+	if !exists {
+		helpers.HandleError(c, http.StatusInternalServerError, "User not found in context", nil)
+		return
+	}
+
+	authenticatedUser := user.(*models.User)
+
+	sessions, err := controller.authService.ListSessions(authenticatedUser.Id)
+	if err != nil {
+		helpers.HandleError(c, http.StatusInternalServerError, "Could not retrieve sessions", err)
+		return
+	}
+
+	currentSession := requestClaims(c).SessionID
+	for i := range sessions {
+		sessions[i].Current = sessions[i].Id == currentSession
+	}
+
+	helpers.Success(c, http.StatusOK, sessions)
+}
+
+// DeleteSession godoc
+// @Summary		Revoke a session
+// @Description	Log out one device: the refresh token of the session stops working. Its current access token stays valid until it expires.
+// @Tags		Auth
+// @Produce		json
+// @Security	BearerAuth
+// @Param		id path string true "Session ID"
+// @Success		200 {object} helpers.APIResponse{data=object{message=string}}
+// @Failure		401 {object} helpers.APIResponse{error=helpers.APIError}
+// @Failure		404 {object} helpers.APIResponse{error=helpers.APIError}
+// @Failure		500 {object} helpers.APIResponse{error=helpers.APIError}
+// @Router		/auth/sessions/{id} [delete]
+func (controller *AuthControllerImp) DeleteSession(c *gin.Context) {
+	user, exists := c.Get("user")
+	if !exists {
+		helpers.HandleError(c, http.StatusInternalServerError, "User not found in context", nil)
+		return
+	}
+
+	authenticatedUser := user.(*models.User)
+
+	if err := controller.authService.RevokeSession(authenticatedUser.Id, c.Param("id")); err != nil {
+		if errors.Is(err, services.ErrSessionNotFound) {
+			helpers.HandleError(c, http.StatusNotFound, "Session not found", err)
+			return
+		}
+		helpers.HandleError(c, http.StatusInternalServerError, "Could not revoke session", err)
+		return
+	}
+
+	helpers.Success(c, http.StatusOK, gin.H{"message": "Session revoked"})
+}
+
+// VerifyEmail godoc
+// @Summary		Verify email
+// @Description	Confirm the email address with the token of the link sent after registering or with /auth/verify-email/resend. Each link works once, and only for the email it was sent to.
+// @Tags		Auth
+// @Accept		json
+// @Produce		json
+// @Param		body body object{token=string} true "Token from the email link"
+// @Success		200 {object} helpers.APIResponse{data=object{message=string}}
+// @Failure		400 {object} helpers.APIResponse{error=helpers.APIError}
+// @Failure		500 {object} helpers.APIResponse{error=helpers.APIError}
+// @Router		/auth/verify-email [post]
+func (controller *AuthControllerImp) VerifyEmail(c *gin.Context) {
+	var req struct {
+		Token string `json:"token" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		helpers.HandleError(c, http.StatusBadRequest, "token is required", err)
+		return
+	}
+
+	if _, err := controller.accountEmailService.VerifyEmail(req.Token); err != nil {
+		if errors.Is(err, services.ErrInvalidEmailToken) {
+			helpers.HandleError(c, http.StatusBadRequest, "Invalid, expired or already used link", err)
+			return
+		}
+		helpers.HandleError(c, http.StatusInternalServerError, "Could not verify email", err)
+		return
+	}
+
+	helpers.Success(c, http.StatusOK, gin.H{"message": "Email verified"})
+}
+
+// ResendVerificationEmail godoc
+// @Summary		Resend verification email
+// @Description	Send a new verification link to the email of the authenticated user. Earlier links stop working.
+// @Tags		Auth
+// @Produce		json
+// @Security	BearerAuth
+// @Success		200 {object} helpers.APIResponse{data=object{message=string}}
+// @Failure		401 {object} helpers.APIResponse{error=helpers.APIError}
+// @Failure		409 {object} helpers.APIResponse{error=helpers.APIError}
+// @Failure		500 {object} helpers.APIResponse{error=helpers.APIError}
+// @Router		/auth/verify-email/resend [post]
+func (controller *AuthControllerImp) ResendVerificationEmail(c *gin.Context) {
+	user, exists := c.Get("user")
+	if !exists {
+		helpers.HandleError(c, http.StatusInternalServerError, "User not found in context", nil)
+		return
+	}
+
+	authenticatedUser := user.(*models.User)
+
+	if err := controller.accountEmailService.SendVerificationEmail(authenticatedUser.Id); err != nil {
+		if errors.Is(err, services.ErrEmailAlreadyVerified) {
+			helpers.HandleError(c, http.StatusConflict, "Email already verified", err)
+			return
+		}
+		helpers.HandleError(c, http.StatusInternalServerError, "Could not send verification email", err)
+		return
+	}
+
+	helpers.Success(c, http.StatusOK, gin.H{"message": "Verification email sent"})
+}
+
+// RequestPasswordReset godoc
+// @Summary		Request a password reset
+// @Description	Send a password reset link to the email, if it belongs to an account. The response is the same either way, so it does not reveal which emails are registered.
+// @Tags		Auth
+// @Accept		json
+// @Produce		json
+// @Param		body body object{email=string} true "Account email"
+// @Success		200 {object} helpers.APIResponse{data=object{message=string}}
+// @Failure		400 {object} helpers.APIResponse{error=helpers.APIError}
+// @Failure		500 {object} helpers.APIResponse{error=helpers.APIError}
+// @Router		/auth/password-reset/request [post]
+func (controller *AuthControllerImp) RequestPasswordReset(c *gin.Context) {
+	var req struct {
+		Email string `json:"email" binding:"required,email"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		helpers.HandleError(c, http.StatusBadRequest, "Valid email is required", err)
+		return
+	}
+
+	if err := controller.accountEmailService.RequestPasswordReset(req.Email); err != nil {
+		helpers.HandleError(c, http.StatusInternalServerError, "Could not request password reset", err)
+		return
+	}
+
+	helpers.Success(c, http.StatusOK, gin.H{"message": "If the email belongs to an account, a reset link was sent"})
+}
+
+// ConfirmPasswordReset godoc
+// @Summary		Reset password
+// @Description	Set a new password with the token of the reset link. The link works once; all sessions are closed, existing access tokens stop working and the user's API keys are deleted.
+// @Tags		Auth
+// @Accept		json
+// @Produce		json
+// @Param		body body object{token=string,new_password=string} true "Token from the email link and new password (min 8 chars)"
+// @Success		200 {object} helpers.APIResponse{data=object{message=string}}
+// @Failure		400 {object} helpers.APIResponse{error=helpers.APIError}
+// @Failure		500 {object} helpers.APIResponse{error=helpers.APIError}
+// @Router		/auth/password-reset/confirm [post]
+func (controller *AuthControllerImp) ConfirmPasswordReset(c *gin.Context) {
+	var req struct {
+		Token       string `json:"token" binding:"required"`
+		NewPassword string `json:"new_password" binding:"required,min=8"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		helpers.HandleError(c, http.StatusBadRequest, "token and new_password (min 8 chars) are required", err)
+		return
+	}
+
+	if err := controller.accountEmailService.ResetPassword(req.Token, req.NewPassword); err != nil {
+		if errors.Is(err, services.ErrInvalidEmailToken) {
+			helpers.HandleError(c, http.StatusBadRequest, "Invalid, expired or already used link", err)
+			return
+		}
+		helpers.HandleError(c, http.StatusInternalServerError, "Could not reset password", err)
+		return
+	}
+
+	helpers.Success(c, http.StatusOK, gin.H{"message": "Password updated, log in again"})
+}
+
+// GetJWKS sirve /.well-known/jwks.json: las claves públicas con las que otros servicios
+// validan los tokens RS256/EdDSA sin conocer ningún secreto. Va fuera de /api/v1 y sin
+// el formato APIResponse, porque los clientes JWKS esperan el documento estándar.
+func (controller *AuthControllerImp) GetJWKS(c *gin.Context) {
+	keys, err := controller.authService.PublicKeys()
+	if err != nil {
+		helpers.HandleError(c, http.StatusInternalServerError, "Could not load signing keys", err)
+		return
+	}
+
+	// Una clave nueva tarda en publicarse a lo sumo este tiempo en los caches de los clientes
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, keys)
+}
+
+// sessionDevice arma los datos del dispositivo que inicia o renueva una sesión
+func sessionDevice(c *gin.Context, name string) services.SessionDevice {
+	return services.SessionDevice{
+		Name:      name,
+		UserAgent: c.Request.UserAgent(),
+		IP:        c.ClientIP(),
+	}
+}
+
+// requestClaims retorna los claims del access token de la petición; vacíos si no los hay
+func requestClaims(c *gin.Context) *models.AuthClaims {
+	if claims, ok := c.Get("claims"); ok {
+		if authClaims, ok := claims.(*models.AuthClaims); ok && authClaims != nil {
+			return authClaims
+		}
+	}
+	return &models.AuthClaims{}
+}
+
 type AuthControllerImp struct {
-	authService services.AuthService
-	userService services.UserService
+	authService         services.AuthService
+	userService         services.UserService
+	accountEmailService services.AccountEmailService
 }
 
 // NewAuthController crea una nueva instancia del controlador de autenticación
-func NewAuthController(authService services.AuthService, userService services.UserService) AuthController {
-	return &AuthControllerImp{authService: authService, userService: userService}
+func NewAuthController(authService services.AuthService, userService services.UserService, accountEmailService services.AccountEmailService) AuthController {
+	return &AuthControllerImp{authService: authService, userService: userService, accountEmailService: accountEmailService}
 }
@@ -20,12 +20,28 @@ func setupAuthRouter(controller AuthController) *gin.Engine {
 	r.POST("/auth/login", controller.Login)
 	r.POST("/auth/register", controller.Register)
 	r.POST("/auth/refresh", controller.RefreshToken)
+	r.GET("/.well-known/jwks.json", controller.GetJWKS)
+	r.POST("/auth/verify-email", controller.VerifyEmail)
+	r.POST("/auth/password-reset/request", controller.RequestPasswordReset)
+	r.POST("/auth/password-reset/confirm", controller.ConfirmPasswordReset)
+
+	// Rutas protegidas con usuario simulado
+	protected := r.Group("")
+	protected.Use(func(c *gin.Context) {
+		c.Set("user", &models.User{Id: "user-123", Username: "testuser"})
+		c.Set("claims", &models.AuthClaims{SessionID: "session-1", TokenID: "token-1"})
+		c.Next()
+	})
+	protected.POST("/auth/logout", controller.Logout)
+	protected.GET("/auth/sessions", controller.GetSessions)
+	protected.DELETE("/auth/sessions/:id", controller.DeleteSession)
+	protected.POST("/auth/verify-email/resend", controller.ResendVerificationEmail)
 	return r
 }
 
 func TestLogin_Success(t *testing.T) {
 	mockAuth := &mocks.MockAuthService{
-		LoginFn: func(username, password string) (*services.TokenPair, error) {
+		LoginFn: func(username, password string, device services.SessionDevice) (*services.TokenPair, error) {
 			return &services.TokenPair{
 				AccessToken:  "access-token-123",
 				RefreshToken: "refresh-token-456",
@@ -33,7 +49,7 @@ func TestLogin_Success(t *testing.T) {
 		},
 	}
 
-	controller := NewAuthController(mockAuth, nil)
+	controller := NewAuthController(mockAuth, nil, nil)
 	router := setupAuthRouter(controller)
 
 	body, _ := json.Marshal(models.UserLogin{Username: "testuser", Password: "password123"})
@@ -61,7 +77,7 @@ func TestLogin_Success(t *testing.T) {
 }
 
 func TestLogin_BadRequest(t *testing.T) {
-	controller := NewAuthController(&mocks.MockAuthService{}, nil)
+	controller := NewAuthController(&mocks.MockAuthService{}, nil, nil)
 	router := setupAuthRouter(controller)
 
 	req, _ := http.NewRequest("POST", "/auth/login", bytes.NewBuffer([]byte("{}")))
@@ -77,12 +93,12 @@ func TestLogin_BadRequest(t *testing.T) {
 
 func TestLogin_InvalidCredentials(t *testing.T) {
 	mockAuth := &mocks.MockAuthService{
-		LoginFn: func(username, password string) (*services.TokenPair, error) {
+		LoginFn: func(username, password string, device services.SessionDevice) (*services.TokenPair, error) {
 			return nil, errors.New("invalid credentials")
 		},
 	}
 
-	controller := NewAuthController(mockAuth, nil)
+	controller := NewAuthController(mockAuth, nil, nil)
 	router := setupAuthRouter(controller)
 
 	body, _ := json.Marshal(models.UserLogin{Username: "testuser", Password: "wrongpass"})
@@ -97,6 +113,28 @@ func TestLogin_InvalidCredentials(t *testing.T) {
 	}
 }
 
+func TestLogin_Suspended(t *testing.T) {
+	mockAuth := &mocks.MockAuthService{
+		LoginFn: func(username, password string, device services.SessionDevice) (*services.TokenPair, error) {
+			return nil, services.ErrUserSuspended
+		},
+	}
+
+	controller := NewAuthController(mockAuth, nil, nil)
+	router := setupAuthRouter(controller)
+
+	body, _ := json.Marshal(models.UserLogin{Username: "testuser", Password: "password123"})
+	req, _ := http.NewRequest("POST", "/auth/login", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusForbidden {
+		t.Errorf("expected status %d, got %d", http.StatusForbidden, w.Code)
+	}
+}
+
 func TestRegister_Success(t *testing.T) {
 	mockUser := &mocks.MockUserService{
 		CreateUserFn: func(user *models.User) (*models.User, error) {
@@ -106,18 +144,23 @@ func TestRegister_Success(t *testing.T) {
 	}
 
 	mockAuth := &mocks.MockAuthService{
-		GenerateTokenFn: func(user *models.User) (string, error) {
-			return "access-token", nil
-		},
-		GenerateRefreshTokenFn: func(user *models.User) (string, error) {
-			return "refresh-token", nil
+		CreateSessionFn: func(user *models.User, device services.SessionDevice) (*services.TokenPair, error) {
+			return &services.TokenPair{
+				AccessToken:  "access-token",
+				RefreshToken: "refresh-token",
+			}, nil
 		},
-		SaveRefreshTokenFn: func(userId, refreshToken string) error {
+	}
+
+	var verificationSentTo string
+	mockAccountEmail := &mocks.MockAccountEmailService{
+		SendVerificationEmailFn: func(userId string) error {
+			verificationSentTo = userId
 			return nil
 		},
 	}
 
-	controller := NewAuthController(mockAuth, mockUser)
+	controller := NewAuthController(mockAuth, mockUser, mockAccountEmail)
 	router := setupAuthRouter(controller)
 
 	body, _ := json.Marshal(map[string]string{
@@ -134,6 +177,9 @@ func TestRegister_Success(t *testing.T) {
 	if w.Code != http.StatusCreated {
 		t.Errorf("expected status %d, got %d", http.StatusCreated, w.Code)
 	}
+	if verificationSentTo != "user-123" {
+		t.Errorf("expected verification email for user-123, got %q", verificationSentTo)
+	}
 }
 
 func TestRegister_Conflict(t *testing.T) {
@@ -143,7 +189,7 @@ func TestRegister_Conflict(t *testing.T) {
 		},
 	}
 
-	controller := NewAuthController(&mocks.MockAuthService{}, mockUser)
+	controller := NewAuthController(&mocks.MockAuthService{}, mockUser, nil)
 	router := setupAuthRouter(controller)
 
 	body, _ := json.Marshal(map[string]string{
@@ -164,7 +210,7 @@ func TestRegister_Conflict(t *testing.T) {
 
 func TestRefreshToken_Success(t *testing.T) {
 	mockAuth := &mocks.MockAuthService{
-		RefreshTokensFn: func(refreshToken string) (*services.TokenPair, error) {
+		RefreshTokensFn: func(refreshToken string, device services.SessionDevice) (*services.TokenPair, error) {
 			return &services.TokenPair{
 				AccessToken:  "new-access",
 				RefreshToken: "new-refresh",
@@ -172,7 +218,7 @@ func TestRefreshToken_Success(t *testing.T) {
 		},
 	}
 
-	controller := NewAuthController(mockAuth, nil)
+	controller := NewAuthController(mockAuth, nil, nil)
 	router := setupAuthRouter(controller)
 
 	body, _ := json.Marshal(map[string]string{"refresh_token": "old-refresh"})
@@ -189,12 +235,12 @@ func TestRefreshToken_Success(t *testing.T) {
 
 func TestRefreshToken_Invalid(t *testing.T) {
 	mockAuth := &mocks.MockAuthService{
-		RefreshTokensFn: func(refreshToken string) (*services.TokenPair, error) {
+		RefreshTokensFn: func(refreshToken string, device services.SessionDevice) (*services.TokenPair, error) {
 			return nil, errors.New("invalid token")
 		},
 	}
 
-	controller := NewAuthController(mockAuth, nil)
+	controller := NewAuthController(mockAuth, nil, nil)
 	router := setupAuthRouter(controller)
 
 	body, _ := json.Marshal(map[string]string{"refresh_token": "bad-token"})
@@ -208,3 +254,424 @@ func TestRefreshToken_Invalid(t *testing.T) {
 		t.Errorf("expected status %d, got %d", http.StatusUnauthorized, w.Code)
 	}
 }
+
+func TestLogin_SendsDevice(t *testing.T) {
+	mockAuth := &mocks.MockAuthService{
+		LoginFn: func(username, password string, device services.SessionDevice) (*services.TokenPair, error) {
+			if device.Name != "Pixel 8" || device.UserAgent != "test-agent" {
+				t.Errorf("unexpected device: %+v", device)
+			}
+			return &services.TokenPair{AccessToken: "access", RefreshToken: "refresh"}, nil
+		},
+	}
+
+	controller := NewAuthController(mockAuth, nil, nil)
+	router := setupAuthRouter(controller)
+
+	body, _ := json.Marshal(models.UserLogin{Username: "testuser", Password: "password123", DeviceName: "Pixel 8"})
+	req, _ := http.NewRequest("POST", "/auth/login", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "test-agent")
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("expected status %d, got %d", http.StatusOK, w.Code)
+	}
+}
+
+func TestRefreshToken_Reused(t *testing.T) {
+	mockAuth := &mocks.MockAuthService{
+		RefreshTokensFn: func(refreshToken string, device services.SessionDevice) (*services.TokenPair, error) {
+			return nil, services.ErrRefreshTokenReused
+		},
+	}
+
+	controller := NewAuthController(mockAuth, nil, nil)
+	router := setupAuthRouter(controller)
+
+	body, _ := json.Marshal(map[string]string{"refresh_token": "rotated-token"})
+	req, _ := http.NewRequest("POST", "/auth/refresh", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("expected status %d, got %d", http.StatusUnauthorized, w.Code)
+	}
+}
+
+func TestLogout_RevokesCurrentSession(t *testing.T) {
+	var revoked string
+	mockAuth := &mocks.MockAuthService{
+		RevokeSessionFn: func(userId, sessionId string) error {
+			revoked = sessionId
+			return nil
+		},
+		RevokeAccessTokenFn: func(user *models.User, claims *models.AuthClaims) error {
+			return nil
+		},
+	}
+
+	controller := NewAuthController(mockAuth, nil, nil)
+	router := setupAuthRouter(controller)
+
+	req, _ := http.NewRequest("POST", "/auth/logout", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, w.Code)
+	}
+	if revoked != "session-1" {
+		t.Errorf("expected session-1 to be revoked, got %q", revoked)
+	}
+}
+
+func TestLogout_RevokesAccessToken(t *testing.T) {
+	var revokedToken string
+	mockAuth := &mocks.MockAuthService{
+		RevokeSessionFn: func(userId, sessionId string) error {
+			// La sesión ya pudo haberse cerrado desde otro dispositivo
+			return services.ErrSessionNotFound
+		},
+		RevokeAccessTokenFn: func(user *models.User, claims *models.AuthClaims) error {
+			revokedToken = claims.TokenID
+			return nil
+		},
+	}
+
+	controller := NewAuthController(mockAuth, nil, nil)
+	router := setupAuthRouter(controller)
+
+	req, _ := http.NewRequest("POST", "/auth/logout", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, w.Code)
+	}
+	if revokedToken != "token-1" {
+		t.Errorf("expected token-1 to be revoked, got %q", revokedToken)
+	}
+}
+
+func TestLogout_RevokeAccessTokenError(t *testing.T) {
+	mockAuth := &mocks.MockAuthService{
+		RevokeSessionFn: func(userId, sessionId string) error {
+			return nil
+		},
+		RevokeAccessTokenFn: func(user *models.User, claims *models.AuthClaims) error {
+			return errors.New("db down")
+		},
+	}
+
+	controller := NewAuthController(mockAuth, nil, nil)
+	router := setupAuthRouter(controller)
+
+	req, _ := http.NewRequest("POST", "/auth/logout", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf("expected status %d, got %d", http.StatusInternalServerError, w.Code)
+	}
+}
+
+func TestGetSessions_MarksCurrent(t *testing.T) {
+	mockAuth := &mocks.MockAuthService{
+		ListSessionsFn: func(userId string) ([]models.Session, error) {
+			return []models.Session{
+				{Id: "session-1", DeviceName: "Laptop"},
+				{Id: "session-2", DeviceName: "Phone"},
+			}, nil
+		},
+	}
+
+	controller := NewAuthController(mockAuth, nil, nil)
+	router := setupAuthRouter(controller)
+
+	req, _ := http.NewRequest("GET", "/auth/sessions", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, w.Code)
+	}
+
+	var response struct {
+		Data []models.Session `json:"data"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &response)
+
+	if len(response.Data) != 2 || !response.Data[0].Current || response.Data[1].Current {
+		t.Errorf("unexpected sessions: %+v", response.Data)
+	}
+}
+
+func TestDeleteSession_NotFound(t *testing.T) {
+	mockAuth := &mocks.MockAuthService{
+		RevokeSessionFn: func(userId, sessionId string) error {
+			return services.ErrSessionNotFound
+		},
+	}
+
+	controller := NewAuthController(mockAuth, nil, nil)
+	router := setupAuthRouter(controller)
+
+	req, _ := http.NewRequest("DELETE", "/auth/sessions/other-user-session", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusNotFound {
+		t.Errorf("expected status %d, got %d", http.StatusNotFound, w.Code)
+	}
+}
+
+func TestGetJWKS_Success(t *testing.T) {
+	mockAuth := &mocks.MockAuthService{
+		PublicKeysFn: func() (*services.JWKSet, error) {
+			return &services.JWKSet{Keys: []services.JWK{
+				{Kty: "OKP", Kid: "20261019T120000Z", Use: "sig", Alg: "EdDSA", Crv: "Ed25519", X: "public-key"},
+			}}, nil
+		},
+	}
+
+	controller := NewAuthController(mockAuth, nil, nil)
+	router := setupAuthRouter(controller)
+
+	req, _ := http.NewRequest("GET", "/.well-known/jwks.json", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, w.Code)
+	}
+
+	// El documento JWKS va sin el envoltorio APIResponse
+	var response services.JWKSet
+	json.Unmarshal(w.Body.Bytes(), &response)
+
+	if len(response.Keys) != 1 || response.Keys[0].Kid != "20261019T120000Z" {
+		t.Errorf("unexpected keys: %+v", response.Keys)
+	}
+}
+
+func TestGetJWKS_Error(t *testing.T) {
+	mockAuth := &mocks.MockAuthService{
+		PublicKeysFn: func() (*services.JWKSet, error) {
+			return nil, errors.New("permission denied")
+		},
+	}
+
+	controller := NewAuthController(mockAuth, nil, nil)
+	router := setupAuthRouter(controller)
+
+	req, _ := http.NewRequest("GET", "/.well-known/jwks.json", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf("expected status %d, got %d", http.StatusInternalServerError, w.Code)
+	}
+}
+
+func TestRegister_VerificationEmailErrorStillCreated(t *testing.T) {
+	mockUser := &mocks.MockUserService{
+		CreateUserFn: func(user *models.User) (*models.User, error) {
+			user.Id = "user-123"
+			return user, nil
+		},
+	}
+	mockAuth := &mocks.MockAuthService{
+		CreateSessionFn: func(user *models.User, device services.SessionDevice) (*services.TokenPair, error) {
+			return &services.TokenPair{AccessToken: "access-token", RefreshToken: "refresh-token"}, nil
+		},
+	}
+	mockAccountEmail := &mocks.MockAccountEmailService{
+		SendVerificationEmailFn: func(userId string) error {
+			return errors.New("smtp unavailable")
+		},
+	}
+
+	controller := NewAuthController(mockAuth, mockUser, mockAccountEmail)
+	router := setupAuthRouter(controller)
+
+	body, _ := json.Marshal(map[string]string{
+		"username": "newuser",
+		"password": "password123",
+		"email":    "test@example.com",
+	})
+	req, _ := http.NewRequest("POST", "/auth/register", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	// La cuenta ya se creó: un fallo del mailer no debe devolver error
+	if w.Code != http.StatusCreated {
+		t.Errorf("expected status %d, got %d", http.StatusCreated, w.Code)
+	}
+}
+
+func TestVerifyEmail_Success(t *testing.T) {
+	var receivedToken string
+	mockAccountEmail := &mocks.MockAccountEmailService{
+		VerifyEmailFn: func(token string) (*models.User, error) {
+			receivedToken = token
+			return &models.User{Id: "user-123"}, nil
+		},
+	}
+
+	controller := NewAuthController(&mocks.MockAuthService{}, nil, mockAccountEmail)
+	router := setupAuthRouter(controller)
+
+	body, _ := json.Marshal(map[string]string{"token": "email-token"})
+	req, _ := http.NewRequest("POST", "/auth/verify-email", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("expected status %d, got %d", http.StatusOK, w.Code)
+	}
+	if receivedToken != "email-token" {
+		t.Errorf("expected token email-token, got %q", receivedToken)
+	}
+}
+
+func TestVerifyEmail_InvalidToken(t *testing.T) {
+	mockAccountEmail := &mocks.MockAccountEmailService{
+		VerifyEmailFn: func(token string) (*models.User, error) {
+			return nil, services.ErrInvalidEmailToken
+		},
+	}
+
+	controller := NewAuthController(&mocks.MockAuthService{}, nil, mockAccountEmail)
+	router := setupAuthRouter(controller)
+
+	body, _ := json.Marshal(map[string]string{"token": "used-token"})
+	req, _ := http.NewRequest("POST", "/auth/verify-email", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected status %d, got %d", http.StatusBadRequest, w.Code)
+	}
+}
+
+func TestResendVerificationEmail_AlreadyVerified(t *testing.T) {
+	mockAccountEmail := &mocks.MockAccountEmailService{
+		SendVerificationEmailFn: func(userId string) error {
+			return services.ErrEmailAlreadyVerified
+		},
+	}
+
+	controller := NewAuthController(&mocks.MockAuthService{}, nil, mockAccountEmail)
+	router := setupAuthRouter(controller)
+
+	req, _ := http.NewRequest("POST", "/auth/verify-email/resend", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusConflict {
+		t.Errorf("expected status %d, got %d", http.StatusConflict, w.Code)
+	}
+}
+
+func TestRequestPasswordReset_Success(t *testing.T) {
+	var receivedEmail string
+	mockAccountEmail := &mocks.MockAccountEmailService{
+		RequestPasswordResetFn: func(email string) error {
+			receivedEmail = email
+			return nil
+		},
+	}
+
+	controller := NewAuthController(&mocks.MockAuthService{}, nil, mockAccountEmail)
+	router := setupAuthRouter(controller)
+
+	body, _ := json.Marshal(map[string]string{"email": "test@example.com"})
+	req, _ := http.NewRequest("POST", "/auth/password-reset/request", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("expected status %d, got %d", http.StatusOK, w.Code)
+	}
+	if receivedEmail != "test@example.com" {
+		t.Errorf("expected email test@example.com, got %q", receivedEmail)
+	}
+}
+
+func TestRequestPasswordReset_InvalidEmail(t *testing.T) {
+	controller := NewAuthController(&mocks.MockAuthService{}, nil, &mocks.MockAccountEmailService{})
+	router := setupAuthRouter(controller)
+
+	body, _ := json.Marshal(map[string]string{"email": "not-an-email"})
+	req, _ := http.NewRequest("POST", "/auth/password-reset/request", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected status %d, got %d", http.StatusBadRequest, w.Code)
+	}
+}
+
+func TestConfirmPasswordReset_Success(t *testing.T) {
+	var receivedToken, receivedPassword string
+	mockAccountEmail := &mocks.MockAccountEmailService{
+		ResetPasswordFn: func(token, newPassword string) error {
+			receivedToken, receivedPassword = token, newPassword
+			return nil
+		},
+	}
+
+	controller := NewAuthController(&mocks.MockAuthService{}, nil, mockAccountEmail)
+	router := setupAuthRouter(controller)
+
+	body, _ := json.Marshal(map[string]string{"token": "reset-token", "new_password": "newpassword123"})
+	req, _ := http.NewRequest("POST", "/auth/password-reset/confirm", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("expected status %d, got %d", http.StatusOK, w.Code)
+	}
+	if receivedToken != "reset-token" || receivedPassword != "newpassword123" {
+		t.Errorf("unexpected arguments: %q, %q", receivedToken, receivedPassword)
+	}
+}
+
+func TestConfirmPasswordReset_InvalidToken(t *testing.T) {
+	mockAccountEmail := &mocks.MockAccountEmailService{
+		ResetPasswordFn: func(token, newPassword string) error {
+			return services.ErrInvalidEmailToken
+		},
+	}
+
+	controller := NewAuthController(&mocks.MockAuthService{}, nil, mockAccountEmail)
+	router := setupAuthRouter(controller)
+
+	body, _ := json.Marshal(map[string]string{"token": "expired-token", "new_password": "newpassword123"})
+	req, _ := http.NewRequest("POST", "/auth/password-reset/confirm", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected status %d, got %d", http.StatusBadRequest, w.Code)
+	}
+}
@@ -0,0 +1,308 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/unbot2313/go-streaming-service/internal/helpers"
+	"github.com/unbot2313/go-streaming-service/internal/models"
+	"github.com/unbot2313/go-streaming-service/internal/services"
+)
+
+type ChapterController interface {
+	GetChapters(c *gin.Context)
+	GetChaptersVTT(c *gin.Context)
+	GetChaptersPlaylist(c *gin.Context)
+	CreateChapter(c *gin.Context)
+	UpdateChapter(c *gin.Context)
+	DeleteChapter(c *gin.Context)
+}
+
+type ChapterControllerImpl struct {
+	chapterService       services.ChapterService
+	databaseVideoService services.DatabaseVideoService
+}
+
+func NewChapterController(chapterService services.ChapterService, databaseVideoService services.DatabaseVideoService) ChapterController {
+	return &ChapterControllerImpl{
+		chapterService:       chapterService,
+		databaseVideoService: databaseVideoService,
+	}
+}
+
+// ChapterRequest valida el inicio y el título de un capítulo
+type ChapterRequest struct {
+	StartSeconds *float64 `json:"start_seconds" binding:"required,gte=0" example:"95"`
+	Title        string   `json:"title" binding:"required,min=1,max=100" example:"Intro"`
+}
+
+// GetChapters godoc
+// @Summary		List a video's chapters
+// @Description	List the chapters of a video ordered by start time. Chapters are parsed from the description (`00:00 Intro` lines) when the video is created or its description changes, and can be edited by the owner. Chapters of scheduled or suspended videos are only visible to the owner.
+// @Tags		chapters
+// @Produce		json
+// @Param		videoid path string true "Video ID"
+// @Success		200 {object} helpers.APIResponse{data=[]models.ChapterSwagger}
+// @Failure		404 {object} helpers.APIResponse{error=helpers.APIError}
+// @Failure		500 {object} helpers.APIResponse{error=helpers.APIError}
+// @Router		/streaming/{videoid}/chapters [get]
+func (cc *ChapterControllerImpl) GetChapters(c *gin.Context) {
+	video, ok := cc.findVisibleVideo(c)
+	if !ok {
+		return
+	}
+
+	chapters, err := cc.chapterService.ListChapters(video.Id)
+	if err != nil {
+		helpers.HandleError(c, http.StatusInternalServerError, "Could not retrieve chapters", err)
+		return
+	}
+
+	helpers.Success(c, http.StatusOK, chapters)
+}
+
+// GetChaptersVTT godoc
+// @Summary		Get a video's chapters as WebVTT
+// @Description	WebVTT chapters track for the video player (`<track kind="chapters">`). Each chapter lasts until the next one starts; the last one until the end of the video. Scheduled or suspended videos return 404 except to the owner.
+// @Tags		chapters
+// @Produce		plain
+// @Param		videoid path string true "Video ID"
+// @Success		200 {string} string "WebVTT chapters track"
+// @Failure		404 {object} helpers.APIResponse{error=helpers.APIError}
+// @Failure		500 {object} helpers.APIResponse{error=helpers.APIError}
+// @Router		/streaming/{videoid}/chapters.vtt [get]
+func (cc *ChapterControllerImpl) GetChaptersVTT(c *gin.Context) {
+	video, ok := cc.findVisibleVideo(c)
+	if !ok {
+		return
+	}
+
+	vtt, err := cc.chapterService.ChaptersVTT(video)
+	if err != nil {
+		helpers.HandleError(c, http.StatusInternalServerError, "Could not build chapters track", err)
+		return
+	}
+
+	c.Data(http.StatusOK, "text/vtt; charset=utf-8", vtt)
+}
+
+// GetChaptersPlaylist godoc
+// @Summary		Get a video's HLS playlist with chapters
+// @Description	The video's HLS playlist with one `EXT-X-DATERANGE` per chapter (CLASS "chapter", title in X-TITLE), anchored with `EXT-X-PROGRAM-DATE-TIME`. Segment URIs point to the storage. Scheduled or suspended videos return 404 except to the owner.
+// @Tags		chapters
+// @Produce		plain
+// @Param		videoid path string true "Video ID"
+// @Success		200 {string} string "HLS playlist"
+// @Failure		404 {object} helpers.APIResponse{error=helpers.APIError}
+// @Failure		502 {object} helpers.APIResponse{error=helpers.APIError}
+// @Router		/streaming/{videoid}/playlist.m3u8 [get]
+func (cc *ChapterControllerImpl) GetChaptersPlaylist(c *gin.Context) {
+	video, ok := cc.findVisibleVideo(c)
+	if !ok {
+		return
+	}
+
+	playlist, err := cc.chapterService.ChaptersPlaylist(c.Request.Context(), video)
+	if err != nil {
+		helpers.HandleError(c, http.StatusBadGateway, "Could not build playlist", err)
+		return
+	}
+
+	c.Data(http.StatusOK, "application/vnd.apple.mpegurl", playlist)
+}
+
+// CreateChapter godoc
+// @Summary		Add a chapter to a video
+// @Description	Add a chapter starting at `start_seconds`. Only the owner can add chapters. Updating the description with timestamps replaces the chapters.
+// @Tags		chapters
+// @Accept		json
+// @Produce		json
+// @Security	BearerAuth
+// @Security	APIKeyAuth
+// @Param		videoid path string true "Video ID"
+// @Param		body body ChapterRequest true "Chapter data"
+// @Success		201 {object} helpers.APIResponse{data=models.ChapterSwagger}
+// @Failure		400 {object} helpers.APIResponse{error=helpers.APIError}
+// @Failure		403 {object} helpers.APIResponse{error=helpers.APIError}
+// @Failure		404 {object} helpers.APIResponse{error=helpers.APIError}
+// @Failure		409 {object} helpers.APIResponse{error=helpers.APIError}
+// @Failure		500 {object} helpers.APIResponse{error=helpers.APIError}
+// @Router		/streaming/{videoid}/chapters [post]
+func (cc *ChapterControllerImpl) CreateChapter(c *gin.Context) {
+	video, ok := cc.findOwnVideo(c)
+	if !ok {
+		return
+	}
+
+	req, ok := bindChapterRequest(c, video)
+	if !ok {
+		return
+	}
+
+	chapter, err := cc.chapterService.CreateChapter(&models.Chapter{
+		VideoID:      video.Id,
+		StartSeconds: *req.StartSeconds,
+		Title:        req.Title,
+	})
+	if err != nil {
+		handleChapterError(c, err, "Could not create chapter")
+		return
+	}
+
+	helpers.Success(c, http.StatusCreated, chapter)
+}
+
+// UpdateChapter godoc
+// @Summary		Update a chapter
+// @Description	Change the start time and title of a chapter. Only the owner of the video can update it.
+// @Tags		chapters
+// @Accept		json
+// @Produce		json
+// @Security	BearerAuth
+// @Security	APIKeyAuth
+// @Param		videoid path string true "Video ID"
+// @Param		chapterid path string true "Chapter ID"
+// @Param		body body ChapterRequest true "Chapter data"
+// @Success		200 {object} helpers.APIResponse{data=models.ChapterSwagger}
+// @Failure		400 {object} helpers.APIResponse{error=helpers.APIError}
+// @Failure		403 {object} helpers.APIResponse{error=helpers.APIError}
+// @Failure		404 {object} helpers.APIResponse{error=helpers.APIError}
+// @Failure		409 {object} helpers.APIResponse{error=helpers.APIError}
+// @Failure		500 {object} helpers.APIResponse{error=helpers.APIError}
+// @Router		/streaming/{videoid}/chapters/{chapterid} [put]
+func (cc *ChapterControllerImpl) UpdateChapter(c *gin.Context) {
+	video, ok := cc.findOwnVideo(c)
+	if !ok {
+		return
+	}
+
+	chapter, ok := cc.findVideoChapter(c, video)
+	if !ok {
+		return
+	}
+
+	req, ok := bindChapterRequest(c, video)
+	if !ok {
+		return
+	}
+
+	chapter.StartSeconds = *req.StartSeconds
+	chapter.Title = req.Title
+
+	updated, err := cc.chapterService.UpdateChapter(chapter)
+	if err != nil {
+		handleChapterError(c, err, "Could not update chapter")
+		return
+	}
+
+	helpers.Success(c, http.StatusOK, updated)
+}
+
+// DeleteChapter godoc
+// @Summary		Delete a chapter
+// @Description	Delete a chapter of a video. Only the owner of the video can delete it.
+// @Tags		chapters
+// @Produce		json
+// @Security	BearerAuth
+// @Security	APIKeyAuth
+// @Param		videoid path string true "Video ID"
+// @Param		chapterid path string true "Chapter ID"
+// @Success		200 {object} helpers.APIResponse{data=object{message=string}}
+// @Failure		403 {object} helpers.APIResponse{error=helpers.APIError}
+// @Failure		404 {object} helpers.APIResponse{error=helpers.APIError}
+// @Failure		500 {object} helpers.APIResponse{error=helpers.APIError}
+// @Router		/streaming/{videoid}/chapters/{chapterid} [delete]
+func (cc *ChapterControllerImpl) DeleteChapter(c *gin.Context) {
+	video, ok := cc.findOwnVideo(c)
+	if !ok {
+		return
+	}
+
+	chapter, ok := cc.findVideoChapter(c, video)
+	if !ok {
+		return
+	}
+
+	if err := cc.chapterService.DeleteChapter(chapter.Id); err != nil {
+		helpers.HandleError(c, http.StatusInternalServerError, "Could not delete chapter", err)
+		return
+	}
+
+	helpers.Success(c, http.StatusOK, gin.H{"message": "Chapter deleted successfully"})
+}
+
+// findOwnVideo busca el video de la ruta y verifica que sea del usuario autenticado.
+// Si falla responde el error y retorna false.
+func (cc *ChapterControllerImpl) findOwnVideo(c *gin.Context) (*models.VideoModel, bool) {
+	user, exists := c.Get("user")
+	if !exists {
+		helpers.HandleError(c, http.StatusInternalServerError, "User not found in context", nil)
+		return nil, false
+	}
+	authenticatedUser := user.(*models.User)
+
+	video, err := cc.databaseVideoService.FindVideoByID(c.Param("videoid"))
+	if err != nil {
+		helpers.HandleError(c, http.StatusNotFound, "Video not found", err)
+		return nil, false
+	}
+
+	if video.UserID != authenticatedUser.Id {
+		helpers.HandleError(c, http.StatusForbidden, "You are not the owner of this video", nil)
+		return nil, false
+	}
+
+	return video, true
+}
+
+// findVisibleVideo busca el video de la ruta; uno suspendido o sin publicar solo lo ve su dueño
+// y para el resto responde 404. Si falla responde el error y retorna false.
+func (cc *ChapterControllerImpl) findVisibleVideo(c *gin.Context) (*models.VideoModel, bool) {
+	video, err := cc.databaseVideoService.FindVideoByID(c.Param("videoid"))
+	if err != nil || !canViewVideo(c, video) {
+		helpers.HandleError(c, http.StatusNotFound, "Video not found", err)
+		return nil, false
+	}
+
+	return video, true
+}
+
+// findVideoChapter busca el capítulo de la ruta; un capítulo de otro video responde 404
+func (cc *ChapterControllerImpl) findVideoChapter(c *gin.Context, video *models.VideoModel) (*models.Chapter, bool) {
+	chapter, err := cc.chapterService.FindChapterByID(c.Param("chapterid"))
+	if err != nil || chapter.VideoID != video.Id {
+		helpers.HandleError(c, http.StatusNotFound, "Chapter not found", err)
+		return nil, false
+	}
+
+	return chapter, true
+}
+
+// bindChapterRequest valida el body y que el capítulo empiece dentro del video
+func bindChapterRequest(c *gin.Context, video *models.VideoModel) (*ChapterRequest, bool) {
+	var req ChapterRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		helpers.HandleError(c, http.StatusBadRequest, "start_seconds is required and title must be 1 to 100 characters", err)
+		return nil, false
+	}
+
+	if duration := services.DurationSeconds(video.Duration); duration > 0 && *req.StartSeconds >= duration {
+		helpers.HandleError(c, http.StatusBadRequest, "start_seconds must be within the video's duration", nil)
+		return nil, false
+	}
+
+	return &req, true
+}
+
+// handleChapterError responde los errores de negocio de capítulos con su status
+func handleChapterError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, services.ErrChapterExists):
+		helpers.HandleError(c, http.StatusConflict, "A chapter already starts at that second", err)
+	case errors.Is(err, services.ErrTooManyChapters):
+		helpers.HandleError(c, http.StatusBadRequest, "The video reached the maximum number of chapters", err)
+	default:
+		helpers.HandleError(c, http.StatusInternalServerError, message, err)
+	}
+}
@@ -29,6 +29,7 @@ func NewJobController(jobService services.JobService) JobController {
 // @Tags		jobs
 // @Produce		json
 // @Security	BearerAuth
+// @Security	APIKeyAuth
 // @Param		jobid path string true "Job ID"
 // @Success		200 {object} helpers.APIResponse{data=models.JobSwagger}
 // @Failure		401 {object} helpers.APIResponse{error=helpers.APIError}
@@ -0,0 +1,85 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/unbot2313/go-streaming-service/internal/helpers"
+	"github.com/unbot2313/go-streaming-service/internal/models"
+	"github.com/unbot2313/go-streaming-service/internal/services"
+)
+
+type KeyController interface {
+	GetKey(c *gin.Context)
+}
+
+type KeyControllerImpl struct {
+	hlsKeyService        services.HLSKeyService
+	databaseVideoService services.DatabaseVideoService
+}
+
+func NewKeyController(hlsKeyService services.HLSKeyService, databaseVideoService services.DatabaseVideoService) KeyController {
+	return &KeyControllerImpl{
+		hlsKeyService:        hlsKeyService,
+		databaseVideoService: databaseVideoService,
+	}
+}
+
+// GetKey godoc
+// @Summary		Get an HLS decryption key
+// @Description	Returns the raw AES-128 key of an encrypted video. The URI of each `#EXT-X-KEY` tag in the playlists points here; the player must send the access token. The owner can always get the keys; any other authenticated user or API key only once the video is published. There is no per-user entitlement: encryption only keeps anonymous clients and hotlinking players from decrypting the segments.
+// @Tags		streaming
+// @Produce		octet-stream
+// @Security	BearerAuth
+// @Security	APIKeyAuth
+// @Param		videoid path string true "Video ID"
+// @Param		kid query string true "Key ID"
+// @Success		200 {file} binary "16-byte AES-128 key"
+// @Failure		400 {object} helpers.APIResponse{error=helpers.APIError}
+// @Failure		401 {object} helpers.APIResponse{error=helpers.APIError}
+// @Failure		403 {object} helpers.APIResponse{error=helpers.APIError}
+// @Failure		404 {object} helpers.APIResponse{error=helpers.APIError}
+// @Failure		500 {object} helpers.APIResponse{error=helpers.APIError}
+// @Router		/streaming/{videoid}/key [get]
+func (kc *KeyControllerImpl) GetKey(c *gin.Context) {
+	user, exists := c.Get("user")
+	if !exists {
+		helpers.HandleError(c, http.StatusInternalServerError, "User not found in context", nil)
+		return
+	}
+	authenticatedUser := user.(*models.User)
+
+	keyId := c.Query("kid")
+	if keyId == "" {
+		helpers.HandleError(c, http.StatusBadRequest, "kid es requerido", nil)
+		return
+	}
+
+	video, err := kc.databaseVideoService.FindVideoByID(c.Param("videoid"))
+	if err != nil {
+		helpers.HandleError(c, http.StatusNotFound, "Video not found", err)
+		return
+	}
+
+	// Mientras el video esté programado o suspendido solo su dueño puede reproducirlo.
+	// Publicado, cualquier usuario autenticado obtiene la clave: el cifrado solo evita que
+	// clientes anónimos o players de otros sitios reproduzcan los segmentos.
+	if video.UserID != authenticatedUser.Id && (!video.IsPublished() || video.IsSuspended()) {
+		helpers.HandleError(c, http.StatusForbidden, "You are not allowed to play this video", nil)
+		return
+	}
+
+	key, err := kc.hlsKeyService.GetKey(video.Id, keyId)
+	if err != nil {
+		if errors.Is(err, services.ErrVideoKeyNotFound) {
+			helpers.HandleError(c, http.StatusNotFound, "Key not found", err)
+			return
+		}
+		helpers.HandleError(c, http.StatusInternalServerError, "Could not retrieve key", err)
+		return
+	}
+
+	c.Header("Cache-Control", "no-store")
+	c.Data(http.StatusOK, "application/octet-stream", key)
+}
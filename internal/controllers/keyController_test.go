@@ -0,0 +1,109 @@
+package controllers
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/unbot2313/go-streaming-service/internal/mocks"
+	"github.com/unbot2313/go-streaming-service/internal/models"
+	"github.com/unbot2313/go-streaming-service/internal/services"
+)
+
+func setupKeyRouter(controller KeyController) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+
+	// Rutas protegidas con usuario simulado
+	protected := r.Group("")
+	protected.Use(func(c *gin.Context) {
+		c.Set("user", &models.User{Id: "user-123", Username: "testuser"})
+		c.Next()
+	})
+	protected.GET("/streaming/:videoid/key", controller.GetKey)
+
+	return r
+}
+
+func TestGetKey_Owner(t *testing.T) {
+	key := bytes.Repeat([]byte{0x2a}, 16)
+	mockKeys := &mocks.MockHLSKeyService{
+		GetKeyFn: func(videoId, keyId string) ([]byte, error) {
+			if videoId != "video-1" || keyId != "key-1" {
+				t.Errorf("unexpected key lookup: %s %s", videoId, keyId)
+			}
+			return key, nil
+		},
+	}
+
+	controller := NewKeyController(mockKeys, newOwnedVideoService("user-123"))
+	router := setupKeyRouter(controller)
+
+	req, _ := http.NewRequest("GET", "/streaming/video-1/key?kid=key-1", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, w.Code)
+	}
+	if !bytes.Equal(w.Body.Bytes(), key) {
+		t.Errorf("unexpected key: %x", w.Body.Bytes())
+	}
+	if w.Header().Get("Cache-Control") != "no-store" {
+		t.Errorf("expected Cache-Control no-store, got %q", w.Header().Get("Cache-Control"))
+	}
+}
+
+func TestGetKey_UnpublishedVideoOfAnotherUser(t *testing.T) {
+	mockKeys := &mocks.MockHLSKeyService{
+		GetKeyFn: func(videoId, keyId string) ([]byte, error) {
+			t.Error("the key must not be looked up")
+			return nil, nil
+		},
+	}
+
+	controller := NewKeyController(mockKeys, newOwnedVideoService("other-user"))
+	router := setupKeyRouter(controller)
+
+	req, _ := http.NewRequest("GET", "/streaming/video-1/key?kid=key-1", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusForbidden {
+		t.Errorf("expected status %d, got %d", http.StatusForbidden, w.Code)
+	}
+}
+
+func TestGetKey_NotFound(t *testing.T) {
+	mockKeys := &mocks.MockHLSKeyService{
+		GetKeyFn: func(videoId, keyId string) ([]byte, error) {
+			return nil, services.ErrVideoKeyNotFound
+		},
+	}
+
+	controller := NewKeyController(mockKeys, newOwnedVideoService("user-123"))
+	router := setupKeyRouter(controller)
+
+	req, _ := http.NewRequest("GET", "/streaming/video-1/key?kid=missing", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusNotFound {
+		t.Errorf("expected status %d, got %d", http.StatusNotFound, w.Code)
+	}
+}
+
+func TestGetKey_MissingKid(t *testing.T) {
+	controller := NewKeyController(&mocks.MockHLSKeyService{}, newOwnedVideoService("user-123"))
+	router := setupKeyRouter(controller)
+
+	req, _ := http.NewRequest("GET", "/streaming/video-1/key", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected status %d, got %d", http.StatusBadRequest, w.Code)
+	}
+}
@@ -0,0 +1,337 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/unbot2313/go-streaming-service/internal/helpers"
+	"github.com/unbot2313/go-streaming-service/internal/models"
+	"github.com/unbot2313/go-streaming-service/internal/services"
+)
+
+type ModerationController interface {
+	GetUsers(c *gin.Context)
+	SuspendUser(c *gin.Context)
+	UnsuspendUser(c *gin.Context)
+	DeleteUser(c *gin.Context)
+	SetUserRole(c *gin.Context)
+	GetVideos(c *gin.Context)
+	SuspendVideo(c *gin.Context)
+	UnsuspendVideo(c *gin.Context)
+	DeleteVideo(c *gin.Context)
+}
+
+type ModerationControllerImpl struct {
+	moderationService    services.ModerationService
+	userService          services.UserService
+	databaseVideoService services.DatabaseVideoService
+}
+
+func NewModerationController(moderationService services.ModerationService, userService services.UserService, databaseVideoService services.DatabaseVideoService) ModerationController {
+	return &ModerationControllerImpl{
+		moderationService:    moderationService,
+		userService:          userService,
+		databaseVideoService: databaseVideoService,
+	}
+}
+
+// SetUserRoleRequest valida el rol que asigna un administrador
+type SetUserRoleRequest struct {
+	Role string `json:"role" binding:"required,oneof=user moderator admin" example:"moderator"`
+}
+
+// GetUsers godoc
+// @Summary		List all users
+// @Description	List every user, including suspended ones, newest first. Requires the moderator or admin role.
+// @Tags		admin
+// @Produce		json
+// @Security	BearerAuth
+// @Param		page query int false "Page number (default: 1)" default(1)
+// @Param		page_size query int false "Items per page (default: 20, max: 100)" default(20)
+// @Success		200 {object} helpers.APIResponse{data=services.PaginatedUsers}
+// @Failure		401 {object} helpers.APIResponse{error=helpers.APIError}
+// @Failure		403 {object} helpers.APIResponse{error=helpers.APIError}
+// @Failure		500 {object} helpers.APIResponse{error=helpers.APIError}
+// @Router		/admin/users [get]
+func (mc *ModerationControllerImpl) GetUsers(c *gin.Context) {
+	page, pageSize := moderationPage(c)
+
+	users, err := mc.moderationService.ListUsers(page, pageSize)
+	if err != nil {
+		helpers.HandleError(c, http.StatusInternalServerError, "Could not retrieve users", err)
+		return
+	}
+
+	helpers.Success(c, http.StatusOK, users)
+}
+
+// SuspendUser godoc
+// @Summary		Suspend a user
+// @Description	Suspend an account: the user can no longer log in or refresh their session. Moderators can only suspend regular users, and nobody can suspend themselves.
+// @Tags		admin
+// @Produce		json
+// @Security	BearerAuth
+// @Param		id path string true "User ID"
+// @Success		200 {object} helpers.APIResponse{data=models.UserSwagger}
+// @Failure		401 {object} helpers.APIResponse{error=helpers.APIError}
+// @Failure		403 {object} helpers.APIResponse{error=helpers.APIError}
+// @Failure		404 {object} helpers.APIResponse{error=helpers.APIError}
+// @Failure		500 {object} helpers.APIResponse{error=helpers.APIError}
+// @Router		/admin/users/{id}/suspension [put]
+func (mc *ModerationControllerImpl) SuspendUser(c *gin.Context) {
+	mc.setUserSuspended(c, true)
+}
+
+// UnsuspendUser godoc
+// @Summary		Lift a user's suspension
+// @Description	Reactivate a suspended account. Moderators can only reactivate regular users.
+// @Tags		admin
+// @Produce		json
+// @Security	BearerAuth
+// @Param		id path string true "User ID"
+// @Success		200 {object} helpers.APIResponse{data=models.UserSwagger}
+// @Failure		401 {object} helpers.APIResponse{error=helpers.APIError}
+// @Failure		403 {object} helpers.APIResponse{error=helpers.APIError}
+// @Failure		404 {object} helpers.APIResponse{error=helpers.APIError}
+// @Failure		500 {object} helpers.APIResponse{error=helpers.APIError}
+// @Router		/admin/users/{id}/suspension [delete]
+func (mc *ModerationControllerImpl) UnsuspendUser(c *gin.Context) {
+	mc.setUserSuspended(c, false)
+}
+
+func (mc *ModerationControllerImpl) setUserSuspended(c *gin.Context, suspended bool) {
+	target, ok := mc.findModeratedUser(c)
+	if !ok {
+		return
+	}
+
+	user, err := mc.moderationService.SetUserSuspended(target.Id, suspended)
+	if err != nil {
+		handleModerationError(c, err, "Could not update user")
+		return
+	}
+
+	helpers.Success(c, http.StatusOK, user)
+}
+
+// DeleteUser godoc
+// @Summary		Delete any user
+// @Description	Delete a user account. Moderators can only delete regular users, and nobody can delete themselves from here.
+// @Tags		admin
+// @Produce		json
+// @Security	BearerAuth
+// @Param		id path string true "User ID"
+// @Success		200 {object} helpers.APIResponse{data=object{message=string}}
+// @Failure		401 {object} helpers.APIResponse{error=helpers.APIError}
+// @Failure		403 {object} helpers.APIResponse{error=helpers.APIError}
+// @Failure		404 {object} helpers.APIResponse{error=helpers.APIError}
+// @Failure		500 {object} helpers.APIResponse{error=helpers.APIError}
+// @Router		/admin/users/{id} [delete]
+func (mc *ModerationControllerImpl) DeleteUser(c *gin.Context) {
+	target, ok := mc.findModeratedUser(c)
+	if !ok {
+		return
+	}
+
+	if err := mc.userService.DeleteUserByID(target.Id); err != nil {
+		helpers.HandleError(c, http.StatusInternalServerError, "Could not delete user", err)
+		return
+	}
+
+	helpers.Success(c, http.StatusOK, gin.H{"message": "User deleted"})
+}
+
+// SetUserRole godoc
+// @Summary		Change a user's role
+// @Description	Set the role of a user (user, moderator or admin). Requires the admin role; admins cannot change their own role. The user's access tokens are invalidated; the new role applies once the client refreshes.
+// @Tags		admin
+// @Accept		json
+// @Produce		json
+// @Security	BearerAuth
+// @Param		id path string true "User ID"
+// @Param		body body SetUserRoleRequest true "New role"
+// @Success		200 {object} helpers.APIResponse{data=models.UserSwagger}
+// @Failure		400 {object} helpers.APIResponse{error=helpers.APIError}
+// @Failure		401 {object} helpers.APIResponse{error=helpers.APIError}
+// @Failure		403 {object} helpers.APIResponse{error=helpers.APIError}
+// @Failure		404 {object} helpers.APIResponse{error=helpers.APIError}
+// @Failure		500 {object} helpers.APIResponse{error=helpers.APIError}
+// @Router		/admin/users/{id}/role [put]
+func (mc *ModerationControllerImpl) SetUserRole(c *gin.Context) {
+	var req SetUserRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		helpers.HandleError(c, http.StatusBadRequest, "role debe ser user, moderator o admin", err)
+		return
+	}
+
+	target, ok := mc.findModeratedUser(c)
+	if !ok {
+		return
+	}
+
+	user, err := mc.moderationService.SetUserRole(target.Id, req.Role)
+	if err != nil {
+		handleModerationError(c, err, "Could not update user")
+		return
+	}
+
+	helpers.Success(c, http.StatusOK, user)
+}
+
+// GetVideos godoc
+// @Summary		List all videos
+// @Description	List every video, including scheduled and suspended ones, newest first. Requires the moderator or admin role.
+// @Tags		admin
+// @Produce		json
+// @Security	BearerAuth
+// @Param		page query int false "Page number (default: 1)" default(1)
+// @Param		page_size query int false "Items per page (default: 20, max: 100)" default(20)
+// @Success		200 {object} helpers.APIResponse{data=services.PaginatedVideos}
+// @Failure		401 {object} helpers.APIResponse{error=helpers.APIError}
+// @Failure		403 {object} helpers.APIResponse{error=helpers.APIError}
+// @Failure		500 {object} helpers.APIResponse{error=helpers.APIError}
+// @Router		/admin/videos [get]
+func (mc *ModerationControllerImpl) GetVideos(c *gin.Context) {
+	page, pageSize := moderationPage(c)
+
+	videos, err := mc.moderationService.ListVideos(page, pageSize)
+	if err != nil {
+		helpers.HandleError(c, http.StatusInternalServerError, "Could not retrieve videos", err)
+		return
+	}
+
+	helpers.Success(c, http.StatusOK, videos)
+}
+
+// SuspendVideo godoc
+// @Summary		Suspend a video
+// @Description	Hide a video from listings, search, tags and podcast feeds. Only its owner can still play it.
+// @Tags		admin
+// @Produce		json
+// @Security	BearerAuth
+// @Param		videoid path string true "Video ID"
+// @Success		200 {object} helpers.APIResponse{data=models.VideoSwagger}
+// @Failure		401 {object} helpers.APIResponse{error=helpers.APIError}
+// @Failure		403 {object} helpers.APIResponse{error=helpers.APIError}
+// @Failure		404 {object} helpers.APIResponse{error=helpers.APIError}
+// @Failure		500 {object} helpers.APIResponse{error=helpers.APIError}
+// @Router		/admin/videos/{videoid}/suspension [put]
+func (mc *ModerationControllerImpl) SuspendVideo(c *gin.Context) {
+	mc.setVideoSuspended(c, true)
+}
+
+// UnsuspendVideo godoc
+// @Summary		Lift a video's suspension
+// @Description	Make a suspended video visible again.
+// @Tags		admin
+// @Produce		json
+// @Security	BearerAuth
+// @Param		videoid path string true "Video ID"
+// @Success		200 {object} helpers.APIResponse{data=models.VideoSwagger}
+// @Failure		401 {object} helpers.APIResponse{error=helpers.APIError}
+// @Failure		403 {object} helpers.APIResponse{error=helpers.APIError}
+// @Failure		404 {object} helpers.APIResponse{error=helpers.APIError}
+// @Failure		500 {object} helpers.APIResponse{error=helpers.APIError}
+// @Router		/admin/videos/{videoid}/suspension [delete]
+func (mc *ModerationControllerImpl) UnsuspendVideo(c *gin.Context) {
+	mc.setVideoSuspended(c, false)
+}
+
+func (mc *ModerationControllerImpl) setVideoSuspended(c *gin.Context, suspended bool) {
+	video, err := mc.moderationService.SetVideoSuspended(c.Param("videoid"), suspended)
+	if err != nil {
+		handleModerationError(c, err, "Could not update video")
+		return
+	}
+
+	helpers.Success(c, http.StatusOK, video)
+}
+
+// DeleteVideo godoc
+// @Summary		Delete any video
+// @Description	Delete a video regardless of its owner. The owner gets the video.deleted webhook as usual.
+// @Tags		admin
+// @Produce		json
+// @Security	BearerAuth
+// @Param		videoid path string true "Video ID"
+// @Success		200 {object} helpers.APIResponse{data=object{message=string}}
+// @Failure		401 {object} helpers.APIResponse{error=helpers.APIError}
+// @Failure		403 {object} helpers.APIResponse{error=helpers.APIError}
+// @Failure		404 {object} helpers.APIResponse{error=helpers.APIError}
+// @Failure		500 {object} helpers.APIResponse{error=helpers.APIError}
+// @Router		/admin/videos/{videoid} [delete]
+func (mc *ModerationControllerImpl) DeleteVideo(c *gin.Context) {
+	video, err := mc.databaseVideoService.FindVideoByID(c.Param("videoid"))
+	if err != nil {
+		helpers.HandleError(c, http.StatusNotFound, "Video not found", err)
+		return
+	}
+
+	if err := mc.databaseVideoService.DeleteVideo(video.Id); err != nil {
+		helpers.HandleError(c, http.StatusInternalServerError, "Could not delete video", err)
+		return
+	}
+
+	helpers.Success(c, http.StatusOK, gin.H{"message": "Video deleted successfully"})
+}
+
+// findModeratedUser busca el usuario de la ruta y verifica que quien llama puede moderarlo:
+// nadie se modera a sí mismo y los moderadores solo gestionan usuarios comunes
+func (mc *ModerationControllerImpl) findModeratedUser(c *gin.Context) (*models.User, bool) {
+	user, exists := c.Get("user")
+	if !exists {
+		helpers.HandleError(c, http.StatusInternalServerError, "User not found in context", nil)
+		return nil, false
+	}
+	authenticatedUser := user.(*models.User)
+
+	target, err := mc.userService.GetUserByID(c.Param("id"))
+	if err != nil {
+		helpers.HandleError(c, http.StatusNotFound, "User not found", err)
+		return nil, false
+	}
+
+	if target.Id == authenticatedUser.Id {
+		helpers.HandleError(c, http.StatusForbidden, "You cannot moderate your own account", nil)
+		return nil, false
+	}
+
+	if !authenticatedUser.HasRole(models.UserRoleAdmin) && target.Role != models.UserRoleUser {
+		helpers.HandleError(c, http.StatusForbidden, "Only admins can moderate moderators and admins", nil)
+		return nil, false
+	}
+
+	return target, true
+}
+
+// moderationPage lee la paginación de los listados de moderación
+func moderationPage(c *gin.Context) (int, int) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
+
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 {
+		pageSize = 20
+	}
+	if pageSize > 100 {
+		pageSize = 100
+	}
+
+	return page, pageSize
+}
+
+// handleModerationError traduce los errores del servicio de moderación a respuestas HTTP
+func handleModerationError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, services.ErrUserNotFound):
+		helpers.HandleError(c, http.StatusNotFound, "User not found", err)
+	case errors.Is(err, services.ErrVideoNotFound):
+		helpers.HandleError(c, http.StatusNotFound, "Video not found", err)
+	default:
+		helpers.HandleError(c, http.StatusInternalServerError, message, err)
+	}
+}
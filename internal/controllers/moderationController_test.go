@@ -0,0 +1,228 @@
+package controllers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/unbot2313/go-streaming-service/internal/middlewares"
+	"github.com/unbot2313/go-streaming-service/internal/mocks"
+	"github.com/unbot2313/go-streaming-service/internal/models"
+	"github.com/unbot2313/go-streaming-service/internal/services"
+)
+
+// setupModerationRouter arma las rutas como en routes.go con el rol simulado de quien llama
+func setupModerationRouter(controller ModerationController, role string) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+
+	admin := r.Group("/admin")
+	admin.Use(func(c *gin.Context) {
+		c.Set("user", &models.User{Id: "mod-1", Username: "mod", Role: role})
+		c.Next()
+	}, middlewares.RequireRole(models.UserRoleModerator, models.UserRoleAdmin))
+	admin.GET("/users", controller.GetUsers)
+	admin.PUT("/users/:id/suspension", controller.SuspendUser)
+	admin.DELETE("/users/:id", controller.DeleteUser)
+	admin.PUT("/videos/:videoid/suspension", controller.SuspendVideo)
+
+	platform := admin.Group("")
+	platform.Use(middlewares.RequireRole(models.UserRoleAdmin))
+	platform.PUT("/users/:id/role", controller.SetUserRole)
+
+	return r
+}
+
+func newUserServiceWithRole(role string) *mocks.MockUserService {
+	return &mocks.MockUserService{
+		GetUserByIDFn: func(id string) (*models.User, error) {
+			return &models.User{Id: id, Username: "target", Role: role}, nil
+		},
+	}
+}
+
+func TestGetUsers_RequiresRole(t *testing.T) {
+	controller := NewModerationController(&mocks.MockModerationService{}, nil, nil)
+	router := setupModerationRouter(controller, models.UserRoleUser)
+
+	req, _ := http.NewRequest("GET", "/admin/users", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusForbidden {
+		t.Errorf("expected status %d, got %d", http.StatusForbidden, w.Code)
+	}
+}
+
+func TestGetUsers_Moderator(t *testing.T) {
+	mockModeration := &mocks.MockModerationService{
+		ListUsersFn: func(page, pageSize int) (*services.PaginatedUsers, error) {
+			if page != 2 || pageSize != 100 {
+				t.Errorf("unexpected pagination: page=%d page_size=%d", page, pageSize)
+			}
+			return &services.PaginatedUsers{Data: []*models.User{{Id: "user-1"}}, Page: page, PageSize: pageSize, Total: 1}, nil
+		},
+	}
+
+	controller := NewModerationController(mockModeration, nil, nil)
+	router := setupModerationRouter(controller, models.UserRoleModerator)
+
+	req, _ := http.NewRequest("GET", "/admin/users?page=2&page_size=500", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, w.Code)
+	}
+}
+
+func TestSuspendUser_Success(t *testing.T) {
+	now := time.Now()
+	mockModeration := &mocks.MockModerationService{
+		SetUserSuspendedFn: func(userId string, suspended bool) (*models.User, error) {
+			if userId != "user-1" || !suspended {
+				t.Errorf("unexpected suspension: %s %v", userId, suspended)
+			}
+			return &models.User{Id: userId, Role: models.UserRoleUser, SuspendedAt: &now}, nil
+		},
+	}
+
+	controller := NewModerationController(mockModeration, newUserServiceWithRole(models.UserRoleUser), nil)
+	router := setupModerationRouter(controller, models.UserRoleModerator)
+
+	req, _ := http.NewRequest("PUT", "/admin/users/user-1/suspension", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, w.Code)
+	}
+
+	var response struct {
+		Data models.User `json:"data"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &response)
+
+	if response.Data.SuspendedAt == nil {
+		t.Error("expected suspended_at in the response")
+	}
+}
+
+func TestSuspendUser_ModeratorCannotSuspendAdmin(t *testing.T) {
+	mockModeration := &mocks.MockModerationService{
+		SetUserSuspendedFn: func(userId string, suspended bool) (*models.User, error) {
+			t.Error("the admin must not be suspended")
+			return nil, nil
+		},
+	}
+
+	controller := NewModerationController(mockModeration, newUserServiceWithRole(models.UserRoleAdmin), nil)
+	router := setupModerationRouter(controller, models.UserRoleModerator)
+
+	req, _ := http.NewRequest("PUT", "/admin/users/admin-1/suspension", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusForbidden {
+		t.Errorf("expected status %d, got %d", http.StatusForbidden, w.Code)
+	}
+}
+
+func TestDeleteUser_CannotDeleteSelf(t *testing.T) {
+	mockUser := newUserServiceWithRole(models.UserRoleAdmin)
+	mockUser.DeleteUserByIDFn = func(id string) error {
+		t.Error("the caller must not be deleted")
+		return nil
+	}
+
+	controller := NewModerationController(&mocks.MockModerationService{}, mockUser, nil)
+	router := setupModerationRouter(controller, models.UserRoleAdmin)
+
+	req, _ := http.NewRequest("DELETE", "/admin/users/mod-1", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusForbidden {
+		t.Errorf("expected status %d, got %d", http.StatusForbidden, w.Code)
+	}
+}
+
+func TestSetUserRole_RequiresAdmin(t *testing.T) {
+	controller := NewModerationController(&mocks.MockModerationService{}, newUserServiceWithRole(models.UserRoleUser), nil)
+	router := setupModerationRouter(controller, models.UserRoleModerator)
+
+	req, _ := http.NewRequest("PUT", "/admin/users/user-1/role", bytes.NewBufferString(`{"role":"moderator"}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusForbidden {
+		t.Errorf("expected status %d, got %d", http.StatusForbidden, w.Code)
+	}
+}
+
+func TestSetUserRole_Admin(t *testing.T) {
+	mockModeration := &mocks.MockModerationService{
+		SetUserRoleFn: func(userId, role string) (*models.User, error) {
+			return &models.User{Id: userId, Role: role}, nil
+		},
+	}
+
+	controller := NewModerationController(mockModeration, newUserServiceWithRole(models.UserRoleUser), nil)
+	router := setupModerationRouter(controller, models.UserRoleAdmin)
+
+	req, _ := http.NewRequest("PUT", "/admin/users/user-1/role", bytes.NewBufferString(`{"role":"moderator"}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, w.Code)
+	}
+
+	var response struct {
+		Data models.User `json:"data"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &response)
+
+	if response.Data.Role != models.UserRoleModerator {
+		t.Errorf("expected role moderator, got %q", response.Data.Role)
+	}
+}
+
+func TestSetUserRole_InvalidRole(t *testing.T) {
+	controller := NewModerationController(&mocks.MockModerationService{}, newUserServiceWithRole(models.UserRoleUser), nil)
+	router := setupModerationRouter(controller, models.UserRoleAdmin)
+
+	req, _ := http.NewRequest("PUT", "/admin/users/user-1/role", bytes.NewBufferString(`{"role":"owner"}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected status %d, got %d", http.StatusBadRequest, w.Code)
+	}
+}
+
+func TestSuspendVideo_NotFound(t *testing.T) {
+	mockModeration := &mocks.MockModerationService{
+		SetVideoSuspendedFn: func(videoId string, suspended bool) (*models.VideoModel, error) {
+			return nil, services.ErrVideoNotFound
+		},
+	}
+
+	controller := NewModerationController(mockModeration, nil, nil)
+	router := setupModerationRouter(controller, models.UserRoleModerator)
+
+	req, _ := http.NewRequest("PUT", "/admin/videos/missing/suspension", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusNotFound {
+		t.Errorf("expected status %d, got %d", http.StatusNotFound, w.Code)
+	}
+}
@@ -0,0 +1,57 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/unbot2313/go-streaming-service/internal/helpers"
+	"github.com/unbot2313/go-streaming-service/internal/services"
+)
+
+type PodcastController interface {
+	GetFeed(c *gin.Context)
+}
+
+type PodcastControllerImpl struct {
+	podcastService services.PodcastService
+}
+
+func NewPodcastController(podcastService services.PodcastService) PodcastController {
+	return &PodcastControllerImpl{
+		podcastService: podcastService,
+	}
+}
+
+// GetFeed godoc
+// @Summary		Get a user's podcast feed
+// @Description	RSS 2.0 feed with the user's published audio-only uploads, newest first. Each episode's enclosure is a single M4A file, so it works in any podcast app.
+// @Tags		podcasts
+// @Produce		xml
+// @Param		username path string true "User Name"
+// @Success		200 {string} string "RSS 2.0 feed"
+// @Failure		404 {object} helpers.APIResponse{error=helpers.APIError}
+// @Failure		500 {object} helpers.APIResponse{error=helpers.APIError}
+// @Router		/podcasts/{username}/feed.xml [get]
+func (pc *PodcastControllerImpl) GetFeed(c *gin.Context) {
+	username := c.Param("username")
+
+	// El feed se referencia a sí mismo (atom:link rel="self") con la URL pedida
+	scheme := "http"
+	if c.Request.TLS != nil || c.GetHeader("X-Forwarded-Proto") == "https" {
+		scheme = "https"
+	}
+	feedURL := scheme + "://" + c.Request.Host + c.Request.URL.Path
+
+	feed, err := pc.podcastService.BuildFeed(username, feedURL)
+	if err != nil {
+		if errors.Is(err, services.ErrPodcastNotFound) {
+			helpers.HandleError(c, http.StatusNotFound, "User not found", err)
+			return
+		}
+		helpers.HandleError(c, http.StatusInternalServerError, "Could not build podcast feed", err)
+		return
+	}
+
+	c.Data(http.StatusOK, "application/rss+xml; charset=utf-8", feed)
+}
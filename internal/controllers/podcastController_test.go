@@ -0,0 +1,75 @@
+package controllers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/unbot2313/go-streaming-service/internal/mocks"
+	"github.com/unbot2313/go-streaming-service/internal/services"
+)
+
+func setupPodcastRouter(controller PodcastController) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/podcasts/:username/feed.xml", controller.GetFeed)
+	return r
+}
+
+func TestGetPodcastFeed_Success(t *testing.T) {
+	var receivedUsername, receivedURL string
+
+	mockPodcast := &mocks.MockPodcastService{
+		BuildFeedFn: func(username, feedURL string) ([]byte, error) {
+			receivedUsername = username
+			receivedURL = feedURL
+			return []byte(`<rss version="2.0"></rss>`), nil
+		},
+	}
+
+	controller := NewPodcastController(mockPodcast)
+	router := setupPodcastRouter(controller)
+
+	req, _ := http.NewRequest("GET", "/podcasts/testuser/feed.xml", nil)
+	req.Host = "api.example.com"
+	req.Header.Set("X-Forwarded-Proto", "https")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("expected status %d, got %d", http.StatusOK, w.Code)
+	}
+
+	if !strings.HasPrefix(w.Header().Get("Content-Type"), "application/rss+xml") {
+		t.Errorf("expected RSS content type, got %s", w.Header().Get("Content-Type"))
+	}
+
+	if receivedUsername != "testuser" {
+		t.Errorf("expected username testuser, got %s", receivedUsername)
+	}
+
+	if receivedURL != "https://api.example.com/podcasts/testuser/feed.xml" {
+		t.Errorf("unexpected feed URL: %s", receivedURL)
+	}
+}
+
+func TestGetPodcastFeed_UserNotFound(t *testing.T) {
+	mockPodcast := &mocks.MockPodcastService{
+		BuildFeedFn: func(username, feedURL string) ([]byte, error) {
+			return nil, services.ErrPodcastNotFound
+		},
+	}
+
+	controller := NewPodcastController(mockPodcast)
+	router := setupPodcastRouter(controller)
+
+	req, _ := http.NewRequest("GET", "/podcasts/missing/feed.xml", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusNotFound {
+		t.Errorf("expected status %d, got %d", http.StatusNotFound, w.Code)
+	}
+}
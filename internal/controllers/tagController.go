@@ -99,6 +99,7 @@ func (tc *TagControllerImpl) GetVideosByTag(c *gin.Context) {
 // @Accept		json
 // @Produce		json
 // @Security	BearerAuth
+// @Security	APIKeyAuth
 // @Param		videoid path string true "Video ID"
 // @Param		body body AddTagsRequest true "Tags to add"
 // @Success		200 {object} helpers.APIResponse{data=object{message=string}}
@@ -149,6 +150,7 @@ func (tc *TagControllerImpl) AddTagsToVideo(c *gin.Context) {
 // @Accept		json
 // @Produce		json
 // @Security	BearerAuth
+// @Security	APIKeyAuth
 // @Param		videoid path string true "Video ID"
 // @Param		body body RemoveTagRequest true "Tag to remove"
 // @Success		200 {object} helpers.APIResponse{data=object{message=string}}
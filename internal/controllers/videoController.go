@@ -2,12 +2,14 @@ package controllers
 
 import (
 	"encoding/json"
+	"errors"
 	"log/slog"
+	"mime/multipart"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
-	"github.com/unbot2313/go-streaming-service/config"
 	"github.com/unbot2313/go-streaming-service/internal/helpers"
 	"github.com/unbot2313/go-streaming-service/internal/models"
 	"github.com/unbot2313/go-streaming-service/internal/services"
@@ -21,12 +23,24 @@ type VideoController interface {
 	UpdateVideo(c *gin.Context)
 	DeleteVideo(c *gin.Context)
 	SearchVideos(c *gin.Context)
+	RegenerateThumbnail(c *gin.Context)
+	ReplaceSource(c *gin.Context)
+	CreateClip(c *gin.Context)
+	GetVideoVersions(c *gin.Context)
+	RollbackVideoVersion(c *gin.Context)
 }
 
 // CreateVideoRequest valida los campos del formulario de upload
 type CreateVideoRequest struct {
-	Title       string `form:"title" binding:"required,min=1,max=100"`
-	Description string `form:"description" binding:"max=500"`
+	Title           string     `form:"title" binding:"required,min=1,max=100"`
+	Description     string     `form:"description" binding:"max=500"`
+	PublishAt       *time.Time `form:"publish_at" time_format:"2006-01-02T15:04:05Z07:00"`
+	// EncodingProfile elige el perfil de codificación; vacío usa el perfil por defecto
+	EncodingProfile string     `form:"encoding_profile" binding:"max=50"`
+	// SkipWatermark omite la marca de agua del usuario en este upload
+	SkipWatermark   bool       `form:"skip_watermark"`
+	// Encrypt cifra las renditions con AES-128; las claves se piden autenticado
+	Encrypt         bool       `form:"encrypt"`
 }
 
 // GetLatestVideos	godoc
@@ -65,7 +79,7 @@ func (vc *VideoControllerImpl) GetLatestVideos(c *gin.Context) {
 
 // GetVideoByID		godoc
 // @Summary 		Get a video by ID
-// @Description 	Get a video by its ID
+// @Description 	Get a video by its ID. Scheduled and suspended videos are only returned to their owner, who has to send an access token or an API key with the videos:read scope; anyone else gets a 404.
 // @Tags 			streaming
 // @Produce 		json
 // @Param 			videoid path string true "Video ID"
@@ -82,9 +96,25 @@ func (vc *VideoControllerImpl) GetVideoByID(c *gin.Context) {
 		return
 	}
 
+	if !canViewVideo(c, video) {
+		helpers.HandleError(c, http.StatusNotFound, "Video not found", nil)
+		return
+	}
+
 	helpers.Success(c, http.StatusOK, video)
 }
 
+// canViewVideo indica si quien hace la petición puede ver el video en una ruta pública.
+// Los videos programados y los suspendidos por un moderador solo los ve su dueño.
+func canViewVideo(c *gin.Context, video *models.VideoModel) bool {
+	if video.IsPublished() && !video.IsSuspended() {
+		return true
+	}
+
+	user, exists := c.Get("user")
+	return exists && user.(*models.User).Id == video.UserID
+}
+
 // IncrementViews		godoc
 // @Summary 		Increment the views of a video
 // @Description 	Increment the views of a video by 1
@@ -109,22 +139,26 @@ func (vc *VideoControllerImpl) IncrementViews(c *gin.Context) {
 
 // CreateVideo godoc
 // @Summary 		Upload a video for processing
-// @Description 	Upload a video file and queue it for async processing. Returns a job ID to track progress.
+// @Description 	Upload a video file and queue it for async processing. Returns a job ID to track progress. Audio files are transcoded to audio-only AAC HLS, get a waveform image as thumbnail and are published in the user's podcast feed.
 // @Tags 			streaming
 // @Accept 			multipart/form-data
 // @Produce 		json
 // @Security		BearerAuth
+// @Security		APIKeyAuth
+// @Param 			Idempotency-Key header string false "Unique key per upload; retrying with the same key returns the original job instead of creating a new one"
 // @Param 			title formData string true "Video Title"
 // @Param 			description formData string false "Video Description"
-// @Param 			video formData file true "Video File"
+// @Param 			publish_at formData string false "Scheduled publication time (RFC 3339). Until then the video is hidden from listings, search and tags"
+// @Param 			encoding_profile formData string false "Encoding profile name (default: default)"
+// @Param 			skip_watermark formData bool false "Do not burn the user's watermark into this upload"
+// @Param 			encrypt formData bool false "Encrypt the HLS segments with AES-128. Keys are served by GET /streaming/{videoid}/key to authenticated users. Not available for audio uploads"
+// @Param 			video formData file true "Video File, or an audio file (mp3, m4a, wav, flac) for an audio-only upload"
 // @Success 		202 {object} helpers.APIResponse{data=models.JobSwagger}
 // @Failure 		400 {object} helpers.APIResponse{error=helpers.APIError}
 // @Failure 		401 {object} helpers.APIResponse{error=helpers.APIError}
 // @Failure 		500 {object} helpers.APIResponse{error=helpers.APIError}
 // @Router 			/streaming/upload [post]
 func (vc *VideoControllerImpl) CreateVideo(c *gin.Context) {
-	cfg := config.GetConfig()
-
 	// 1. Recuperar el usuario del contexto (del middleware JWT)
 	user, exists := c.Get("user")
 	if !exists {
@@ -138,16 +172,38 @@ func (vc *VideoControllerImpl) CreateVideo(c *gin.Context) {
 		return
 	}
 
+	// Si el cliente reintenta con el mismo Idempotency-Key, responder con el job original
+	idempotencyKey := c.GetHeader("Idempotency-Key")
+	if len(idempotencyKey) > 255 {
+		helpers.HandleError(c, http.StatusBadRequest, "Idempotency-Key excede los 255 caracteres", nil)
+		return
+	}
+	if idempotencyKey != "" {
+		existingJob, err := vc.jobService.FindJobByIdempotencyKey(authenticatedUser.Id, idempotencyKey)
+		if err != nil {
+			helpers.HandleError(c, http.StatusInternalServerError, "Could not check Idempotency-Key", err)
+			return
+		}
+		if existingJob != nil {
+			respondJobAccepted(c, existingJob)
+			return
+		}
+	}
+
 	// 2. Validar campos requeridos (title obligatorio)
 	var req CreateVideoRequest
 	if err := c.ShouldBind(&req); err != nil {
 		helpers.HandleError(c, http.StatusBadRequest, "title es requerido (max 100 caracteres)", err)
 		return
 	}
+	if req.Encrypt && !services.HLSEncryptionEnabled() {
+		helpers.HandleError(c, http.StatusBadRequest, "El cifrado de videos no está habilitado en este servidor", services.ErrEncryptionNotConfigured)
+		return
+	}
 
 	// 3. Validar extensión del archivo
 	if !vc.videoService.IsValidVideoExtension(c) {
-		helpers.HandleError(c, http.StatusBadRequest, "El archivo no es un tipo de video valido", nil)
+		helpers.HandleError(c, http.StatusBadRequest, "El archivo no es un tipo de video o audio valido", nil)
 		return
 	}
 
@@ -166,49 +222,59 @@ func (vc *VideoControllerImpl) CreateVideo(c *gin.Context) {
 		helpers.HandleError(c, http.StatusInternalServerError, "Could not save video", err)
 		return
 	}
-
-	// 6. Crear Job en DB con status "pending"
-	job := &models.Job{
-		Id:          videoData.Id,
-		UserID:      authenticatedUser.Id,
-		Status:      "pending",
-		LocalPath:   videoData.LocalPath,
-		UniqueName:  videoData.UniqueName,
-		Title:       videoData.Title,
-		Description: videoData.Description,
-	}
-
-	createdJob, err := vc.jobService.CreateJob(job)
-	if err != nil {
-		// Si falla crear el job, limpiar el video local
+	if req.Encrypt && videoData.MediaType == models.MediaTypeAudio {
 		vc.videoService.GetFilesService().RemoveFile(videoData.LocalPath)
-		helpers.HandleError(c, http.StatusInternalServerError, "Could not create processing job", err)
+		helpers.HandleError(c, http.StatusBadRequest, "Los audios no se pueden cifrar", nil)
 		return
 	}
 
-	// 7. Crear y serializar tarea para la cola
-	videoTask := models.VideoTask{
-		JobID:       createdJob.Id,
-		UserID:      authenticatedUser.Id,
-		LocalPath:   videoData.LocalPath,
-		UniqueName:  videoData.UniqueName,
-		Title:       videoData.Title,
-		Description: videoData.Description,
-		Duration:    videoData.Duration,
-	}
-
-	taskJSON, err := json.Marshal(videoTask)
+	// 6. Crear Job con status "pending" (el job usa el mismo ID que el video)
+	job := &models.Job{
+		Id:              videoData.Id,
+		UserID:          authenticatedUser.Id,
+		Status:          "pending",
+		LocalPath:       videoData.LocalPath,
+		UniqueName:      videoData.UniqueName,
+		Title:           videoData.Title,
+		Description:     videoData.Description,
+		Duration:        videoData.Duration,
+		PublishAt:       req.PublishAt,
+		IdempotencyKey:  idempotencyKey,
+		EncodingProfile: req.EncodingProfile,
+		MediaType:       videoData.MediaType,
+		SkipWatermark:   req.SkipWatermark,
+		Encrypt:         req.Encrypt,
+	}
+
+	// 7. Serializar la tarea para la cola
+	taskJSON, err := json.Marshal(job.Task())
 	if err != nil {
-		vc.jobService.UpdateJobStatus(createdJob.Id, "failed", "Error serializando tarea")
+		vc.videoService.GetFilesService().RemoveFile(videoData.LocalPath)
 		helpers.HandleError(c, http.StatusInternalServerError, "Error preparando tarea", err)
 		return
 	}
 
-	// 9. Publicar tarea a la cola de video
-	err = vc.rabbitMQService.Publish(cfg.RabbitMQVideoQueue, taskJSON)
+	// 8. Guardar el job y su mensaje en el outbox (misma transacción).
+	// El relay del outbox publica la tarea en RabbitMQ con confirmación del broker.
+	createdJob, err := vc.jobService.CreateJobWithTask(job, taskJSON)
 	if err != nil {
-		vc.jobService.UpdateJobStatus(createdJob.Id, "failed", "Error publicando a cola")
-		helpers.HandleError(c, http.StatusInternalServerError, "Error encolando tarea", err)
+		// Si falla crear el job, limpiar el video local
+		vc.videoService.GetFilesService().RemoveFile(videoData.LocalPath)
+
+		if errors.Is(err, services.ErrEncodingProfileNotFound) {
+			helpers.HandleError(c, http.StatusBadRequest, "El perfil de codificación no existe", err)
+			return
+		}
+
+		// Otra petición con el mismo Idempotency-Key creó el job mientras se subía este archivo
+		if errors.Is(err, services.ErrIdempotencyKeyInUse) {
+			if existingJob, findErr := vc.jobService.FindJobByIdempotencyKey(authenticatedUser.Id, idempotencyKey); findErr == nil && existingJob != nil {
+				respondJobAccepted(c, existingJob)
+				return
+			}
+		}
+
+		helpers.HandleError(c, http.StatusInternalServerError, "Could not create processing job", err)
 		return
 	}
 
@@ -217,28 +283,35 @@ func (vc *VideoControllerImpl) CreateVideo(c *gin.Context) {
 		slog.String("file", videoData.UniqueName),
 	)
 
-	// 10. Responder inmediatamente con el job_id
+	// 9. Responder inmediatamente con el job_id
 	// NOTA: La limpieza de archivos locales la hace el WORKER después de procesar
+	respondJobAccepted(c, createdJob)
+}
+
+// respondJobAccepted responde 202 con el job encolado
+func respondJobAccepted(c *gin.Context, job *models.JobModel) {
 	helpers.Success(c, http.StatusAccepted, gin.H{
-		"job_id":  createdJob.Id,
-		"status":  createdJob.Status,
-		"message": "Video en cola de procesamiento. Consulta GET /jobs/" + createdJob.Id,
+		"job_id":  job.Id,
+		"status":  job.Status,
+		"message": "Video en cola de procesamiento. Consulta GET /jobs/" + job.Id,
 	})
 }
 
 // UpdateVideoRequest validates the fields for updating a video
 type UpdateVideoRequest struct {
-	Title       string `json:"title" binding:"required,min=1,max=100"`
-	Description string `json:"description" binding:"max=500"`
+	Title       string     `json:"title" binding:"required,min=1,max=100"`
+	Description string     `json:"description" binding:"max=500"`
+	PublishAt   *time.Time `json:"publish_at" example:"2026-11-01T18:00:00Z"`
 }
 
 // UpdateVideo godoc
 // @Summary		Update a video's metadata
-// @Description	Update title and description of a video. Only the owner can update.
+// @Description	Update title, description and scheduled publication of a video. A future publish_at hides the video from listings until that time; a past one publishes it now. Only the owner can update.
 // @Tags		streaming
 // @Accept		json
 // @Produce		json
 // @Security	BearerAuth
+// @Security	APIKeyAuth
 // @Param		videoid path string true "Video ID"
 // @Param		body body UpdateVideoRequest true "Updated video data"
 // @Success		200 {object} helpers.APIResponse{data=models.VideoSwagger}
@@ -275,6 +348,9 @@ func (vc *VideoControllerImpl) UpdateVideo(c *gin.Context) {
 
 	video.Title = req.Title
 	video.Description = req.Description
+	if req.PublishAt != nil {
+		video.PublishAt = req.PublishAt
+	}
 
 	updated, err := vc.databaseVideoService.UpdateVideo(video)
 	if err != nil {
@@ -291,6 +367,7 @@ func (vc *VideoControllerImpl) UpdateVideo(c *gin.Context) {
 // @Tags		streaming
 // @Produce		json
 // @Security	BearerAuth
+// @Security	APIKeyAuth
 // @Param		videoid path string true "Video ID"
 // @Success		200 {object} helpers.APIResponse{data=object{message=string}}
 // @Failure		403 {object} helpers.APIResponse{error=helpers.APIError}
@@ -366,18 +443,445 @@ func (vc *VideoControllerImpl) SearchVideos(c *gin.Context) {
 	helpers.Success(c, http.StatusOK, result)
 }
 
+// RegenerateThumbnail godoc
+// @Summary		Change a video's thumbnail
+// @Description	With a `thumbnail` file (JPEG or PNG, at least 320x180, max 5MB) the image is resized to the standard sizes, converted to WebP and replaces the thumbnail right away; the automatic thumbnails no longer replace it. Without a file, queues the generation of a new thumbnail from the frame at the second `at`, or from one of the video's `thumbnail_candidates` with `candidate` (its index). The video is not transcoded again. Only the owner can change it.
+// @Tags		streaming
+// @Accept		multipart/form-data
+// @Produce		json
+// @Security	BearerAuth
+// @Security	APIKeyAuth
+// @Param		videoid path string true "Video ID"
+// @Param		thumbnail formData file false "Custom thumbnail image (JPEG or PNG)"
+// @Param		at query number false "Second of the video to take the frame from"
+// @Param		candidate query int false "Index of the thumbnail candidate to use"
+// @Success		200 {object} helpers.APIResponse{data=models.VideoSwagger}
+// @Success		202 {object} helpers.APIResponse{data=object{message=string}}
+// @Failure		400 {object} helpers.APIResponse{error=helpers.APIError}
+// @Failure		403 {object} helpers.APIResponse{error=helpers.APIError}
+// @Failure		404 {object} helpers.APIResponse{error=helpers.APIError}
+// @Failure		500 {object} helpers.APIResponse{error=helpers.APIError}
+// @Router		/streaming/{videoid}/thumbnail [post]
+func (vc *VideoControllerImpl) RegenerateThumbnail(c *gin.Context) {
+	videoId := c.Param("videoid")
+
+	user, exists := c.Get("user")
+	if !exists {
+		helpers.HandleError(c, http.StatusInternalServerError, "User not found in context", nil)
+		return
+	}
+	authenticatedUser := user.(*models.User)
+
+	video, err := vc.databaseVideoService.FindVideoByID(videoId)
+	if err != nil {
+		helpers.HandleError(c, http.StatusNotFound, "Video not found", err)
+		return
+	}
+
+	if video.UserID != authenticatedUser.Id {
+		helpers.HandleError(c, http.StatusForbidden, "You are not the owner of this video", nil)
+		return
+	}
+
+	// Con un archivo en el formulario se sube la miniatura; sin él se genera de un frame del video
+	if header, err := c.FormFile("thumbnail"); err == nil {
+		vc.uploadThumbnail(c, video, header)
+		return
+	}
+
+	// Los audios no tienen frames: su miniatura es la forma de onda o una imagen subida
+	if video.IsAudio() {
+		helpers.HandleError(c, http.StatusBadRequest, "Audio uploads only accept an uploaded thumbnail image", nil)
+		return
+	}
+
+	var at float64
+	if candidate := c.Query("candidate"); candidate != "" {
+		index, err := strconv.Atoi(candidate)
+		if err != nil || index < 0 || index >= len(video.ThumbnailCandidates) {
+			helpers.HandleError(c, http.StatusBadRequest, "Query parameter 'candidate' must be the index of one of the video's thumbnail candidates", err)
+			return
+		}
+		at = video.ThumbnailCandidates[index].At
+	} else {
+		at, err = strconv.ParseFloat(c.Query("at"), 64)
+		if err != nil || at < 0 {
+			helpers.HandleError(c, http.StatusBadRequest, "Query parameter 'at' must be a positive number of seconds", err)
+			return
+		}
+
+		if duration := services.DurationSeconds(video.Duration); duration > 0 && at > duration {
+			helpers.HandleError(c, http.StatusBadRequest, "Query parameter 'at' exceeds the video duration", nil)
+			return
+		}
+	}
+
+	if err := vc.thumbnailService.RequestThumbnail(video, at); err != nil {
+		helpers.HandleError(c, http.StatusInternalServerError, "Could not queue thumbnail generation", err)
+		return
+	}
+
+	helpers.Success(c, http.StatusAccepted, gin.H{"message": "Thumbnail en cola de generación"})
+}
+
+// uploadThumbnail reemplaza la miniatura del video por la imagen subida por el dueño
+func (vc *VideoControllerImpl) uploadThumbnail(c *gin.Context, video *models.VideoModel, header *multipart.FileHeader) {
+	if header.Size > services.MaxThumbnailUploadSize {
+		helpers.HandleError(c, http.StatusBadRequest, "El archivo excede el limite de tamaño permitido", nil)
+		return
+	}
+
+	file, err := header.Open()
+	if err != nil {
+		helpers.HandleError(c, http.StatusBadRequest, "Could not read thumbnail", err)
+		return
+	}
+	defer file.Close()
+
+	updated, err := vc.thumbnailService.UploadThumbnail(c.Request.Context(), video, file)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidThumbnail) {
+			helpers.HandleError(c, http.StatusBadRequest, err.Error(), err)
+			return
+		}
+		helpers.HandleError(c, http.StatusInternalServerError, "Could not upload thumbnail", err)
+		return
+	}
+
+	helpers.Success(c, http.StatusOK, updated)
+}
+
+// ReplaceSource godoc
+// @Summary		Replace the source file of a video
+// @Description	Upload a new file for an existing video. It goes through the full processing pipeline under the same video id, keeping views and tags. The current renditions keep serving until the new ones are ready; the previous ones are kept as a version that can be rolled back until the retention period ends. Only the owner can replace it.
+// @Tags		streaming
+// @Accept		multipart/form-data
+// @Produce		json
+// @Security	BearerAuth
+// @Security	APIKeyAuth
+// @Param		videoid path string true "Video ID"
+// @Param		video formData file true "Video File"
+// @Param		skip_watermark formData bool false "Do not burn the user's watermark into the new renditions"
+// @Success		202 {object} helpers.APIResponse{data=models.JobSwagger}
+// @Failure		400 {object} helpers.APIResponse{error=helpers.APIError}
+// @Failure		403 {object} helpers.APIResponse{error=helpers.APIError}
+// @Failure		404 {object} helpers.APIResponse{error=helpers.APIError}
+// @Failure		500 {object} helpers.APIResponse{error=helpers.APIError}
+// @Router		/streaming/{videoid}/source [post]
+func (vc *VideoControllerImpl) ReplaceSource(c *gin.Context) {
+	videoId := c.Param("videoid")
+
+	user, exists := c.Get("user")
+	if !exists {
+		helpers.HandleError(c, http.StatusInternalServerError, "User not found in context", nil)
+		return
+	}
+	authenticatedUser := user.(*models.User)
+
+	video, err := vc.databaseVideoService.FindVideoByID(videoId)
+	if err != nil {
+		helpers.HandleError(c, http.StatusNotFound, "Video not found", err)
+		return
+	}
+
+	if video.UserID != authenticatedUser.Id {
+		helpers.HandleError(c, http.StatusForbidden, "You are not the owner of this video", nil)
+		return
+	}
+
+	if !vc.videoService.IsValidVideoExtension(c) {
+		helpers.HandleError(c, http.StatusBadRequest, "El archivo no es un tipo de video o audio valido", nil)
+		return
+	}
+
+	const maxFileSize = 100 * 1024 * 1024
+	if c.Request.ContentLength > maxFileSize {
+		helpers.HandleError(c, http.StatusBadRequest, "El archivo excede el limite de tamaño permitido", nil)
+		return
+	}
+
+	videoData, err := vc.videoService.SaveVideo(c.Request.Context(), c)
+	if err != nil {
+		helpers.HandleError(c, http.StatusInternalServerError, "Could not save video", err)
+		return
+	}
+
+	// Un video no se puede reemplazar por un audio ni al revés
+	if video.IsAudio() != (videoData.MediaType == models.MediaTypeAudio) {
+		vc.videoService.GetFilesService().RemoveFile(videoData.LocalPath)
+		helpers.HandleError(c, http.StatusBadRequest, "El archivo debe ser del mismo tipo (video o audio) que el original", nil)
+		return
+	}
+
+	skipWatermark, _ := strconv.ParseBool(c.PostForm("skip_watermark"))
+
+	// El job tiene su propio id, que también es la carpeta de las nuevas renditions;
+	// al terminar, el worker cambia la fuente del video existente en vez de crear uno
+	job := &models.Job{
+		Id:              videoData.Id,
+		UserID:          authenticatedUser.Id,
+		Status:          "pending",
+		LocalPath:       videoData.LocalPath,
+		UniqueName:      videoData.UniqueName,
+		Title:           video.Title,
+		Description:     video.Description,
+		Duration:        videoData.Duration,
+		ReplaceVideoID:  video.Id,
+		EncodingProfile: video.EncodingProfile,
+		MediaType:       videoData.MediaType,
+		SkipWatermark:   skipWatermark,
+		Encrypt:         video.Encrypted,
+	}
+
+	taskJSON, err := json.Marshal(job.Task())
+	if err != nil {
+		vc.videoService.GetFilesService().RemoveFile(videoData.LocalPath)
+		helpers.HandleError(c, http.StatusInternalServerError, "Error preparando tarea", err)
+		return
+	}
+
+	createdJob, err := vc.jobService.CreateJobWithTask(job, taskJSON)
+	if err != nil {
+		vc.videoService.GetFilesService().RemoveFile(videoData.LocalPath)
+		helpers.HandleError(c, http.StatusInternalServerError, "Could not create processing job", err)
+		return
+	}
+
+	slog.Info("source replacement enqueued",
+		slog.String("job_id", createdJob.Id),
+		slog.String("video_id", video.Id),
+		slog.String("file", videoData.UniqueName),
+	)
+
+	respondJobAccepted(c, createdJob)
+}
+
+// MinClipSeconds es la duración mínima de un clip
+const MinClipSeconds = 1.0
+
+// CreateClipRequest valida el recorte pedido sobre un video existente
+type CreateClipRequest struct {
+	Start       float64 `json:"start" binding:"gte=0" example:"12.5"`
+	End         float64 `json:"end" binding:"required,gt=0" example:"42"`
+	Title       string  `json:"title" binding:"max=100" example:"Mejor momento"`
+	Description string  `json:"description" binding:"max=500"`
+	// Accurate recodifica para cortar en el frame exacto; sin él se corta en keyframes, más rápido
+	Accurate bool `json:"accurate" example:"false"`
+}
+
+// CreateClip godoc
+// @Summary		Create a clip from a video
+// @Description	Create a new video from the segment between `start` and `end` (seconds) of an existing video. A job cuts the current renditions at keyframes, or re-encodes with `accurate` for frame accuracy, and runs the normal processing pipeline. The new video keeps a `source_video_id` link to the original; the watermark is not applied again. Title and description default to the original's. Only the owner can clip a video.
+// @Tags		streaming
+// @Accept		json
+// @Produce		json
+// @Security	BearerAuth
+// @Security	APIKeyAuth
+// @Param		videoid path string true "Video ID"
+// @Param		body body CreateClipRequest true "Clip range"
+// @Success		202 {object} helpers.APIResponse{data=models.JobSwagger}
+// @Failure		400 {object} helpers.APIResponse{error=helpers.APIError}
+// @Failure		403 {object} helpers.APIResponse{error=helpers.APIError}
+// @Failure		404 {object} helpers.APIResponse{error=helpers.APIError}
+// @Failure		500 {object} helpers.APIResponse{error=helpers.APIError}
+// @Router		/streaming/{videoid}/clips [post]
+func (vc *VideoControllerImpl) CreateClip(c *gin.Context) {
+	videoId := c.Param("videoid")
+
+	user, exists := c.Get("user")
+	if !exists {
+		helpers.HandleError(c, http.StatusInternalServerError, "User not found in context", nil)
+		return
+	}
+	authenticatedUser := user.(*models.User)
+
+	video, err := vc.databaseVideoService.FindVideoByID(videoId)
+	if err != nil {
+		helpers.HandleError(c, http.StatusNotFound, "Video not found", err)
+		return
+	}
+
+	if video.UserID != authenticatedUser.Id {
+		helpers.HandleError(c, http.StatusForbidden, "You are not the owner of this video", nil)
+		return
+	}
+
+	var req CreateClipRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		helpers.HandleError(c, http.StatusBadRequest, "start and end are required (seconds), title max 100 and description max 500 characters", err)
+		return
+	}
+
+	if req.End-req.Start < MinClipSeconds {
+		helpers.HandleError(c, http.StatusBadRequest, "end must be at least 1 second after start", nil)
+		return
+	}
+	if duration := services.DurationSeconds(video.Duration); duration > 0 && req.End > duration {
+		helpers.HandleError(c, http.StatusBadRequest, "end must be within the video's duration", nil)
+		return
+	}
+
+	clipData := vc.videoService.NewClip(video, req.Start, req.End)
+	if req.Title != "" {
+		clipData.Title = req.Title
+	}
+	if req.Description != "" {
+		clipData.Description = req.Description
+	}
+
+	// La marca de agua ya está grabada en las renditions del video fuente
+	job := &models.Job{
+		Id:              clipData.Id,
+		UserID:          authenticatedUser.Id,
+		Status:          "pending",
+		LocalPath:       clipData.LocalPath,
+		UniqueName:      clipData.UniqueName,
+		Title:           clipData.Title,
+		Description:     clipData.Description,
+		Duration:        clipData.Duration,
+		EncodingProfile: video.EncodingProfile,
+		MediaType:       clipData.MediaType,
+		SkipWatermark:   true,
+		Encrypt:         video.Encrypted,
+		SourceVideoID:   video.Id,
+		ClipStart:       req.Start,
+		ClipEnd:         req.End,
+		ClipAccurate:    req.Accurate,
+	}
+
+	taskJSON, err := json.Marshal(job.Task())
+	if err != nil {
+		helpers.HandleError(c, http.StatusInternalServerError, "Error preparando tarea", err)
+		return
+	}
+
+	createdJob, err := vc.jobService.CreateJobWithTask(job, taskJSON)
+	if err != nil {
+		helpers.HandleError(c, http.StatusInternalServerError, "Could not create processing job", err)
+		return
+	}
+
+	slog.Info("clip enqueued",
+		slog.String("job_id", createdJob.Id),
+		slog.String("source_video_id", video.Id),
+	)
+
+	respondJobAccepted(c, createdJob)
+}
+
+// GetVideoVersions godoc
+// @Summary		List the previous versions of a video
+// @Description	List the previous sources of a video that can still be rolled back, newest first. Only the owner can see them.
+// @Tags		streaming
+// @Produce		json
+// @Security	BearerAuth
+// @Security	APIKeyAuth
+// @Param		videoid path string true "Video ID"
+// @Success		200 {object} helpers.APIResponse{data=[]models.VideoVersionSwagger}
+// @Failure		403 {object} helpers.APIResponse{error=helpers.APIError}
+// @Failure		404 {object} helpers.APIResponse{error=helpers.APIError}
+// @Failure		500 {object} helpers.APIResponse{error=helpers.APIError}
+// @Router		/streaming/{videoid}/versions [get]
+func (vc *VideoControllerImpl) GetVideoVersions(c *gin.Context) {
+	videoId := c.Param("videoid")
+
+	user, exists := c.Get("user")
+	if !exists {
+		helpers.HandleError(c, http.StatusInternalServerError, "User not found in context", nil)
+		return
+	}
+	authenticatedUser := user.(*models.User)
+
+	video, err := vc.databaseVideoService.FindVideoByID(videoId)
+	if err != nil {
+		helpers.HandleError(c, http.StatusNotFound, "Video not found", err)
+		return
+	}
+
+	if video.UserID != authenticatedUser.Id {
+		helpers.HandleError(c, http.StatusForbidden, "You are not the owner of this video", nil)
+		return
+	}
+
+	versions, err := vc.videoVersionService.ListVersions(videoId)
+	if err != nil {
+		helpers.HandleError(c, http.StatusInternalServerError, "Could not retrieve versions", err)
+		return
+	}
+
+	helpers.Success(c, http.StatusOK, versions)
+}
+
+// RollbackVideoVersion godoc
+// @Summary		Roll back a video to a previous version
+// @Description	Restore a previous source of the video. The current one is kept as a new version, so the rollback can be undone. Only the owner can roll back.
+// @Tags		streaming
+// @Produce		json
+// @Security	BearerAuth
+// @Security	APIKeyAuth
+// @Param		videoid path string true "Video ID"
+// @Param		versionid path string true "Version ID"
+// @Success		200 {object} helpers.APIResponse{data=models.VideoSwagger}
+// @Failure		403 {object} helpers.APIResponse{error=helpers.APIError}
+// @Failure		404 {object} helpers.APIResponse{error=helpers.APIError}
+// @Failure		410 {object} helpers.APIResponse{error=helpers.APIError}
+// @Failure		500 {object} helpers.APIResponse{error=helpers.APIError}
+// @Router		/streaming/{videoid}/versions/{versionid}/rollback [post]
+func (vc *VideoControllerImpl) RollbackVideoVersion(c *gin.Context) {
+	videoId := c.Param("videoid")
+	versionId := c.Param("versionid")
+
+	user, exists := c.Get("user")
+	if !exists {
+		helpers.HandleError(c, http.StatusInternalServerError, "User not found in context", nil)
+		return
+	}
+	authenticatedUser := user.(*models.User)
+
+	video, err := vc.databaseVideoService.FindVideoByID(videoId)
+	if err != nil {
+		helpers.HandleError(c, http.StatusNotFound, "Video not found", err)
+		return
+	}
+
+	if video.UserID != authenticatedUser.Id {
+		helpers.HandleError(c, http.StatusForbidden, "You are not the owner of this video", nil)
+		return
+	}
+
+	version, err := vc.videoVersionService.FindVersionByID(versionId)
+	if err != nil || version.VideoID != video.Id {
+		helpers.HandleError(c, http.StatusNotFound, "Version not found", err)
+		return
+	}
+
+	restored, err := vc.videoVersionService.Rollback(version)
+	if err != nil {
+		if errors.Is(err, services.ErrVersionExpired) {
+			helpers.HandleError(c, http.StatusGone, "The version has expired", err)
+			return
+		}
+		helpers.HandleError(c, http.StatusInternalServerError, "Could not roll back video", err)
+		return
+	}
+
+	helpers.Success(c, http.StatusOK, restored)
+}
+
 type VideoControllerImpl struct {
 	videoService         services.VideoService
 	databaseVideoService services.DatabaseVideoService
 	jobService           services.JobService
-	rabbitMQService      services.RabbitMQService
+	thumbnailService     services.ThumbnailService
+	videoVersionService  services.VideoVersionService
 }
 
-func NewVideoController(videoService services.VideoService, databaseVideoService services.DatabaseVideoService, jobService services.JobService, rabbitMQService services.RabbitMQService) VideoController {
+func NewVideoController(videoService services.VideoService, databaseVideoService services.DatabaseVideoService, jobService services.JobService, thumbnailService services.ThumbnailService, videoVersionService services.VideoVersionService) VideoController {
 	return &VideoControllerImpl{
 		videoService:         videoService,
 		databaseVideoService: databaseVideoService,
 		jobService:           jobService,
-		rabbitMQService:      rabbitMQService,
+		thumbnailService:     thumbnailService,
+		videoVersionService:  videoVersionService,
 	}
 }
\ No newline at end of file
@@ -1,11 +1,16 @@
 package controllers
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
+	"io"
+	"mime/multipart"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/unbot2313/go-streaming-service/internal/models"
@@ -20,9 +25,71 @@ func setupVideoRouter(controller VideoController) *gin.Engine {
 	r.GET("/streaming/search", controller.SearchVideos)
 	r.GET("/streaming/id/:videoid", controller.GetVideoByID)
 	r.PATCH("/streaming/views/:videoid", controller.IncrementViews)
+
+	// Rutas protegidas con usuario simulado
+	protected := r.Group("")
+	protected.Use(func(c *gin.Context) {
+		c.Set("user", &models.User{Id: "user-123", Username: "testuser"})
+		c.Next()
+	})
+	protected.POST("/streaming/upload", controller.CreateVideo)
+	protected.PUT("/streaming/:videoid", controller.UpdateVideo)
+	protected.POST("/streaming/:videoid/thumbnail", controller.RegenerateThumbnail)
+	protected.POST("/streaming/:videoid/source", controller.ReplaceSource)
+	protected.POST("/streaming/:videoid/clips", controller.CreateClip)
+	protected.GET("/streaming/:videoid/versions", controller.GetVideoVersions)
+	protected.POST("/streaming/:videoid/versions/:versionid/rollback", controller.RollbackVideoVersion)
 	return r
 }
 
+// newUploadRequest construye un multipart/form-data con los campos y un archivo de video falso
+func newUploadRequest(t *testing.T, fields map[string]string) *http.Request {
+	t.Helper()
+	return newMultipartVideoRequest(t, "/streaming/upload", fields)
+}
+
+// newMultipartVideoRequest construye el multipart/form-data con el archivo de video para cualquier ruta
+func newMultipartVideoRequest(t *testing.T, path string, fields map[string]string) *http.Request {
+	t.Helper()
+
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	for key, value := range fields {
+		writer.WriteField(key, value)
+	}
+	part, _ := writer.CreateFormFile("video", "clip.mp4")
+	part.Write([]byte("fake video"))
+	writer.Close()
+
+	req, _ := http.NewRequest("POST", path, body)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	return req
+}
+
+// newUploadVideoService simula un video guardado en disco correctamente
+func newUploadVideoService(removedFiles *[]string) *mocks.MockVideoService {
+	return &mocks.MockVideoService{
+		IsValidVideoExtensionFn: func(c *gin.Context) bool { return true },
+		SaveVideoFn: func(ctx context.Context, c *gin.Context) (*models.Video, error) {
+			return &models.Video{
+				Id:         "video-123",
+				Title:      "My Video",
+				LocalPath:  "static/videos/video-123.mp4",
+				UniqueName: "video-123.mp4",
+				Duration:   "1:30",
+			}, nil
+		},
+		GetFilesServiceFn: func() services.FilesService {
+			return &mocks.MockFilesService{
+				RemoveFileFn: func(filePath string) error {
+					*removedFiles = append(*removedFiles, filePath)
+					return nil
+				},
+			}
+		},
+	}
+}
+
 func TestGetLatestVideos_Success(t *testing.T) {
 	mockDBVideo := &mocks.MockDatabaseVideoService{
 		FindLatestVideosFn: func(page, pageSize int) (*services.PaginatedVideos, error) {
@@ -35,7 +102,7 @@ func TestGetLatestVideos_Success(t *testing.T) {
 		},
 	}
 
-	controller := NewVideoController(nil, mockDBVideo, nil, nil)
+	controller := NewVideoController(nil, mockDBVideo, nil, nil, nil)
 	router := setupVideoRouter(controller)
 
 	req, _ := http.NewRequest("GET", "/streaming/latest", nil)
@@ -70,7 +137,7 @@ func TestGetLatestVideos_WithPagination(t *testing.T) {
 		},
 	}
 
-	controller := NewVideoController(nil, mockDBVideo, nil, nil)
+	controller := NewVideoController(nil, mockDBVideo, nil, nil, nil)
 	router := setupVideoRouter(controller)
 
 	req, _ := http.NewRequest("GET", "/streaming/latest?page=2&page_size=25", nil)
@@ -100,7 +167,7 @@ func TestGetLatestVideos_PageSizeMax(t *testing.T) {
 		},
 	}
 
-	controller := NewVideoController(nil, mockDBVideo, nil, nil)
+	controller := NewVideoController(nil, mockDBVideo, nil, nil, nil)
 	router := setupVideoRouter(controller)
 
 	req, _ := http.NewRequest("GET", "/streaming/latest?page_size=999", nil)
@@ -113,13 +180,14 @@ func TestGetLatestVideos_PageSizeMax(t *testing.T) {
 }
 
 func TestGetVideoByID_Success(t *testing.T) {
+	publishedAt := time.Now().Add(-time.Hour)
 	mockDBVideo := &mocks.MockDatabaseVideoService{
 		FindVideoByIDFn: func(videoId string) (*models.VideoModel, error) {
-			return &models.VideoModel{Title: "Found Video"}, nil
+			return &models.VideoModel{Title: "Found Video", PublishedAt: &publishedAt}, nil
 		},
 	}
 
-	controller := NewVideoController(nil, mockDBVideo, nil, nil)
+	controller := NewVideoController(nil, mockDBVideo, nil, nil, nil)
 	router := setupVideoRouter(controller)
 
 	req, _ := http.NewRequest("GET", "/streaming/id/video-123", nil)
@@ -138,7 +206,7 @@ func TestGetVideoByID_NotFound(t *testing.T) {
 		},
 	}
 
-	controller := NewVideoController(nil, mockDBVideo, nil, nil)
+	controller := NewVideoController(nil, mockDBVideo, nil, nil, nil)
 	router := setupVideoRouter(controller)
 
 	req, _ := http.NewRequest("GET", "/streaming/id/nonexistent", nil)
@@ -150,6 +218,74 @@ func TestGetVideoByID_NotFound(t *testing.T) {
 	}
 }
 
+func TestGetVideoByID_Scheduled(t *testing.T) {
+	publishAt := time.Now().Add(24 * time.Hour)
+	mockDBVideo := &mocks.MockDatabaseVideoService{
+		FindVideoByIDFn: func(videoId string) (*models.VideoModel, error) {
+			return &models.VideoModel{Id: videoId, UserID: "owner-1", PublishAt: &publishAt}, nil
+		},
+	}
+
+	controller := NewVideoController(nil, mockDBVideo, nil, nil, nil)
+	router := setupVideoRouter(controller)
+
+	req, _ := http.NewRequest("GET", "/streaming/id/video-123", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusNotFound {
+		t.Errorf("expected status %d, got %d", http.StatusNotFound, w.Code)
+	}
+}
+
+func TestGetVideoByID_Suspended(t *testing.T) {
+	publishedAt := time.Now().Add(-time.Hour)
+	suspendedAt := time.Now()
+	mockDBVideo := &mocks.MockDatabaseVideoService{
+		FindVideoByIDFn: func(videoId string) (*models.VideoModel, error) {
+			return &models.VideoModel{Id: videoId, UserID: "owner-1", PublishedAt: &publishedAt, SuspendedAt: &suspendedAt}, nil
+		},
+	}
+
+	controller := NewVideoController(nil, mockDBVideo, nil, nil, nil)
+	router := setupVideoRouter(controller)
+
+	req, _ := http.NewRequest("GET", "/streaming/id/video-123", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusNotFound {
+		t.Errorf("expected status %d, got %d", http.StatusNotFound, w.Code)
+	}
+}
+
+func TestGetVideoByID_ScheduledOwner(t *testing.T) {
+	publishAt := time.Now().Add(24 * time.Hour)
+	mockDBVideo := &mocks.MockDatabaseVideoService{
+		FindVideoByIDFn: func(videoId string) (*models.VideoModel, error) {
+			return &models.VideoModel{Id: videoId, UserID: "user-123", PublishAt: &publishAt}, nil
+		},
+	}
+
+	controller := NewVideoController(nil, mockDBVideo, nil, nil, nil)
+
+	// La ruta es pública: el dueño llega con el usuario que deja la autenticación opcional
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/streaming/id/:videoid", func(c *gin.Context) {
+		c.Set("user", &models.User{Id: "user-123", Username: "testuser"})
+		c.Next()
+	}, controller.GetVideoByID)
+
+	req, _ := http.NewRequest("GET", "/streaming/id/video-123", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("expected status %d, got %d", http.StatusOK, w.Code)
+	}
+}
+
 func TestIncrementViews_Success(t *testing.T) {
 	mockDBVideo := &mocks.MockDatabaseVideoService{
 		IncrementViewsFn: func(videoId string) (*models.VideoModel, error) {
@@ -157,7 +293,7 @@ func TestIncrementViews_Success(t *testing.T) {
 		},
 	}
 
-	controller := NewVideoController(nil, mockDBVideo, nil, nil)
+	controller := NewVideoController(nil, mockDBVideo, nil, nil, nil)
 	router := setupVideoRouter(controller)
 
 	req, _ := http.NewRequest("PATCH", "/streaming/views/video-123", nil)
@@ -176,7 +312,7 @@ func TestIncrementViews_Error(t *testing.T) {
 		},
 	}
 
-	controller := NewVideoController(nil, mockDBVideo, nil, nil)
+	controller := NewVideoController(nil, mockDBVideo, nil, nil, nil)
 	router := setupVideoRouter(controller)
 
 	req, _ := http.NewRequest("PATCH", "/streaming/views/video-123", nil)
@@ -200,7 +336,7 @@ func TestSearchVideos_Success(t *testing.T) {
 		},
 	}
 
-	controller := NewVideoController(nil, mockDBVideo, nil, nil)
+	controller := NewVideoController(nil, mockDBVideo, nil, nil, nil)
 	router := setupVideoRouter(controller)
 
 	req, _ := http.NewRequest("GET", "/streaming/search?q=Go", nil)
@@ -220,7 +356,7 @@ func TestSearchVideos_Success(t *testing.T) {
 }
 
 func TestSearchVideos_MissingQuery(t *testing.T) {
-	controller := NewVideoController(nil, nil, nil, nil)
+	controller := NewVideoController(nil, nil, nil, nil, nil)
 	router := setupVideoRouter(controller)
 
 	req, _ := http.NewRequest("GET", "/streaming/search", nil)
@@ -245,7 +381,7 @@ func TestSearchVideos_WithPagination(t *testing.T) {
 		},
 	}
 
-	controller := NewVideoController(nil, mockDBVideo, nil, nil)
+	controller := NewVideoController(nil, mockDBVideo, nil, nil, nil)
 	router := setupVideoRouter(controller)
 
 	req, _ := http.NewRequest("GET", "/streaming/search?q=tutorial&page=3&page_size=20", nil)
@@ -268,3 +404,776 @@ func TestSearchVideos_WithPagination(t *testing.T) {
 		t.Errorf("expected pageSize 20, got %d", receivedPageSize)
 	}
 }
+
+func TestCreateVideo_EnqueuesTaskWithJob(t *testing.T) {
+	var removedFiles []string
+	var receivedTask models.VideoTask
+
+	mockJob := &mocks.MockJobService{
+		CreateJobWithTaskFn: func(job *models.Job, task []byte) (*models.JobModel, error) {
+			json.Unmarshal(task, &receivedTask)
+			return &models.JobModel{Job: *job}, nil
+		},
+	}
+
+	controller := NewVideoController(newUploadVideoService(&removedFiles), nil, mockJob, nil, nil)
+	router := setupVideoRouter(controller)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, newUploadRequest(t, map[string]string{"title": "My Video"}))
+
+	if w.Code != http.StatusAccepted {
+		t.Errorf("expected status %d, got %d", http.StatusAccepted, w.Code)
+	}
+
+	if receivedTask.JobID != "video-123" || receivedTask.UserID != "user-123" {
+		t.Errorf("unexpected task enqueued: %+v", receivedTask)
+	}
+
+	if len(removedFiles) != 0 {
+		t.Errorf("expected local file to be kept for the worker, removed %v", removedFiles)
+	}
+}
+
+func TestCreateVideo_JobError_RemovesLocalFile(t *testing.T) {
+	var removedFiles []string
+
+	mockJob := &mocks.MockJobService{
+		CreateJobWithTaskFn: func(job *models.Job, task []byte) (*models.JobModel, error) {
+			return nil, errors.New("database error")
+		},
+	}
+
+	controller := NewVideoController(newUploadVideoService(&removedFiles), nil, mockJob, nil, nil)
+	router := setupVideoRouter(controller)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, newUploadRequest(t, map[string]string{"title": "My Video"}))
+
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf("expected status %d, got %d", http.StatusInternalServerError, w.Code)
+	}
+
+	if len(removedFiles) != 1 || removedFiles[0] != "static/videos/video-123.mp4" {
+		t.Errorf("expected local file to be removed, got %v", removedFiles)
+	}
+}
+
+func TestRegenerateThumbnail_Success(t *testing.T) {
+	var receivedAt float64
+
+	mockDBVideo := &mocks.MockDatabaseVideoService{
+		FindVideoByIDFn: func(videoId string) (*models.VideoModel, error) {
+			return &models.VideoModel{Id: videoId, UserID: "user-123", Duration: "1:30"}, nil
+		},
+	}
+	mockThumbnail := &mocks.MockThumbnailService{
+		RequestThumbnailFn: func(video *models.VideoModel, at float64) error {
+			receivedAt = at
+			return nil
+		},
+	}
+
+	controller := NewVideoController(nil, mockDBVideo, nil, mockThumbnail, nil)
+	router := setupVideoRouter(controller)
+
+	req, _ := http.NewRequest("POST", "/streaming/video-123/thumbnail?at=42.5", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusAccepted {
+		t.Errorf("expected status %d, got %d", http.StatusAccepted, w.Code)
+	}
+
+	if receivedAt != 42.5 {
+		t.Errorf("expected at 42.5, got %v", receivedAt)
+	}
+}
+
+func TestRegenerateThumbnail_InvalidAt(t *testing.T) {
+	mockDBVideo := &mocks.MockDatabaseVideoService{
+		FindVideoByIDFn: func(videoId string) (*models.VideoModel, error) {
+			return &models.VideoModel{Id: videoId, UserID: "user-123", Duration: "1:30"}, nil
+		},
+	}
+
+	controller := NewVideoController(nil, mockDBVideo, nil, &mocks.MockThumbnailService{}, nil)
+	router := setupVideoRouter(controller)
+
+	for _, query := range []string{"", "?at=abc", "?at=-1", "?at=120"} {
+		req, _ := http.NewRequest("POST", "/streaming/video-123/thumbnail"+query, nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("query %q: expected status %d, got %d", query, http.StatusBadRequest, w.Code)
+		}
+	}
+}
+
+func TestRegenerateThumbnail_Forbidden(t *testing.T) {
+	mockDBVideo := &mocks.MockDatabaseVideoService{
+		FindVideoByIDFn: func(videoId string) (*models.VideoModel, error) {
+			return &models.VideoModel{Id: videoId, UserID: "other-user"}, nil
+		},
+	}
+
+	controller := NewVideoController(nil, mockDBVideo, nil, &mocks.MockThumbnailService{}, nil)
+	router := setupVideoRouter(controller)
+
+	req, _ := http.NewRequest("POST", "/streaming/video-123/thumbnail?at=5", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusForbidden {
+		t.Errorf("expected status %d, got %d", http.StatusForbidden, w.Code)
+	}
+}
+
+func TestRegenerateThumbnail_AudioRejectsFrame(t *testing.T) {
+	mockDBVideo := &mocks.MockDatabaseVideoService{
+		FindVideoByIDFn: func(videoId string) (*models.VideoModel, error) {
+			return &models.VideoModel{Id: videoId, UserID: "user-123", Duration: "1:30", MediaType: models.MediaTypeAudio}, nil
+		},
+	}
+
+	controller := NewVideoController(nil, mockDBVideo, nil, &mocks.MockThumbnailService{}, nil)
+	router := setupVideoRouter(controller)
+
+	for _, query := range []string{"?at=5", "?candidate=0"} {
+		req, _ := http.NewRequest("POST", "/streaming/video-123/thumbnail"+query, nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("query %q: expected status %d, got %d", query, http.StatusBadRequest, w.Code)
+		}
+	}
+}
+
+func TestCreateVideo_IdempotencyKey_ReturnsExistingJob(t *testing.T) {
+	saved := false
+
+	mockVideo := &mocks.MockVideoService{
+		IsValidVideoExtensionFn: func(c *gin.Context) bool { return true },
+		SaveVideoFn: func(ctx context.Context, c *gin.Context) (*models.Video, error) {
+			saved = true
+			return nil, errors.New("should not be called")
+		},
+	}
+	mockJob := &mocks.MockJobService{
+		FindJobByIdempotencyKeyFn: func(userId, idempotencyKey string) (*models.JobModel, error) {
+			if userId != "user-123" || idempotencyKey != "upload-1" {
+				t.Errorf("unexpected lookup: %s %s", userId, idempotencyKey)
+			}
+			return &models.JobModel{Job: models.Job{Id: "job-original", Status: "processing"}}, nil
+		},
+	}
+
+	controller := NewVideoController(mockVideo, nil, mockJob, nil, nil)
+	router := setupVideoRouter(controller)
+
+	req := newUploadRequest(t, map[string]string{"title": "My Video"})
+	req.Header.Set("Idempotency-Key", "upload-1")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusAccepted {
+		t.Errorf("expected status %d, got %d", http.StatusAccepted, w.Code)
+	}
+
+	if saved {
+		t.Error("expected the upload not to be saved again")
+	}
+
+	var response map[string]interface{}
+	json.Unmarshal(w.Body.Bytes(), &response)
+	data := response["data"].(map[string]interface{})
+	if data["job_id"] != "job-original" {
+		t.Errorf("expected original job, got %v", data["job_id"])
+	}
+}
+
+func TestCreateVideo_IdempotencyKey_ConcurrentDuplicate(t *testing.T) {
+	var removedFiles []string
+	var storedKey string
+	lookups := 0
+
+	mockJob := &mocks.MockJobService{
+		FindJobByIdempotencyKeyFn: func(userId, idempotencyKey string) (*models.JobModel, error) {
+			lookups++
+			if lookups == 1 {
+				return nil, nil
+			}
+			return &models.JobModel{Job: models.Job{Id: "job-original", Status: "pending"}}, nil
+		},
+		CreateJobWithTaskFn: func(job *models.Job, task []byte) (*models.JobModel, error) {
+			storedKey = job.IdempotencyKey
+			return nil, services.ErrIdempotencyKeyInUse
+		},
+	}
+
+	controller := NewVideoController(newUploadVideoService(&removedFiles), nil, mockJob, nil, nil)
+	router := setupVideoRouter(controller)
+
+	req := newUploadRequest(t, map[string]string{"title": "My Video"})
+	req.Header.Set("Idempotency-Key", "upload-1")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusAccepted {
+		t.Errorf("expected status %d, got %d", http.StatusAccepted, w.Code)
+	}
+
+	if storedKey != "upload-1" {
+		t.Errorf("expected idempotency key to be stored on the job, got %q", storedKey)
+	}
+
+	if len(removedFiles) != 1 {
+		t.Errorf("expected duplicate upload to be removed, got %v", removedFiles)
+	}
+}
+
+func TestCreateVideo_PublishAt(t *testing.T) {
+	var removedFiles []string
+	var receivedTask models.VideoTask
+
+	mockJob := &mocks.MockJobService{
+		CreateJobWithTaskFn: func(job *models.Job, task []byte) (*models.JobModel, error) {
+			json.Unmarshal(task, &receivedTask)
+			return &models.JobModel{Job: *job}, nil
+		},
+	}
+
+	controller := NewVideoController(newUploadVideoService(&removedFiles), nil, mockJob, nil, nil)
+	router := setupVideoRouter(controller)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, newUploadRequest(t, map[string]string{"title": "My Video", "publish_at": "2026-11-01T18:00:00Z"}))
+
+	if w.Code != http.StatusAccepted {
+		t.Errorf("expected status %d, got %d", http.StatusAccepted, w.Code)
+	}
+
+	expected := time.Date(2026, 11, 1, 18, 0, 0, 0, time.UTC)
+	if receivedTask.PublishAt == nil || !receivedTask.PublishAt.Equal(expected) {
+		t.Errorf("expected publish_at %v in task, got %v", expected, receivedTask.PublishAt)
+	}
+}
+
+func TestCreateVideo_SkipWatermark(t *testing.T) {
+	var removedFiles []string
+	var receivedTask models.VideoTask
+
+	mockJob := &mocks.MockJobService{
+		CreateJobWithTaskFn: func(job *models.Job, task []byte) (*models.JobModel, error) {
+			json.Unmarshal(task, &receivedTask)
+			return &models.JobModel{Job: *job}, nil
+		},
+	}
+
+	controller := NewVideoController(newUploadVideoService(&removedFiles), nil, mockJob, nil, nil)
+	router := setupVideoRouter(controller)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, newUploadRequest(t, map[string]string{"title": "My Video", "skip_watermark": "true"}))
+
+	if w.Code != http.StatusAccepted {
+		t.Errorf("expected status %d, got %d", http.StatusAccepted, w.Code)
+	}
+
+	if !receivedTask.SkipWatermark {
+		t.Error("expected skip_watermark in task")
+	}
+}
+
+func TestCreateVideo_InvalidPublishAt(t *testing.T) {
+	mockVideo := &mocks.MockVideoService{
+		IsValidVideoExtensionFn: func(c *gin.Context) bool { return true },
+	}
+
+	controller := NewVideoController(mockVideo, nil, &mocks.MockJobService{}, nil, nil)
+	router := setupVideoRouter(controller)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, newUploadRequest(t, map[string]string{"title": "My Video", "publish_at": "tomorrow"}))
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected status %d, got %d", http.StatusBadRequest, w.Code)
+	}
+}
+
+func TestUpdateVideo_SchedulesPublication(t *testing.T) {
+	var updatedVideo *models.VideoModel
+
+	mockDB := &mocks.MockDatabaseVideoService{
+		FindVideoByIDFn: func(videoId string) (*models.VideoModel, error) {
+			return &models.VideoModel{Id: videoId, UserID: "user-123", Title: "Old"}, nil
+		},
+		UpdateVideoFn: func(video *models.VideoModel) (*models.VideoModel, error) {
+			updatedVideo = video
+			return video, nil
+		},
+	}
+
+	controller := NewVideoController(nil, mockDB, nil, nil, nil)
+	router := setupVideoRouter(controller)
+
+	body := `{"title": "New", "publish_at": "2026-11-01T18:00:00Z"}`
+	req, _ := http.NewRequest("PUT", "/streaming/video-123", bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("expected status %d, got %d", http.StatusOK, w.Code)
+	}
+
+	if updatedVideo == nil || updatedVideo.PublishAt == nil || updatedVideo.Title != "New" {
+		t.Fatalf("expected video to be updated with publish_at, got %+v", updatedVideo)
+	}
+
+	if !updatedVideo.PublishAt.Equal(time.Date(2026, 11, 1, 18, 0, 0, 0, time.UTC)) {
+		t.Errorf("unexpected publish_at: %v", updatedVideo.PublishAt)
+	}
+}
+
+func TestReplaceSource_EnqueuesReplacement(t *testing.T) {
+	var removedFiles []string
+	var receivedTask models.VideoTask
+
+	mockDBVideo := &mocks.MockDatabaseVideoService{
+		FindVideoByIDFn: func(videoId string) (*models.VideoModel, error) {
+			return &models.VideoModel{Id: videoId, UserID: "user-123", Title: "Original"}, nil
+		},
+	}
+	mockJob := &mocks.MockJobService{
+		CreateJobWithTaskFn: func(job *models.Job, task []byte) (*models.JobModel, error) {
+			json.Unmarshal(task, &receivedTask)
+			return &models.JobModel{Job: *job}, nil
+		},
+	}
+
+	controller := NewVideoController(newUploadVideoService(&removedFiles), mockDBVideo, mockJob, nil, nil)
+	router := setupVideoRouter(controller)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, newMultipartVideoRequest(t, "/streaming/existing-video/source", nil))
+
+	if w.Code != http.StatusAccepted {
+		t.Errorf("expected status %d, got %d", http.StatusAccepted, w.Code)
+	}
+
+	if receivedTask.ReplaceVideoID != "existing-video" || receivedTask.JobID != "video-123" {
+		t.Errorf("unexpected task enqueued: %+v", receivedTask)
+	}
+
+	if receivedTask.Title != "Original" {
+		t.Errorf("expected title of the existing video, got %q", receivedTask.Title)
+	}
+}
+
+func TestReplaceSource_Forbidden(t *testing.T) {
+	var removedFiles []string
+
+	mockDBVideo := &mocks.MockDatabaseVideoService{
+		FindVideoByIDFn: func(videoId string) (*models.VideoModel, error) {
+			return &models.VideoModel{Id: videoId, UserID: "other-user"}, nil
+		},
+	}
+
+	controller := NewVideoController(newUploadVideoService(&removedFiles), mockDBVideo, &mocks.MockJobService{}, nil, nil)
+	router := setupVideoRouter(controller)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, newMultipartVideoRequest(t, "/streaming/existing-video/source", nil))
+
+	if w.Code != http.StatusForbidden {
+		t.Errorf("expected status %d, got %d", http.StatusForbidden, w.Code)
+	}
+}
+
+func TestGetVideoVersions_Success(t *testing.T) {
+	mockDBVideo := &mocks.MockDatabaseVideoService{
+		FindVideoByIDFn: func(videoId string) (*models.VideoModel, error) {
+			return &models.VideoModel{Id: videoId, UserID: "user-123"}, nil
+		},
+	}
+	mockVersions := &mocks.MockVideoVersionService{
+		ListVersionsFn: func(videoId string) ([]models.VideoVersion, error) {
+			return []models.VideoVersion{{Id: "version-1", VideoID: videoId}}, nil
+		},
+	}
+
+	controller := NewVideoController(nil, mockDBVideo, nil, nil, mockVersions)
+	router := setupVideoRouter(controller)
+
+	req, _ := http.NewRequest("GET", "/streaming/video-123/versions", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("expected status %d, got %d", http.StatusOK, w.Code)
+	}
+
+	var response map[string]interface{}
+	json.Unmarshal(w.Body.Bytes(), &response)
+	data := response["data"].([]interface{})
+	if len(data) != 1 {
+		t.Errorf("expected 1 version, got %d", len(data))
+	}
+}
+
+func TestRollbackVideoVersion_Success(t *testing.T) {
+	rolledBack := false
+
+	mockDBVideo := &mocks.MockDatabaseVideoService{
+		FindVideoByIDFn: func(videoId string) (*models.VideoModel, error) {
+			return &models.VideoModel{Id: videoId, UserID: "user-123"}, nil
+		},
+	}
+	mockVersions := &mocks.MockVideoVersionService{
+		FindVersionByIDFn: func(versionId string) (*models.VideoVersion, error) {
+			return &models.VideoVersion{Id: versionId, VideoID: "video-123"}, nil
+		},
+		RollbackFn: func(version *models.VideoVersion) (*models.VideoModel, error) {
+			rolledBack = true
+			return &models.VideoModel{Id: version.VideoID, StorageFolder: "old-folder"}, nil
+		},
+	}
+
+	controller := NewVideoController(nil, mockDBVideo, nil, nil, mockVersions)
+	router := setupVideoRouter(controller)
+
+	req, _ := http.NewRequest("POST", "/streaming/video-123/versions/version-1/rollback", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("expected status %d, got %d", http.StatusOK, w.Code)
+	}
+
+	if !rolledBack {
+		t.Error("expected the version to be restored")
+	}
+}
+
+func TestRollbackVideoVersion_OtherVideo(t *testing.T) {
+	mockDBVideo := &mocks.MockDatabaseVideoService{
+		FindVideoByIDFn: func(videoId string) (*models.VideoModel, error) {
+			return &models.VideoModel{Id: videoId, UserID: "user-123"}, nil
+		},
+	}
+	mockVersions := &mocks.MockVideoVersionService{
+		FindVersionByIDFn: func(versionId string) (*models.VideoVersion, error) {
+			return &models.VideoVersion{Id: versionId, VideoID: "someone-elses-video"}, nil
+		},
+	}
+
+	controller := NewVideoController(nil, mockDBVideo, nil, nil, mockVersions)
+	router := setupVideoRouter(controller)
+
+	req, _ := http.NewRequest("POST", "/streaming/video-123/versions/version-1/rollback", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusNotFound {
+		t.Errorf("expected status %d, got %d", http.StatusNotFound, w.Code)
+	}
+}
+
+func TestRollbackVideoVersion_Expired(t *testing.T) {
+	mockDBVideo := &mocks.MockDatabaseVideoService{
+		FindVideoByIDFn: func(videoId string) (*models.VideoModel, error) {
+			return &models.VideoModel{Id: videoId, UserID: "user-123"}, nil
+		},
+	}
+	mockVersions := &mocks.MockVideoVersionService{
+		FindVersionByIDFn: func(versionId string) (*models.VideoVersion, error) {
+			return &models.VideoVersion{Id: versionId, VideoID: "video-123"}, nil
+		},
+		RollbackFn: func(version *models.VideoVersion) (*models.VideoModel, error) {
+			return nil, services.ErrVersionExpired
+		},
+	}
+
+	controller := NewVideoController(nil, mockDBVideo, nil, nil, mockVersions)
+	router := setupVideoRouter(controller)
+
+	req, _ := http.NewRequest("POST", "/streaming/video-123/versions/version-1/rollback", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusGone {
+		t.Errorf("expected status %d, got %d", http.StatusGone, w.Code)
+	}
+}
+
+// newThumbnailUploadRequest construye un multipart/form-data con una imagen falsa en el campo thumbnail
+func newThumbnailUploadRequest(t *testing.T) *http.Request {
+	t.Helper()
+
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	part, _ := writer.CreateFormFile("thumbnail", "cover.png")
+	part.Write([]byte("fake image"))
+	writer.Close()
+
+	req, _ := http.NewRequest("POST", "/streaming/video-123/thumbnail", body)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	return req
+}
+
+func TestRegenerateThumbnail_UploadCustom(t *testing.T) {
+	var receivedImage []byte
+
+	mockDBVideo := &mocks.MockDatabaseVideoService{
+		FindVideoByIDFn: func(videoId string) (*models.VideoModel, error) {
+			return &models.VideoModel{Id: videoId, UserID: "user-123", Duration: "1:30"}, nil
+		},
+	}
+	mockThumbnail := &mocks.MockThumbnailService{
+		UploadThumbnailFn: func(ctx context.Context, video *models.VideoModel, image io.Reader) (*models.VideoModel, error) {
+			receivedImage, _ = io.ReadAll(image)
+			video.ThumbnailURL = "https://cdn.example.com/thumbnails/video-123/custom-1280x720.webp"
+			video.CustomThumbnail = true
+			return video, nil
+		},
+	}
+
+	controller := NewVideoController(nil, mockDBVideo, nil, mockThumbnail, nil)
+	router := setupVideoRouter(controller)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, newThumbnailUploadRequest(t))
+
+	if w.Code != http.StatusOK {
+		t.Errorf("expected status %d, got %d", http.StatusOK, w.Code)
+	}
+
+	if string(receivedImage) != "fake image" {
+		t.Errorf("expected uploaded image to be passed to the service, got %q", receivedImage)
+	}
+
+	var response map[string]interface{}
+	json.Unmarshal(w.Body.Bytes(), &response)
+	data := response["data"].(map[string]interface{})
+	if data["custom_thumbnail"] != true {
+		t.Errorf("expected custom thumbnail in response, got %v", data["custom_thumbnail"])
+	}
+}
+
+func TestRegenerateThumbnail_UploadInvalidImage(t *testing.T) {
+	mockDBVideo := &mocks.MockDatabaseVideoService{
+		FindVideoByIDFn: func(videoId string) (*models.VideoModel, error) {
+			return &models.VideoModel{Id: videoId, UserID: "user-123"}, nil
+		},
+	}
+	mockThumbnail := &mocks.MockThumbnailService{
+		UploadThumbnailFn: func(ctx context.Context, video *models.VideoModel, image io.Reader) (*models.VideoModel, error) {
+			return nil, services.ErrInvalidThumbnail
+		},
+	}
+
+	controller := NewVideoController(nil, mockDBVideo, nil, mockThumbnail, nil)
+	router := setupVideoRouter(controller)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, newThumbnailUploadRequest(t))
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected status %d, got %d", http.StatusBadRequest, w.Code)
+	}
+}
+
+func TestRegenerateThumbnail_Candidate(t *testing.T) {
+	var receivedAt float64
+
+	mockDBVideo := &mocks.MockDatabaseVideoService{
+		FindVideoByIDFn: func(videoId string) (*models.VideoModel, error) {
+			return &models.VideoModel{
+				Id:     videoId,
+				UserID: "user-123",
+				ThumbnailCandidates: []models.ThumbnailCandidate{
+					{At: 15, URL: "candidate-1.webp"},
+					{At: 30, URL: "candidate-2.webp"},
+					{At: 45, URL: "candidate-3.webp"},
+				},
+			}, nil
+		},
+	}
+	mockThumbnail := &mocks.MockThumbnailService{
+		RequestThumbnailFn: func(video *models.VideoModel, at float64) error {
+			receivedAt = at
+			return nil
+		},
+	}
+
+	controller := NewVideoController(nil, mockDBVideo, nil, mockThumbnail, nil)
+	router := setupVideoRouter(controller)
+
+	req, _ := http.NewRequest("POST", "/streaming/video-123/thumbnail?candidate=1", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusAccepted {
+		t.Errorf("expected status %d, got %d", http.StatusAccepted, w.Code)
+	}
+
+	if receivedAt != 30 {
+		t.Errorf("expected frame of candidate 1 (30s), got %v", receivedAt)
+	}
+}
+
+func TestRegenerateThumbnail_CandidateOutOfRange(t *testing.T) {
+	mockDBVideo := &mocks.MockDatabaseVideoService{
+		FindVideoByIDFn: func(videoId string) (*models.VideoModel, error) {
+			return &models.VideoModel{Id: videoId, UserID: "user-123"}, nil
+		},
+	}
+
+	controller := NewVideoController(nil, mockDBVideo, nil, &mocks.MockThumbnailService{}, nil)
+	router := setupVideoRouter(controller)
+
+	req, _ := http.NewRequest("POST", "/streaming/video-123/thumbnail?candidate=3", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected status %d, got %d", http.StatusBadRequest, w.Code)
+	}
+}
+
+func TestCreateVideo_UnknownEncodingProfile(t *testing.T) {
+	var removedFiles []string
+	var receivedProfile string
+
+	mockJob := &mocks.MockJobService{
+		CreateJobWithTaskFn: func(job *models.Job, task []byte) (*models.JobModel, error) {
+			receivedProfile = job.EncodingProfile
+			return nil, services.ErrEncodingProfileNotFound
+		},
+	}
+
+	controller := NewVideoController(newUploadVideoService(&removedFiles), nil, mockJob, nil, nil)
+	router := setupVideoRouter(controller)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, newUploadRequest(t, map[string]string{"title": "My Video", "encoding_profile": "missing"}))
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected status %d, got %d", http.StatusBadRequest, w.Code)
+	}
+
+	if receivedProfile != "missing" {
+		t.Errorf("expected encoding profile on the job, got %q", receivedProfile)
+	}
+
+	if len(removedFiles) != 1 {
+		t.Errorf("expected local file to be removed, got %v", removedFiles)
+	}
+}
+
+// newClipVideoService simula la preparación de un clip
+func newClipVideoService() *mocks.MockVideoService {
+	return &mocks.MockVideoService{
+		NewClipFn: func(source *models.VideoModel, start, end float64) *models.Video {
+			return &models.Video{
+				Id:            "clip-123",
+				Title:         source.Title,
+				LocalPath:     "static/videos/clip-123.mp4",
+				UniqueName:    "clip-123.mp4",
+				Duration:      "30s",
+				MediaType:     models.MediaTypeVideo,
+				SourceVideoID: source.Id,
+			}
+		},
+	}
+}
+
+func TestCreateClip_Success(t *testing.T) {
+	var receivedTask models.VideoTask
+
+	mockDBVideo := &mocks.MockDatabaseVideoService{
+		FindVideoByIDFn: func(videoId string) (*models.VideoModel, error) {
+			return &models.VideoModel{Id: videoId, UserID: "user-123", Title: "Original", Duration: "1:30", EncodingProfile: "hd"}, nil
+		},
+	}
+	mockJob := &mocks.MockJobService{
+		CreateJobWithTaskFn: func(job *models.Job, task []byte) (*models.JobModel, error) {
+			json.Unmarshal(task, &receivedTask)
+			return &models.JobModel{Job: *job}, nil
+		},
+	}
+
+	controller := NewVideoController(newClipVideoService(), mockDBVideo, mockJob, nil, nil)
+	router := setupVideoRouter(controller)
+
+	body := bytes.NewBufferString(`{"start": 10, "end": 40, "title": "Highlight", "accurate": true}`)
+	req, _ := http.NewRequest("POST", "/streaming/video-123/clips", body)
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusAccepted {
+		t.Fatalf("expected status %d, got %d", http.StatusAccepted, w.Code)
+	}
+
+	if receivedTask.JobID != "clip-123" || receivedTask.SourceVideoID != "video-123" {
+		t.Errorf("expected clip job linked to video-123, got %+v", receivedTask)
+	}
+	if receivedTask.ClipStart != 10 || receivedTask.ClipEnd != 40 || !receivedTask.ClipAccurate {
+		t.Errorf("unexpected clip range in task: %+v", receivedTask)
+	}
+	if receivedTask.Title != "Highlight" || receivedTask.EncodingProfile != "hd" || !receivedTask.SkipWatermark {
+		t.Errorf("unexpected clip options in task: %+v", receivedTask)
+	}
+}
+
+func TestCreateClip_InvalidRange(t *testing.T) {
+	mockDBVideo := &mocks.MockDatabaseVideoService{
+		FindVideoByIDFn: func(videoId string) (*models.VideoModel, error) {
+			return &models.VideoModel{Id: videoId, UserID: "user-123", Duration: "1:30"}, nil
+		},
+	}
+
+	controller := NewVideoController(newClipVideoService(), mockDBVideo, &mocks.MockJobService{}, nil, nil)
+	router := setupVideoRouter(controller)
+
+	for _, body := range []string{
+		`{"start": 10}`,
+		`{"start": -1, "end": 10}`,
+		`{"start": 20, "end": 10}`,
+		`{"start": 10, "end": 10.5}`,
+		`{"start": 10, "end": 120}`,
+	} {
+		req, _ := http.NewRequest("POST", "/streaming/video-123/clips", bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("body %s: expected status %d, got %d", body, http.StatusBadRequest, w.Code)
+		}
+	}
+}
+
+func TestCreateClip_Forbidden(t *testing.T) {
+	mockDBVideo := &mocks.MockDatabaseVideoService{
+		FindVideoByIDFn: func(videoId string) (*models.VideoModel, error) {
+			return &models.VideoModel{Id: videoId, UserID: "other-user", Duration: "1:30"}, nil
+		},
+	}
+
+	controller := NewVideoController(newClipVideoService(), mockDBVideo, &mocks.MockJobService{}, nil, nil)
+	router := setupVideoRouter(controller)
+
+	req, _ := http.NewRequest("POST", "/streaming/video-123/clips", bytes.NewBufferString(`{"start": 0, "end": 10}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusForbidden {
+		t.Errorf("expected status %d, got %d", http.StatusForbidden, w.Code)
+	}
+}
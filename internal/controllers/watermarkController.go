@@ -0,0 +1,187 @@
+package controllers
+
+import (
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/unbot2313/go-streaming-service/internal/helpers"
+	"github.com/unbot2313/go-streaming-service/internal/models"
+	"github.com/unbot2313/go-streaming-service/internal/services"
+)
+
+type WatermarkController interface {
+	GetWatermark(c *gin.Context)
+	SaveWatermark(c *gin.Context)
+	DeleteWatermark(c *gin.Context)
+}
+
+type WatermarkControllerImpl struct {
+	watermarkService services.WatermarkService
+}
+
+func NewWatermarkController(watermarkService services.WatermarkService) WatermarkController {
+	return &WatermarkControllerImpl{
+		watermarkService: watermarkService,
+	}
+}
+
+// SaveWatermarkRequest valida las opciones de la marca de agua; los campos que no
+// se envían conservan el valor actual (o el valor por defecto si no hay marca de agua)
+type SaveWatermarkRequest struct {
+	Position *string  `form:"position" binding:"omitempty,oneof=top-left top-right bottom-left bottom-right center"`
+	Opacity  *float64 `form:"opacity" binding:"omitempty,gt=0,lte=1"`
+	Scale    *float64 `form:"scale" binding:"omitempty,gte=0.05,lte=0.5"`
+}
+
+// GetWatermark godoc
+// @Summary		Get your watermark
+// @Description	Get the watermark burned into the authenticated user's new renditions
+// @Tags		users
+// @Produce		json
+// @Security	BearerAuth
+// @Success		200 {object} helpers.APIResponse{data=models.WatermarkSwagger}
+// @Failure		401 {object} helpers.APIResponse{error=helpers.APIError}
+// @Failure		404 {object} helpers.APIResponse{error=helpers.APIError}
+// @Failure		500 {object} helpers.APIResponse{error=helpers.APIError}
+// @Router		/users/watermark [get]
+func (wc *WatermarkControllerImpl) GetWatermark(c *gin.Context) {
+	user, exists := c.Get("user")
+	if !exists {
+		helpers.HandleError(c, http.StatusInternalServerError, "User not found in context", nil)
+		return
+	}
+	authenticatedUser := user.(*models.User)
+
+	watermark, err := wc.watermarkService.GetWatermark(authenticatedUser.Id)
+	if err != nil {
+		if errors.Is(err, services.ErrWatermarkNotFound) {
+			helpers.HandleError(c, http.StatusNotFound, "Watermark not found", err)
+			return
+		}
+		helpers.HandleError(c, http.StatusInternalServerError, "Could not retrieve watermark", err)
+		return
+	}
+
+	helpers.Success(c, http.StatusOK, watermark)
+}
+
+// SaveWatermark godoc
+// @Summary		Upload or update your watermark
+// @Description	Upload a PNG logo (max 2MB, up to 4096x4096) that is burned into the renditions of your next uploads, and choose its position, opacity and scale (width as a fraction of the video width). Without a file only the options change. Uploads can skip it with `skip_watermark`. Videos already processed are not changed.
+// @Tags		users
+// @Accept		multipart/form-data
+// @Produce		json
+// @Security	BearerAuth
+// @Param		watermark formData file false "Watermark PNG (required the first time)"
+// @Param		position formData string false "Position (default: bottom-right)" Enums(top-left, top-right, bottom-left, bottom-right, center)
+// @Param		opacity formData number false "Opacity, greater than 0 and up to 1 (default: 1)"
+// @Param		scale formData number false "Width as a fraction of the video width, 0.05 to 0.5 (default: 0.15)"
+// @Success		200 {object} helpers.APIResponse{data=models.WatermarkSwagger}
+// @Failure		400 {object} helpers.APIResponse{error=helpers.APIError}
+// @Failure		401 {object} helpers.APIResponse{error=helpers.APIError}
+// @Failure		500 {object} helpers.APIResponse{error=helpers.APIError}
+// @Router		/users/watermark [put]
+func (wc *WatermarkControllerImpl) SaveWatermark(c *gin.Context) {
+	user, exists := c.Get("user")
+	if !exists {
+		helpers.HandleError(c, http.StatusInternalServerError, "User not found in context", nil)
+		return
+	}
+	authenticatedUser := user.(*models.User)
+
+	var req SaveWatermarkRequest
+	if err := c.ShouldBind(&req); err != nil {
+		helpers.HandleError(c, http.StatusBadRequest, "position must be a valid position, opacity between 0 and 1 and scale between 0.05 and 0.5", err)
+		return
+	}
+
+	// Partir de la marca de agua actual o de los valores por defecto
+	watermark, err := wc.watermarkService.GetWatermark(authenticatedUser.Id)
+	if err != nil {
+		if !errors.Is(err, services.ErrWatermarkNotFound) {
+			helpers.HandleError(c, http.StatusInternalServerError, "Could not retrieve watermark", err)
+			return
+		}
+		watermark = &models.Watermark{
+			UserID:   authenticatedUser.Id,
+			Position: models.WatermarkBottomRight,
+			Opacity:  services.DefaultWatermarkOpacity,
+			Scale:    services.DefaultWatermarkScale,
+		}
+	}
+
+	if req.Position != nil {
+		watermark.Position = *req.Position
+	}
+	if req.Opacity != nil {
+		watermark.Opacity = *req.Opacity
+	}
+	if req.Scale != nil {
+		watermark.Scale = *req.Scale
+	}
+
+	var image io.Reader
+	if header, err := c.FormFile("watermark"); err == nil {
+		if header.Size > services.MaxWatermarkUploadSize {
+			helpers.HandleError(c, http.StatusBadRequest, "El archivo excede el limite de tamaño permitido", nil)
+			return
+		}
+
+		file, err := header.Open()
+		if err != nil {
+			helpers.HandleError(c, http.StatusBadRequest, "Could not read watermark", err)
+			return
+		}
+		defer file.Close()
+		image = file
+	}
+
+	saved, err := wc.watermarkService.SaveWatermark(c.Request.Context(), watermark, image)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidWatermark) {
+			helpers.HandleError(c, http.StatusBadRequest, err.Error(), err)
+			return
+		}
+		if errors.Is(err, services.ErrWatermarkNotFound) {
+			helpers.HandleError(c, http.StatusBadRequest, "A watermark PNG file is required", err)
+			return
+		}
+		helpers.HandleError(c, http.StatusInternalServerError, "Could not save watermark", err)
+		return
+	}
+
+	helpers.Success(c, http.StatusOK, saved)
+}
+
+// DeleteWatermark godoc
+// @Summary		Delete your watermark
+// @Description	Delete the authenticated user's watermark. Next uploads are not watermarked; videos already processed keep it.
+// @Tags		users
+// @Produce		json
+// @Security	BearerAuth
+// @Success		200 {object} helpers.APIResponse{data=object{message=string}}
+// @Failure		401 {object} helpers.APIResponse{error=helpers.APIError}
+// @Failure		404 {object} helpers.APIResponse{error=helpers.APIError}
+// @Failure		500 {object} helpers.APIResponse{error=helpers.APIError}
+// @Router		/users/watermark [delete]
+func (wc *WatermarkControllerImpl) DeleteWatermark(c *gin.Context) {
+	user, exists := c.Get("user")
+	if !exists {
+		helpers.HandleError(c, http.StatusInternalServerError, "User not found in context", nil)
+		return
+	}
+	authenticatedUser := user.(*models.User)
+
+	if err := wc.watermarkService.DeleteWatermark(c.Request.Context(), authenticatedUser.Id); err != nil {
+		if errors.Is(err, services.ErrWatermarkNotFound) {
+			helpers.HandleError(c, http.StatusNotFound, "Watermark not found", err)
+			return
+		}
+		helpers.HandleError(c, http.StatusInternalServerError, "Could not delete watermark", err)
+		return
+	}
+
+	helpers.Success(c, http.StatusOK, gin.H{"message": "Watermark deleted successfully"})
+}
@@ -0,0 +1,242 @@
+package controllers
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/unbot2313/go-streaming-service/internal/mocks"
+	"github.com/unbot2313/go-streaming-service/internal/models"
+	"github.com/unbot2313/go-streaming-service/internal/services"
+)
+
+func setupWatermarkRouter(controller WatermarkController) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+
+	// Rutas protegidas con usuario simulado
+	protected := r.Group("")
+	protected.Use(func(c *gin.Context) {
+		c.Set("user", &models.User{Id: "user-123", Username: "testuser"})
+		c.Next()
+	})
+	protected.GET("/users/watermark", controller.GetWatermark)
+	protected.PUT("/users/watermark", controller.SaveWatermark)
+	protected.DELETE("/users/watermark", controller.DeleteWatermark)
+	return r
+}
+
+// newWatermarkRequest arma el formulario multipart con los campos y, si image no es nil, el PNG
+func newWatermarkRequest(fields map[string]string, image []byte) *http.Request {
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	for key, value := range fields {
+		writer.WriteField(key, value)
+	}
+	if image != nil {
+		part, _ := writer.CreateFormFile("watermark", "logo.png")
+		part.Write(image)
+	}
+	writer.Close()
+
+	req, _ := http.NewRequest("PUT", "/users/watermark", body)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	return req
+}
+
+func TestSaveWatermark_NewWithImage(t *testing.T) {
+	var received *models.Watermark
+	var receivedImage string
+
+	mockWatermark := &mocks.MockWatermarkService{
+		GetWatermarkFn: func(userId string) (*models.Watermark, error) {
+			return nil, services.ErrWatermarkNotFound
+		},
+		SaveWatermarkFn: func(ctx context.Context, watermark *models.Watermark, image io.Reader) (*models.Watermark, error) {
+			received = watermark
+			if image != nil {
+				data, _ := io.ReadAll(image)
+				receivedImage = string(data)
+			}
+			return watermark, nil
+		},
+	}
+
+	controller := NewWatermarkController(mockWatermark)
+	router := setupWatermarkRouter(controller)
+
+	req := newWatermarkRequest(map[string]string{"position": "top-left", "opacity": "0.5"}, []byte("fake png"))
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, w.Code)
+	}
+
+	if received.UserID != "user-123" || received.Position != models.WatermarkTopLeft || received.Opacity != 0.5 {
+		t.Errorf("unexpected watermark saved: %+v", received)
+	}
+
+	// La escala no enviada usa el valor por defecto
+	if received.Scale != services.DefaultWatermarkScale {
+		t.Errorf("expected default scale %v, got %v", services.DefaultWatermarkScale, received.Scale)
+	}
+
+	if receivedImage != "fake png" {
+		t.Errorf("expected uploaded image to be passed to the service, got %q", receivedImage)
+	}
+}
+
+func TestSaveWatermark_UpdateOptionsKeepsCurrent(t *testing.T) {
+	var received *models.Watermark
+	imageWasSent := false
+
+	mockWatermark := &mocks.MockWatermarkService{
+		GetWatermarkFn: func(userId string) (*models.Watermark, error) {
+			return &models.Watermark{UserID: userId, ImageURL: "https://cdn/logo.png", Position: models.WatermarkTopRight, Opacity: 0.8, Scale: 0.2}, nil
+		},
+		SaveWatermarkFn: func(ctx context.Context, watermark *models.Watermark, image io.Reader) (*models.Watermark, error) {
+			received = watermark
+			imageWasSent = image != nil
+			return watermark, nil
+		},
+	}
+
+	controller := NewWatermarkController(mockWatermark)
+	router := setupWatermarkRouter(controller)
+
+	req := newWatermarkRequest(map[string]string{"scale": "0.1"}, nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, w.Code)
+	}
+
+	if imageWasSent {
+		t.Error("expected no image to be sent when only options change")
+	}
+
+	if received.ImageURL != "https://cdn/logo.png" || received.Position != models.WatermarkTopRight || received.Opacity != 0.8 || received.Scale != 0.1 {
+		t.Errorf("unexpected watermark saved: %+v", received)
+	}
+}
+
+func TestSaveWatermark_InvalidOptions(t *testing.T) {
+	controller := NewWatermarkController(&mocks.MockWatermarkService{})
+	router := setupWatermarkRouter(controller)
+
+	for _, fields := range []map[string]string{
+		{"position": "middle"},
+		{"opacity": "0"},
+		{"opacity": "1.5"},
+		{"scale": "0.9"},
+	} {
+		req := newWatermarkRequest(fields, []byte("fake png"))
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("fields %v: expected status %d, got %d", fields, http.StatusBadRequest, w.Code)
+		}
+	}
+}
+
+func TestSaveWatermark_InvalidImage(t *testing.T) {
+	mockWatermark := &mocks.MockWatermarkService{
+		GetWatermarkFn: func(userId string) (*models.Watermark, error) {
+			return nil, services.ErrWatermarkNotFound
+		},
+		SaveWatermarkFn: func(ctx context.Context, watermark *models.Watermark, image io.Reader) (*models.Watermark, error) {
+			return nil, services.ErrInvalidWatermark
+		},
+	}
+
+	controller := NewWatermarkController(mockWatermark)
+	router := setupWatermarkRouter(controller)
+
+	req := newWatermarkRequest(nil, []byte("not a png"))
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected status %d, got %d", http.StatusBadRequest, w.Code)
+	}
+}
+
+func TestSaveWatermark_FirstTimeWithoutImage(t *testing.T) {
+	mockWatermark := &mocks.MockWatermarkService{
+		GetWatermarkFn: func(userId string) (*models.Watermark, error) {
+			return nil, services.ErrWatermarkNotFound
+		},
+		SaveWatermarkFn: func(ctx context.Context, watermark *models.Watermark, image io.Reader) (*models.Watermark, error) {
+			return nil, services.ErrWatermarkNotFound
+		},
+	}
+
+	controller := NewWatermarkController(mockWatermark)
+	router := setupWatermarkRouter(controller)
+
+	req := newWatermarkRequest(map[string]string{"position": "center"}, nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected status %d, got %d", http.StatusBadRequest, w.Code)
+	}
+
+	if !strings.Contains(w.Body.String(), "PNG file is required") {
+		t.Errorf("unexpected body: %s", w.Body.String())
+	}
+}
+
+func TestGetWatermark_NotFound(t *testing.T) {
+	mockWatermark := &mocks.MockWatermarkService{
+		GetWatermarkFn: func(userId string) (*models.Watermark, error) {
+			return nil, services.ErrWatermarkNotFound
+		},
+	}
+
+	controller := NewWatermarkController(mockWatermark)
+	router := setupWatermarkRouter(controller)
+
+	req, _ := http.NewRequest("GET", "/users/watermark", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusNotFound {
+		t.Errorf("expected status %d, got %d", http.StatusNotFound, w.Code)
+	}
+}
+
+func TestDeleteWatermark_Success(t *testing.T) {
+	var deletedFor string
+
+	mockWatermark := &mocks.MockWatermarkService{
+		DeleteWatermarkFn: func(ctx context.Context, userId string) error {
+			deletedFor = userId
+			return nil
+		},
+	}
+
+	controller := NewWatermarkController(mockWatermark)
+	router := setupWatermarkRouter(controller)
+
+	req, _ := http.NewRequest("DELETE", "/users/watermark", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("expected status %d, got %d", http.StatusOK, w.Code)
+	}
+
+	if deletedFor != "user-123" {
+		t.Errorf("expected watermark of user-123 to be deleted, got %s", deletedFor)
+	}
+}
@@ -0,0 +1,219 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/unbot2313/go-streaming-service/internal/helpers"
+	"github.com/unbot2313/go-streaming-service/internal/models"
+	"github.com/unbot2313/go-streaming-service/internal/services"
+)
+
+type WebhookController interface {
+	CreateWebhook(c *gin.Context)
+	GetWebhooks(c *gin.Context)
+	DeleteWebhook(c *gin.Context)
+	GetWebhookDeliveries(c *gin.Context)
+	RedeliverWebhook(c *gin.Context)
+}
+
+type WebhookControllerImpl struct {
+	webhookService services.WebhookService
+}
+
+func NewWebhookController(webhookService services.WebhookService) WebhookController {
+	return &WebhookControllerImpl{
+		webhookService: webhookService,
+	}
+}
+
+// CreateWebhookRequest valida los datos para suscribir un webhook
+type CreateWebhookRequest struct {
+	URL    string   `json:"url" binding:"required,url,max=2048"`
+	Events []string `json:"events" binding:"required,min=1,dive,oneof=job.processing job.completed job.failed video.deleted video.published"`
+}
+
+// CreateWebhook godoc
+// @Summary		Subscribe a webhook
+// @Description	Register an endpoint that receives the selected events (job.processing, job.completed, job.failed, video.deleted, video.published). job.failed is sent once, when the job has no attempts left; failures that are retried are not notified. Each request is signed with HMAC-SHA256 in the X-Webhook-Signature header using the returned secret, which is only shown once. The URL must be https and cannot point to a loopback, private or link-local address.
+// @Tags		webhooks
+// @Accept		json
+// @Produce		json
+// @Security	BearerAuth
+// @Param		body body CreateWebhookRequest true "Webhook data"
+// @Success		201 {object} helpers.APIResponse{data=models.WebhookSubscriptionSwagger}
+// @Failure		400 {object} helpers.APIResponse{error=helpers.APIError}
+// @Failure		401 {object} helpers.APIResponse{error=helpers.APIError}
+// @Failure		500 {object} helpers.APIResponse{error=helpers.APIError}
+// @Router		/webhooks [post]
+func (wc *WebhookControllerImpl) CreateWebhook(c *gin.Context) {
+	user, exists := c.Get("user")
+	if !exists {
+		helpers.HandleError(c, http.StatusInternalServerError, "User not found in context", nil)
+		return
+	}
+	authenticatedUser := user.(*models.User)
+
+	var req CreateWebhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		helpers.HandleError(c, http.StatusBadRequest, "Invalid input", err)
+		return
+	}
+
+	subscription, err := wc.webhookService.CreateSubscription(authenticatedUser.Id, req.URL, req.Events)
+	if err != nil {
+		if errors.Is(err, services.ErrWebhookURLNotAllowed) {
+			helpers.HandleError(c, http.StatusBadRequest, "Webhook URL must be https and cannot point to an internal address", err)
+			return
+		}
+		helpers.HandleError(c, http.StatusInternalServerError, "Could not create webhook", err)
+		return
+	}
+
+	helpers.Success(c, http.StatusCreated, subscription)
+}
+
+// GetWebhooks godoc
+// @Summary		List webhooks
+// @Description	List the webhooks of the authenticated user
+// @Tags		webhooks
+// @Produce		json
+// @Security	BearerAuth
+// @Success		200 {object} helpers.APIResponse{data=[]models.WebhookSubscriptionSwagger}
+// @Failure		401 {object} helpers.APIResponse{error=helpers.APIError}
+// @Failure		500 {object} helpers.APIResponse{error=helpers.APIError}
+// @Router		/webhooks [get]
+func (wc *WebhookControllerImpl) GetWebhooks(c *gin.Context) {
+	user, exists := c.Get("user")
+	if !exists {
+		helpers.HandleError(c, http.StatusInternalServerError, "User not found in context", nil)
+		return
+	}
+	authenticatedUser := user.(*models.User)
+
+	subscriptions, err := wc.webhookService.ListSubscriptions(authenticatedUser.Id)
+	if err != nil {
+		helpers.HandleError(c, http.StatusInternalServerError, "Could not retrieve webhooks", err)
+		return
+	}
+
+	helpers.Success(c, http.StatusOK, subscriptions)
+}
+
+// DeleteWebhook godoc
+// @Summary		Delete a webhook
+// @Description	Delete a webhook subscription. Pending deliveries are not sent. Only the owner can delete it.
+// @Tags		webhooks
+// @Produce		json
+// @Security	BearerAuth
+// @Param		id path string true "Webhook ID"
+// @Success		200 {object} helpers.APIResponse{data=object{message=string}}
+// @Failure		403 {object} helpers.APIResponse{error=helpers.APIError}
+// @Failure		404 {object} helpers.APIResponse{error=helpers.APIError}
+// @Failure		500 {object} helpers.APIResponse{error=helpers.APIError}
+// @Router		/webhooks/{id} [delete]
+func (wc *WebhookControllerImpl) DeleteWebhook(c *gin.Context) {
+	subscription, ok := wc.findOwnSubscription(c)
+	if !ok {
+		return
+	}
+
+	if err := wc.webhookService.DeleteSubscription(subscription.Id); err != nil {
+		helpers.HandleError(c, http.StatusInternalServerError, "Could not delete webhook", err)
+		return
+	}
+
+	helpers.Success(c, http.StatusOK, gin.H{"message": "Webhook deleted successfully"})
+}
+
+// GetWebhookDeliveries godoc
+// @Summary		List webhook deliveries
+// @Description	Delivery log of a webhook (last 100), with attempts, response status and last error. Only the owner can view it.
+// @Tags		webhooks
+// @Produce		json
+// @Security	BearerAuth
+// @Param		id path string true "Webhook ID"
+// @Success		200 {object} helpers.APIResponse{data=[]models.WebhookDeliverySwagger}
+// @Failure		403 {object} helpers.APIResponse{error=helpers.APIError}
+// @Failure		404 {object} helpers.APIResponse{error=helpers.APIError}
+// @Failure		500 {object} helpers.APIResponse{error=helpers.APIError}
+// @Router		/webhooks/{id}/deliveries [get]
+func (wc *WebhookControllerImpl) GetWebhookDeliveries(c *gin.Context) {
+	subscription, ok := wc.findOwnSubscription(c)
+	if !ok {
+		return
+	}
+
+	deliveries, err := wc.webhookService.ListDeliveries(subscription.Id)
+	if err != nil {
+		helpers.HandleError(c, http.StatusInternalServerError, "Could not retrieve deliveries", err)
+		return
+	}
+
+	helpers.Success(c, http.StatusOK, deliveries)
+}
+
+// RedeliverWebhook godoc
+// @Summary		Redeliver a webhook event
+// @Description	Queue a new delivery of a past event with the same event ID and payload. Only the owner can redeliver it.
+// @Tags		webhooks
+// @Produce		json
+// @Security	BearerAuth
+// @Param		deliveryid path string true "Delivery ID"
+// @Success		202 {object} helpers.APIResponse{data=models.WebhookDeliverySwagger}
+// @Failure		403 {object} helpers.APIResponse{error=helpers.APIError}
+// @Failure		404 {object} helpers.APIResponse{error=helpers.APIError}
+// @Failure		500 {object} helpers.APIResponse{error=helpers.APIError}
+// @Router		/webhooks/deliveries/{deliveryid}/redeliver [post]
+func (wc *WebhookControllerImpl) RedeliverWebhook(c *gin.Context) {
+	user, exists := c.Get("user")
+	if !exists {
+		helpers.HandleError(c, http.StatusInternalServerError, "User not found in context", nil)
+		return
+	}
+	authenticatedUser := user.(*models.User)
+
+	delivery, err := wc.webhookService.FindDeliveryByID(c.Param("deliveryid"))
+	if err != nil {
+		helpers.HandleError(c, http.StatusNotFound, "Delivery not found", err)
+		return
+	}
+
+	if delivery.UserID != authenticatedUser.Id {
+		helpers.HandleError(c, http.StatusForbidden, "You are not the owner of this webhook", nil)
+		return
+	}
+
+	redelivery, err := wc.webhookService.Redeliver(delivery)
+	if err != nil {
+		helpers.HandleError(c, http.StatusInternalServerError, "Could not redeliver event", err)
+		return
+	}
+
+	helpers.Success(c, http.StatusAccepted, redelivery)
+}
+
+// findOwnSubscription busca el webhook del parámetro :id y verifica que sea del usuario autenticado.
+// Si no, responde el error y retorna false.
+func (wc *WebhookControllerImpl) findOwnSubscription(c *gin.Context) (*models.WebhookSubscription, bool) {
+	user, exists := c.Get("user")
+	if !exists {
+		helpers.HandleError(c, http.StatusInternalServerError, "User not found in context", nil)
+		return nil, false
+	}
+	authenticatedUser := user.(*models.User)
+
+	subscription, err := wc.webhookService.FindSubscriptionByID(c.Param("id"))
+	if err != nil {
+		helpers.HandleError(c, http.StatusNotFound, "Webhook not found", err)
+		return nil, false
+	}
+
+	if subscription.UserID != authenticatedUser.Id {
+		helpers.HandleError(c, http.StatusForbidden, "You are not the owner of this webhook", nil)
+		return nil, false
+	}
+
+	return subscription, true
+}
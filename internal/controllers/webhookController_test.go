@@ -0,0 +1,215 @@
+package controllers
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/unbot2313/go-streaming-service/internal/mocks"
+	"github.com/unbot2313/go-streaming-service/internal/models"
+	"github.com/unbot2313/go-streaming-service/internal/services"
+)
+
+func setupWebhookRouter(controller WebhookController) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+
+	// Rutas protegidas con usuario simulado
+	protected := r.Group("")
+	protected.Use(func(c *gin.Context) {
+		c.Set("user", &models.User{Id: "user-123", Username: "testuser"})
+		c.Next()
+	})
+	protected.POST("/webhooks", controller.CreateWebhook)
+	protected.GET("/webhooks", controller.GetWebhooks)
+	protected.DELETE("/webhooks/:id", controller.DeleteWebhook)
+	protected.GET("/webhooks/:id/deliveries", controller.GetWebhookDeliveries)
+	protected.POST("/webhooks/deliveries/:deliveryid/redeliver", controller.RedeliverWebhook)
+	return r
+}
+
+func TestCreateWebhook_Success(t *testing.T) {
+	var receivedEvents []string
+
+	mockWebhook := &mocks.MockWebhookService{
+		CreateSubscriptionFn: func(userId, url string, events []string) (*models.WebhookSubscription, error) {
+			receivedEvents = events
+			return &models.WebhookSubscription{Id: "hook-1", UserID: userId, URL: url, Secret: "whsec_abc", Events: events}, nil
+		},
+	}
+
+	controller := NewWebhookController(mockWebhook)
+	router := setupWebhookRouter(controller)
+
+	body, _ := json.Marshal(map[string]interface{}{
+		"url":    "https://example.com/hooks",
+		"events": []string{"job.completed", "job.failed"},
+	})
+	req, _ := http.NewRequest("POST", "/webhooks", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusCreated {
+		t.Errorf("expected status %d, got %d", http.StatusCreated, w.Code)
+	}
+
+	if len(receivedEvents) != 2 {
+		t.Errorf("expected 2 events, got %v", receivedEvents)
+	}
+
+	var response map[string]interface{}
+	json.Unmarshal(w.Body.Bytes(), &response)
+	data := response["data"].(map[string]interface{})
+	if data["secret"] != "whsec_abc" {
+		t.Errorf("expected secret in response, got %v", data["secret"])
+	}
+}
+
+func TestCreateWebhook_InvalidInput(t *testing.T) {
+	controller := NewWebhookController(&mocks.MockWebhookService{})
+	router := setupWebhookRouter(controller)
+
+	bodies := []map[string]interface{}{
+		{"url": "not-a-url", "events": []string{"job.completed"}},
+		{"url": "https://example.com/hooks", "events": []string{}},
+		{"url": "https://example.com/hooks", "events": []string{"video.created"}},
+	}
+
+	for _, b := range bodies {
+		body, _ := json.Marshal(b)
+		req, _ := http.NewRequest("POST", "/webhooks", bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("body %v: expected status %d, got %d", b, http.StatusBadRequest, w.Code)
+		}
+	}
+}
+
+func TestCreateWebhook_URLNotAllowed(t *testing.T) {
+	mockWebhook := &mocks.MockWebhookService{
+		CreateSubscriptionFn: func(userId, url string, events []string) (*models.WebhookSubscription, error) {
+			return nil, fmt.Errorf("%w: 169.254.169.254 es una dirección interna", services.ErrWebhookURLNotAllowed)
+		},
+	}
+
+	controller := NewWebhookController(mockWebhook)
+	router := setupWebhookRouter(controller)
+
+	body, _ := json.Marshal(map[string]interface{}{
+		"url":    "http://169.254.169.254/latest/meta-data",
+		"events": []string{"job.completed"},
+	})
+	req, _ := http.NewRequest("POST", "/webhooks", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected status %d, got %d", http.StatusBadRequest, w.Code)
+	}
+}
+
+func TestDeleteWebhook_Forbidden(t *testing.T) {
+	deleted := false
+
+	mockWebhook := &mocks.MockWebhookService{
+		FindSubscriptionByIDFn: func(subscriptionId string) (*models.WebhookSubscription, error) {
+			return &models.WebhookSubscription{Id: subscriptionId, UserID: "other-user"}, nil
+		},
+		DeleteSubscriptionFn: func(subscriptionId string) error {
+			deleted = true
+			return nil
+		},
+	}
+
+	controller := NewWebhookController(mockWebhook)
+	router := setupWebhookRouter(controller)
+
+	req, _ := http.NewRequest("DELETE", "/webhooks/hook-1", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusForbidden {
+		t.Errorf("expected status %d, got %d", http.StatusForbidden, w.Code)
+	}
+
+	if deleted {
+		t.Error("webhook of another user should not be deleted")
+	}
+}
+
+func TestGetWebhookDeliveries_NotFound(t *testing.T) {
+	mockWebhook := &mocks.MockWebhookService{
+		FindSubscriptionByIDFn: func(subscriptionId string) (*models.WebhookSubscription, error) {
+			return nil, errors.New("not found")
+		},
+	}
+
+	controller := NewWebhookController(mockWebhook)
+	router := setupWebhookRouter(controller)
+
+	req, _ := http.NewRequest("GET", "/webhooks/hook-1/deliveries", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusNotFound {
+		t.Errorf("expected status %d, got %d", http.StatusNotFound, w.Code)
+	}
+}
+
+func TestRedeliverWebhook_Success(t *testing.T) {
+	mockWebhook := &mocks.MockWebhookService{
+		FindDeliveryByIDFn: func(deliveryId string) (*models.WebhookDelivery, error) {
+			return &models.WebhookDelivery{Id: deliveryId, UserID: "user-123", EventID: "event-1", Status: models.WebhookDeliveryFailed}, nil
+		},
+		RedeliverFn: func(delivery *models.WebhookDelivery) (*models.WebhookDelivery, error) {
+			return &models.WebhookDelivery{Id: "delivery-2", EventID: delivery.EventID, Status: models.WebhookDeliveryPending}, nil
+		},
+	}
+
+	controller := NewWebhookController(mockWebhook)
+	router := setupWebhookRouter(controller)
+
+	req, _ := http.NewRequest("POST", "/webhooks/deliveries/delivery-1/redeliver", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusAccepted {
+		t.Errorf("expected status %d, got %d", http.StatusAccepted, w.Code)
+	}
+
+	var response map[string]interface{}
+	json.Unmarshal(w.Body.Bytes(), &response)
+	data := response["data"].(map[string]interface{})
+	if data["event_id"] != "event-1" || data["status"] != models.WebhookDeliveryPending {
+		t.Errorf("unexpected redelivery: %v", data)
+	}
+}
+
+func TestRedeliverWebhook_Forbidden(t *testing.T) {
+	mockWebhook := &mocks.MockWebhookService{
+		FindDeliveryByIDFn: func(deliveryId string) (*models.WebhookDelivery, error) {
+			return &models.WebhookDelivery{Id: deliveryId, UserID: "other-user"}, nil
+		},
+	}
+
+	controller := NewWebhookController(mockWebhook)
+	router := setupWebhookRouter(controller)
+
+	req, _ := http.NewRequest("POST", "/webhooks/deliveries/delivery-1/redeliver", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusForbidden {
+		t.Errorf("expected status %d, got %d", http.StatusForbidden, w.Code)
+	}
+}
@@ -1,18 +1,31 @@
 package middlewares
 
 import (
+	"errors"
 	"net/http"
 	"strings"
 
 	"github.com/gin-gonic/gin"
 	"github.com/unbot2313/go-streaming-service/internal/helpers"
+	"github.com/unbot2313/go-streaming-service/internal/models"
 	"github.com/unbot2313/go-streaming-service/internal/services"
 )
 
-func AuthMiddleware(authService services.AuthService) gin.HandlerFunc {
+// AuthMiddleware autentica con un access token o con una API key (header X-API-Key o Bearer sk_...).
+// Las API keys solo entran a las rutas que declaran scopes, y necesitan todos ellos.
+func AuthMiddleware(authService services.AuthService, apiKeyService services.APIKeyService, scopes ...string) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		rawToken := c.GetHeader("Authorization")
 
+		rawKey := c.GetHeader("X-API-Key")
+		if rawKey == "" && strings.HasPrefix(rawToken, "Bearer "+models.APIKeyPrefix) {
+			rawKey = strings.TrimPrefix(rawToken, "Bearer ")
+		}
+		if rawKey != "" {
+			authenticateAPIKey(c, apiKeyService, rawKey, scopes)
+			return
+		}
+
 		if rawToken == "" {
 			helpers.HandleError(c, http.StatusUnauthorized, "Authorization header not provided", nil)
 			c.Abort()
@@ -27,14 +40,76 @@ func AuthMiddleware(authService services.AuthService) gin.HandlerFunc {
 
 		token := strings.TrimPrefix(rawToken, "Bearer ")
 
-		user, err := authService.ValidateToken(token)
+		user, claims, err := authService.ValidateToken(token)
 		if err != nil {
 			helpers.HandleError(c, http.StatusUnauthorized, "Invalid or expired token", err)
 			c.Abort()
 			return
 		}
 
+		// Logout, cambio de contraseña o suspensión revocan tokens que aún no vencieron
+		revoked, err := authService.IsTokenRevoked(user, claims)
+		if err != nil {
+			helpers.HandleError(c, http.StatusInternalServerError, "Could not verify token", err)
+			c.Abort()
+			return
+		}
+		if revoked {
+			helpers.HandleError(c, http.StatusUnauthorized, "Token has been revoked", nil)
+			c.Abort()
+			return
+		}
+
 		c.Set("user", user)
+		c.Set("claims", claims)
 		c.Next()
 	}
 }
+
+// OptionalAuthMiddleware autentica como AuthMiddleware si la petición trae credenciales y
+// deja pasar sin usuario si no trae ninguna. Es para rutas públicas que al dueño le muestran más.
+func OptionalAuthMiddleware(authService services.AuthService, apiKeyService services.APIKeyService, scopes ...string) gin.HandlerFunc {
+	authenticate := AuthMiddleware(authService, apiKeyService, scopes...)
+
+	return func(c *gin.Context) {
+		if c.GetHeader("Authorization") == "" && c.GetHeader("X-API-Key") == "" {
+			c.Next()
+			return
+		}
+		authenticate(c)
+	}
+}
+
+func authenticateAPIKey(c *gin.Context, apiKeyService services.APIKeyService, rawKey string, scopes []string) {
+	user, apiKey, err := apiKeyService.Authenticate(rawKey)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrInvalidAPIKey):
+			helpers.HandleError(c, http.StatusUnauthorized, "Invalid or expired API key", err)
+		case errors.Is(err, services.ErrUserSuspended):
+			helpers.HandleError(c, http.StatusForbidden, "Account is suspended", err)
+		default:
+			helpers.HandleError(c, http.StatusInternalServerError, "Could not verify API key", err)
+		}
+		c.Abort()
+		return
+	}
+
+	// Sin scopes la ruta es solo para sesiones: cuenta, sesiones, API keys, administración
+	if len(scopes) == 0 {
+		helpers.HandleError(c, http.StatusForbidden, "API keys cannot access this resource", nil)
+		c.Abort()
+		return
+	}
+	for _, scope := range scopes {
+		if !apiKey.HasScope(scope) {
+			helpers.HandleError(c, http.StatusForbidden, "API key is missing the "+scope+" scope", nil)
+			c.Abort()
+			return
+		}
+	}
+
+	c.Set("user", user)
+	c.Set("apiKey", apiKey)
+	c.Next()
+}
@@ -0,0 +1,31 @@
+package middlewares
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/unbot2313/go-streaming-service/internal/helpers"
+	"github.com/unbot2313/go-streaming-service/internal/models"
+)
+
+// RequireRole deja pasar solo a los usuarios con alguno de los roles.
+// Va después de AuthMiddleware, que deja el usuario del token en el contexto.
+func RequireRole(roles ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user, exists := c.Get("user")
+		if !exists {
+			helpers.HandleError(c, http.StatusUnauthorized, "Unauthorized", nil)
+			c.Abort()
+			return
+		}
+
+		authenticatedUser, ok := user.(*models.User)
+		if !ok || !authenticatedUser.HasRole(roles...) {
+			helpers.HandleError(c, http.StatusForbidden, "You do not have permission to access this resource", nil)
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
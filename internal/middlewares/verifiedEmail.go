@@ -0,0 +1,37 @@
+package middlewares
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/unbot2313/go-streaming-service/internal/helpers"
+	"github.com/unbot2313/go-streaming-service/internal/models"
+	"github.com/unbot2313/go-streaming-service/internal/services"
+)
+
+// RequireVerifiedEmail deja pasar solo a los usuarios que confirmaron su email.
+// Va después de AuthMiddleware; consulta la DB porque el access token no sabe si el email se verificó.
+func RequireVerifiedEmail(accountEmailService services.AccountEmailService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user, exists := c.Get("user")
+		if !exists {
+			helpers.HandleError(c, http.StatusUnauthorized, "Unauthorized", nil)
+			c.Abort()
+			return
+		}
+
+		verified, err := accountEmailService.IsEmailVerified(user.(*models.User).Id)
+		if err != nil {
+			helpers.HandleError(c, http.StatusInternalServerError, "Could not verify email status", err)
+			c.Abort()
+			return
+		}
+		if !verified {
+			helpers.HandleError(c, http.StatusForbidden, "Verify your email before uploading videos", nil)
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
@@ -0,0 +1,31 @@
+package mocks
+
+import "github.com/unbot2313/go-streaming-service/internal/models"
+
+type MockAccountEmailService struct {
+	SendVerificationEmailFn func(userId string) error
+	VerifyEmailFn           func(token string) (*models.User, error)
+	RequestPasswordResetFn  func(email string) error
+	ResetPasswordFn         func(token, newPassword string) error
+	IsEmailVerifiedFn       func(userId string) (bool, error)
+}
+
+func (m *MockAccountEmailService) SendVerificationEmail(userId string) error {
+	return m.SendVerificationEmailFn(userId)
+}
+
+func (m *MockAccountEmailService) VerifyEmail(token string) (*models.User, error) {
+	return m.VerifyEmailFn(token)
+}
+
+func (m *MockAccountEmailService) RequestPasswordReset(email string) error {
+	return m.RequestPasswordResetFn(email)
+}
+
+func (m *MockAccountEmailService) ResetPassword(token, newPassword string) error {
+	return m.ResetPasswordFn(token, newPassword)
+}
+
+func (m *MockAccountEmailService) IsEmailVerified(userId string) (bool, error) {
+	return m.IsEmailVerifiedFn(userId)
+}
@@ -0,0 +1,30 @@
+package mocks
+
+import (
+	"time"
+
+	"github.com/unbot2313/go-streaming-service/internal/models"
+)
+
+type MockAPIKeyService struct {
+	CreateAPIKeyFn func(userId, name string, scopes []string, expiresAt time.Time) (*models.APIKey, error)
+	ListAPIKeysFn  func(userId string) ([]models.APIKey, error)
+	DeleteAPIKeyFn func(userId, keyId string) error
+	AuthenticateFn func(rawKey string) (*models.User, *models.APIKey, error)
+}
+
+func (m *MockAPIKeyService) CreateAPIKey(userId, name string, scopes []string, expiresAt time.Time) (*models.APIKey, error) {
+	return m.CreateAPIKeyFn(userId, name, scopes, expiresAt)
+}
+
+func (m *MockAPIKeyService) ListAPIKeys(userId string) ([]models.APIKey, error) {
+	return m.ListAPIKeysFn(userId)
+}
+
+func (m *MockAPIKeyService) DeleteAPIKey(userId, keyId string) error {
+	return m.DeleteAPIKeyFn(userId, keyId)
+}
+
+func (m *MockAPIKeyService) Authenticate(rawKey string) (*models.User, *models.APIKey, error) {
+	return m.AuthenticateFn(rawKey)
+}
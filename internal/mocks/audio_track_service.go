@@ -0,0 +1,36 @@
+package mocks
+
+import (
+	"context"
+
+	"github.com/unbot2313/go-streaming-service/internal/models"
+	"github.com/unbot2313/go-streaming-service/internal/services"
+)
+
+type MockAudioTrackService struct {
+	ListAudioTracksFn    func(videoId string) ([]models.AudioTrack, error)
+	FindAudioTrackByIDFn func(trackId string) (*models.AudioTrack, error)
+	SaveEmbeddedTracksFn func(ctx context.Context, video *models.VideoModel, tracks []models.AudioTrack) error
+	AddDubFn             func(ctx context.Context, video *models.VideoModel, dub services.DubUpload) (*models.AudioTrack, error)
+	DeleteDubFn          func(ctx context.Context, video *models.VideoModel, track *models.AudioTrack) error
+}
+
+func (m *MockAudioTrackService) ListAudioTracks(videoId string) ([]models.AudioTrack, error) {
+	return m.ListAudioTracksFn(videoId)
+}
+
+func (m *MockAudioTrackService) FindAudioTrackByID(trackId string) (*models.AudioTrack, error) {
+	return m.FindAudioTrackByIDFn(trackId)
+}
+
+func (m *MockAudioTrackService) SaveEmbeddedTracks(ctx context.Context, video *models.VideoModel, tracks []models.AudioTrack) error {
+	return m.SaveEmbeddedTracksFn(ctx, video, tracks)
+}
+
+func (m *MockAudioTrackService) AddDub(ctx context.Context, video *models.VideoModel, dub services.DubUpload) (*models.AudioTrack, error) {
+	return m.AddDubFn(ctx, video, dub)
+}
+
+func (m *MockAudioTrackService) DeleteDub(ctx context.Context, video *models.VideoModel, track *models.AudioTrack) error {
+	return m.DeleteDubFn(ctx, video, track)
+}
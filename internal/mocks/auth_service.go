@@ -7,43 +7,68 @@ import (
 
 type MockAuthService struct {
 	GenerateTokenFn        func(user *models.User) (string, error)
-	ValidateTokenFn        func(token string) (*models.User, error)
-	LoginFn                func(username, password string) (*services.TokenPair, error)
-	GenerateRefreshTokenFn func(user *models.User) (string, error)
-	ValidateRefreshTokenFn func(tokenString string) (*models.User, error)
-	SaveRefreshTokenFn     func(userId, refreshToken string) error
-	ClearRefreshTokenFn    func(userId string) error
-	RefreshTokensFn        func(refreshToken string) (*services.TokenPair, error)
+	ValidateTokenFn        func(token string) (*models.User, *models.AuthClaims, error)
+	LoginFn                func(username, password string, device services.SessionDevice) (*services.TokenPair, error)
+	CreateSessionFn        func(user *models.User, device services.SessionDevice) (*services.TokenPair, error)
+	GenerateRefreshTokenFn func(user *models.User, sessionId string) (string, error)
+	ValidateRefreshTokenFn func(tokenString string) (*models.User, *models.Session, error)
+	RefreshTokensFn        func(refreshToken string, device services.SessionDevice) (*services.TokenPair, error)
+	ListSessionsFn         func(userId string) ([]models.Session, error)
+	RevokeSessionFn        func(userId, sessionId string) error
+	RevokeUserSessionsFn   func(userId string) error
+	IsTokenRevokedFn       func(user *models.User, claims *models.AuthClaims) (bool, error)
+	RevokeAccessTokenFn    func(user *models.User, claims *models.AuthClaims) error
+	PublicKeysFn           func() (*services.JWKSet, error)
 }
 
 func (m *MockAuthService) GenerateToken(user *models.User) (string, error) {
 	return m.GenerateTokenFn(user)
 }
 
-func (m *MockAuthService) ValidateToken(token string) (*models.User, error) {
+func (m *MockAuthService) ValidateToken(token string) (*models.User, *models.AuthClaims, error) {
 	return m.ValidateTokenFn(token)
 }
 
-func (m *MockAuthService) Login(username, password string) (*services.TokenPair, error) {
-	return m.LoginFn(username, password)
+func (m *MockAuthService) Login(username, password string, device services.SessionDevice) (*services.TokenPair, error) {
+	return m.LoginFn(username, password, device)
 }
 
-func (m *MockAuthService) GenerateRefreshToken(user *models.User) (string, error) {
-	return m.GenerateRefreshTokenFn(user)
+func (m *MockAuthService) CreateSession(user *models.User, device services.SessionDevice) (*services.TokenPair, error) {
+	return m.CreateSessionFn(user, device)
 }
 
-func (m *MockAuthService) ValidateRefreshToken(tokenString string) (*models.User, error) {
+func (m *MockAuthService) GenerateRefreshToken(user *models.User, sessionId string) (string, error) {
+	return m.GenerateRefreshTokenFn(user, sessionId)
+}
+
+func (m *MockAuthService) ValidateRefreshToken(tokenString string) (*models.User, *models.Session, error) {
 	return m.ValidateRefreshTokenFn(tokenString)
 }
 
-func (m *MockAuthService) SaveRefreshToken(userId, refreshToken string) error {
-	return m.SaveRefreshTokenFn(userId, refreshToken)
+func (m *MockAuthService) RefreshTokens(refreshToken string, device services.SessionDevice) (*services.TokenPair, error) {
+	return m.RefreshTokensFn(refreshToken, device)
+}
+
+func (m *MockAuthService) ListSessions(userId string) ([]models.Session, error) {
+	return m.ListSessionsFn(userId)
+}
+
+func (m *MockAuthService) RevokeSession(userId, sessionId string) error {
+	return m.RevokeSessionFn(userId, sessionId)
+}
+
+func (m *MockAuthService) RevokeUserSessions(userId string) error {
+	return m.RevokeUserSessionsFn(userId)
+}
+
+func (m *MockAuthService) IsTokenRevoked(user *models.User, claims *models.AuthClaims) (bool, error) {
+	return m.IsTokenRevokedFn(user, claims)
 }
 
-func (m *MockAuthService) ClearRefreshToken(userId string) error {
-	return m.ClearRefreshTokenFn(userId)
+func (m *MockAuthService) RevokeAccessToken(user *models.User, claims *models.AuthClaims) error {
+	return m.RevokeAccessTokenFn(user, claims)
 }
 
-func (m *MockAuthService) RefreshTokens(refreshToken string) (*services.TokenPair, error) {
-	return m.RefreshTokensFn(refreshToken)
+func (m *MockAuthService) PublicKeys() (*services.JWKSet, error) {
+	return m.PublicKeysFn()
 }
@@ -0,0 +1,45 @@
+package mocks
+
+import (
+	"context"
+
+	"github.com/unbot2313/go-streaming-service/internal/models"
+)
+
+type MockChapterService struct {
+	ListChaptersFn     func(videoId string) ([]models.Chapter, error)
+	FindChapterByIDFn  func(chapterId string) (*models.Chapter, error)
+	CreateChapterFn    func(chapter *models.Chapter) (*models.Chapter, error)
+	UpdateChapterFn    func(chapter *models.Chapter) (*models.Chapter, error)
+	DeleteChapterFn    func(chapterId string) error
+	ChaptersVTTFn      func(video *models.VideoModel) ([]byte, error)
+	ChaptersPlaylistFn func(ctx context.Context, video *models.VideoModel) ([]byte, error)
+}
+
+func (m *MockChapterService) ListChapters(videoId string) ([]models.Chapter, error) {
+	return m.ListChaptersFn(videoId)
+}
+
+func (m *MockChapterService) FindChapterByID(chapterId string) (*models.Chapter, error) {
+	return m.FindChapterByIDFn(chapterId)
+}
+
+func (m *MockChapterService) CreateChapter(chapter *models.Chapter) (*models.Chapter, error) {
+	return m.CreateChapterFn(chapter)
+}
+
+func (m *MockChapterService) UpdateChapter(chapter *models.Chapter) (*models.Chapter, error) {
+	return m.UpdateChapterFn(chapter)
+}
+
+func (m *MockChapterService) DeleteChapter(chapterId string) error {
+	return m.DeleteChapterFn(chapterId)
+}
+
+func (m *MockChapterService) ChaptersVTT(video *models.VideoModel) ([]byte, error) {
+	return m.ChaptersVTTFn(video)
+}
+
+func (m *MockChapterService) ChaptersPlaylist(ctx context.Context, video *models.VideoModel) ([]byte, error) {
+	return m.ChaptersPlaylistFn(ctx, video)
+}
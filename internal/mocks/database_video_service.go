@@ -14,6 +14,7 @@ type MockDatabaseVideoService struct {
 	UpdateVideoFn      func(video *models.VideoModel) (*models.VideoModel, error)
 	DeleteVideoFn      func(videoId string) error
 	SearchVideosFn     func(query string, page, pageSize int) (*services.PaginatedVideos, error)
+	PublishDueVideosFn func(limit int) ([]models.VideoModel, error)
 }
 
 func (m *MockDatabaseVideoService) FindLatestVideos(page, pageSize int) (*services.PaginatedVideos, error) {
@@ -47,3 +48,7 @@ func (m *MockDatabaseVideoService) DeleteVideo(videoId string) error {
 func (m *MockDatabaseVideoService) SearchVideos(query string, page, pageSize int) (*services.PaginatedVideos, error) {
 	return m.SearchVideosFn(query, page, pageSize)
 }
+
+func (m *MockDatabaseVideoService) PublishDueVideos(limit int) ([]models.VideoModel, error) {
+	return m.PublishDueVideosFn(limit)
+}
@@ -0,0 +1,23 @@
+package mocks
+
+import (
+	"github.com/unbot2313/go-streaming-service/internal/models"
+)
+
+type MockEncodingProfileService struct {
+	ListProfilesFn func() ([]models.EncodingProfile, error)
+	GetProfileFn   func(name string) (*models.EncodingProfile, error)
+	SaveProfileFn  func(profile *models.EncodingProfile) (*models.EncodingProfile, error)
+}
+
+func (m *MockEncodingProfileService) ListProfiles() ([]models.EncodingProfile, error) {
+	return m.ListProfilesFn()
+}
+
+func (m *MockEncodingProfileService) GetProfile(name string) (*models.EncodingProfile, error) {
+	return m.GetProfileFn(name)
+}
+
+func (m *MockEncodingProfileService) SaveProfile(profile *models.EncodingProfile) (*models.EncodingProfile, error) {
+	return m.SaveProfileFn(profile)
+}
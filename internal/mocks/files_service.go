@@ -0,0 +1,24 @@
+package mocks
+
+type MockFilesService struct {
+	EnsureDirFn    func(dirName string) error
+	CreateFolderFn func(path string) error
+	RemoveFolderFn func(folder string) error
+	RemoveFileFn   func(filePath string) error
+}
+
+func (m *MockFilesService) EnsureDir(dirName string) error {
+	return m.EnsureDirFn(dirName)
+}
+
+func (m *MockFilesService) CreateFolder(path string) error {
+	return m.CreateFolderFn(path)
+}
+
+func (m *MockFilesService) RemoveFolder(folder string) error {
+	return m.RemoveFolderFn(folder)
+}
+
+func (m *MockFilesService) RemoveFile(filePath string) error {
+	return m.RemoveFileFn(filePath)
+}
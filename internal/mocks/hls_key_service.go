@@ -0,0 +1,25 @@
+package mocks
+
+import (
+	"context"
+
+	"github.com/unbot2313/go-streaming-service/internal/services"
+)
+
+type MockHLSKeyService struct {
+	EncryptionFn    func(videoId string) (*services.HLSEncryption, error)
+	GetKeyFn        func(videoId, keyId string) ([]byte, error)
+	PrepareSourceFn func(ctx context.Context, videoId, sourceURL string) (string, func(), error)
+}
+
+func (m *MockHLSKeyService) Encryption(videoId string) (*services.HLSEncryption, error) {
+	return m.EncryptionFn(videoId)
+}
+
+func (m *MockHLSKeyService) GetKey(videoId, keyId string) ([]byte, error) {
+	return m.GetKeyFn(videoId, keyId)
+}
+
+func (m *MockHLSKeyService) PrepareSource(ctx context.Context, videoId, sourceURL string) (string, func(), error) {
+	return m.PrepareSourceFn(ctx, videoId, sourceURL)
+}
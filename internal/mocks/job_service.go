@@ -5,16 +5,28 @@ import (
 )
 
 type MockJobService struct {
-	CreateJobFn         func(job *models.Job) (*models.JobModel, error)
-	GetJobByIDFn        func(jobId string) (*models.JobModel, error)
-	UpdateJobStatusFn   func(jobId, status, errorMsg string) error
-	UpdateJobCompletedFn func(jobId, videoID string) error
+	CreateJobFn               func(job *models.Job) (*models.JobModel, error)
+	CreateJobWithTaskFn       func(job *models.Job, task []byte) (*models.JobModel, error)
+	GetJobByIDFn              func(jobId string) (*models.JobModel, error)
+	UpdateJobStatusFn         func(jobId, status, errorMsg string) error
+	UpdateJobCompletedFn      func(jobId, videoID string) error
+	ClaimJobFn                func(jobId, workerId string) (bool, error)
+	UpdateJobStageFn          func(jobId, stage, m3u8FileURL string) error
+	UpdateJobLoudnessFn       func(jobId string, loudness models.Loudness) error
+	UpdateJobAudioTracksFn    func(jobId string, tracks []models.AudioTrack) error
+	FindJobByIdempotencyKeyFn func(userId, idempotencyKey string) (*models.JobModel, error)
+	SetJobPriorityFn          func(jobId string, priority int) (*models.JobModel, error)
+	CountPendingByPriorityFn  func() (map[int]int, error)
 }
 
 func (m *MockJobService) CreateJob(job *models.Job) (*models.JobModel, error) {
 	return m.CreateJobFn(job)
 }
 
+func (m *MockJobService) CreateJobWithTask(job *models.Job, task []byte) (*models.JobModel, error) {
+	return m.CreateJobWithTaskFn(job, task)
+}
+
 func (m *MockJobService) GetJobByID(jobId string) (*models.JobModel, error) {
 	return m.GetJobByIDFn(jobId)
 }
@@ -26,3 +38,31 @@ func (m *MockJobService) UpdateJobStatus(jobId, status, errorMsg string) error {
 func (m *MockJobService) UpdateJobCompleted(jobId, videoID string) error {
 	return m.UpdateJobCompletedFn(jobId, videoID)
 }
+
+func (m *MockJobService) ClaimJob(jobId, workerId string) (bool, error) {
+	return m.ClaimJobFn(jobId, workerId)
+}
+
+func (m *MockJobService) UpdateJobStage(jobId, stage, m3u8FileURL string) error {
+	return m.UpdateJobStageFn(jobId, stage, m3u8FileURL)
+}
+
+func (m *MockJobService) UpdateJobLoudness(jobId string, loudness models.Loudness) error {
+	return m.UpdateJobLoudnessFn(jobId, loudness)
+}
+
+func (m *MockJobService) UpdateJobAudioTracks(jobId string, tracks []models.AudioTrack) error {
+	return m.UpdateJobAudioTracksFn(jobId, tracks)
+}
+
+func (m *MockJobService) FindJobByIdempotencyKey(userId, idempotencyKey string) (*models.JobModel, error) {
+	return m.FindJobByIdempotencyKeyFn(userId, idempotencyKey)
+}
+
+func (m *MockJobService) SetJobPriority(jobId string, priority int) (*models.JobModel, error) {
+	return m.SetJobPriorityFn(jobId, priority)
+}
+
+func (m *MockJobService) CountPendingByPriority() (map[int]int, error) {
+	return m.CountPendingByPriorityFn()
+}
@@ -0,0 +1,39 @@
+package mocks
+
+import (
+	"github.com/unbot2313/go-streaming-service/internal/models"
+	"github.com/unbot2313/go-streaming-service/internal/services"
+)
+
+type MockModerationService struct {
+	ListUsersFn         func(page, pageSize int) (*services.PaginatedUsers, error)
+	SetUserSuspendedFn  func(userId string, suspended bool) (*models.User, error)
+	SetUserRoleFn       func(userId, role string) (*models.User, error)
+	ListVideosFn        func(page, pageSize int) (*services.PaginatedVideos, error)
+	SetVideoSuspendedFn func(videoId string, suspended bool) (*models.VideoModel, error)
+	BootstrapAdminFn    func(username string) (*models.User, error)
+}
+
+func (m *MockModerationService) ListUsers(page, pageSize int) (*services.PaginatedUsers, error) {
+	return m.ListUsersFn(page, pageSize)
+}
+
+func (m *MockModerationService) SetUserSuspended(userId string, suspended bool) (*models.User, error) {
+	return m.SetUserSuspendedFn(userId, suspended)
+}
+
+func (m *MockModerationService) SetUserRole(userId, role string) (*models.User, error) {
+	return m.SetUserRoleFn(userId, role)
+}
+
+func (m *MockModerationService) ListVideos(page, pageSize int) (*services.PaginatedVideos, error) {
+	return m.ListVideosFn(page, pageSize)
+}
+
+func (m *MockModerationService) SetVideoSuspended(videoId string, suspended bool) (*models.VideoModel, error) {
+	return m.SetVideoSuspendedFn(videoId, suspended)
+}
+
+func (m *MockModerationService) BootstrapAdmin(username string) (*models.User, error) {
+	return m.BootstrapAdminFn(username)
+}
@@ -0,0 +1,9 @@
+package mocks
+
+type MockPodcastService struct {
+	BuildFeedFn func(username, feedURL string) ([]byte, error)
+}
+
+func (m *MockPodcastService) BuildFeed(username, feedURL string) ([]byte, error) {
+	return m.BuildFeedFn(username, feedURL)
+}
@@ -0,0 +1,13 @@
+package mocks
+
+import (
+	"github.com/unbot2313/go-streaming-service/internal/models"
+)
+
+type MockQueueStatsService struct {
+	ListQueuesFn func() ([]models.QueueStats, error)
+}
+
+func (m *MockQueueStatsService) ListQueues() ([]models.QueueStats, error) {
+	return m.ListQueuesFn()
+}
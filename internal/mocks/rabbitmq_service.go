@@ -1,14 +1,18 @@
 package mocks
 
 import (
+	"context"
+
 	"github.com/unbot2313/go-streaming-service/internal/services"
 )
 
 type MockRabbitMQService struct {
-	ConnectFn func() error
-	CloseFn   func()
-	PublishFn func(queueName string, message []byte) error
-	ConsumeFn func(queueName string, handler services.MessageHandler) error
+	ConnectFn  func() error
+	CloseFn    func()
+	PublishFn  func(queueName string, message []byte, props services.MessageProperties) error
+	ConsumeFn  func(queueName string, concurrency int, handler services.MessageHandler) error
+	ShutdownFn func(ctx context.Context) error
+	InspectFn  func(queueName string) (*services.QueueInfo, error)
 }
 
 func (m *MockRabbitMQService) Connect() error {
@@ -19,10 +23,18 @@ func (m *MockRabbitMQService) Close() {
 	m.CloseFn()
 }
 
-func (m *MockRabbitMQService) Publish(queueName string, message []byte) error {
-	return m.PublishFn(queueName, message)
+func (m *MockRabbitMQService) Publish(queueName string, message []byte, props services.MessageProperties) error {
+	return m.PublishFn(queueName, message, props)
+}
+
+func (m *MockRabbitMQService) Consume(queueName string, concurrency int, handler services.MessageHandler) error {
+	return m.ConsumeFn(queueName, concurrency, handler)
+}
+
+func (m *MockRabbitMQService) Shutdown(ctx context.Context) error {
+	return m.ShutdownFn(ctx)
 }
 
-func (m *MockRabbitMQService) Consume(queueName string, handler services.MessageHandler) error {
-	return m.ConsumeFn(queueName, handler)
+func (m *MockRabbitMQService) Inspect(queueName string) (*services.QueueInfo, error) {
+	return m.InspectFn(queueName)
 }
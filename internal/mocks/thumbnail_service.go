@@ -0,0 +1,26 @@
+package mocks
+
+import (
+	"context"
+	"io"
+
+	"github.com/unbot2313/go-streaming-service/internal/models"
+)
+
+type MockThumbnailService struct {
+	RequestThumbnailFn func(video *models.VideoModel, at float64) error
+	UploadThumbnailFn  func(ctx context.Context, video *models.VideoModel, image io.Reader) (*models.VideoModel, error)
+	ProcessTaskFn      func(ctx context.Context, task models.ThumbnailTask) error
+}
+
+func (m *MockThumbnailService) RequestThumbnail(video *models.VideoModel, at float64) error {
+	return m.RequestThumbnailFn(video, at)
+}
+
+func (m *MockThumbnailService) UploadThumbnail(ctx context.Context, video *models.VideoModel, image io.Reader) (*models.VideoModel, error) {
+	return m.UploadThumbnailFn(ctx, video, image)
+}
+
+func (m *MockThumbnailService) ProcessTask(ctx context.Context, task models.ThumbnailTask) error {
+	return m.ProcessTaskFn(ctx, task)
+}
@@ -11,10 +11,11 @@ import (
 
 type MockVideoService struct {
 	SaveVideoFn             func(ctx context.Context, c *gin.Context) (*models.Video, error)
-	FormatVideoFn           func(ctx context.Context, videoName string) (string, error)
+	FormatVideoFn           func(ctx context.Context, videoName string, options services.FormatOptions) (*services.FormatResult, error)
+	NewClipFn               func(source *models.VideoModel, start, end float64) *models.Video
+	CutClipFn               func(ctx context.Context, sourceURL, outputPath string, start, end float64, accurate bool) error
 	UploadFolderFn          func(ctx context.Context, folder string) (storage.UploadResult, error)
 	DeleteFolderFn          func(ctx context.Context, folderName string) error
-	GenerateThumbnailFn     func(ctx context.Context, videoPath, outputDir string) (string, error)
 	GetFilesServiceFn       func() services.FilesService
 	IsValidVideoExtensionFn func(c *gin.Context) bool
 }
@@ -23,8 +24,16 @@ func (m *MockVideoService) SaveVideo(ctx context.Context, c *gin.Context) (*mode
 	return m.SaveVideoFn(ctx, c)
 }
 
-func (m *MockVideoService) FormatVideo(ctx context.Context, videoName string) (string, error) {
-	return m.FormatVideoFn(ctx, videoName)
+func (m *MockVideoService) FormatVideo(ctx context.Context, videoName string, options services.FormatOptions) (*services.FormatResult, error) {
+	return m.FormatVideoFn(ctx, videoName, options)
+}
+
+func (m *MockVideoService) NewClip(source *models.VideoModel, start, end float64) *models.Video {
+	return m.NewClipFn(source, start, end)
+}
+
+func (m *MockVideoService) CutClip(ctx context.Context, sourceURL, outputPath string, start, end float64, accurate bool) error {
+	return m.CutClipFn(ctx, sourceURL, outputPath, start, end, accurate)
 }
 
 func (m *MockVideoService) UploadFolder(ctx context.Context, folder string) (storage.UploadResult, error) {
@@ -35,10 +44,6 @@ func (m *MockVideoService) DeleteFolder(ctx context.Context, folderName string)
 	return m.DeleteFolderFn(ctx, folderName)
 }
 
-func (m *MockVideoService) GenerateThumbnail(ctx context.Context, videoPath, outputDir string) (string, error) {
-	return m.GenerateThumbnailFn(ctx, videoPath, outputDir)
-}
-
 func (m *MockVideoService) GetFilesService() services.FilesService {
 	return m.GetFilesServiceFn()
 }
@@ -0,0 +1,35 @@
+package mocks
+
+import (
+	"context"
+
+	"github.com/unbot2313/go-streaming-service/internal/models"
+)
+
+type MockVideoVersionService struct {
+	ReplaceSourceFn   func(videoId, folder, m3u8FileURL, duration string, loudness models.Loudness) error
+	ListVersionsFn    func(videoId string) ([]models.VideoVersion, error)
+	FindVersionByIDFn func(versionId string) (*models.VideoVersion, error)
+	RollbackFn        func(version *models.VideoVersion) (*models.VideoModel, error)
+	PurgeExpiredFn    func(ctx context.Context, batchSize int) (int, error)
+}
+
+func (m *MockVideoVersionService) ReplaceSource(videoId, folder, m3u8FileURL, duration string, loudness models.Loudness) error {
+	return m.ReplaceSourceFn(videoId, folder, m3u8FileURL, duration, loudness)
+}
+
+func (m *MockVideoVersionService) ListVersions(videoId string) ([]models.VideoVersion, error) {
+	return m.ListVersionsFn(videoId)
+}
+
+func (m *MockVideoVersionService) FindVersionByID(versionId string) (*models.VideoVersion, error) {
+	return m.FindVersionByIDFn(versionId)
+}
+
+func (m *MockVideoVersionService) Rollback(version *models.VideoVersion) (*models.VideoModel, error) {
+	return m.RollbackFn(version)
+}
+
+func (m *MockVideoVersionService) PurgeExpired(ctx context.Context, batchSize int) (int, error) {
+	return m.PurgeExpiredFn(ctx, batchSize)
+}
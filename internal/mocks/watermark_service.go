@@ -0,0 +1,26 @@
+package mocks
+
+import (
+	"context"
+	"io"
+
+	"github.com/unbot2313/go-streaming-service/internal/models"
+)
+
+type MockWatermarkService struct {
+	GetWatermarkFn    func(userId string) (*models.Watermark, error)
+	SaveWatermarkFn   func(ctx context.Context, watermark *models.Watermark, image io.Reader) (*models.Watermark, error)
+	DeleteWatermarkFn func(ctx context.Context, userId string) error
+}
+
+func (m *MockWatermarkService) GetWatermark(userId string) (*models.Watermark, error) {
+	return m.GetWatermarkFn(userId)
+}
+
+func (m *MockWatermarkService) SaveWatermark(ctx context.Context, watermark *models.Watermark, image io.Reader) (*models.Watermark, error) {
+	return m.SaveWatermarkFn(ctx, watermark, image)
+}
+
+func (m *MockWatermarkService) DeleteWatermark(ctx context.Context, userId string) error {
+	return m.DeleteWatermarkFn(ctx, userId)
+}
@@ -0,0 +1,50 @@
+package mocks
+
+import (
+	"context"
+
+	"github.com/unbot2313/go-streaming-service/internal/models"
+)
+
+type MockWebhookService struct {
+	CreateSubscriptionFn   func(userId, url string, events []string) (*models.WebhookSubscription, error)
+	ListSubscriptionsFn    func(userId string) ([]models.WebhookSubscription, error)
+	FindSubscriptionByIDFn func(subscriptionId string) (*models.WebhookSubscription, error)
+	DeleteSubscriptionFn   func(subscriptionId string) error
+	ListDeliveriesFn       func(subscriptionId string) ([]models.WebhookDelivery, error)
+	FindDeliveryByIDFn     func(deliveryId string) (*models.WebhookDelivery, error)
+	RedeliverFn            func(delivery *models.WebhookDelivery) (*models.WebhookDelivery, error)
+	DeliverPendingFn       func(ctx context.Context, batchSize int) (int, error)
+}
+
+func (m *MockWebhookService) CreateSubscription(userId, url string, events []string) (*models.WebhookSubscription, error) {
+	return m.CreateSubscriptionFn(userId, url, events)
+}
+
+func (m *MockWebhookService) ListSubscriptions(userId string) ([]models.WebhookSubscription, error) {
+	return m.ListSubscriptionsFn(userId)
+}
+
+func (m *MockWebhookService) FindSubscriptionByID(subscriptionId string) (*models.WebhookSubscription, error) {
+	return m.FindSubscriptionByIDFn(subscriptionId)
+}
+
+func (m *MockWebhookService) DeleteSubscription(subscriptionId string) error {
+	return m.DeleteSubscriptionFn(subscriptionId)
+}
+
+func (m *MockWebhookService) ListDeliveries(subscriptionId string) ([]models.WebhookDelivery, error) {
+	return m.ListDeliveriesFn(subscriptionId)
+}
+
+func (m *MockWebhookService) FindDeliveryByID(deliveryId string) (*models.WebhookDelivery, error) {
+	return m.FindDeliveryByIDFn(deliveryId)
+}
+
+func (m *MockWebhookService) Redeliver(delivery *models.WebhookDelivery) (*models.WebhookDelivery, error) {
+	return m.RedeliverFn(delivery)
+}
+
+func (m *MockWebhookService) DeliverPending(ctx context.Context, batchSize int) (int, error) {
+	return m.DeliverPendingFn(ctx, batchSize)
+}
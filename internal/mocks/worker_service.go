@@ -0,0 +1,29 @@
+package mocks
+
+import (
+	"github.com/unbot2313/go-streaming-service/internal/models"
+	"github.com/unbot2313/go-streaming-service/internal/services"
+)
+
+type MockWorkerService struct {
+	HeartbeatFn     func(worker *models.Worker) error
+	RemoveWorkerFn  func(workerId string) error
+	ListWorkersFn   func() ([]models.Worker, error)
+	ReapStaleJobsFn func() (*services.ReapResult, error)
+}
+
+func (m *MockWorkerService) Heartbeat(worker *models.Worker) error {
+	return m.HeartbeatFn(worker)
+}
+
+func (m *MockWorkerService) RemoveWorker(workerId string) error {
+	return m.RemoveWorkerFn(workerId)
+}
+
+func (m *MockWorkerService) ListWorkers() ([]models.Worker, error) {
+	return m.ListWorkersFn()
+}
+
+func (m *MockWorkerService) ReapStaleJobs() (*services.ReapResult, error) {
+	return m.ReapStaleJobsFn()
+}
@@ -0,0 +1,62 @@
+package models
+
+import "time"
+
+// APIKeyPrefix distingue una API key de un JWT en el header Authorization
+const APIKeyPrefix = "sk_"
+
+// Scopes que se le pueden dar a una API key
+const (
+	APIKeyScopeVideosWrite = "videos:write"
+	APIKeyScopeVideosRead  = "videos:read"
+	APIKeyScopeJobsRead    = "jobs:read"
+)
+
+// APIKey es una credencial de larga duración para automatizaciones (CI, scripts).
+// Solo se guarda el hash SHA-256; la key completa se muestra una única vez al crearla.
+type APIKey struct {
+	Id         string     `json:"id" gorm:"primaryKey;not null;uniqueIndex"`
+	UserID     string     `json:"user_id" gorm:"not null;index"`
+	Name       string     `json:"name" gorm:"type:varchar(100);not null"`
+	Prefix     string     `json:"prefix" gorm:"type:varchar(16);not null"`
+	KeyHash    string     `json:"-" gorm:"type:varchar(64);not null;uniqueIndex"`
+	Scopes     []string   `json:"scopes" gorm:"serializer:json;not null"`
+	ExpiresAt  time.Time  `json:"expires_at" gorm:"not null"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	// Key es la key completa; solo viene en la respuesta de creación
+	Key string `json:"key,omitempty" gorm:"-"`
+}
+
+// TableName especifica el nombre de la tabla
+func (APIKey) TableName() string {
+	return "api_keys"
+}
+
+// HasScope indica si la key tiene el scope
+func (k APIKey) HasScope(scope string) bool {
+	for _, s := range k.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// IsExpired indica si la key ya venció
+func (k APIKey) IsExpired() bool {
+	return time.Now().After(k.ExpiresAt)
+}
+
+// APIKeySwagger es el modelo para documentación Swagger
+type APIKeySwagger struct {
+	Id         string     `json:"id" example:"3f2a1b0c-9d8e-4f7a-b6c5-d4e3f2a1b0c9"`
+	UserID     string     `json:"user_id" example:"550e8400-e29b-41d4-a716-446655440001"`
+	Name       string     `json:"name" example:"GitHub Actions"`
+	Prefix     string     `json:"prefix" example:"sk_Q2hhbmdl"`
+	Scopes     []string   `json:"scopes" example:"videos:write,jobs:read"`
+	ExpiresAt  time.Time  `json:"expires_at"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	Key        string     `json:"key,omitempty" example:"sk_Q2hhbmdlTWU..."`
+}
@@ -0,0 +1,47 @@
+package models
+
+import "time"
+
+const (
+	// AudioTrackSourceEmbedded es una pista de audio que venía en el archivo fuente
+	AudioTrackSourceEmbedded = "embedded"
+	// AudioTrackSourceDub es una pista doblada que el dueño subió aparte
+	AudioTrackSourceDub = "dub"
+)
+
+// AudioTrack es una rendition de audio alternativa del video. Cada una se publica
+// como un #EXT-X-MEDIA:TYPE=AUDIO del master playlist; la de menor posición es la predeterminada.
+type AudioTrack struct {
+	Id       string `json:"id" gorm:"primaryKey;not null;uniqueIndex"`
+	VideoID  string `json:"video_id" gorm:"not null;index"`
+	Language string `json:"language" gorm:"type:varchar(35);not null"`
+	Name     string `json:"name" gorm:"type:varchar(100);not null"`
+	Source   string `json:"source" gorm:"type:varchar(10);not null"`
+	Position int    `json:"position" gorm:"not null;default:0"`
+	// PlaylistURL es el media playlist de la pista, relativo a la carpeta del video o absoluto.
+	// Vacío cuando el audio va muxeado dentro del playlist del video.
+	PlaylistURL string    `json:"playlist_url,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// TableName especifica el nombre de la tabla
+func (AudioTrack) TableName() string {
+	return "audio_tracks"
+}
+
+// IsDub indica si la pista la subió el dueño
+func (t AudioTrack) IsDub() bool {
+	return t.Source == AudioTrackSourceDub
+}
+
+// AudioTrackSwagger es el modelo para documentación Swagger
+type AudioTrackSwagger struct {
+	Id          string    `json:"id" example:"550e8400-e29b-41d4-a716-446655440004"`
+	VideoID     string    `json:"video_id" example:"550e8400-e29b-41d4-a716-446655440000"`
+	Language    string    `json:"language" example:"es"`
+	Name        string    `json:"name" example:"Español"`
+	Source      string    `json:"source" enums:"embedded,dub"`
+	Position    int       `json:"position" example:"1"`
+	PlaylistURL string    `json:"playlist_url,omitempty" example:"https://cdn.example.com/550e8400-e29b-41d4-a716-446655440004/output.m3u8"`
+	CreatedAt   time.Time `json:"created_at"`
+}
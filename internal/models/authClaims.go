@@ -0,0 +1,13 @@
+package models
+
+import "time"
+
+// AuthClaims son los datos del access token con el que se autenticó una petición que no
+// son del usuario. El middleware de auth los guarda en el contexto como "claims", junto a "user".
+type AuthClaims struct {
+	// SessionID es la sesión del token; vacío en los tokens anteriores a las sesiones
+	SessionID string
+	// TokenID y TokenExpiresAt son el jti y el vencimiento del token, para revocarlo
+	TokenID        string
+	TokenExpiresAt time.Time
+}
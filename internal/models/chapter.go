@@ -0,0 +1,29 @@
+package models
+
+import "time"
+
+// Chapter marca el inicio de una sección del video. El capítulo dura hasta el
+// inicio del siguiente; el último, hasta el final del video.
+type Chapter struct {
+	Id           string    `json:"id" gorm:"primaryKey;not null;uniqueIndex"`
+	VideoID      string    `json:"video_id" gorm:"not null;uniqueIndex:idx_chapters_video_start,priority:1"`
+	StartSeconds float64   `json:"start_seconds" gorm:"not null;uniqueIndex:idx_chapters_video_start,priority:2"`
+	Title        string    `json:"title" gorm:"type:varchar(100);not null"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+// TableName especifica el nombre de la tabla
+func (Chapter) TableName() string {
+	return "chapters"
+}
+
+// ChapterSwagger es el modelo para documentación Swagger
+type ChapterSwagger struct {
+	Id           string    `json:"id" example:"550e8400-e29b-41d4-a716-446655440003"`
+	VideoID      string    `json:"video_id" example:"550e8400-e29b-41d4-a716-446655440000"`
+	StartSeconds float64   `json:"start_seconds" example:"95"`
+	Title        string    `json:"title" example:"Intro"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
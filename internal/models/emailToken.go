@@ -0,0 +1,25 @@
+package models
+
+import "time"
+
+// Propósitos de los tokens que se envían por email
+const (
+	EmailTokenVerifyEmail   = "verify_email"
+	EmailTokenPasswordReset = "password_reset"
+)
+
+// EmailToken registra un token enviado por email para que se pueda usar una sola vez.
+// El token en sí es un JWT firmado; acá solo queda su jti.
+type EmailToken struct {
+	Jti       string    `gorm:"primaryKey;type:varchar(36);not null"`
+	UserID    string    `gorm:"not null;index"`
+	Purpose   string    `gorm:"type:varchar(20);not null"`
+	ExpiresAt time.Time `gorm:"not null;index"`
+	UsedAt    *time.Time
+	CreatedAt time.Time
+}
+
+// TableName especifica el nombre de la tabla
+func (EmailToken) TableName() string {
+	return "email_tokens"
+}
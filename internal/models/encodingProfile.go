@@ -0,0 +1,34 @@
+package models
+
+import "time"
+
+// DefaultEncodingProfile es el perfil que se usa cuando el upload no elige uno
+const DefaultEncodingProfile = "default"
+
+// EncodingProfile agrupa las opciones del pipeline de procesamiento. Cada upload
+// elige un perfil por nombre y el video lo conserva para los reprocesos.
+type EncodingProfile struct {
+	Name        string `json:"name" gorm:"primaryKey;type:varchar(50)"`
+	Description string `json:"description" gorm:"type:varchar(255)"`
+	// Preview genera la vista previa animada que se muestra al pasar el mouse por el video
+	Preview bool `json:"preview" gorm:"not null;default:false"`
+	// Loudnorm normaliza el volumen del audio a EBU R128 con dos pasadas de loudnorm
+	Loudnorm  bool      `json:"loudnorm" gorm:"not null;default:false"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// TableName especifica el nombre de la tabla
+func (EncodingProfile) TableName() string {
+	return "encoding_profiles"
+}
+
+// EncodingProfileSwagger es el modelo para documentación Swagger
+type EncodingProfileSwagger struct {
+	Name        string    `json:"name" example:"default"`
+	Description string    `json:"description" example:"Perfil por defecto"`
+	Preview     bool      `json:"preview" example:"true"`
+	Loudnorm    bool      `json:"loudnorm" example:"false"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
@@ -6,17 +6,77 @@ import (
 	"gorm.io/gorm"
 )
 
+// JobStageUploaded indica que las renditions HLS ya se subieron al storage.
+// Si la tarea se vuelve a entregar, el worker no repite ffmpeg ni la subida.
+const JobStageUploaded = "uploaded"
+
 // Job es la estructura base para tareas de procesamiento de video
 type Job struct {
 	Id           string `json:"id" gorm:"primaryKey;not null;uniqueIndex"`
 	VideoID      string `json:"video_id"`
-	UserID       string `json:"user_id" gorm:"not null"`
+	UserID       string `json:"user_id" gorm:"not null;uniqueIndex:idx_jobs_user_idempotency_key,priority:1,where:idempotency_key <> ''"`
 	Status       string `json:"status" gorm:"type:varchar(20);not null;default:'pending'"`
 	LocalPath    string `json:"-" gorm:"not null"`
 	UniqueName   string `json:"-"`
 	Title        string `json:"title" gorm:"type:varchar(100)"`
 	Description  string `json:"description"`
+	Duration     string `json:"-"`
 	ErrorMessage string `json:"error_message,omitempty"`
+	WorkerID     string `json:"worker_id,omitempty" gorm:"index"`
+	Attempts     int    `json:"attempts" gorm:"not null;default:0"`
+	Stage        string `json:"stage,omitempty" gorm:"type:varchar(20)"`
+	M3u8FileURL  string `json:"-"`
+	// Priority es la prioridad en la cola de video (0 a 10, mayor se procesa antes)
+	Priority int `json:"priority" gorm:"not null;default:0"`
+	// PublishAt es la publicación programada que pidió el usuario al subir el video
+	PublishAt *time.Time `json:"publish_at,omitempty"`
+	// ReplaceVideoID es el video cuyo archivo fuente reemplaza este job; vacío si crea un video nuevo
+	ReplaceVideoID string `json:"replace_video_id,omitempty" gorm:"index"`
+	// EncodingProfile es el perfil de codificación elegido; vacío usa DefaultEncodingProfile
+	EncodingProfile string `json:"encoding_profile,omitempty" gorm:"type:varchar(50)"`
+	// MediaType es "video" o "audio" según la extensión del archivo subido
+	MediaType string `json:"media_type,omitempty" gorm:"type:varchar(10)"`
+	// SkipWatermark omite la marca de agua del usuario en este upload
+	SkipWatermark bool `json:"skip_watermark,omitempty" gorm:"not null;default:false"`
+	// Encrypt cifra los segmentos HLS con AES-128
+	Encrypt bool `json:"encrypt,omitempty" gorm:"not null;default:false"`
+	// SourceVideoID es el video del que se recorta este job; vacío si procesa un archivo subido
+	SourceVideoID string `json:"source_video_id,omitempty" gorm:"index"`
+	// ClipStart y ClipEnd son los segundos del video fuente que se recortan
+	ClipStart float64 `json:"clip_start,omitempty"`
+	ClipEnd   float64 `json:"clip_end,omitempty"`
+	// ClipAccurate recodifica el recorte para cortar en el frame exacto en vez de en el keyframe anterior
+	ClipAccurate bool `json:"clip_accurate,omitempty" gorm:"not null;default:false"`
+	// Loudness es la medición de loudnorm del archivo original; se guarda con la
+	// transcodificación para que un reintento que no repite ffmpeg la conserve
+	Loudness Loudness `json:"-" gorm:"embedded;embeddedPrefix:loudness_"`
+	// AudioTracks son las pistas de audio detectadas al transcodificar, por el mismo motivo
+	AudioTracks []AudioTrack `json:"-" gorm:"serializer:json"`
+	// IdempotencyKey es el header Idempotency-Key del upload, único por usuario
+	IdempotencyKey string `json:"-" gorm:"type:varchar(255);uniqueIndex:idx_jobs_user_idempotency_key,priority:2,where:idempotency_key <> ''"`
+}
+
+// Task reconstruye la tarea que se publica en la cola de video para este job
+func (j Job) Task() VideoTask {
+	return VideoTask{
+		JobID:           j.Id,
+		UserID:          j.UserID,
+		LocalPath:       j.LocalPath,
+		UniqueName:      j.UniqueName,
+		Title:           j.Title,
+		Description:     j.Description,
+		Duration:        j.Duration,
+		PublishAt:       j.PublishAt,
+		ReplaceVideoID:  j.ReplaceVideoID,
+		EncodingProfile: j.EncodingProfile,
+		MediaType:       j.MediaType,
+		SkipWatermark:   j.SkipWatermark,
+		Encrypt:         j.Encrypt,
+		SourceVideoID:   j.SourceVideoID,
+		ClipStart:       j.ClipStart,
+		ClipEnd:         j.ClipEnd,
+		ClipAccurate:    j.ClipAccurate,
+	}
 }
 
 // JobModel embebe Job y agrega campos de GORM para la base de datos
@@ -34,23 +94,43 @@ func (JobModel) TableName() string {
 
 // JobSwagger es el modelo para documentación Swagger
 type JobSwagger struct {
-	Id           string `json:"id" example:"550e8400-e29b-41d4-a716-446655440000"`
-	VideoID      string `json:"video_id" example:""`
-	UserID       string `json:"user_id" example:"550e8400-e29b-41d4-a716-446655440001"`
-	Status       string `json:"status" example:"pending" enums:"pending,processing,completed,failed"`
-	Title        string `json:"title" example:"Mi Video"`
-	Description  string `json:"description" example:"Descripcion del video"`
-	ErrorMessage string `json:"error_message,omitempty" example:""`
-	Message      string `json:"message,omitempty" example:"Video en cola de procesamiento"`
+	Id              string `json:"id" example:"550e8400-e29b-41d4-a716-446655440000"`
+	VideoID         string `json:"video_id" example:""`
+	UserID          string `json:"user_id" example:"550e8400-e29b-41d4-a716-446655440001"`
+	Status          string `json:"status" example:"pending" enums:"pending,processing,completed,failed"`
+	Title           string `json:"title" example:"Mi Video"`
+	Description     string `json:"description" example:"Descripcion del video"`
+	ErrorMessage    string `json:"error_message,omitempty" example:""`
+	WorkerID        string `json:"worker_id,omitempty" example:"worker-1-3f2a9c1e"`
+	Attempts        int    `json:"attempts" example:"1"`
+	Stage           string `json:"stage,omitempty" example:"uploaded"`
+	Priority        int    `json:"priority" example:"6"`
+	ReplaceVideoID  string `json:"replace_video_id,omitempty" example:""`
+	EncodingProfile string `json:"encoding_profile,omitempty" example:"default"`
+	MediaType       string `json:"media_type,omitempty" example:"video" enums:"video,audio"`
+	SkipWatermark   bool   `json:"skip_watermark,omitempty" example:"false"`
+	Encrypt         bool   `json:"encrypt,omitempty" example:"false"`
+	SourceVideoID   string `json:"source_video_id,omitempty" example:""`
+	Message         string `json:"message,omitempty" example:"Video en cola de procesamiento"`
 }
 
 // VideoTask es la estructura del mensaje enviado a RabbitMQ
 type VideoTask struct {
-	JobID       string `json:"job_id"`
-	UserID      string `json:"user_id"`
-	LocalPath   string `json:"local_path"`
-	UniqueName  string `json:"unique_name"`
-	Title       string `json:"title"`
-	Description string `json:"description"`
-	Duration    string `json:"duration"`
+	JobID           string     `json:"job_id"`
+	UserID          string     `json:"user_id"`
+	LocalPath       string     `json:"local_path"`
+	UniqueName      string     `json:"unique_name"`
+	Title           string     `json:"title"`
+	Description     string     `json:"description"`
+	Duration        string     `json:"duration"`
+	PublishAt       *time.Time `json:"publish_at,omitempty"`
+	ReplaceVideoID  string     `json:"replace_video_id,omitempty"`
+	EncodingProfile string     `json:"encoding_profile,omitempty"`
+	MediaType       string     `json:"media_type,omitempty"`
+	SkipWatermark   bool       `json:"skip_watermark,omitempty"`
+	Encrypt         bool       `json:"encrypt,omitempty"`
+	SourceVideoID   string     `json:"source_video_id,omitempty"`
+	ClipStart       float64    `json:"clip_start,omitempty"`
+	ClipEnd         float64    `json:"clip_end,omitempty"`
+	ClipAccurate    bool       `json:"clip_accurate,omitempty"`
 }
@@ -0,0 +1,16 @@
+package models
+
+// Loudness es la sonoridad del audio original medida por la primera pasada de
+// loudnorm (EBU R128). Queda vacía si el perfil no normaliza o el archivo no tiene audio.
+type Loudness struct {
+	// Integrated es la sonoridad integrada en LUFS
+	Integrated *float64 `json:"integrated,omitempty"`
+	// TruePeak es el pico real en dBTP
+	TruePeak *float64 `json:"true_peak,omitempty"`
+}
+
+// LoudnessSwagger es el modelo para documentación Swagger
+type LoudnessSwagger struct {
+	Integrated float64 `json:"integrated,omitempty" example:"-23.4"`
+	TruePeak   float64 `json:"true_peak,omitempty" example:"-2.1"`
+}
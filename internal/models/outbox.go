@@ -0,0 +1,31 @@
+package models
+
+import "time"
+
+// Estados posibles de un mensaje del outbox
+const (
+	OutboxStatusPending = "pending"
+	OutboxStatusSent    = "sent"
+)
+
+// OutboxMessage es un mensaje pendiente de publicar en RabbitMQ.
+// Se escribe en la misma transacción que el cambio que lo origina (ej: crear un Job)
+// y el relay del outbox lo publica después, así nunca queda un job sin su mensaje.
+type OutboxMessage struct {
+	Id        string     `json:"id" gorm:"primaryKey;not null;uniqueIndex"`
+	Queue     string     `json:"queue" gorm:"type:varchar(100);not null"`
+	Payload   []byte     `json:"-" gorm:"not null"`
+	Priority  int        `json:"priority" gorm:"not null;default:0"`
+	Status    string     `json:"status" gorm:"type:varchar(20);not null;default:'pending';index"`
+	Attempts  int        `json:"attempts" gorm:"not null;default:0"`
+	LastError string     `json:"last_error,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+	SentAt    *time.Time `json:"sent_at,omitempty"`
+	// LockedUntil es el lease del relay que lo está publicando; vencido, otro relay lo puede tomar
+	LockedUntil *time.Time `json:"-"`
+}
+
+// TableName especifica el nombre de la tabla
+func (OutboxMessage) TableName() string {
+	return "outbox"
+}
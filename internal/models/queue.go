@@ -0,0 +1,16 @@
+package models
+
+// QueuePriorityDepth es la cantidad de mensajes en espera con una prioridad
+type QueuePriorityDepth struct {
+	Priority int `json:"priority" example:"6"`
+	Messages int `json:"messages" example:"3"`
+}
+
+// QueueStats es el estado de una cola de procesamiento
+type QueueStats struct {
+	Queue     string `json:"queue" example:"video_processing"`
+	Messages  int    `json:"messages" example:"12"`
+	Consumers int    `json:"consumers" example:"2"`
+	// Priorities tiene la profundidad por prioridad, de mayor a menor
+	Priorities []QueuePriorityDepth `json:"priorities"`
+}
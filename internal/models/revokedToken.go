@@ -0,0 +1,17 @@
+package models
+
+import "time"
+
+// RevokedToken es un access token revocado antes de vencer (logout o sesión cerrada).
+// La fila se puede borrar cuando pasa ExpiresAt: el token ya no es válido de todos modos.
+type RevokedToken struct {
+	Jti       string    `gorm:"primaryKey;type:varchar(36);not null"`
+	UserID    string    `gorm:"not null;index"`
+	ExpiresAt time.Time `gorm:"not null;index"`
+	CreatedAt time.Time
+}
+
+// TableName especifica el nombre de la tabla
+func (RevokedToken) TableName() string {
+	return "revoked_tokens"
+}
@@ -0,0 +1,38 @@
+package models
+
+import "time"
+
+// Session es un inicio de sesión en un dispositivo. Guarda el hash del refresh token
+// vigente: cada refresh lo rota, y presentar uno ya rotado revoca la sesión entera.
+type Session struct {
+	Id               string `json:"id" gorm:"primaryKey;not null;uniqueIndex"`
+	UserID           string `json:"-" gorm:"not null;index"`
+	DeviceName       string `json:"device_name" gorm:"type:varchar(100)"`
+	UserAgent        string `json:"user_agent" gorm:"type:varchar(255)"`
+	IP               string `json:"ip" gorm:"type:varchar(45)"`
+	RefreshTokenHash string `json:"-" gorm:"type:varchar(64);not null;uniqueIndex"`
+	// AccessTokenID es el jti del último access token emitido; se revoca al cerrar la sesión
+	AccessTokenID string    `json:"-" gorm:"type:varchar(36)"`
+	ExpiresAt     time.Time `json:"expires_at" gorm:"not null;index"`
+	LastUsedAt    time.Time `json:"last_used_at"`
+	CreatedAt     time.Time `json:"created_at"`
+	// Current indica que es la sesión del access token con el que se hizo la petición
+	Current bool `json:"current" gorm:"-"`
+}
+
+// TableName especifica el nombre de la tabla
+func (Session) TableName() string {
+	return "sessions"
+}
+
+// SessionSwagger es el modelo para documentación Swagger
+type SessionSwagger struct {
+	Id         string    `json:"id" example:"550e8400-e29b-41d4-a716-446655440004"`
+	DeviceName string    `json:"device_name" example:"Pixel 8"`
+	UserAgent  string    `json:"user_agent" example:"Mozilla/5.0 (Linux; Android 14)"`
+	IP         string    `json:"ip" example:"203.0.113.7"`
+	ExpiresAt  time.Time `json:"expires_at"`
+	LastUsedAt time.Time `json:"last_used_at"`
+	CreatedAt  time.Time `json:"created_at"`
+	Current    bool      `json:"current"`
+}
@@ -0,0 +1,36 @@
+package models
+
+const (
+	// ThumbnailKindThumbnail genera la miniatura WebP en un segundo dado
+	ThumbnailKindThumbnail = "thumbnail"
+	// ThumbnailKindStoryboard genera el sprite de frames usado al recorrer la barra de progreso
+	ThumbnailKindStoryboard = "storyboard"
+	// ThumbnailKindCandidates genera los frames que el dueño puede elegir como miniatura
+	ThumbnailKindCandidates = "candidates"
+	// ThumbnailKindPreview genera la vista previa animada con fragmentos del video
+	ThumbnailKindPreview = "preview"
+	// ThumbnailKindWaveform genera la imagen de la forma de onda, que reemplaza a la miniatura en los audios
+	ThumbnailKindWaveform = "waveform"
+	// ThumbnailKindAudioFile genera el archivo M4A que se publica en el feed de podcast
+	ThumbnailKindAudioFile = "audio_file"
+)
+
+// ThumbnailTask es el mensaje enviado a la cola de thumbnails.
+// El worker usa el playlist HLS ya publicado como entrada, así no hace falta
+// el archivo original ni volver a transcodificar el video.
+type ThumbnailTask struct {
+	VideoID   string  `json:"video_id"`
+	Folder    string  `json:"folder,omitempty"`
+	Kind      string  `json:"kind"`
+	SourceURL string  `json:"source_url"`
+	Duration  string  `json:"duration"`
+	At        float64 `json:"at,omitempty"`
+	// Requested indica que el dueño pidió el frame; reemplaza también una miniatura subida
+	Requested bool `json:"requested,omitempty"`
+}
+
+// ThumbnailCandidate es un frame generado automáticamente que se puede elegir como miniatura
+type ThumbnailCandidate struct {
+	At  float64 `json:"at" example:"12.5"`
+	URL string  `json:"url" example:"https://cdn.example.com/550e8400-e29b-41d4-a716-446655440000/candidates/candidate-1.webp"`
+}
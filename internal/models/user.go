@@ -6,6 +6,24 @@ import (
 	"gorm.io/gorm"
 )
 
+// Planes de usuario; el plan sube la prioridad de procesamiento de sus videos
+const (
+	UserPlanFree = "free"
+	UserPlanPro  = "pro"
+)
+
+// Roles de usuario; los moderadores gestionan usuarios y videos, los administradores además la plataforma
+const (
+	UserRoleUser      = "user"
+	UserRoleModerator = "moderator"
+	UserRoleAdmin     = "admin"
+)
+
+// IsValidUserRole indica si role es uno de los roles de usuario
+func IsValidUserRole(role string) bool {
+	return role == UserRoleUser || role == UserRoleModerator || role == UserRoleAdmin
+}
+
 // Esto es lo que deberia recibir el controlador al crear
 // un nuevo usuario
 type UserCreate struct {
@@ -18,12 +36,15 @@ type UserCreate struct {
 type UserLogin struct {
 	Username string `json:"username" binding:"required"`
 	Password string `json:"password" binding:"required"`
+	// DeviceName es un nombre opcional para reconocer la sesión en GET /auth/sessions
+	DeviceName string `json:"device_name" binding:"max=100" example:"Pixel 8"`
 }
 
 type UserRegister struct {
 	Username string `json:"username" binding:"required,min=3,max=100"`
 	Password string `json:"password" binding:"required,min=8"`
 	Email    string `json:"email" binding:"required,email"`
+	DeviceName string `json:"device_name" binding:"max=100" example:"Pixel 8"`
 }
 
 // UserSwagger se usa en la documentacion ya que Swaggo no reconoce
@@ -33,7 +54,10 @@ type UserSwagger struct {
 	Username     string    `json:"username" gorm:"type:varchar(100);not null;uniqueIndex"`
 	Password     string    `json:"-" gorm:"not null"`
 	Email        string    `json:"email" gorm:"type:varchar(100);uniqueIndex"`
-	RefreshToken string    `json:"-"`
+	Plan         string    `json:"plan" example:"free" enums:"free,pro"`
+	Role         string    `json:"role" example:"user" enums:"user,moderator,admin"`
+	SuspendedAt  *time.Time `json:"suspended_at,omitempty"`
+	EmailVerifiedAt *time.Time `json:"email_verified_at,omitempty"`
 	Videos []VideoSwagger 	`json:"videos" gorm:"foreignKey:UserID"`
 }
 
@@ -43,9 +67,36 @@ type User struct {
 	Username     string    `json:"username" gorm:"type:varchar(100);not null;uniqueIndex"`
 	Password     string    `json:"-" gorm:"not null"`
 	Email        string    `json:"email" gorm:"type:varchar(100);uniqueIndex"`
-	RefreshToken string    `json:"-"`
+	Plan         string    `json:"plan" gorm:"type:varchar(20);not null;default:'free'"`
+	Role         string    `json:"role" gorm:"type:varchar(20);not null;default:'user'"`
+	// SuspendedAt es cuándo se suspendió la cuenta; un usuario suspendido no puede iniciar sesión
+	SuspendedAt  *time.Time `json:"suspended_at,omitempty"`
+	// EmailVerifiedAt es cuándo el usuario confirmó su email; se borra al cambiar el email
+	EmailVerifiedAt *time.Time `json:"email_verified_at,omitempty"`
 	Videos 		 []VideoModel 	`json:"videos" gorm:"foreignKey:UserID"`
+	// TokenVersion invalida todos los access tokens emitidos antes de cambiar la contraseña o el email
+	TokenVersion int       `json:"-" gorm:"not null;default:0"`
 	CreatedAt    time.Time
 	UpdatedAt    time.Time
 	DeletedAt    gorm.DeletedAt `gorm:"index" swaggertype:"string"`
-}
\ No newline at end of file
+}
+
+// HasRole indica si el usuario tiene alguno de los roles
+func (u User) HasRole(roles ...string) bool {
+	for _, role := range roles {
+		if u.Role == role {
+			return true
+		}
+	}
+	return false
+}
+
+// IsSuspended indica si la cuenta está suspendida
+func (u User) IsSuspended() bool {
+	return u.SuspendedAt != nil
+}
+
+// IsEmailVerified indica si el usuario confirmó su email actual
+func (u User) IsEmailVerified() bool {
+	return u.EmailVerifiedAt != nil
+}
@@ -6,6 +6,13 @@ import (
 	"gorm.io/gorm"
 )
 
+const (
+	// MediaTypeVideo es un upload de video, con renditions HLS de video y audio
+	MediaTypeVideo = "video"
+	// MediaTypeAudio es un upload solo de audio (podcasts), con renditions HLS AAC
+	MediaTypeAudio = "audio"
+)
+
 type Video struct {
 	Id          	string
 	Video       	string
@@ -16,6 +23,12 @@ type Video struct {
 	M3u8FileURL  	string
 	Duration   		string	
 	ThumbnailURL 	string
+	PublishAt		*time.Time
+	EncodingProfile	string
+	MediaType		string
+	Loudness		Loudness
+	SourceVideoID	string
+	Encrypted		bool
 }
 
 
@@ -29,6 +42,20 @@ type VideoSwagger struct {
 	UserID			string		`json:"user_id" gorm:"not null"`
 	Duration   		string	 	`json:"duration"`
 	ThumbnailURL 	string   	`json:"thumbnail"`
+	StoryboardURL	string		`json:"storyboard_url"`
+	PreviewURL		string		`json:"preview_url"`
+	EncodingProfile	string		`json:"encoding_profile"`
+	MediaType		string		`json:"media_type" enums:"video,audio"`
+	AudioFileURL	string		`json:"audio_file_url,omitempty"`
+	Loudness		LoudnessSwagger	`json:"loudness"`
+	SourceVideoID	string		`json:"source_video_id,omitempty"`
+	Encrypted		bool		`json:"encrypted"`
+	CustomThumbnail	bool		`json:"custom_thumbnail"`
+	ThumbnailSizes	map[string]string	`json:"thumbnail_sizes,omitempty"`
+	ThumbnailCandidates	[]ThumbnailCandidate	`json:"thumbnail_candidates,omitempty"`
+	PublishAt		*time.Time	`json:"publish_at,omitempty"`
+	PublishedAt		*time.Time	`json:"published_at,omitempty"`
+	SuspendedAt		*time.Time	`json:"suspended_at,omitempty"`
 	Views 			uint		`json:"views" gorm:"default:0"`
 	Tags			[]Tag		`json:"tags" gorm:"many2many:video_tags;"`
 }
@@ -42,6 +69,37 @@ type VideoModel struct {
 	UserID			string			`json:"user_id" gorm:"not null"`
 	Duration   		string	 		`json:"duration"`
 	ThumbnailURL 	string   		`json:"thumbnail"`
+	StoryboardURL	string			`json:"storyboard_url"`
+	// PreviewURL es la vista previa animada (WebP) que se muestra al pasar el mouse; vacía si el perfil no la genera
+	PreviewURL		string			`json:"preview_url"`
+	// EncodingProfile es el perfil de codificación con el que se procesó el video
+	EncodingProfile	string			`json:"encoding_profile" gorm:"type:varchar(50)"`
+	// MediaType distingue los videos de los uploads solo de audio
+	MediaType		string			`json:"media_type" gorm:"type:varchar(10);not null;default:'video'"`
+	// AudioFileURL es el audio en un solo archivo M4A que se publica como enclosure del feed de podcast
+	AudioFileURL	string			`json:"audio_file_url,omitempty"`
+	// AudioFileSize es el tamaño en bytes de AudioFileURL, requerido por el enclosure RSS
+	AudioFileSize	int64			`json:"-"`
+	// Loudness es la sonoridad medida del audio original cuando el perfil normaliza el volumen
+	Loudness		Loudness		`json:"loudness" gorm:"embedded;embeddedPrefix:loudness_"`
+	// SourceVideoID es el video del que se recortó este clip; vacío si no es un clip
+	SourceVideoID	string			`json:"source_video_id,omitempty" gorm:"index"`
+	// Encrypted indica que los segmentos HLS están cifrados con AES-128; las claves se piden a /streaming/:videoid/key
+	Encrypted		bool			`json:"encrypted" gorm:"not null;default:false"`
+	// CustomThumbnail indica que la miniatura la subió el dueño: las generadas automáticamente no la reemplazan
+	CustomThumbnail	bool			`json:"custom_thumbnail" gorm:"not null;default:false"`
+	// ThumbnailSizes son las URLs de la miniatura subida por tamaño ("1280x720")
+	ThumbnailSizes	map[string]string	`json:"thumbnail_sizes,omitempty" gorm:"serializer:json"`
+	// ThumbnailCandidates son los frames que el dueño puede elegir como miniatura
+	ThumbnailCandidates	[]ThumbnailCandidate	`json:"thumbnail_candidates,omitempty" gorm:"serializer:json"`
+	// PublishAt es la fecha programada de publicación; hasta entonces el video no aparece en los listados
+	PublishAt		*time.Time		`json:"publish_at,omitempty" gorm:"index"`
+	// PublishedAt es cuándo se publicó el video; nil mientras esté programado
+	PublishedAt		*time.Time		`json:"published_at,omitempty" gorm:"index"`
+	// SuspendedAt es cuándo un moderador suspendió el video; suspendido no aparece en los listados ni se reproduce
+	SuspendedAt		*time.Time		`json:"suspended_at,omitempty"`
+	// StorageFolder es la carpeta del storage con las renditions actuales; vacía equivale al id del video
+	StorageFolder	string			`json:"-" gorm:"type:varchar(255)"`
 	Views 			uint			`json:"views" gorm:"default:0"`
 	Tags			[]Tag			`json:"tags" gorm:"many2many:video_tags;"`
 	CreatedAt 		time.Time
@@ -53,3 +111,27 @@ type VideoModel struct {
 func (VideoModel) TableName() string {
     return "videos"
 }
+
+// IsAudio indica si el upload es solo de audio
+func (v VideoModel) IsAudio() bool {
+	return v.MediaType == MediaTypeAudio
+}
+
+// IsPublished indica si el video ya se publicó
+func (v VideoModel) IsPublished() bool {
+	return v.PublishedAt != nil
+}
+
+// IsSuspended indica si un moderador suspendió el video
+func (v VideoModel) IsSuspended() bool {
+	return v.SuspendedAt != nil
+}
+
+// Folder retorna la carpeta del storage con las renditions actuales del video.
+// Cambia cuando se reemplaza el archivo fuente del video.
+func (v VideoModel) Folder() string {
+	if v.StorageFolder != "" {
+		return v.StorageFolder
+	}
+	return v.Id
+}
@@ -0,0 +1,17 @@
+package models
+
+import "time"
+
+// VideoKey es una clave AES-128 de los segmentos HLS de un video. La clave se guarda
+// cifrada con HLS_KEY_ENCRYPTION_KEY; un video con rotación tiene una por cada N segmentos.
+type VideoKey struct {
+	Id           string `gorm:"primaryKey;not null;uniqueIndex"`
+	VideoID      string `gorm:"not null;index"`
+	EncryptedKey []byte `gorm:"not null"`
+	CreatedAt    time.Time
+}
+
+// TableName especifica el nombre de la tabla
+func (VideoKey) TableName() string {
+	return "video_keys"
+}
@@ -0,0 +1,41 @@
+package models
+
+import "time"
+
+// VideoVersion es una versión anterior de las renditions de un video, guardada al
+// reemplazar su archivo fuente. Su carpeta se conserva en el storage hasta ExpiresAt
+// para poder volver a ella; después el worker la borra.
+type VideoVersion struct {
+	Id            string    `json:"id" gorm:"primaryKey;not null;uniqueIndex"`
+	VideoID       string    `json:"video_id" gorm:"not null;index"`
+	StorageFolder string    `json:"-" gorm:"type:varchar(255);not null"`
+	VideoUrl      string    `json:"video" gorm:"not null"`
+	Duration      string    `json:"duration"`
+	ThumbnailURL  string    `json:"thumbnail"`
+	StoryboardURL string    `json:"storyboard_url"`
+	PreviewURL    string    `json:"preview_url"`
+	AudioFileURL  string    `json:"audio_file_url,omitempty"`
+	AudioFileSize int64     `json:"-"`
+	Loudness      Loudness  `json:"-" gorm:"embedded;embeddedPrefix:loudness_"`
+	CreatedAt     time.Time `json:"created_at"`
+	ExpiresAt     time.Time `json:"expires_at" gorm:"index"`
+}
+
+// TableName especifica el nombre de la tabla
+func (VideoVersion) TableName() string {
+	return "video_versions"
+}
+
+// VideoVersionSwagger es el modelo para documentación Swagger
+type VideoVersionSwagger struct {
+	Id            string    `json:"id" example:"550e8400-e29b-41d4-a716-446655440002"`
+	VideoID       string    `json:"video_id" example:"550e8400-e29b-41d4-a716-446655440000"`
+	VideoUrl      string    `json:"video" example:"https://cdn.example.com/550e8400-e29b-41d4-a716-446655440000/index.m3u8"`
+	Duration      string    `json:"duration" example:"00:01:30"`
+	ThumbnailURL  string    `json:"thumbnail" example:"https://cdn.example.com/550e8400-e29b-41d4-a716-446655440000/thumbnail.webp"`
+	StoryboardURL string    `json:"storyboard_url" example:"https://cdn.example.com/550e8400-e29b-41d4-a716-446655440000/storyboard.webp"`
+	PreviewURL    string    `json:"preview_url" example:"https://cdn.example.com/550e8400-e29b-41d4-a716-446655440000/preview.webp"`
+	AudioFileURL  string    `json:"audio_file_url,omitempty" example:""`
+	CreatedAt     time.Time `json:"created_at"`
+	ExpiresAt     time.Time `json:"expires_at"`
+}
@@ -0,0 +1,42 @@
+package models
+
+import "time"
+
+// Posiciones en las que se puede ubicar la marca de agua sobre el video
+const (
+	WatermarkTopLeft     = "top-left"
+	WatermarkTopRight    = "top-right"
+	WatermarkBottomLeft  = "bottom-left"
+	WatermarkBottomRight = "bottom-right"
+	WatermarkCenter      = "center"
+)
+
+// Watermark es el logo PNG de un usuario que se graba sobre las renditions de sus
+// videos al transcodificar. Cada usuario tiene a lo sumo una marca de agua.
+type Watermark struct {
+	UserID   string `json:"user_id" gorm:"primaryKey;not null"`
+	ImageURL string `json:"image_url" gorm:"not null"`
+	Position string `json:"position" gorm:"type:varchar(20);not null;default:'bottom-right'"`
+	// Opacity va de 0 (transparente) a 1 (opaca)
+	Opacity float64 `json:"opacity" gorm:"not null;default:1"`
+	// Scale es el ancho de la marca de agua como fracción del ancho del video
+	Scale     float64   `json:"scale" gorm:"not null;default:0.15"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// TableName especifica el nombre de la tabla
+func (Watermark) TableName() string {
+	return "watermarks"
+}
+
+// WatermarkSwagger es el modelo para documentación Swagger
+type WatermarkSwagger struct {
+	UserID    string    `json:"user_id" example:"550e8400-e29b-41d4-a716-446655440001"`
+	ImageURL  string    `json:"image_url" example:"https://cdn.example.com/watermarks/550e8400-e29b-41d4-a716-446655440001/watermark-1760000000000.png"`
+	Position  string    `json:"position" example:"bottom-right" enums:"top-left,top-right,bottom-left,bottom-right,center"`
+	Opacity   float64   `json:"opacity" example:"0.8"`
+	Scale     float64   `json:"scale" example:"0.15"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
@@ -0,0 +1,117 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Eventos que se pueden suscribir con un webhook
+const (
+	WebhookEventJobProcessing  = "job.processing"
+	WebhookEventJobCompleted   = "job.completed"
+	WebhookEventJobFailed      = "job.failed"
+	WebhookEventVideoDeleted   = "video.deleted"
+	WebhookEventVideoPublished = "video.published"
+)
+
+// WebhookEvents lista todos los eventos soportados
+var WebhookEvents = []string{
+	WebhookEventJobProcessing,
+	WebhookEventJobCompleted,
+	WebhookEventJobFailed,
+	WebhookEventVideoDeleted,
+	WebhookEventVideoPublished,
+}
+
+// Estados posibles de una entrega de webhook
+const (
+	WebhookDeliveryPending   = "pending"
+	WebhookDeliveryDelivered = "delivered"
+	WebhookDeliveryFailed    = "failed"
+)
+
+// WebhookSubscription es un endpoint de un usuario que recibe los eventos suscritos.
+// El Secret firma cada payload con HMAC-SHA256 y solo se muestra al crear la suscripción.
+type WebhookSubscription struct {
+	Id        string         `json:"id" gorm:"primaryKey;not null;uniqueIndex"`
+	UserID    string         `json:"user_id" gorm:"not null;index"`
+	URL       string         `json:"url" gorm:"not null"`
+	Secret    string         `json:"secret,omitempty" gorm:"not null"`
+	Events    []string       `json:"events" gorm:"serializer:json;not null"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`
+}
+
+// TableName especifica el nombre de la tabla
+func (WebhookSubscription) TableName() string {
+	return "webhook_subscriptions"
+}
+
+// Subscribed indica si la suscripción recibe el evento
+func (s WebhookSubscription) Subscribed(event string) bool {
+	for _, e := range s.Events {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
+
+// WebhookDelivery es el registro de un evento enviado (o por enviar) a una suscripción
+type WebhookDelivery struct {
+	Id             string     `json:"id" gorm:"primaryKey;not null;uniqueIndex"`
+	SubscriptionID string     `json:"subscription_id" gorm:"not null;index"`
+	UserID         string     `json:"user_id" gorm:"not null"`
+	EventID        string     `json:"event_id" gorm:"not null"`
+	Event          string     `json:"event" gorm:"type:varchar(50);not null"`
+	Payload        string     `json:"payload" gorm:"type:text;not null"`
+	Status         string     `json:"status" gorm:"type:varchar(20);not null;default:'pending'"`
+	Attempts       int        `json:"attempts" gorm:"not null;default:0"`
+	ResponseStatus int        `json:"response_status,omitempty"`
+	LastError      string     `json:"last_error,omitempty"`
+	NextAttemptAt  time.Time  `json:"next_attempt_at" gorm:"index"`
+	DeliveredAt    *time.Time `json:"delivered_at,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+}
+
+// TableName especifica el nombre de la tabla
+func (WebhookDelivery) TableName() string {
+	return "webhook_deliveries"
+}
+
+// WebhookEvent es el cuerpo JSON que recibe el endpoint suscrito
+type WebhookEvent struct {
+	Id        string      `json:"id"`
+	Event     string      `json:"event"`
+	CreatedAt time.Time   `json:"created_at"`
+	Data      interface{} `json:"data"`
+}
+
+// WebhookSubscriptionSwagger es el modelo para documentación Swagger
+type WebhookSubscriptionSwagger struct {
+	Id        string    `json:"id" example:"7b1e6a8e-2d4c-4a57-9a0b-3f1c2d4e5f60"`
+	UserID    string    `json:"user_id" example:"550e8400-e29b-41d4-a716-446655440001"`
+	URL       string    `json:"url" example:"https://example.com/hooks/streaming"`
+	Secret    string    `json:"secret,omitempty" example:"whsec_5f1c0e..."`
+	Events    []string  `json:"events" example:"job.completed,job.failed"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// WebhookDeliverySwagger es el modelo para documentación Swagger
+type WebhookDeliverySwagger struct {
+	Id             string     `json:"id" example:"0c9e1f7a-6d2b-4e38-8f1a-2b3c4d5e6f70"`
+	SubscriptionID string     `json:"subscription_id" example:"7b1e6a8e-2d4c-4a57-9a0b-3f1c2d4e5f60"`
+	EventID        string     `json:"event_id" example:"a1b2c3d4-e5f6-4a7b-8c9d-0e1f2a3b4c5d"`
+	Event          string     `json:"event" example:"job.completed"`
+	Payload        string     `json:"payload" example:"{\"id\":\"a1b2c3d4-e5f6-4a7b-8c9d-0e1f2a3b4c5d\",\"event\":\"job.completed\",\"data\":{}}"`
+	Status         string     `json:"status" example:"delivered" enums:"pending,delivered,failed"`
+	Attempts       int        `json:"attempts" example:"1"`
+	ResponseStatus int        `json:"response_status,omitempty" example:"200"`
+	LastError      string     `json:"last_error,omitempty" example:""`
+	NextAttemptAt  time.Time  `json:"next_attempt_at"`
+	DeliveredAt    *time.Time `json:"delivered_at,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+}
@@ -0,0 +1,34 @@
+package models
+
+import "time"
+
+// Worker representa una instancia del worker que procesa la cola de video.
+// Cada worker actualiza LastHeartbeatAt periódicamente; si deja de hacerlo,
+// el reaper considera perdidos los jobs que tenía asignados.
+type Worker struct {
+	Id              string    `json:"id" gorm:"primaryKey;not null;uniqueIndex"`
+	Hostname        string    `json:"hostname" gorm:"type:varchar(255);not null"`
+	Queue           string    `json:"queue" gorm:"type:varchar(100)"`
+	Concurrency     int       `json:"concurrency"`
+	CurrentJobs     []string  `json:"current_jobs" gorm:"serializer:json"`
+	StartedAt       time.Time `json:"started_at"`
+	LastHeartbeatAt time.Time `json:"last_heartbeat_at" gorm:"index"`
+	Alive           bool      `json:"alive" gorm:"-"`
+}
+
+// TableName especifica el nombre de la tabla
+func (Worker) TableName() string {
+	return "workers"
+}
+
+// WorkerSwagger es el modelo para documentación Swagger
+type WorkerSwagger struct {
+	Id              string    `json:"id" example:"worker-1-3f2a9c1e"`
+	Hostname        string    `json:"hostname" example:"worker-1"`
+	Queue           string    `json:"queue" example:"video_processing"`
+	Concurrency     int       `json:"concurrency" example:"2"`
+	CurrentJobs     []string  `json:"current_jobs" example:"550e8400-e29b-41d4-a716-446655440000"`
+	StartedAt       time.Time `json:"started_at"`
+	LastHeartbeatAt time.Time `json:"last_heartbeat_at"`
+	Alive           bool      `json:"alive" example:"true"`
+}
@@ -4,30 +4,74 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/unbot2313/go-streaming-service/config"
 	"github.com/unbot2313/go-streaming-service/internal/controllers"
 	"github.com/unbot2313/go-streaming-service/internal/middlewares"
+	"github.com/unbot2313/go-streaming-service/internal/models"
 	"github.com/unbot2313/go-streaming-service/internal/services"
 	"golang.org/x/time/rate"
 )
 
+// Controllers son los controladores que atienden las rutas
+type Controllers struct {
+	User       controllers.UserController
+	Auth       controllers.AuthController
+	Video      controllers.VideoController
+	Job        controllers.JobController
+	Tag        controllers.TagController
+	Admin      controllers.AdminController
+	Webhook    controllers.WebhookController
+	Podcast    controllers.PodcastController
+	Watermark  controllers.WatermarkController
+	Chapter    controllers.ChapterController
+	AudioTrack controllers.AudioTrackController
+	Key        controllers.KeyController
+	Moderation controllers.ModerationController
+	APIKey     controllers.APIKeyController
+}
+
+// Services son los servicios que usan los middlewares de las rutas
+type Services struct {
+	Auth         services.AuthService
+	APIKey       services.APIKeyService
+	AccountEmail services.AccountEmailService
+}
+
 // SetupRoutes configura todas las rutas
-func SetupRoutes(router *gin.RouterGroup, userController controllers.UserController, authController controllers.AuthController, videoController controllers.VideoController, jobController controllers.JobController, tagController controllers.TagController, authService services.AuthService) {
-	// Middleware de autenticación (una sola instancia reutilizada)
-	authMiddleware := middlewares.AuthMiddleware(authService)
+func SetupRoutes(router *gin.RouterGroup, ctl Controllers, svc Services) {
+	// Middleware de autenticación (una sola instancia reutilizada); no acepta API keys
+	authMiddleware := middlewares.AuthMiddleware(svc.Auth, svc.APIKey)
+	// Rutas que también aceptan API keys con el scope correspondiente
+	videosWriteAuth := middlewares.AuthMiddleware(svc.Auth, svc.APIKey, models.APIKeyScopeVideosWrite)
+	videosReadAuth := middlewares.AuthMiddleware(svc.Auth, svc.APIKey, models.APIKeyScopeVideosRead)
+	jobsReadAuth := middlewares.AuthMiddleware(svc.Auth, svc.APIKey, models.APIKeyScopeJobsRead)
+	// Rutas públicas que al dueño le muestran también sus videos programados o suspendidos
+	videosOptionalAuth := middlewares.OptionalAuthMiddleware(svc.Auth, svc.APIKey, models.APIKeyScopeVideosRead)
+	// Con REQUIRE_VERIFIED_EMAIL, subir contenido exige haber confirmado el email
+	uploadGate := func(c *gin.Context) { c.Next() }
+	if config.GetConfig().RequireVerifiedEmail {
+		uploadGate = middlewares.RequireVerifiedEmail(svc.AccountEmail)
+	}
 
 	// Rutas de usuarios
 	userRoutes := router.Group("/users")
 	{
 		// Rutas publicas (lectura)
-		userRoutes.GET("/id/:id", userController.GetUserByID)
-		userRoutes.GET("/username/:username", userController.GetUserByUserName)
+		userRoutes.GET("/id/:id", ctl.User.GetUserByID)
+		userRoutes.GET("/username/:username", ctl.User.GetUserByUserName)
 
 		// Rutas protegidas
 		protectedUserRoutes := userRoutes.Group("")
 		protectedUserRoutes.Use(authMiddleware)
-		protectedUserRoutes.DELETE("/:id", userController.DeleteUserByID)
-		protectedUserRoutes.PATCH("/email", userController.UpdateEmail)
-		protectedUserRoutes.PATCH("/password", userController.UpdatePassword)
+		protectedUserRoutes.DELETE("/:id", ctl.User.DeleteUserByID)
+		protectedUserRoutes.PATCH("/email", ctl.User.UpdateEmail)
+		protectedUserRoutes.PATCH("/password", ctl.User.UpdatePassword)
+		protectedUserRoutes.GET("/watermark", ctl.Watermark.GetWatermark)
+		protectedUserRoutes.PUT("/watermark", ctl.Watermark.SaveWatermark)
+		protectedUserRoutes.DELETE("/watermark", ctl.Watermark.DeleteWatermark)
+		protectedUserRoutes.POST("/me/api-keys", ctl.APIKey.CreateAPIKey)
+		protectedUserRoutes.GET("/me/api-keys", ctl.APIKey.GetAPIKeys)
+		protectedUserRoutes.DELETE("/me/api-keys/:id", ctl.APIKey.DeleteAPIKey)
 	}
 
 	// Rutas de autenticación
@@ -35,51 +79,117 @@ func SetupRoutes(router *gin.RouterGroup, userController controllers.UserControl
 	authLimiter := middlewares.NewRateLimiter(rate.Every(20*time.Second), 3)
 	authRoutes := router.Group("/auth")
 	{
-		authRoutes.POST("/login", authLimiter.Middleware(), authController.Login)
-		authRoutes.POST("/register", authLimiter.Middleware(), authController.Register)
-		authRoutes.POST("/refresh", authLimiter.Middleware(), authController.RefreshToken)
+		authRoutes.POST("/login", authLimiter.Middleware(), ctl.Auth.Login)
+		authRoutes.POST("/register", authLimiter.Middleware(), ctl.Auth.Register)
+		authRoutes.POST("/refresh", authLimiter.Middleware(), ctl.Auth.RefreshToken)
+		authRoutes.POST("/verify-email", authLimiter.Middleware(), ctl.Auth.VerifyEmail)
+		authRoutes.POST("/password-reset/request", authLimiter.Middleware(), ctl.Auth.RequestPasswordReset)
+		authRoutes.POST("/password-reset/confirm", authLimiter.Middleware(), ctl.Auth.ConfirmPasswordReset)
 
-		// Logout requiere estar autenticado
+		// Logout y la gestión de sesiones requieren estar autenticado
 		protectedAuthRoutes := authRoutes.Group("")
 		protectedAuthRoutes.Use(authMiddleware)
-		protectedAuthRoutes.POST("/logout", authController.Logout)
+		protectedAuthRoutes.POST("/logout", ctl.Auth.Logout)
+		protectedAuthRoutes.GET("/sessions", ctl.Auth.GetSessions)
+		protectedAuthRoutes.DELETE("/sessions/:id", ctl.Auth.DeleteSession)
+		protectedAuthRoutes.POST("/verify-email/resend", authLimiter.Middleware(), ctl.Auth.ResendVerificationEmail)
 	}
 
     VideoRoutes := router.Group("/streaming")
     {
 		ProtectedRoute := VideoRoutes.Group("")
-		ProtectedRoute.Use(authMiddleware)
+		ProtectedRoute.Use(videosWriteAuth)
+
+		// Lectura de datos privados de los videos propios
+		ReadRoute := VideoRoutes.Group("")
+		ReadRoute.Use(videosReadAuth)
 
 		// Rutas públicas
-        VideoRoutes.GET("/latest", videoController.GetLatestVideos)
-		VideoRoutes.GET("/search", videoController.SearchVideos)
-		VideoRoutes.GET("/id/:videoid", videoController.GetVideoByID)
-		VideoRoutes.PATCH("/views/:videoid", videoController.IncrementViews)
+        VideoRoutes.GET("/latest", ctl.Video.GetLatestVideos)
+		VideoRoutes.GET("/search", ctl.Video.SearchVideos)
+		VideoRoutes.GET("/id/:videoid", videosOptionalAuth, ctl.Video.GetVideoByID)
+		VideoRoutes.PATCH("/views/:videoid", ctl.Video.IncrementViews)
+		VideoRoutes.GET("/:videoid/chapters", videosOptionalAuth, ctl.Chapter.GetChapters)
+		VideoRoutes.GET("/:videoid/chapters.vtt", videosOptionalAuth, ctl.Chapter.GetChaptersVTT)
+		VideoRoutes.GET("/:videoid/playlist.m3u8", videosOptionalAuth, ctl.Chapter.GetChaptersPlaylist)
+		VideoRoutes.GET("/:videoid/audio-tracks", videosOptionalAuth, ctl.AudioTrack.GetAudioTracks)
 
 		// Rutas protegidas
-        ProtectedRoute.POST("/upload", videoController.CreateVideo)
-		ProtectedRoute.PUT("/:videoid", videoController.UpdateVideo)
-		ProtectedRoute.DELETE("/:videoid", videoController.DeleteVideo)
+        ProtectedRoute.POST("/upload", uploadGate, ctl.Video.CreateVideo)
+		ProtectedRoute.PUT("/:videoid", ctl.Video.UpdateVideo)
+		ProtectedRoute.DELETE("/:videoid", ctl.Video.DeleteVideo)
+		ProtectedRoute.POST("/:videoid/thumbnail", ctl.Video.RegenerateThumbnail)
+		ProtectedRoute.POST("/:videoid/source", uploadGate, ctl.Video.ReplaceSource)
+		ProtectedRoute.POST("/:videoid/clips", uploadGate, ctl.Video.CreateClip)
+		ReadRoute.GET("/:videoid/versions", ctl.Video.GetVideoVersions)
+		ProtectedRoute.POST("/:videoid/versions/:versionid/rollback", ctl.Video.RollbackVideoVersion)
+		ProtectedRoute.POST("/:videoid/chapters", ctl.Chapter.CreateChapter)
+		ProtectedRoute.PUT("/:videoid/chapters/:chapterid", ctl.Chapter.UpdateChapter)
+		ProtectedRoute.DELETE("/:videoid/chapters/:chapterid", ctl.Chapter.DeleteChapter)
+		ProtectedRoute.POST("/:videoid/audio-tracks", uploadGate, ctl.AudioTrack.AddDub)
+		ProtectedRoute.DELETE("/:videoid/audio-tracks/:trackid", ctl.AudioTrack.DeleteDub)
+		ReadRoute.GET("/:videoid/key", ctl.Key.GetKey)
     }
 
 	// Rutas de jobs (protegidas)
 	jobRoutes := router.Group("/jobs")
-	jobRoutes.Use(authMiddleware)
+	jobRoutes.Use(jobsReadAuth)
 	{
-		jobRoutes.GET("/:jobid", jobController.GetJobByID)
+		jobRoutes.GET("/:jobid", ctl.Job.GetJobByID)
 	}
 
 	// Rutas de tags
 	tagRoutes := router.Group("/tags")
 	{
 		// Rutas públicas
-		tagRoutes.GET("", tagController.GetAllTags)
-		tagRoutes.GET("/:tag/videos", tagController.GetVideosByTag)
+		tagRoutes.GET("", ctl.Tag.GetAllTags)
+		tagRoutes.GET("/:tag/videos", ctl.Tag.GetVideosByTag)
 
 		// Rutas protegidas (solo el owner del video puede agregar/quitar tags)
 		protectedTagRoutes := tagRoutes.Group("")
-		protectedTagRoutes.Use(authMiddleware)
-		protectedTagRoutes.POST("/:videoid", tagController.AddTagsToVideo)
-		protectedTagRoutes.DELETE("/:videoid", tagController.RemoveTagFromVideo)
+		protectedTagRoutes.Use(videosWriteAuth)
+		protectedTagRoutes.POST("/:videoid", ctl.Tag.AddTagsToVideo)
+		protectedTagRoutes.DELETE("/:videoid", ctl.Tag.RemoveTagFromVideo)
+	}
+
+	// Rutas de webhooks (protegidas, cada usuario gestiona los suyos)
+	webhookRoutes := router.Group("/webhooks")
+	webhookRoutes.Use(authMiddleware)
+	{
+		webhookRoutes.POST("", ctl.Webhook.CreateWebhook)
+		webhookRoutes.GET("", ctl.Webhook.GetWebhooks)
+		webhookRoutes.DELETE("/:id", ctl.Webhook.DeleteWebhook)
+		webhookRoutes.GET("/:id/deliveries", ctl.Webhook.GetWebhookDeliveries)
+		webhookRoutes.POST("/deliveries/:deliveryid/redeliver", ctl.Webhook.RedeliverWebhook)
+	}
+
+	// Rutas de podcasts (públicas, feed RSS con los audios publicados del usuario)
+	podcastRoutes := router.Group("/podcasts")
+	{
+		podcastRoutes.GET("/:username/feed.xml", ctl.Podcast.GetFeed)
+	}
+
+	// Rutas de administración: moderadores y administradores gestionan usuarios y videos,
+	// la operación de la plataforma y los roles quedan para los administradores
+	adminRoutes := router.Group("/admin")
+	adminRoutes.Use(authMiddleware, middlewares.RequireRole(models.UserRoleModerator, models.UserRoleAdmin))
+	{
+		adminRoutes.GET("/users", ctl.Moderation.GetUsers)
+		adminRoutes.PUT("/users/:id/suspension", ctl.Moderation.SuspendUser)
+		adminRoutes.DELETE("/users/:id/suspension", ctl.Moderation.UnsuspendUser)
+		adminRoutes.DELETE("/users/:id", ctl.Moderation.DeleteUser)
+		adminRoutes.GET("/videos", ctl.Moderation.GetVideos)
+		adminRoutes.PUT("/videos/:videoid/suspension", ctl.Moderation.SuspendVideo)
+		adminRoutes.DELETE("/videos/:videoid/suspension", ctl.Moderation.UnsuspendVideo)
+		adminRoutes.DELETE("/videos/:videoid", ctl.Moderation.DeleteVideo)
+
+		platformRoutes := adminRoutes.Group("")
+		platformRoutes.Use(middlewares.RequireRole(models.UserRoleAdmin))
+		platformRoutes.PUT("/users/:id/role", ctl.Moderation.SetUserRole)
+		platformRoutes.GET("/workers", ctl.Admin.GetWorkers)
+		platformRoutes.GET("/queues", ctl.Admin.GetQueues)
+		platformRoutes.PATCH("/jobs/:jobid/priority", ctl.Admin.SetJobPriority)
+		platformRoutes.GET("/encoding-profiles", ctl.Admin.GetEncodingProfiles)
+		platformRoutes.PUT("/encoding-profiles/:name", ctl.Admin.SaveEncodingProfile)
 	}
 }
@@ -0,0 +1,318 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/unbot2313/go-streaming-service/config"
+	"github.com/unbot2313/go-streaming-service/internal/models"
+	"github.com/unbot2313/go-streaming-service/internal/services/mailer"
+	"gorm.io/gorm"
+)
+
+// accountEmailTimeout limita el envío de cada email, para no colgar la petición ni la goroutine que lo manda
+const accountEmailTimeout = time.Minute
+
+var (
+	// ErrInvalidEmailToken indica que el link no es válido, venció, ya se usó o fue reemplazado por otro
+	ErrInvalidEmailToken = errors.New("el link es inválido, venció o ya fue usado")
+	// ErrEmailAlreadyVerified indica que el email del usuario ya está verificado
+	ErrEmailAlreadyVerified = errors.New("el email ya está verificado")
+)
+
+// AccountEmailService maneja los flujos que se confirman con un link por email:
+// la verificación del email y la recuperación de la contraseña
+type AccountEmailService interface {
+	SendVerificationEmail(userId string) error
+	VerifyEmail(token string) (*models.User, error)
+	RequestPasswordReset(email string) error
+	ResetPassword(token, newPassword string) error
+	IsEmailVerified(userId string) (bool, error)
+}
+
+type accountEmailServiceImp struct {
+	mailer mailer.Mailer
+}
+
+func NewAccountEmailService(emailSender mailer.Mailer) AccountEmailService {
+	return &accountEmailServiceImp{mailer: emailSender}
+}
+
+// SendVerificationEmail envía al usuario un link para confirmar su email actual.
+// Los links anteriores dejan de servir.
+func (s *accountEmailServiceImp) SendVerificationEmail(userId string) error {
+	db, err := config.GetDB()
+	if err != nil {
+		return err
+	}
+
+	var user models.User
+	if err := db.Where("id = ?", userId).First(&user).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrUserNotFound
+		}
+		return err
+	}
+	if user.IsEmailVerified() {
+		return ErrEmailAlreadyVerified
+	}
+
+	ttl := config.GetConfig().EmailVerificationTTL
+	token, err := issueEmailToken(db, &user, models.EmailTokenVerifyEmail, ttl)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), accountEmailTimeout)
+	defer cancel()
+
+	return s.mailer.Send(ctx, mailer.Message{
+		To:      user.Email,
+		Subject: "Verify your email",
+		Body: fmt.Sprintf("Hi %s,\n\nConfirm your email address by opening this link:\n\n%s\n\nThe link expires in %s. If you did not create an account, ignore this email.\n",
+			user.Username, emailLink("/verify-email", token), formatEmailTTL(ttl)),
+	})
+}
+
+// VerifyEmail marca como verificado el email para el que se emitió el token
+func (s *accountEmailServiceImp) VerifyEmail(token string) (*models.User, error) {
+	db, err := config.GetDB()
+	if err != nil {
+		return nil, err
+	}
+
+	var user *models.User
+	err = db.Transaction(func(tx *gorm.DB) error {
+		user, _, err = consumeEmailToken(tx, token, models.EmailTokenVerifyEmail)
+		if err != nil {
+			return err
+		}
+		if user.IsEmailVerified() {
+			return nil
+		}
+
+		now := time.Now()
+		if err := tx.Model(&models.User{}).Where("id = ?", user.Id).Update("email_verified_at", now).Error; err != nil {
+			return err
+		}
+		user.EmailVerifiedAt = &now
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+// RequestPasswordReset envía un link para elegir una contraseña nueva. No indica si el email
+// existe: un email desconocido no es un error y el envío sale en segundo plano.
+func (s *accountEmailServiceImp) RequestPasswordReset(email string) error {
+	db, err := config.GetDB()
+	if err != nil {
+		return err
+	}
+
+	var user models.User
+	if err := db.Where("email = ?", email).First(&user).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return err
+	}
+
+	ttl := config.GetConfig().PasswordResetTTL
+	token, err := issueEmailToken(db, &user, models.EmailTokenPasswordReset, ttl)
+	if err != nil {
+		return err
+	}
+
+	message := mailer.Message{
+		To:      user.Email,
+		Subject: "Reset your password",
+		Body: fmt.Sprintf("Hi %s,\n\nChoose a new password by opening this link:\n\n%s\n\nThe link expires in %s and works once. If you did not ask for it, ignore this email; your password does not change.\n",
+			user.Username, emailLink("/reset-password", token), formatEmailTTL(ttl)),
+	}
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), accountEmailTimeout)
+		defer cancel()
+
+		if err := s.mailer.Send(ctx, message); err != nil {
+			slog.Error("failed to send password reset email", slog.String("user_id", user.Id), slog.Any("error", err))
+		}
+	}()
+
+	return nil
+}
+
+// ResetPassword cambia la contraseña con un token de recuperación. Como al cambiarla con la
+// contraseña actual, se cierran todas las sesiones y se invalidan los access tokens. Además
+// se borran las API keys: quien pide el reset puede haber perdido el control de la cuenta.
+func (s *accountEmailServiceImp) ResetPassword(token, newPassword string) error {
+	hashedPassword, err := HashPassword(newPassword)
+	if err != nil {
+		return err
+	}
+
+	db, err := config.GetDB()
+	if err != nil {
+		return err
+	}
+
+	var userId string
+	err = db.Transaction(func(tx *gorm.DB) error {
+		user, claims, err := consumeEmailToken(tx, token, models.EmailTokenPasswordReset)
+		if err != nil {
+			return err
+		}
+
+		// Si la contraseña cambió después de pedir el link, el link ya no vale
+		if version, ok := claims["ver"].(float64); !ok || int(version) != user.TokenVersion {
+			return ErrInvalidEmailToken
+		}
+
+		updates := map[string]interface{}{"password": hashedPassword}
+		// Abrir el link demuestra que el email es suyo
+		if !user.IsEmailVerified() {
+			updates["email_verified_at"] = time.Now()
+		}
+		if err := tx.Model(&models.User{}).Where("id = ?", user.Id).Updates(updates).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", user.Id).Delete(&models.Session{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", user.Id).Delete(&models.APIKey{}).Error; err != nil {
+			return err
+		}
+		userId = user.Id
+		return bumpTokenVersion(tx, user.Id)
+	})
+	if err != nil {
+		return err
+	}
+
+	forgetTokenVersion(userId)
+
+	return nil
+}
+
+// IsEmailVerified indica si el usuario confirmó su email actual
+func (s *accountEmailServiceImp) IsEmailVerified(userId string) (bool, error) {
+	db, err := config.GetDB()
+	if err != nil {
+		return false, err
+	}
+
+	var user models.User
+	if err := db.Select("id", "email_verified_at").Where("id = ?", userId).First(&user).Error; err != nil {
+		return false, err
+	}
+
+	return user.IsEmailVerified(), nil
+}
+
+// issueEmailToken firma un token de un solo uso para el email actual del usuario y
+// anula los que se habían emitido antes con el mismo propósito
+func issueEmailToken(db *gorm.DB, user *models.User, purpose string, ttl time.Duration) (string, error) {
+	now := time.Now()
+	jti := uuid.New().String()
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		// Los tokens vencidos ya no pasan la validación: sus filas no hacen falta
+		if err := tx.Where("expires_at < ?", now).Delete(&models.EmailToken{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&models.EmailToken{}).
+			Where("user_id = ? AND purpose = ? AND used_at IS NULL", user.Id, purpose).
+			Update("used_at", now).Error; err != nil {
+			return err
+		}
+		return tx.Create(&models.EmailToken{Jti: jti, UserID: user.Id, Purpose: purpose, ExpiresAt: now.Add(ttl)}).Error
+	})
+	if err != nil {
+		return "", err
+	}
+
+	token, err := signToken(jwt.MapClaims{
+		"jti":     jti,
+		"user_id": user.Id,
+		"email":   user.Email,
+		"type":    purpose,
+		"ver":     user.TokenVersion,
+		"exp":     now.Add(ttl).Unix(),
+	})
+	if err != nil {
+		return "", fmt.Errorf("error al firmar el token: %v", err)
+	}
+
+	return token, nil
+}
+
+// consumeEmailToken valida la firma y el propósito del token y lo marca como usado.
+// Falla si el usuario cambió de email después de recibirlo.
+func consumeEmailToken(tx *gorm.DB, token, purpose string) (*models.User, jwt.MapClaims, error) {
+	parsedToken, err := parseToken(token)
+	if err != nil {
+		return nil, nil, ErrInvalidEmailToken
+	}
+
+	claims, ok := parsedToken.Claims.(jwt.MapClaims)
+	if !ok || !parsedToken.Valid {
+		return nil, nil, ErrInvalidEmailToken
+	}
+	if tokenType, _ := claims["type"].(string); tokenType != purpose {
+		return nil, nil, ErrInvalidEmailToken
+	}
+	jti, _ := claims["jti"].(string)
+	userId, _ := claims["user_id"].(string)
+	email, _ := claims["email"].(string)
+
+	// El UPDATE condicional asegura un solo uso aunque lleguen dos pedidos a la vez
+	result := tx.Model(&models.EmailToken{}).
+		Where("jti = ? AND user_id = ? AND purpose = ? AND used_at IS NULL AND expires_at > ?", jti, userId, purpose, time.Now()).
+		Update("used_at", time.Now())
+	if result.Error != nil {
+		return nil, nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, nil, ErrInvalidEmailToken
+	}
+
+	var user models.User
+	if err := tx.Where("id = ?", userId).First(&user).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, ErrInvalidEmailToken
+		}
+		return nil, nil, err
+	}
+	if user.Email != email {
+		return nil, nil, ErrInvalidEmailToken
+	}
+
+	return &user, claims, nil
+}
+
+// emailLink arma el link del frontend que recibe el token
+func emailLink(path, token string) string {
+	return config.GetConfig().AppURL + path + "?token=" + url.QueryEscape(token)
+}
+
+// formatEmailTTL escribe la vigencia de un link en horas o minutos
+func formatEmailTTL(ttl time.Duration) string {
+	if ttl >= time.Hour {
+		hours := int(ttl.Hours())
+		if hours == 1 {
+			return "1 hour"
+		}
+		return fmt.Sprintf("%d hours", hours)
+	}
+	return fmt.Sprintf("%d minutes", int(ttl.Minutes()))
+}
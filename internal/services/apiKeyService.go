@@ -0,0 +1,158 @@
+package services
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/unbot2313/go-streaming-service/config"
+	"github.com/unbot2313/go-streaming-service/internal/models"
+	"gorm.io/gorm"
+)
+
+const (
+	// MaxAPIKeysPerUser es el máximo de API keys activas por usuario
+	MaxAPIKeysPerUser = 20
+	// apiKeyPrefixLength es cuántos caracteres de la key se guardan para reconocerla en el listado
+	apiKeyPrefixLength = 11
+	// apiKeyLastUsedResolution evita escribir last_used_at en cada request de un pipeline
+	apiKeyLastUsedResolution = time.Minute
+)
+
+var (
+	// ErrAPIKeyNotFound indica que la API key no existe o es de otro usuario
+	ErrAPIKeyNotFound = errors.New("API key no encontrada")
+	// ErrInvalidAPIKey indica que la API key no existe, venció o su usuario ya no existe
+	ErrInvalidAPIKey = errors.New("API key inválida o vencida")
+	// ErrTooManyAPIKeys indica que el usuario llegó a MaxAPIKeysPerUser
+	ErrTooManyAPIKeys = errors.New("se alcanzó el máximo de API keys")
+)
+
+type APIKeyService interface {
+	CreateAPIKey(userId, name string, scopes []string, expiresAt time.Time) (*models.APIKey, error)
+	ListAPIKeys(userId string) ([]models.APIKey, error)
+	DeleteAPIKey(userId, keyId string) error
+	Authenticate(rawKey string) (*models.User, *models.APIKey, error)
+}
+
+type apiKeyServiceImp struct{}
+
+func NewAPIKeyService() APIKeyService {
+	return &apiKeyServiceImp{}
+}
+
+// CreateAPIKey genera una key nueva. La key completa solo viene en el resultado: en la DB queda su hash.
+func (s *apiKeyServiceImp) CreateAPIKey(userId, name string, scopes []string, expiresAt time.Time) (*models.APIKey, error) {
+	db, err := config.GetDB()
+	if err != nil {
+		return nil, err
+	}
+
+	var count int64
+	if err := db.Model(&models.APIKey{}).Where("user_id = ? AND expires_at > ?", userId, time.Now()).Count(&count).Error; err != nil {
+		return nil, err
+	}
+	if count >= MaxAPIKeysPerUser {
+		return nil, ErrTooManyAPIKeys
+	}
+
+	random := make([]byte, 32)
+	if _, err := rand.Read(random); err != nil {
+		return nil, err
+	}
+	rawKey := models.APIKeyPrefix + base64.RawURLEncoding.EncodeToString(random)
+
+	apiKey := &models.APIKey{
+		Id:        uuid.New().String(),
+		UserID:    userId,
+		Name:      name,
+		Prefix:    rawKey[:apiKeyPrefixLength],
+		KeyHash:   hashSHA256(rawKey),
+		Scopes:    scopes,
+		ExpiresAt: expiresAt,
+	}
+	if err := db.Create(apiKey).Error; err != nil {
+		return nil, err
+	}
+
+	apiKey.Key = rawKey
+	return apiKey, nil
+}
+
+// ListAPIKeys lista las API keys del usuario, incluidas las vencidas, de la más nueva a la más vieja
+func (s *apiKeyServiceImp) ListAPIKeys(userId string) ([]models.APIKey, error) {
+	db, err := config.GetDB()
+	if err != nil {
+		return nil, err
+	}
+
+	apiKeys := []models.APIKey{}
+	if err := db.Where("user_id = ?", userId).Order("created_at DESC").Find(&apiKeys).Error; err != nil {
+		return nil, err
+	}
+
+	return apiKeys, nil
+}
+
+// DeleteAPIKey revoca una API key del usuario; deja de servir en el siguiente request
+func (s *apiKeyServiceImp) DeleteAPIKey(userId, keyId string) error {
+	db, err := config.GetDB()
+	if err != nil {
+		return err
+	}
+
+	result := db.Where("id = ? AND user_id = ?", keyId, userId).Delete(&models.APIKey{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrAPIKeyNotFound
+	}
+
+	return nil
+}
+
+// Authenticate busca la API key por su hash y retorna su usuario, con el rol vigente en la DB
+func (s *apiKeyServiceImp) Authenticate(rawKey string) (*models.User, *models.APIKey, error) {
+	db, err := config.GetDB()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var apiKey models.APIKey
+	if err := db.Where("key_hash = ?", hashSHA256(rawKey)).First(&apiKey).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, ErrInvalidAPIKey
+		}
+		return nil, nil, err
+	}
+	if apiKey.IsExpired() {
+		return nil, nil, ErrInvalidAPIKey
+	}
+
+	var user models.User
+	if err := db.Where("id = ?", apiKey.UserID).First(&user).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, ErrInvalidAPIKey
+		}
+		return nil, nil, err
+	}
+	if user.IsSuspended() {
+		return nil, nil, ErrUserSuspended
+	}
+
+	now := time.Now()
+	if apiKey.LastUsedAt == nil || now.Sub(*apiKey.LastUsedAt) > apiKeyLastUsedResolution {
+		// No poder registrar el uso no debe cortar el request
+		if err := db.Model(&models.APIKey{}).Where("id = ?", apiKey.Id).Update("last_used_at", now).Error; err != nil {
+			slog.Warn("failed to update API key last use", slog.String("api_key_id", apiKey.Id), slog.Any("error", err))
+		} else {
+			apiKey.LastUsedAt = &now
+		}
+	}
+
+	return &user, &apiKey, nil
+}
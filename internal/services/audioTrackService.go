@@ -0,0 +1,442 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/unbot2313/go-streaming-service/config"
+	"github.com/unbot2313/go-streaming-service/internal/models"
+	"github.com/unbot2313/go-streaming-service/internal/services/storage"
+)
+
+const (
+	// AudioGroupID es el GROUP-ID de las pistas de audio en el master playlist
+	AudioGroupID = "audio"
+	// MaxAudioTracks es la cantidad máxima de pistas de audio por video
+	MaxAudioTracks = 16
+	// MaxDubUploadSize es el tamaño máximo del archivo de una pista doblada (500MB)
+	MaxDubUploadSize = 500 << 20
+)
+
+var (
+	// ErrInvalidAudioTrack indica que la pista doblada no se puede agregar o borrar
+	ErrInvalidAudioTrack = errors.New("pista de audio inválida")
+	// ErrAudioTrackNotFound indica que la pista de audio no existe
+	ErrAudioTrackNotFound = errors.New("pista de audio no encontrada")
+	// ErrTooManyAudioTracks indica que el video ya tiene MaxAudioTracks pistas
+	ErrTooManyAudioTracks = errors.New("el video alcanzó el máximo de pistas de audio")
+)
+
+// languageTagPattern valida un tag de idioma BCP 47 simple ("es", "pt-BR", "spa")
+var languageTagPattern = regexp.MustCompile(`^[A-Za-z]{2,3}(-[A-Za-z0-9]{2,8})*$`)
+
+// DubUpload es el archivo de una pista doblada con su idioma y el nombre que ve el usuario
+type DubUpload struct {
+	Filename string
+	File     io.Reader
+	Language string
+	Name     string
+}
+
+type AudioTrackService interface {
+	ListAudioTracks(videoId string) ([]models.AudioTrack, error)
+	FindAudioTrackByID(trackId string) (*models.AudioTrack, error)
+	SaveEmbeddedTracks(ctx context.Context, video *models.VideoModel, tracks []models.AudioTrack) error
+	AddDub(ctx context.Context, video *models.VideoModel, dub DubUpload) (*models.AudioTrack, error)
+	DeleteDub(ctx context.Context, video *models.VideoModel, track *models.AudioTrack) error
+}
+
+type audioTrackServiceImp struct {
+	storageService storage.StorageService
+	filesService   FilesService
+	ffmpegService  FFmpegService
+	hlsKeyService  HLSKeyService
+}
+
+func NewAudioTrackService(storageService storage.StorageService, filesService FilesService, ffmpegService FFmpegService, hlsKeyService HLSKeyService) AudioTrackService {
+	return &audioTrackServiceImp{
+		storageService: storageService,
+		filesService:   filesService,
+		ffmpegService:  ffmpegService,
+		hlsKeyService:  hlsKeyService,
+	}
+}
+
+// EmbeddedAudioTracks arma las pistas de los streams de audio del archivo fuente.
+// Con un solo stream el audio va muxeado en el playlist del video y la pista no tiene playlist propio.
+func EmbeddedAudioTracks(streams []AudioStream) []models.AudioTrack {
+	tracks := make([]models.AudioTrack, 0, len(streams))
+	for _, stream := range streams {
+		name := stream.Title
+		if name == "" {
+			name = fmt.Sprintf("Audio %d (%s)", stream.Index+1, stream.Language)
+		}
+
+		track := models.AudioTrack{
+			Language: stream.Language,
+			Name:     truncateAudioTrackName(name),
+			Source:   models.AudioTrackSourceEmbedded,
+			Position: stream.Index,
+		}
+		if len(streams) > 1 {
+			track.PlaylistURL = AudioPlaylistName(stream.Index)
+		}
+		tracks = append(tracks, track)
+	}
+
+	return tracks
+}
+
+func truncateAudioTrackName(name string) string {
+	runes := []rune(name)
+	if len(runes) > 100 {
+		return string(runes[:100])
+	}
+	return name
+}
+
+// BuildMasterPlaylist arma el master playlist con una variante de video (variantURI) y las
+// pistas de audio como #EXT-X-MEDIA del grupo AudioGroupID. La primera pista es la predeterminada;
+// una pista sin PlaylistURL es el audio muxeado en la variante.
+func BuildMasterPlaylist(variantURI string, bandwidth int64, tracks []models.AudioTrack) []byte {
+	// Las comillas no se pueden escapar en un quoted-string de HLS
+	quote := strings.NewReplacer(`"`, "'", "\n", " ", "\r", " ")
+
+	var out strings.Builder
+	out.WriteString("#EXTM3U\n#EXT-X-VERSION:3\n")
+
+	// NAME tiene que ser único dentro del grupo
+	names := make(map[string]int)
+	for i, track := range tracks {
+		name := quote.Replace(track.Name)
+		names[name]++
+		if names[name] > 1 {
+			name = fmt.Sprintf("%s (%d)", name, names[name])
+		}
+
+		isDefault := "NO"
+		if i == 0 {
+			isDefault = "YES"
+		}
+
+		fmt.Fprintf(&out, "#EXT-X-MEDIA:TYPE=AUDIO,GROUP-ID=\"%s\",LANGUAGE=\"%s\",NAME=\"%s\",DEFAULT=%s,AUTOSELECT=YES",
+			AudioGroupID, quote.Replace(track.Language), name, isDefault)
+		if track.PlaylistURL != "" {
+			fmt.Fprintf(&out, ",URI=\"%s\"", track.PlaylistURL)
+		}
+		out.WriteString("\n")
+	}
+
+	fmt.Fprintf(&out, "#EXT-X-STREAM-INF:BANDWIDTH=%d,AUDIO=\"%s\"\n%s\n", bandwidth, AudioGroupID, variantURI)
+
+	return []byte(out.String())
+}
+
+// VariantBandwidth estima el BANDWIDTH de la variante de video: el bitrate promedio de sus
+// segmentos más el de una pista de audio alternativa
+func VariantBandwidth(objects []storage.ObjectInfo, variantPlaylist string, seconds float64) int64 {
+	prefix := strings.TrimSuffix(variantPlaylist, ".m3u8")
+
+	var size int64
+	for _, object := range objects {
+		name := path.Base(object.Key)
+		if strings.HasPrefix(name, prefix) && strings.HasSuffix(name, ".ts") {
+			size += object.Size
+		}
+	}
+
+	if seconds <= 0 {
+		return AudioTrackBitrate
+	}
+	return int64(float64(size*8)/seconds) + AudioTrackBitrate
+}
+
+func (s *audioTrackServiceImp) ListAudioTracks(videoId string) ([]models.AudioTrack, error) {
+	db, err := config.GetDB()
+	if err != nil {
+		return nil, err
+	}
+
+	var tracks []models.AudioTrack
+	if err := db.Where("video_id = ?", videoId).Order("position ASC, created_at ASC").Find(&tracks).Error; err != nil {
+		return nil, err
+	}
+
+	return tracks, nil
+}
+
+func (s *audioTrackServiceImp) FindAudioTrackByID(trackId string) (*models.AudioTrack, error) {
+	db, err := config.GetDB()
+	if err != nil {
+		return nil, err
+	}
+
+	var track models.AudioTrack
+	if err := db.Where("id = ?", trackId).First(&track).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrAudioTrackNotFound
+		}
+		return nil, err
+	}
+
+	return &track, nil
+}
+
+// SaveEmbeddedTracks reemplaza las pistas del archivo fuente del video por las detectadas al
+// transcodificar. Sus playlists se guardan como URLs absolutas. Si el video tiene pistas dobladas,
+// se vuelve a publicar el master playlist para muxearlas con las renditions nuevas.
+func (s *audioTrackServiceImp) SaveEmbeddedTracks(ctx context.Context, video *models.VideoModel, tracks []models.AudioTrack) error {
+	db, err := config.GetDB()
+	if err != nil {
+		return err
+	}
+
+	base, err := url.Parse(video.VideoUrl)
+	if err != nil {
+		return fmt.Errorf("URL del playlist inválida: %w", err)
+	}
+
+	var dubs int64
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("video_id = ? AND source = ?", video.Id, models.AudioTrackSourceEmbedded).Delete(&models.AudioTrack{}).Error; err != nil {
+			return err
+		}
+
+		for _, track := range tracks {
+			track.Id = uuid.New().String()
+			track.VideoID = video.Id
+			track.Source = models.AudioTrackSourceEmbedded
+			if track.PlaylistURL != "" {
+				if ref, err := url.Parse(track.PlaylistURL); err == nil {
+					track.PlaylistURL = base.ResolveReference(ref).String()
+				}
+			}
+			if err := tx.Create(&track).Error; err != nil {
+				return err
+			}
+		}
+
+		return tx.Model(&models.AudioTrack{}).Where("video_id = ? AND source = ?", video.Id, models.AudioTrackSourceDub).Count(&dubs).Error
+	})
+	if err != nil {
+		return err
+	}
+
+	if dubs == 0 {
+		return nil
+	}
+
+	return s.publishMaster(ctx, video)
+}
+
+// AddDub transcodifica una pista doblada a HLS AAC (normalizada si el video lo está),
+// la sube a su propia carpeta del storage y la publica en el master playlist del video
+func (s *audioTrackServiceImp) AddDub(ctx context.Context, video *models.VideoModel, dub DubUpload) (*models.AudioTrack, error) {
+	if video.IsAudio() {
+		return nil, fmt.Errorf("%w: los audios no admiten pistas alternativas", ErrInvalidAudioTrack)
+	}
+	if !languageTagPattern.MatchString(dub.Language) {
+		return nil, fmt.Errorf("%w: el idioma debe ser un tag BCP 47 como \"es\" o \"pt-BR\"", ErrInvalidAudioTrack)
+	}
+	if MediaTypeFromFilename(dub.Filename) != models.MediaTypeAudio {
+		return nil, fmt.Errorf("%w: el archivo debe ser %s", ErrInvalidAudioTrack, strings.Join(validAudioExtensions, ", "))
+	}
+
+	name := strings.TrimSpace(dub.Name)
+	if name == "" {
+		name = dub.Language
+	}
+
+	tracks, err := s.ListAudioTracks(video.Id)
+	if err != nil {
+		return nil, err
+	}
+	if len(tracks) >= MaxAudioTracks {
+		return nil, ErrTooManyAudioTracks
+	}
+
+	track := &models.AudioTrack{
+		Id:       uuid.New().String(),
+		VideoID:  video.Id,
+		Language: dub.Language,
+		Name:     truncateAudioTrackName(name),
+		Source:   models.AudioTrackSourceDub,
+		Position: len(tracks),
+	}
+
+	playlistURL, err := s.uploadDub(ctx, video, track.Id, dub)
+	if err != nil {
+		return nil, err
+	}
+	track.PlaylistURL = playlistURL
+
+	db, err := config.GetDB()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := db.Create(track).Error; err != nil {
+		s.storageService.DeleteFolder(ctx, track.Id+"/")
+		return nil, err
+	}
+
+	if err := s.publishMaster(ctx, video); err != nil {
+		db.Delete(track)
+		s.storageService.DeleteFolder(ctx, track.Id+"/")
+		return nil, err
+	}
+
+	return track, nil
+}
+
+// uploadDub guarda el archivo subido, lo transcodifica a HLS y sube la carpeta con el nombre
+// de la pista. Retorna la URL de su playlist.
+func (s *audioTrackServiceImp) uploadDub(ctx context.Context, video *models.VideoModel, trackId string, dub DubUpload) (string, error) {
+	workDir, err := os.MkdirTemp("", "dub-"+trackId+"-")
+	if err != nil {
+		return "", fmt.Errorf("error al crear la carpeta temporal: %w", err)
+	}
+	defer s.filesService.RemoveFolder(workDir)
+
+	sourcePath := filepath.Join(workDir, "source"+strings.ToLower(filepath.Ext(dub.Filename)))
+	file, err := os.Create(sourcePath)
+	if err != nil {
+		return "", err
+	}
+	if _, err := io.Copy(file, dub.File); err != nil {
+		file.Close()
+		return "", fmt.Errorf("error al guardar el archivo: %w", err)
+	}
+	if err := file.Close(); err != nil {
+		return "", err
+	}
+
+	// Se normaliza como el audio original para que cambiar de pista no cambie el volumen
+	var measurement *LoudnessMeasurement
+	if video.Loudness.Integrated != nil {
+		measurement, err = s.ffmpegService.MeasureLoudness(ctx, sourcePath)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	// La carpeta se llama como la pista para que UploadFolder la suba con ese nombre
+	outputDir := filepath.Join(workDir, trackId)
+	if err := s.filesService.CreateFolder(outputDir); err != nil {
+		return "", fmt.Errorf("error al crear la carpeta: %w", err)
+	}
+	if _, err := s.ffmpegService.ConvertAudioToHLS(ctx, sourcePath, outputDir, measurement); err != nil {
+		return "", err
+	}
+
+	// En un video cifrado el doblaje también se cifra, con claves propias del mismo video
+	if video.Encrypted {
+		encryption, err := s.hlsKeyService.Encryption(video.Id)
+		if err != nil {
+			return "", err
+		}
+		if err := EncryptHLS(outputDir, encryption); err != nil {
+			return "", err
+		}
+	}
+
+	result, err := s.storageService.UploadFolder(ctx, outputDir)
+	if err != nil {
+		s.storageService.DeleteFolder(ctx, trackId+"/")
+		return "", err
+	}
+
+	return result.M3u8FileURL, nil
+}
+
+// DeleteDub quita una pista doblada del master playlist y borra sus archivos del storage.
+// Las pistas del archivo fuente no se pueden borrar.
+func (s *audioTrackServiceImp) DeleteDub(ctx context.Context, video *models.VideoModel, track *models.AudioTrack) error {
+	if !track.IsDub() {
+		return fmt.Errorf("%w: las pistas del archivo fuente no se pueden borrar", ErrInvalidAudioTrack)
+	}
+
+	db, err := config.GetDB()
+	if err != nil {
+		return err
+	}
+
+	if err := db.Delete(track).Error; err != nil {
+		return err
+	}
+
+	if err := s.publishMaster(ctx, video); err != nil {
+		return err
+	}
+
+	return s.storageService.DeleteFolder(ctx, track.Id+"/")
+}
+
+// publishMaster arma el master playlist del video con todas sus pistas, lo sube a la carpeta
+// de sus renditions y apunta el video a él
+func (s *audioTrackServiceImp) publishMaster(ctx context.Context, video *models.VideoModel) error {
+	tracks, err := s.ListAudioTracks(video.Id)
+	if err != nil {
+		return err
+	}
+
+	// Si el archivo fuente tenía varias pistas, el video está sin audio en VideoPlaylistName
+	variant := MuxedPlaylistName
+	for _, track := range tracks {
+		if !track.IsDub() && track.PlaylistURL != "" {
+			variant = VideoPlaylistName
+			break
+		}
+	}
+
+	folder := video.Folder()
+	objects, err := s.storageService.ListObjects(ctx, folder+"/")
+	if err != nil {
+		return err
+	}
+
+	master := BuildMasterPlaylist(variant, VariantBandwidth(objects, variant, DurationSeconds(video.Duration)), tracks)
+
+	workDir, err := os.MkdirTemp("", "master-"+video.Id+"-")
+	if err != nil {
+		return fmt.Errorf("error al crear la carpeta temporal: %w", err)
+	}
+	defer s.filesService.RemoveFolder(workDir)
+
+	localPath := filepath.Join(workDir, storage.MasterPlaylistName)
+	if err := os.WriteFile(localPath, master, 0644); err != nil {
+		return err
+	}
+
+	masterURL, err := s.storageService.UploadFile(ctx, localPath, folder+"/"+storage.MasterPlaylistName, "application/x-mpegURL")
+	if err != nil {
+		return err
+	}
+
+	if video.VideoUrl == masterURL {
+		return nil
+	}
+
+	db, err := config.GetDB()
+	if err != nil {
+		return err
+	}
+
+	if err := db.Model(&models.VideoModel{}).Where("id = ?", video.Id).Update("video_url", masterURL).Error; err != nil {
+		return err
+	}
+	video.VideoUrl = masterURL
+
+	return nil
+}
@@ -3,15 +3,39 @@ package services
 import (
 	"crypto/sha256"
 	"encoding/hex"
+	"errors"
 	"fmt"
+	"log/slog"
 	"time"
+	"unicode/utf8"
 
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
 	"github.com/unbot2313/go-streaming-service/config"
 	"github.com/unbot2313/go-streaming-service/internal/models"
 	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
 )
 
+var (
+	// ErrUserSuspended indica que un moderador suspendió la cuenta
+	ErrUserSuspended = errors.New("la cuenta está suspendida")
+	// ErrSessionNotFound indica que la sesión no existe, venció o es de otro usuario
+	ErrSessionNotFound = errors.New("sesión no encontrada")
+	// ErrRefreshTokenReused indica que se presentó un refresh token ya rotado; la sesión queda revocada
+	ErrRefreshTokenReused = errors.New("el refresh token ya fue usado, la sesión fue revocada")
+)
+
+// RefreshTokenTTL es cuánto dura un refresh token; cada refresh extiende la sesión
+const RefreshTokenTTL = 7 * 24 * time.Hour
+
+// SessionDevice identifica el dispositivo desde el que se inicia o renueva una sesión
+type SessionDevice struct {
+	Name      string
+	UserAgent string
+	IP        string
+}
+
 type AuthServiceImp struct{
 	userService UserService
 }
@@ -23,13 +47,18 @@ type TokenPair struct {
 
 type AuthService interface {
 	GenerateToken(User *models.User) (string, error)
-	ValidateToken(token string) (*models.User, error)
-	Login(username, password string) (*TokenPair, error)
-	GenerateRefreshToken(user *models.User) (string, error)
-	ValidateRefreshToken(tokenString string) (*models.User, error)
-	SaveRefreshToken(userId, refreshToken string) error
-	ClearRefreshToken(userId string) error
-	RefreshTokens(refreshToken string) (*TokenPair, error)
+	ValidateToken(token string) (*models.User, *models.AuthClaims, error)
+	Login(username, password string, device SessionDevice) (*TokenPair, error)
+	CreateSession(user *models.User, device SessionDevice) (*TokenPair, error)
+	GenerateRefreshToken(user *models.User, sessionId string) (string, error)
+	ValidateRefreshToken(tokenString string) (*models.User, *models.Session, error)
+	RefreshTokens(refreshToken string, device SessionDevice) (*TokenPair, error)
+	ListSessions(userId string) ([]models.Session, error)
+	RevokeSession(userId, sessionId string) error
+	RevokeUserSessions(userId string) error
+	IsTokenRevoked(user *models.User, claims *models.AuthClaims) (bool, error)
+	RevokeAccessToken(user *models.User, claims *models.AuthClaims) error
+	PublicKeys() (*JWKSet, error)
 }
 
 func NewAuthService() AuthService {
@@ -38,7 +67,7 @@ func NewAuthService() AuthService {
 	}
 }
 
-func (service *AuthServiceImp) Login(username, password string) (*TokenPair, error) {
+func (service *AuthServiceImp) Login(username, password string, device SessionDevice) (*TokenPair, error) {
 	_, err := config.GetDB()
 	if err != nil {
 		return nil, fmt.Errorf("error al conectar a la base de datos: %v", err)
@@ -53,18 +82,45 @@ func (service *AuthServiceImp) Login(username, password string) (*TokenPair, err
 		return nil, fmt.Errorf("la contraseña no es válida")
 	}
 
-	accessToken, err := service.GenerateToken(user)
+	if user.IsSuspended() {
+		return nil, ErrUserSuspended
+	}
+
+	return service.CreateSession(user, device)
+}
+
+// CreateSession abre una sesión nueva para el dispositivo y retorna su par de tokens.
+// Las sesiones de otros dispositivos siguen activas.
+func (service *AuthServiceImp) CreateSession(user *models.User, device SessionDevice) (*TokenPair, error) {
+	db, err := config.GetDB()
 	if err != nil {
-		return nil, fmt.Errorf("error al generar el access token: %v", err)
+		return nil, fmt.Errorf("error connecting to database: %v", err)
 	}
 
-	refreshToken, err := service.GenerateRefreshToken(user)
+	session := &models.Session{
+		Id:         uuid.New().String(),
+		UserID:     user.Id,
+		DeviceName: truncateSessionField(device.Name, 100),
+		UserAgent:  truncateSessionField(device.UserAgent, 255),
+		IP:         truncateSessionField(device.IP, 45),
+		ExpiresAt:  time.Now().Add(RefreshTokenTTL),
+		LastUsedAt: time.Now(),
+	}
+
+	refreshToken, err := service.GenerateRefreshToken(user, session.Id)
 	if err != nil {
 		return nil, fmt.Errorf("error al generar el refresh token: %v", err)
 	}
+	session.RefreshTokenHash = hashSHA256(refreshToken)
 
-	if err := service.SaveRefreshToken(user.Id, refreshToken); err != nil {
-		return nil, fmt.Errorf("error al guardar el refresh token: %v", err)
+	accessToken, accessTokenId, err := service.generateAccessToken(user, session.Id)
+	if err != nil {
+		return nil, fmt.Errorf("error al generar el access token: %v", err)
+	}
+	session.AccessTokenID = accessTokenId
+
+	if err := db.Create(session).Error; err != nil {
+		return nil, fmt.Errorf("error al guardar la sesión: %v", err)
 	}
 
 	return &TokenPair{
@@ -74,73 +130,100 @@ func (service *AuthServiceImp) Login(username, password string) (*TokenPair, err
 }
 
 func (service *AuthServiceImp) GenerateToken(user *models.User) (string, error) {
+	tokenString, _, err := service.generateAccessToken(user, "")
+	return tokenString, err
+}
+
+// generateAccessToken firma un access token de la sesión y retorna también su jti, para poder revocarlo
+func (service *AuthServiceImp) generateAccessToken(user *models.User, sessionId string) (string, string, error) {
 
-	SecretToken := []byte(config.GetConfig().JWTSecretKey)
+	jti := uuid.New().String()
 
 	//crear token
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+	claims := jwt.MapClaims{
 		"user_id":  user.Id,               // Identificador único del usuario
 		"username": user.Username,         // Nombre de usuario para referencia
 		"email":    user.Email,            
-		"exp":  time.Now().Add(time.Hour * 24).Unix(), // Expira en 24 horas
-	})
+		"role":     user.Role,             // Rol para autorizar sin consultar la DB
+		"jti":      jti,                   // Permite revocar este token (logout)
+		"ver":      user.TokenVersion,     // Cambia al cambiar la contraseña o el email
+		"exp":  time.Now().Add(config.GetConfig().AccessTokenTTL).Unix(), // ACCESS_TOKEN_TTL
+	}
+	// La sesión permite cerrar solo el dispositivo actual en el logout
+	if sessionId != "" {
+		claims["sid"] = sessionId
+	}
 
-	//firmar token
-	tokenString, err := token.SignedString(SecretToken)
+	//firmar token con la clave activa (JWT_SIGNING_ALGORITHM)
+	tokenString, err := signToken(claims)
 	if err != nil {
-		return "", fmt.Errorf("error al firmar el token: %v", err)
+		return "", "", fmt.Errorf("error al firmar el token: %v", err)
 	}
 
-	return tokenString, nil
+	return tokenString, jti, nil
 }
 
-func (service *AuthServiceImp) ValidateToken(tokenString string) (*models.User, error) {
-	
-	SecretToken := []byte(config.GetConfig().JWTSecretKey)
+func (service *AuthServiceImp) ValidateToken(tokenString string) (*models.User, *models.AuthClaims, error) {
 
-	// Parsear y verificar el token
-	parsedToken, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-		// Validar el método de firma
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("método de firma inesperado: %v", token.Header["alg"])
-		}
-		return SecretToken, nil
-	})
+	// Parsear y verificar el token con la clave de su kid
+	parsedToken, err := parseToken(tokenString)
 
 	if err != nil {
 		// Error al parsear o verificar el token
-		return nil, fmt.Errorf("error al parsear el token: %v", err)
+		return nil, nil, fmt.Errorf("error al parsear el token: %v", err)
 	}
 
 	// Extraer y validar los claims
 	if claims, ok := parsedToken.Claims.(jwt.MapClaims); ok && parsedToken.Valid {
+		// Los refresh tokens y los tokens de los emails se firman con la misma clave pero llevan "type"
+		if _, hasType := claims["type"]; hasType {
+			return nil, nil, fmt.Errorf("el token no es un access token")
+		}
+
 		// Validar y construir el objeto usuario
 		id, ok := claims["user_id"].(string)
 		if !ok {
-			return nil, fmt.Errorf("user_id no es válido")
+			return nil, nil, fmt.Errorf("user_id no es válido")
 		}
 
 		username, ok := claims["username"].(string)
 		if !ok {
-			return nil, fmt.Errorf("username no es válido")
+			return nil, nil, fmt.Errorf("username no es válido")
 		}
 
 		email, ok := claims["email"].(string)
 		if !ok {
-			return nil, fmt.Errorf("email no es válido")
+			return nil, nil, fmt.Errorf("email no es válido")
+		}
+
+		// Los tokens emitidos antes de los roles no traen el claim
+		role, _ := claims["role"].(string)
+		if role == "" {
+			role = models.UserRoleUser
 		}
 
 		user := &models.User{
 			Id:       id,
 			Username: username,
 			Email:    email,
+			Role:     role,
+		}
+		authClaims := &models.AuthClaims{}
+		authClaims.SessionID, _ = claims["sid"].(string)
+		authClaims.TokenID, _ = claims["jti"].(string)
+		// Los números de los claims llegan como float64
+		if version, ok := claims["ver"].(float64); ok {
+			user.TokenVersion = int(version)
+		}
+		if exp, err := claims.GetExpirationTime(); err == nil && exp != nil {
+			authClaims.TokenExpiresAt = exp.Time
 		}
 
-		return user, nil
+		return user, authClaims, nil
 	}
 
 	// Si el token no es válido o los claims no son correctos
-	return nil, fmt.Errorf("token inválido o claims inválidos")
+	return nil, nil, fmt.Errorf("token inválido o claims inválidos")
 }
 
 // Función para hashear una contraseña
@@ -166,17 +249,18 @@ func hashSHA256(input string) string {
 }
 
 // GenerateRefreshToken genera un JWT de refresh con exp de 7 días.
-// Contiene el user_id para poder hacer lookup directo en DB.
-func (service *AuthServiceImp) GenerateRefreshToken(user *models.User) (string, error) {
-	secretToken := []byte(config.GetConfig().JWTSecretKey)
-
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+// Contiene el user_id y la sesión para hacer lookup directo en DB; el jti
+// hace que cada rotación produzca un token distinto.
+func (service *AuthServiceImp) GenerateRefreshToken(user *models.User, sessionId string) (string, error) {
+	claims := jwt.MapClaims{
 		"user_id": user.Id,
+		"sid":     sessionId,
+		"jti":     uuid.New().String(),
 		"type":    "refresh",
-		"exp":     time.Now().Add(7 * 24 * time.Hour).Unix(),
-	})
+		"exp":     time.Now().Add(RefreshTokenTTL).Unix(),
+	}
 
-	tokenString, err := token.SignedString(secretToken)
+	tokenString, err := signToken(claims)
 	if err != nil {
 		return "", fmt.Errorf("error signing refresh token: %v", err)
 	}
@@ -184,103 +268,222 @@ func (service *AuthServiceImp) GenerateRefreshToken(user *models.User) (string,
 	return tokenString, nil
 }
 
-// ValidateRefreshToken parsea el JWT para obtener user_id,
-// busca al usuario en DB y compara el hash SHA-256 almacenado.
-func (service *AuthServiceImp) ValidateRefreshToken(refreshToken string) (*models.User, error) {
-	secretToken := []byte(config.GetConfig().JWTSecretKey)
-
-	parsedToken, err := jwt.Parse(refreshToken, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
-		}
-		return secretToken, nil
-	})
+// ValidateRefreshToken parsea el JWT para obtener user_id y la sesión,
+// y compara el hash SHA-256 con el del refresh token vigente de la sesión.
+// Un token válido pero ya rotado es una reutilización: se revoca la sesión entera.
+func (service *AuthServiceImp) ValidateRefreshToken(refreshToken string) (*models.User, *models.Session, error) {
+	parsedToken, err := parseToken(refreshToken)
 	if err != nil {
-		return nil, fmt.Errorf("invalid refresh token: %v", err)
+		return nil, nil, fmt.Errorf("invalid refresh token: %v", err)
 	}
 
 	claims, ok := parsedToken.Claims.(jwt.MapClaims)
 	if !ok || !parsedToken.Valid {
-		return nil, fmt.Errorf("invalid refresh token claims")
+		return nil, nil, fmt.Errorf("invalid refresh token claims")
 	}
 
 	tokenType, _ := claims["type"].(string)
 	if tokenType != "refresh" {
-		return nil, fmt.Errorf("token is not a refresh token")
+		return nil, nil, fmt.Errorf("token is not a refresh token")
 	}
 
 	userId, ok := claims["user_id"].(string)
 	if !ok {
-		return nil, fmt.Errorf("invalid user_id in refresh token")
+		return nil, nil, fmt.Errorf("invalid user_id in refresh token")
+	}
+
+	// Los refresh tokens anteriores a las sesiones no traen sid: hay que volver a iniciar sesión
+	sessionId, ok := claims["sid"].(string)
+	if !ok {
+		return nil, nil, fmt.Errorf("refresh token has been revoked")
+	}
+
+	session, err := service.findSession(userId, sessionId)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if session.RefreshTokenHash != hashSHA256(refreshToken) {
+		slog.Warn("refresh token reuse detected, revoking session",
+			slog.String("user_id", userId),
+			slog.String("session_id", sessionId),
+		)
+		if err := service.RevokeSession(userId, sessionId); err != nil {
+			return nil, nil, err
+		}
+		return nil, nil, ErrRefreshTokenReused
 	}
 
 	// Lookup directo por user_id (O(1), no iterar todos los usuarios)
 	user, err := service.userService.GetUserByID(userId)
 	if err != nil {
-		return nil, fmt.Errorf("user not found: %v", err)
+		return nil, nil, fmt.Errorf("user not found: %v", err)
+	}
+
+	if user.IsSuspended() {
+		return nil, nil, ErrUserSuspended
+	}
+
+	return user, session, nil
+}
+
+// RefreshTokens valida el refresh token actual y genera un nuevo par de tokens (rotation)
+func (service *AuthServiceImp) RefreshTokens(refreshToken string, device SessionDevice) (*TokenPair, error) {
+	user, session, err := service.ValidateRefreshToken(refreshToken)
+	if err != nil {
+		return nil, err
+	}
+
+	newRefreshToken, err := service.GenerateRefreshToken(user, session.Id)
+	if err != nil {
+		return nil, fmt.Errorf("error generating refresh token: %v", err)
+	}
+
+	accessToken, accessTokenId, err := service.generateAccessToken(user, session.Id)
+	if err != nil {
+		return nil, fmt.Errorf("error generating access token: %v", err)
 	}
 
-	// Comparar hash SHA-256 almacenado con el del token recibido
-	if user.RefreshToken == "" || user.RefreshToken != hashSHA256(refreshToken) {
-		return nil, fmt.Errorf("refresh token has been revoked")
+	db, err := config.GetDB()
+	if err != nil {
+		return nil, fmt.Errorf("error connecting to database: %v", err)
 	}
 
-	return user, nil
+	// La rotación es condicional al hash leído: de dos refresh simultáneos con el mismo
+	// token solo uno gana, el otro cuenta como reutilización
+	result := db.Model(&models.Session{}).
+		Where("id = ? AND refresh_token_hash = ?", session.Id, session.RefreshTokenHash).
+		Updates(map[string]interface{}{
+			"refresh_token_hash": hashSHA256(newRefreshToken),
+			"access_token_id":    accessTokenId,
+			"expires_at":         time.Now().Add(RefreshTokenTTL),
+			"last_used_at":       time.Now(),
+			"ip":                 truncateSessionField(device.IP, 45),
+			"user_agent":         truncateSessionField(device.UserAgent, 255),
+		})
+	if result.Error != nil {
+		return nil, fmt.Errorf("error saving refresh token: %v", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		if err := service.RevokeSession(user.Id, session.Id); err != nil {
+			return nil, err
+		}
+		return nil, ErrRefreshTokenReused
+	}
+
+	return &TokenPair{
+		AccessToken:  accessToken,
+		RefreshToken: newRefreshToken,
+	}, nil
 }
 
-// SaveRefreshToken guarda un hash SHA-256 del refresh token en la DB
-func (service *AuthServiceImp) SaveRefreshToken(userId, refreshToken string) error {
+// ListSessions retorna las sesiones activas del usuario, la usada más recientemente primero
+func (service *AuthServiceImp) ListSessions(userId string) ([]models.Session, error) {
 	db, err := config.GetDB()
 	if err != nil {
-		return fmt.Errorf("error connecting to database: %v", err)
+		return nil, fmt.Errorf("error connecting to database: %v", err)
 	}
 
-	hashed := hashSHA256(refreshToken)
+	var sessions []models.Session
+	if err := db.Where("user_id = ? AND expires_at > ?", userId, time.Now()).
+		Order("last_used_at DESC").
+		Find(&sessions).Error; err != nil {
+		return nil, err
+	}
 
-	if err := db.Model(&models.User{}).Where("id = ?", userId).Update("refresh_token", hashed).Error; err != nil {
-		return fmt.Errorf("error saving refresh token: %v", err)
+	return sessions, nil
+}
+
+// RevokeSession cierra una sesión del usuario: su refresh token deja de servir y
+// se revoca el último access token que se emitió para ella
+func (service *AuthServiceImp) RevokeSession(userId, sessionId string) error {
+	db, err := config.GetDB()
+	if err != nil {
+		return fmt.Errorf("error connecting to database: %v", err)
 	}
 
-	return nil
+	return db.Transaction(func(tx *gorm.DB) error {
+		var session models.Session
+		if err := tx.Where("id = ? AND user_id = ?", sessionId, userId).First(&session).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrSessionNotFound
+			}
+			return err
+		}
+
+		if err := tx.Delete(&session).Error; err != nil {
+			return fmt.Errorf("error revoking session: %v", err)
+		}
+
+		// El access token vence a lo sumo ACCESS_TOKEN_TTL después del último refresh
+		return revokeAccessToken(tx, session.AccessTokenID, userId, session.LastUsedAt.Add(config.GetConfig().AccessTokenTTL))
+	})
 }
 
-// ClearRefreshToken limpia el refresh token del usuario (logout)
-func (service *AuthServiceImp) ClearRefreshToken(userId string) error {
+// RevokeUserSessions cierra todas las sesiones del usuario e invalida todos sus access tokens
+func (service *AuthServiceImp) RevokeUserSessions(userId string) error {
 	db, err := config.GetDB()
 	if err != nil {
 		return fmt.Errorf("error connecting to database: %v", err)
 	}
 
-	if err := db.Model(&models.User{}).Where("id = ?", userId).Update("refresh_token", "").Error; err != nil {
-		return fmt.Errorf("error clearing refresh token: %v", err)
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", userId).Delete(&models.Session{}).Error; err != nil {
+			return fmt.Errorf("error revoking sessions: %v", err)
+		}
+		return bumpTokenVersion(tx, userId)
+	})
+	if err != nil {
+		return err
 	}
 
+	forgetTokenVersion(userId)
+
 	return nil
 }
 
-// RefreshTokens valida el refresh token actual y genera un nuevo par de tokens (rotation)
-func (service *AuthServiceImp) RefreshTokens(refreshToken string) (*TokenPair, error) {
-	user, err := service.ValidateRefreshToken(refreshToken)
-	if err != nil {
-		return nil, err
-	}
+// IsTokenRevoked indica si el access token con el que se autenticó el usuario fue revocado
+func (service *AuthServiceImp) IsTokenRevoked(user *models.User, claims *models.AuthClaims) (bool, error) {
+	return isAccessTokenRevoked(user, claims)
+}
 
-	accessToken, err := service.GenerateToken(user)
+// RevokeAccessToken revoca el access token con el que se autenticó el usuario
+func (service *AuthServiceImp) RevokeAccessToken(user *models.User, claims *models.AuthClaims) error {
+	db, err := config.GetDB()
 	if err != nil {
-		return nil, fmt.Errorf("error generating access token: %v", err)
+		return fmt.Errorf("error connecting to database: %v", err)
 	}
 
-	newRefreshToken, err := service.GenerateRefreshToken(user)
+	return revokeAccessToken(db, claims.TokenID, user.Id, claims.TokenExpiresAt)
+}
+
+// PublicKeys retorna las claves públicas de JWT_KEYS_DIR para que otros servicios validen los tokens
+func (service *AuthServiceImp) PublicKeys() (*JWKSet, error) {
+	return JWKS()
+}
+
+// findSession busca una sesión vigente del usuario
+func (service *AuthServiceImp) findSession(userId, sessionId string) (*models.Session, error) {
+	db, err := config.GetDB()
 	if err != nil {
-		return nil, fmt.Errorf("error generating refresh token: %v", err)
+		return nil, fmt.Errorf("error connecting to database: %v", err)
 	}
 
-	if err := service.SaveRefreshToken(user.Id, newRefreshToken); err != nil {
-		return nil, fmt.Errorf("error saving refresh token: %v", err)
+	var session models.Session
+	if err := db.Where("id = ? AND user_id = ? AND expires_at > ?", sessionId, userId, time.Now()).First(&session).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrSessionNotFound
+		}
+		return nil, err
 	}
 
-	return &TokenPair{
-		AccessToken:  accessToken,
-		RefreshToken: newRefreshToken,
-	}, nil
+	return &session, nil
+}
+
+// truncateSessionField recorta un dato del dispositivo al largo de su columna
+func truncateSessionField(value string, max int) string {
+	if utf8.RuneCountInString(value) <= max {
+		return value
+	}
+	return string([]rune(value)[:max])
 }
@@ -0,0 +1,363 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"github.com/unbot2313/go-streaming-service/config"
+	"github.com/unbot2313/go-streaming-service/internal/models"
+	"gorm.io/gorm"
+)
+
+const (
+	// MaxChapters es la cantidad máxima de capítulos de un video
+	MaxChapters = 100
+	// MaxChapterTitleLength es el largo máximo del título de un capítulo
+	MaxChapterTitleLength = 100
+	// ChapterDateRangeClass es el CLASS de los EXT-X-DATERANGE de capítulos en el playlist
+	ChapterDateRangeClass = "chapter"
+
+	// maxPlaylistSize es el tamaño máximo del playlist HLS que se lee del storage
+	maxPlaylistSize = 5 * 1024 * 1024
+)
+
+var (
+	// ErrChapterExists indica que ya hay un capítulo que empieza en ese segundo
+	ErrChapterExists = errors.New("ya hay un capítulo que empieza en ese segundo")
+	// ErrTooManyChapters indica que el video ya tiene MaxChapters capítulos
+	ErrTooManyChapters = errors.New("el video alcanzó el máximo de capítulos")
+)
+
+// chapterLinePattern reconoce las líneas "00:00 Intro" o "1:02:03 - Cierre" de la descripción
+var chapterLinePattern = regexp.MustCompile(`^(?:(\d{1,2}):)?(\d{1,2}):([0-5]\d)\s+(?:[-–—]\s+)?(\S.*)$`)
+
+type ChapterService interface {
+	ListChapters(videoId string) ([]models.Chapter, error)
+	FindChapterByID(chapterId string) (*models.Chapter, error)
+	CreateChapter(chapter *models.Chapter) (*models.Chapter, error)
+	UpdateChapter(chapter *models.Chapter) (*models.Chapter, error)
+	DeleteChapter(chapterId string) error
+	ChaptersVTT(video *models.VideoModel) ([]byte, error)
+	ChaptersPlaylist(ctx context.Context, video *models.VideoModel) ([]byte, error)
+}
+
+type chapterServiceImp struct {
+	client *http.Client
+}
+
+func NewChapterService() ChapterService {
+	return &chapterServiceImp{
+		client: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// ParseChapters extrae los capítulos de las líneas de la descripción que empiezan con un
+// tiempo ("00:00 Intro", "1:02:03 Cierre"). Los retorna ordenados y sin tiempos repetidos.
+func ParseChapters(description string) []models.Chapter {
+	var chapters []models.Chapter
+	seen := make(map[float64]bool)
+
+	for _, line := range strings.Split(description, "\n") {
+		match := chapterLinePattern.FindStringSubmatch(strings.TrimSpace(line))
+		if match == nil {
+			continue
+		}
+
+		hours, _ := strconv.Atoi(match[1])
+		minutes, _ := strconv.Atoi(match[2])
+		seconds, _ := strconv.Atoi(match[3])
+		start := float64(hours*3600 + minutes*60 + seconds)
+		if seen[start] {
+			continue
+		}
+		seen[start] = true
+
+		chapters = append(chapters, models.Chapter{
+			StartSeconds: start,
+			Title:        truncateChapterTitle(strings.TrimSpace(match[4])),
+		})
+		if len(chapters) == MaxChapters {
+			break
+		}
+	}
+
+	sort.Slice(chapters, func(i, j int) bool {
+		return chapters[i].StartSeconds < chapters[j].StartSeconds
+	})
+
+	return chapters
+}
+
+// truncateChapterTitle recorta el título a MaxChapterTitleLength caracteres
+func truncateChapterTitle(title string) string {
+	if utf8.RuneCountInString(title) <= MaxChapterTitleLength {
+		return title
+	}
+	return string([]rune(title)[:MaxChapterTitleLength])
+}
+
+// replaceChaptersFromDescription reemplaza los capítulos del video por los de la descripción.
+// Si la descripción no tiene tiempos, los capítulos cargados a mano se mantienen.
+func replaceChaptersFromDescription(tx *gorm.DB, videoId, description string) error {
+	chapters := ParseChapters(description)
+	if len(chapters) == 0 {
+		return nil
+	}
+
+	if err := tx.Where("video_id = ?", videoId).Delete(&models.Chapter{}).Error; err != nil {
+		return err
+	}
+
+	for i := range chapters {
+		chapters[i].Id = uuid.New().String()
+		chapters[i].VideoID = videoId
+	}
+
+	return tx.Create(&chapters).Error
+}
+
+func (s *chapterServiceImp) ListChapters(videoId string) ([]models.Chapter, error) {
+	db, err := config.GetDB()
+	if err != nil {
+		return nil, err
+	}
+
+	chapters := []models.Chapter{}
+	if err := db.Where("video_id = ?", videoId).Order("start_seconds ASC").Find(&chapters).Error; err != nil {
+		return nil, err
+	}
+
+	return chapters, nil
+}
+
+func (s *chapterServiceImp) FindChapterByID(chapterId string) (*models.Chapter, error) {
+	db, err := config.GetDB()
+	if err != nil {
+		return nil, err
+	}
+
+	var chapter models.Chapter
+	if err := db.Where("id = ?", chapterId).First(&chapter).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("chapter with id %s not found", chapterId)
+		}
+		return nil, err
+	}
+
+	return &chapter, nil
+}
+
+func (s *chapterServiceImp) CreateChapter(chapter *models.Chapter) (*models.Chapter, error) {
+	db, err := config.GetDB()
+	if err != nil {
+		return nil, err
+	}
+
+	chapter.Id = uuid.New().String()
+
+	err = db.Transaction(func(tx *gorm.DB) error {
+		var count int64
+		if err := tx.Model(&models.Chapter{}).Where("video_id = ?", chapter.VideoID).Count(&count).Error; err != nil {
+			return err
+		}
+		if count >= MaxChapters {
+			return ErrTooManyChapters
+		}
+
+		return tx.Create(chapter).Error
+	})
+	if errors.Is(err, gorm.ErrDuplicatedKey) {
+		return nil, ErrChapterExists
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return chapter, nil
+}
+
+// UpdateChapter guarda el inicio y el título del capítulo
+func (s *chapterServiceImp) UpdateChapter(chapter *models.Chapter) (*models.Chapter, error) {
+	db, err := config.GetDB()
+	if err != nil {
+		return nil, err
+	}
+
+	err = db.Model(chapter).
+		Select("start_seconds", "title").
+		Updates(&models.Chapter{StartSeconds: chapter.StartSeconds, Title: chapter.Title}).Error
+	if errors.Is(err, gorm.ErrDuplicatedKey) {
+		return nil, ErrChapterExists
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return chapter, nil
+}
+
+func (s *chapterServiceImp) DeleteChapter(chapterId string) error {
+	db, err := config.GetDB()
+	if err != nil {
+		return err
+	}
+
+	dbCtx := db.Delete(&models.Chapter{}, "id = ?", chapterId)
+	if dbCtx.Error != nil {
+		return dbCtx.Error
+	}
+	if dbCtx.RowsAffected == 0 {
+		return fmt.Errorf("chapter with id %s not found", chapterId)
+	}
+
+	return nil
+}
+
+// chapterRange es un capítulo con su fin: el inicio del siguiente o el final del video
+type chapterRange struct {
+	chapter models.Chapter
+	end     float64
+}
+
+// chapterRanges calcula el fin de cada capítulo del video. Si no se conoce la duración,
+// el último capítulo dura un segundo.
+func (s *chapterServiceImp) chapterRanges(video *models.VideoModel) ([]chapterRange, error) {
+	chapters, err := s.ListChapters(video.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	duration := DurationSeconds(video.Duration)
+	ranges := make([]chapterRange, 0, len(chapters))
+	for i, chapter := range chapters {
+		end := duration
+		if i+1 < len(chapters) {
+			end = chapters[i+1].StartSeconds
+		}
+		if end <= chapter.StartSeconds {
+			end = chapter.StartSeconds + 1
+		}
+		ranges = append(ranges, chapterRange{chapter: chapter, end: end})
+	}
+
+	return ranges, nil
+}
+
+// ChaptersVTT arma la pista WebVTT de capítulos del video
+func (s *chapterServiceImp) ChaptersVTT(video *models.VideoModel) ([]byte, error) {
+	ranges, err := s.chapterRanges(video)
+	if err != nil {
+		return nil, err
+	}
+
+	var vtt strings.Builder
+	vtt.WriteString("WEBVTT\n")
+	for i, r := range ranges {
+		// Una línea vacía o "-->" dentro del título cortaría el cue
+		title := strings.ReplaceAll(strings.ReplaceAll(r.chapter.Title, "\n", " "), "-->", "->")
+		fmt.Fprintf(&vtt, "\n%d\n%s --> %s\n%s\n", i+1, vttTimestamp(r.chapter.StartSeconds), vttTimestamp(r.end), title)
+	}
+
+	return []byte(vtt.String()), nil
+}
+
+// uriAttributePattern es el atributo URI de un tag HLS
+var uriAttributePattern = regexp.MustCompile(`URI="([^"]*)"`)
+
+// resolveURIAttribute hace absoluto el atributo URI de un tag como #EXT-X-MEDIA
+func resolveURIAttribute(base *url.URL, line string) string {
+	return uriAttributePattern.ReplaceAllStringFunc(line, func(attribute string) string {
+		ref, err := url.Parse(uriAttributePattern.FindStringSubmatch(attribute)[1])
+		if err != nil {
+			return attribute
+		}
+		return `URI="` + base.ResolveReference(ref).String() + `"`
+	})
+}
+
+// vttTimestamp formatea segundos como hh:mm:ss.mmm
+func vttTimestamp(seconds float64) string {
+	millis := int64(seconds*1000 + 0.5)
+	return fmt.Sprintf("%02d:%02d:%02d.%03d", millis/3600000, millis/60000%60, millis/1000%60, millis%1000)
+}
+
+// ChaptersPlaylist lee el playlist HLS del video y le agrega un EXT-X-DATERANGE por
+// capítulo. Los DATERANGE se anclan con EXT-X-PROGRAM-DATE-TIME en la fecha de creación
+// del video, y las URIs de los segmentos pasan a ser absolutas porque el playlist se
+// sirve desde la API y no desde el storage. Un master playlist (videos con varias pistas
+// de audio) no admite DATERANGE: se sirve solo con las URIs absolutas.
+func (s *chapterServiceImp) ChaptersPlaylist(ctx context.Context, video *models.VideoModel) ([]byte, error) {
+	ranges, err := s.chapterRanges(video)
+	if err != nil {
+		return nil, err
+	}
+
+	base, err := url.Parse(video.VideoUrl)
+	if err != nil {
+		return nil, fmt.Errorf("URL del playlist inválida: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, video.VideoUrl, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error leyendo el playlist: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("error leyendo el playlist: status %d", resp.StatusCode)
+	}
+
+	playlist, err := io.ReadAll(io.LimitReader(resp.Body, maxPlaylistSize))
+	if err != nil {
+		return nil, fmt.Errorf("error leyendo el playlist: %w", err)
+	}
+
+	anchor := video.CreatedAt.UTC().Truncate(time.Second)
+
+	var out strings.Builder
+	inserted := false
+	for _, line := range strings.Split(strings.TrimRight(string(playlist), "\n"), "\n") {
+		line = strings.TrimRight(line, "\r")
+
+		if !inserted && strings.HasPrefix(line, "#EXTINF") {
+			fmt.Fprintf(&out, "#EXT-X-PROGRAM-DATE-TIME:%s\n", anchor.Format("2006-01-02T15:04:05.000Z07:00"))
+			for _, r := range ranges {
+				start := anchor.Add(time.Duration(r.chapter.StartSeconds * float64(time.Second)))
+				// Las comillas no se pueden escapar en un quoted-string de HLS
+				title := strings.NewReplacer(`"`, "'", "\n", " ", "\r", " ").Replace(r.chapter.Title)
+				fmt.Fprintf(&out, "#EXT-X-DATERANGE:ID=\"chapter-%s\",CLASS=\"%s\",START-DATE=\"%s\",DURATION=%.3f,X-TITLE=\"%s\"\n",
+					r.chapter.Id, ChapterDateRangeClass, start.Format("2006-01-02T15:04:05.000Z07:00"), r.end-r.chapter.StartSeconds, title)
+			}
+			inserted = true
+		}
+
+		if line != "" && !strings.HasPrefix(line, "#") {
+			if segment, err := url.Parse(line); err == nil {
+				line = base.ResolveReference(segment).String()
+			}
+		}
+		if strings.HasPrefix(line, "#EXT-X-MEDIA:") {
+			line = resolveURIAttribute(base, line)
+		}
+
+		out.WriteString(line)
+		out.WriteString("\n")
+	}
+
+	return []byte(out.String()), nil
+}
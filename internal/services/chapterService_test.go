@@ -0,0 +1,121 @@
+package services
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/unbot2313/go-streaming-service/internal/models"
+)
+
+func TestParseChapters(t *testing.T) {
+	longTitle := strings.Repeat("á", MaxChapterTitleLength+20)
+
+	tests := []struct {
+		name        string
+		description string
+		want        []models.Chapter
+	}{
+		{
+			name:        "minutes and seconds",
+			description: "00:00 Intro\n01:35 Setup\n12:05 Demo",
+			want: []models.Chapter{
+				{StartSeconds: 0, Title: "Intro"},
+				{StartSeconds: 95, Title: "Setup"},
+				{StartSeconds: 725, Title: "Demo"},
+			},
+		},
+		{
+			name:        "hours, minutes and seconds",
+			description: "0:00 Intro\n1:02:30 Q&A\n10:00:00 Cierre",
+			want: []models.Chapter{
+				{StartSeconds: 0, Title: "Intro"},
+				{StartSeconds: 3750, Title: "Q&A"},
+				{StartSeconds: 36000, Title: "Cierre"},
+			},
+		},
+		{
+			name:        "separator and surrounding text",
+			description: "Un video sobre Go.\n\n  00:00 - Intro  \n2:10 — Tests\nGracias por ver",
+			want: []models.Chapter{
+				{StartSeconds: 0, Title: "Intro"},
+				{StartSeconds: 130, Title: "Tests"},
+			},
+		},
+		{
+			name:        "first chapter not at zero",
+			description: "00:30 Setup\n01:00 Demo",
+			want: []models.Chapter{
+				{StartSeconds: 30, Title: "Setup"},
+				{StartSeconds: 60, Title: "Demo"},
+			},
+		},
+		{
+			name:        "unsorted timestamps",
+			description: "05:00 Cierre\n00:00 Intro\n02:00 Demo",
+			want: []models.Chapter{
+				{StartSeconds: 0, Title: "Intro"},
+				{StartSeconds: 120, Title: "Demo"},
+				{StartSeconds: 300, Title: "Cierre"},
+			},
+		},
+		{
+			name:        "duplicate timestamps keep the first one",
+			description: "00:00 Intro\n01:00 Demo\n1:00 Repetido\n0:01:00 Otro",
+			want: []models.Chapter{
+				{StartSeconds: 0, Title: "Intro"},
+				{StartSeconds: 60, Title: "Demo"},
+			},
+		},
+		{
+			name:        "title too long is truncated",
+			description: "00:00 " + longTitle,
+			want: []models.Chapter{
+				{StartSeconds: 0, Title: strings.Repeat("á", MaxChapterTitleLength)},
+			},
+		},
+		{
+			name:        "invalid timestamps are ignored",
+			description: "00:60 Segundos de más\n123:00 Minutos de más\n00:00\nhttps://example.com 00:10 Link",
+			want:        nil,
+		},
+		{
+			name:        "no timestamps",
+			description: "Un video sin capítulos",
+			want:        nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ParseChapters(tt.description)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("expected %+v, got %+v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestParseChapters_MaxChapters(t *testing.T) {
+	var lines []string
+	for i := 0; i < MaxChapters+10; i++ {
+		lines = append(lines, fmt.Sprintf("%d:%02d:%02d Capítulo %d", i/3600, i/60%60, i%60, i))
+	}
+
+	chapters := ParseChapters(strings.Join(lines, "\n"))
+	if len(chapters) != MaxChapters {
+		t.Fatalf("expected %d chapters, got %d", MaxChapters, len(chapters))
+	}
+	if last := chapters[len(chapters)-1]; last.StartSeconds != MaxChapters-1 {
+		t.Errorf("expected the last chapter at %d, got %v", MaxChapters-1, last.StartSeconds)
+	}
+}
+
+func TestTruncateChapterTitle(t *testing.T) {
+	title := truncateChapterTitle(strings.Repeat("ñ", MaxChapterTitleLength+1))
+	if !utf8.ValidString(title) || utf8.RuneCountInString(title) != MaxChapterTitleLength {
+		t.Errorf("expected %d valid runes, got %q", MaxChapterTitleLength, title)
+	}
+}
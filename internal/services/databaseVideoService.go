@@ -3,6 +3,7 @@ package services
 import (
 	"errors"
 	"fmt"
+	"time"
 
 	"github.com/unbot2313/go-streaming-service/config"
 	"github.com/unbot2313/go-streaming-service/internal/models"
@@ -27,6 +28,12 @@ type DatabaseVideoService interface {
 	UpdateVideo(video *models.VideoModel) (*models.VideoModel, error)
 	DeleteVideo(videoId string) error
 	SearchVideos(query string, page, pageSize int) (*PaginatedVideos, error)
+	PublishDueVideos(limit int) ([]models.VideoModel, error)
+}
+
+// publishedVideosScope filtra los videos ya publicados: los programados y los suspendidos no aparecen en los listados
+func publishedVideosScope(db *gorm.DB) *gorm.DB {
+	return db.Where("videos.published_at IS NOT NULL AND videos.suspended_at IS NULL")
 }
 
 func NewDatabaseVideoService() DatabaseVideoService {
@@ -40,14 +47,14 @@ func (service *databaseVideoService) FindLatestVideos(page, pageSize int) (*Pagi
 	}
 
 	var total int64
-	if err := db.Model(&models.VideoModel{}).Count(&total).Error; err != nil {
+	if err := db.Model(&models.VideoModel{}).Scopes(publishedVideosScope).Count(&total).Error; err != nil {
 		return nil, err
 	}
 
 	var videos []*models.VideoModel
 	offset := (page - 1) * pageSize
 
-	dbCtx := db.Order("created_at DESC").Limit(pageSize).Offset(offset).Find(&videos)
+	dbCtx := db.Scopes(publishedVideosScope).Order("created_at DESC").Limit(pageSize).Offset(offset).Find(&videos)
 
 	if dbCtx.Error != nil {
 		return nil, dbCtx.Error
@@ -135,16 +142,34 @@ func (service *databaseVideoService) FindUserVideos(userId string) ([]*models.Vi
 	return videos, nil
 }
 
+// CreateVideo guarda el video procesado y encola la generación de su thumbnail y storyboard.
+// Los tiempos de la descripción ("00:00 Intro") se guardan como capítulos.
+// Si tiene una publicación programada a futuro queda oculto hasta que el scheduler lo publique.
 func (service *databaseVideoService) CreateVideo(videoData *models.Video, userId string) (*models.VideoModel, error) {
 
 	Video := models.VideoModel{
-		Id:           videoData.Id,
-		Title:        videoData.Title,
-		Description:  videoData.Description,
-		UserID:       userId,
-		VideoUrl:     videoData.M3u8FileURL,
-		Duration:     videoData.Duration,
-		ThumbnailURL: videoData.ThumbnailURL,
+		Id:              videoData.Id,
+		Title:           videoData.Title,
+		Description:     videoData.Description,
+		UserID:          userId,
+		VideoUrl:        videoData.M3u8FileURL,
+		Duration:        videoData.Duration,
+		ThumbnailURL:    videoData.ThumbnailURL,
+		PublishAt:       videoData.PublishAt,
+		EncodingProfile: videoData.EncodingProfile,
+		MediaType:       videoData.MediaType,
+		Loudness:        videoData.Loudness,
+		SourceVideoID:   videoData.SourceVideoID,
+		Encrypted:       videoData.Encrypted,
+	}
+
+	if Video.MediaType == "" {
+		Video.MediaType = models.MediaTypeVideo
+	}
+
+	now := time.Now()
+	if Video.PublishAt == nil || !Video.PublishAt.After(now) {
+		Video.PublishedAt = &now
 	}
 
 	db, err := config.GetDB()
@@ -153,19 +178,43 @@ func (service *databaseVideoService) CreateVideo(videoData *models.Video, userId
 		return nil, err
 	}
 
-	dbCtx := db.Create(&Video)
+	// El video y sus tareas de thumbnail/storyboard se guardan en la misma transacción
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&Video).Error; err != nil {
+			return err
+		}
+
+		if err := replaceChaptersFromDescription(tx, Video.Id, Video.Description); err != nil {
+			return err
+		}
+
+		if Video.PublishedAt != nil {
+			if err := dispatchWebhookEvent(tx, Video.UserID, models.WebhookEventVideoPublished, videoPublishedData(&Video)); err != nil {
+				return err
+			}
+		}
+
+		tasks, err := videoThumbnailTasks(tx, &Video)
+		if err != nil {
+			return err
+		}
+
+		return enqueueThumbnailTasks(tx, tasks)
+	})
 
-	if errors.Is(dbCtx.Error, gorm.ErrDuplicatedKey) {
+	if errors.Is(err, gorm.ErrDuplicatedKey) {
 		return nil, fmt.Errorf("ya hay un video con el id %s", videoData.Id)
 	}
 
-	if dbCtx.Error != nil {
-		return nil, dbCtx.Error
+	if err != nil {
+		return nil, err
 	}
 
 	return &Video, nil
 }
 
+// UpdateVideo actualiza título, descripción y publicación programada.
+// Un publish_at futuro oculta el video hasta esa fecha; uno pasado lo publica en el momento.
 func (service *databaseVideoService) UpdateVideo(video *models.VideoModel) (*models.VideoModel, error) {
 	db, err := config.GetDB()
 	if err != nil {
@@ -180,32 +229,136 @@ func (service *databaseVideoService) UpdateVideo(video *models.VideoModel) (*mod
 		return nil, err
 	}
 
-	if err := db.Model(&existing).Updates(map[string]interface{}{
+	updates := map[string]interface{}{
 		"title":       video.Title,
 		"description": video.Description,
-	}).Error; err != nil {
+	}
+
+	publishNow := false
+	if video.PublishAt != nil {
+		updates["publish_at"] = video.PublishAt
+		now := time.Now()
+		if video.PublishAt.After(now) {
+			updates["published_at"] = nil
+		} else if existing.PublishedAt == nil {
+			updates["published_at"] = now
+			publishNow = true
+		}
+	}
+
+	err = db.Transaction(func(tx *gorm.DB) error {
+		descriptionChanged := existing.Description != video.Description
+
+		if err := tx.Model(&existing).Updates(updates).Error; err != nil {
+			return err
+		}
+
+		// Los tiempos de la nueva descripción reemplazan los capítulos
+		if descriptionChanged {
+			if err := replaceChaptersFromDescription(tx, existing.Id, video.Description); err != nil {
+				return err
+			}
+		}
+
+		if !publishNow {
+			return nil
+		}
+
+		return dispatchWebhookEvent(tx, existing.UserID, models.WebhookEventVideoPublished, videoPublishedData(&existing))
+	})
+	if err != nil {
 		return nil, err
 	}
 
 	return &existing, nil
 }
 
-func (service *databaseVideoService) DeleteVideo(videoId string) error {
+// PublishDueVideos publica hasta limit videos cuya fecha programada ya pasó y notifica
+// video.published a los webhooks. Cada video se publica con un update condicional,
+// así varios workers pueden correr el scheduler sin publicar dos veces el mismo video.
+func (service *databaseVideoService) PublishDueVideos(limit int) ([]models.VideoModel, error) {
 	db, err := config.GetDB()
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	result := db.Where("id = ?", videoId).Delete(&models.VideoModel{})
-	if result.Error != nil {
-		return result.Error
+	var due []models.VideoModel
+	if err := db.Where("published_at IS NULL AND publish_at <= ?", time.Now()).
+		Order("publish_at ASC").
+		Limit(limit).
+		Find(&due).Error; err != nil {
+		return nil, err
 	}
 
-	if result.RowsAffected == 0 {
-		return fmt.Errorf("video with id %s not found", videoId)
+	var published []models.VideoModel
+
+	for _, video := range due {
+		claimed := false
+		err := db.Transaction(func(tx *gorm.DB) error {
+			now := time.Now()
+			dbCtx := tx.Model(&models.VideoModel{}).
+				Where("id = ? AND published_at IS NULL", video.Id).
+				Update("published_at", now)
+			if dbCtx.Error != nil {
+				return dbCtx.Error
+			}
+
+			// Otro worker ya lo publicó o el dueño lo reprogramó
+			if dbCtx.RowsAffected == 0 {
+				return nil
+			}
+			claimed = true
+			video.PublishedAt = &now
+
+			return dispatchWebhookEvent(tx, video.UserID, models.WebhookEventVideoPublished, videoPublishedData(&video))
+		})
+		if err != nil {
+			return published, err
+		}
+
+		if claimed {
+			published = append(published, video)
+		}
 	}
 
-	return nil
+	return published, nil
+}
+
+// videoPublishedData son los datos del evento video.published
+func videoPublishedData(video *models.VideoModel) map[string]interface{} {
+	return map[string]interface{}{
+		"video_id":     video.Id,
+		"title":        video.Title,
+		"publish_at":   video.PublishAt,
+		"published_at": video.PublishedAt,
+	}
+}
+
+func (service *databaseVideoService) DeleteVideo(videoId string) error {
+	db, err := config.GetDB()
+	if err != nil {
+		return err
+	}
+
+	// El borrado y la notificación video.deleted se guardan en la misma transacción
+	return db.Transaction(func(tx *gorm.DB) error {
+		var video models.VideoModel
+		if err := tx.Where("id = ?", videoId).First(&video).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return fmt.Errorf("video with id %s not found", videoId)
+			}
+			return err
+		}
+
+		if err := tx.Delete(&video).Error; err != nil {
+			return err
+		}
+
+		return dispatchWebhookEvent(tx, video.UserID, models.WebhookEventVideoDeleted, map[string]interface{}{
+			"video_id": video.Id,
+			"title":    video.Title,
+		})
+	})
 }
 
 func (service *databaseVideoService) SearchVideos(query string, page, pageSize int) (*PaginatedVideos, error) {
@@ -218,6 +371,7 @@ func (service *databaseVideoService) SearchVideos(query string, page, pageSize i
 
 	var total int64
 	if err := db.Model(&models.VideoModel{}).
+		Scopes(publishedVideosScope).
 		Where("title ILIKE ? OR description ILIKE ?", search, search).
 		Count(&total).Error; err != nil {
 		return nil, err
@@ -226,7 +380,8 @@ func (service *databaseVideoService) SearchVideos(query string, page, pageSize i
 	var videos []*models.VideoModel
 	offset := (page - 1) * pageSize
 
-	if err := db.Where("title ILIKE ? OR description ILIKE ?", search, search).
+	if err := db.Scopes(publishedVideosScope).
+		Where("title ILIKE ? OR description ILIKE ?", search, search).
 		Order("created_at DESC").
 		Limit(pageSize).
 		Offset(offset).
@@ -0,0 +1,85 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/unbot2313/go-streaming-service/config"
+	"github.com/unbot2313/go-streaming-service/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrEncodingProfileNotFound indica que el upload pidió un perfil de codificación que no existe
+var ErrEncodingProfileNotFound = errors.New("el perfil de codificación no existe")
+
+type EncodingProfileService interface {
+	ListProfiles() ([]models.EncodingProfile, error)
+	GetProfile(name string) (*models.EncodingProfile, error)
+	SaveProfile(profile *models.EncodingProfile) (*models.EncodingProfile, error)
+}
+
+type encodingProfileServiceImp struct{}
+
+func NewEncodingProfileService() EncodingProfileService {
+	return &encodingProfileServiceImp{}
+}
+
+// findEncodingProfile busca un perfil por nombre; un nombre vacío usa DefaultEncodingProfile
+func findEncodingProfile(tx *gorm.DB, name string) (*models.EncodingProfile, error) {
+	if name == "" {
+		name = models.DefaultEncodingProfile
+	}
+
+	var profile models.EncodingProfile
+	if err := tx.Where("name = ?", name).First(&profile).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("%w: %s", ErrEncodingProfileNotFound, name)
+		}
+		return nil, err
+	}
+
+	return &profile, nil
+}
+
+func (s *encodingProfileServiceImp) ListProfiles() ([]models.EncodingProfile, error) {
+	db, err := config.GetDB()
+	if err != nil {
+		return nil, err
+	}
+
+	var profiles []models.EncodingProfile
+	if err := db.Order("name ASC").Find(&profiles).Error; err != nil {
+		return nil, err
+	}
+
+	return profiles, nil
+}
+
+// GetProfile busca un perfil por nombre; un nombre vacío retorna el perfil por defecto
+func (s *encodingProfileServiceImp) GetProfile(name string) (*models.EncodingProfile, error) {
+	db, err := config.GetDB()
+	if err != nil {
+		return nil, err
+	}
+
+	return findEncodingProfile(db, name)
+}
+
+// SaveProfile crea el perfil o actualiza sus opciones si ya existe.
+// Los videos ya procesados no cambian; el perfil se aplica a los próximos uploads.
+func (s *encodingProfileServiceImp) SaveProfile(profile *models.EncodingProfile) (*models.EncodingProfile, error) {
+	db, err := config.GetDB()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "name"}},
+		DoUpdates: clause.AssignmentColumns([]string{"description", "preview", "loudnorm", "updated_at"}),
+	}).Create(profile).Error; err != nil {
+		return nil, err
+	}
+
+	return findEncodingProfile(db, profile.Name)
+}
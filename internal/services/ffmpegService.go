@@ -8,42 +8,322 @@ import (
 	"os/exec"
 	"path/filepath"
 	"strconv"
+	"strings"
 	"time"
+
+	"github.com/unbot2313/go-streaming-service/config"
+	"github.com/unbot2313/go-streaming-service/internal/models"
 )
 
 // FFmpegService define la interfaz para operaciones de ffmpeg/ffprobe
 type FFmpegService interface {
-	ConvertToHLS(ctx context.Context, inputPath, outputDir string) (string, error)
+	ConvertToHLS(ctx context.Context, inputPath, outputDir string, options HLSOptions) (string, error)
+	ConvertAudioToHLS(ctx context.Context, inputPath, outputDir string, loudness *LoudnessMeasurement) (string, error)
+	MeasureLoudness(ctx context.Context, inputPath string) (*LoudnessMeasurement, error)
+	ProbeAudioStreams(ctx context.Context, inputPath string) ([]AudioStream, error)
+	CutClip(ctx context.Context, sourceURL, outputPath string, start, end float64, accurate bool) error
 	ExtractDuration(ctx context.Context, videoPath string) (string, error)
-	GenerateThumbnail(ctx context.Context, videoPath, outputDir string) (string, error)
+	GenerateThumbnail(ctx context.Context, videoPath, outputDir string, at float64) (string, error)
+	GenerateStoryboard(ctx context.Context, videoPath, outputDir string, durationSeconds float64) (string, error)
+	GeneratePreview(ctx context.Context, videoPath, outputDir string, durationSeconds float64) (string, error)
+	ResizeImage(ctx context.Context, imagePath, outputPath string, width, height int) error
+	GenerateWaveform(ctx context.Context, audioPath, outputDir string) (string, error)
+	ExtractAudioFile(ctx context.Context, audioPath, outputDir string) (string, error)
+}
+
+const (
+	// StoryboardColumns y StoryboardRows definen la grilla de frames del storyboard
+	StoryboardColumns = 5
+	StoryboardRows    = 5
+
+	// PreviewSegments y PreviewSegmentSeconds definen los fragmentos del video
+	// que forman la vista previa animada (4 fragmentos de 1.5s)
+	PreviewSegments       = 4
+	PreviewSegmentSeconds = 1.5
+
+	// LoudnormTargetI, LoudnormTargetTP y LoudnormTargetLRA son los objetivos de loudnorm:
+	// sonoridad integrada (LUFS), pico real (dBTP) y rango de sonoridad (LU)
+	LoudnormTargetI   = -16.0
+	LoudnormTargetTP  = -1.5
+	LoudnormTargetLRA = 11.0
+
+	// MuxedPlaylistName es el playlist HLS con el video y su audio en los mismos segmentos
+	MuxedPlaylistName = "output.m3u8"
+	// VideoPlaylistName es el playlist solo de video cuando las pistas de audio van por separado
+	VideoPlaylistName = "video.m3u8"
+	// AudioTrackBitrate es el bitrate AAC de cada pista de audio alternativa
+	AudioTrackBitrate = 128000
+)
+
+// AudioStream es un stream de audio del archivo fuente detectado por ffprobe
+type AudioStream struct {
+	// Index es la posición entre los streams de audio (0:a:<Index>)
+	Index    int
+	Language string
+	Title    string
+}
+
+// AudioPlaylistName es el playlist de la pista de audio separada con ese índice
+func AudioPlaylistName(index int) string {
+	return fmt.Sprintf("audio_%d.m3u8", index)
+}
+
+// LoudnessMeasurement es el resultado de la primera pasada de loudnorm. La segunda
+// pasada usa estos valores para aplicar una normalización lineal en vez de dinámica.
+type LoudnessMeasurement struct {
+	InputI       string `json:"input_i"`
+	InputTP      string `json:"input_tp"`
+	InputLRA     string `json:"input_lra"`
+	InputThresh  string `json:"input_thresh"`
+	TargetOffset string `json:"target_offset"`
+}
+
+// Loudness retorna la sonoridad integrada y el pico real medidos del archivo original
+func (m *LoudnessMeasurement) Loudness() models.Loudness {
+	var loudness models.Loudness
+	if integrated, err := strconv.ParseFloat(m.InputI, 64); err == nil {
+		loudness.Integrated = &integrated
+	}
+	if truePeak, err := strconv.ParseFloat(m.InputTP, 64); err == nil {
+		loudness.TruePeak = &truePeak
+	}
+	return loudness
+}
+
+// HLSOptions son los pasos opcionales al convertir un video a HLS. Sin ninguno
+// se copian los streams sin recodificar.
+type HLSOptions struct {
+	// Loudness es la medición de la primera pasada de loudnorm; con ella se normaliza el audio
+	Loudness *LoudnessMeasurement
+	// Watermark es la marca de agua que se graba sobre el video (requiere recodificarlo)
+	Watermark *models.Watermark
+	// AudioStreams son los streams de audio del archivo; con más de uno cada pista se
+	// transcodifica a su propio playlist (AudioPlaylistName) y el video va sin audio en VideoPlaylistName
+	AudioStreams []AudioStream
+	// Encryption cifra los segmentos con AES-128 al terminar la transcodificación
+	Encryption *HLSEncryption
+}
+
+// watermarkPositions son las coordenadas del overlay para cada posición, con un margen
+// del 3% del alto del video. W y H son el tamaño del video; w y h, el de la marca de agua.
+var watermarkPositions = map[string]string{
+	models.WatermarkTopLeft:     "x=H*0.03:y=H*0.03",
+	models.WatermarkTopRight:    "x=W-w-H*0.03:y=H*0.03",
+	models.WatermarkBottomLeft:  "x=H*0.03:y=H-h-H*0.03",
+	models.WatermarkBottomRight: "x=W-w-H*0.03:y=H-h-H*0.03",
+	models.WatermarkCenter:      "x=(W-w)/2:y=(H-h)/2",
+}
+
+// watermarkFilter arma el filter_complex que escala el PNG (entrada 1) a una fracción
+// del ancho del video (entrada 0), le aplica la opacidad y lo superpone en [v]
+func watermarkFilter(watermark *models.Watermark) string {
+	position, ok := watermarkPositions[watermark.Position]
+	if !ok {
+		position = watermarkPositions[models.WatermarkBottomRight]
+	}
+
+	return fmt.Sprintf(
+		"[1:v]format=rgba,colorchannelmixer=aa=%g[wm];[wm][0:v]scale2ref=w=main_w*%g:h=ow/a[logo][base];[base][logo]overlay=%s:format=auto,format=yuv420p[v]",
+		watermark.Opacity, watermark.Scale, position,
+	)
+}
+
+// valid indica si los cinco valores medidos son números finitos; si falta alguno o es
+// -inf (un audio en silencio) no se puede usar la normalización lineal
+func (m *LoudnessMeasurement) valid() bool {
+	for _, value := range []string{m.InputI, m.InputTP, m.InputLRA, m.InputThresh, m.TargetOffset} {
+		number, err := strconv.ParseFloat(value, 64)
+		if err != nil || math.IsInf(number, 0) || math.IsNaN(number) {
+			return false
+		}
+	}
+	return true
+}
+
+// loudnormFilter arma el filtro loudnorm con los objetivos y, en la segunda pasada, lo medido.
+// Una medición inválida no se interpola en el filtro: se normaliza en una pasada, en modo dinámico.
+func loudnormFilter(measured *LoudnessMeasurement) string {
+	filter := fmt.Sprintf("loudnorm=I=%g:TP=%g:LRA=%g", LoudnormTargetI, LoudnormTargetTP, LoudnormTargetLRA)
+	if measured == nil {
+		return filter + ":print_format=json"
+	}
+	if !measured.valid() {
+		return filter
+	}
+
+	return filter + fmt.Sprintf(":measured_I=%s:measured_TP=%s:measured_LRA=%s:measured_thresh=%s:offset=%s:linear=true",
+		measured.InputI, measured.InputTP, measured.InputLRA, measured.InputThresh, measured.TargetOffset)
+}
+
+// inputArgs arma la entrada de ffmpeg para path. Un playlist local (la copia de
+// HLSKeyService.PrepareSource) apunta a segmentos en el storage y a claves locales,
+// así que necesita que ffmpeg acepte esos protocolos desde un archivo.
+func inputArgs(path string) []string {
+	if strings.HasSuffix(path, ".m3u8") && !strings.HasPrefix(path, "http://") && !strings.HasPrefix(path, "https://") {
+		return []string{"-protocol_whitelist", "file,crypto,data,http,https,tcp,tls", "-i", path}
+	}
+	return []string{"-i", path}
 }
 
 type ffmpegServiceImp struct {
-	hlsTimeout       time.Duration
-	thumbnailTimeout time.Duration
-	probeTimeout     time.Duration
+	threads           int
+	hlsTimeout        time.Duration
+	thumbnailTimeout  time.Duration
+	storyboardTimeout time.Duration
+	previewTimeout    time.Duration
+	probeTimeout      time.Duration
 }
 
 // NewFFmpegService crea una nueva instancia del servicio FFmpeg
 func NewFFmpegService() FFmpegService {
 	return &ffmpegServiceImp{
-		hlsTimeout:       10 * time.Minute,
-		thumbnailTimeout: 30 * time.Second,
-		probeTimeout:     15 * time.Second,
+		threads:           config.GetConfig().FFmpegThreads,
+		hlsTimeout:        10 * time.Minute,
+		thumbnailTimeout:  30 * time.Second,
+		storyboardTimeout: 5 * time.Minute,
+		previewTimeout:    5 * time.Minute,
+		probeTimeout:      15 * time.Second,
 	}
 }
 
-// ConvertToHLS convierte un video a formato HLS usando ffmpeg
+// ConvertToHLS convierte un video a formato HLS usando ffmpeg. Sin opciones copia los
+// streams; con una marca de agua recodifica el video con el overlay y con loudness
+// recodifica el audio con la segunda pasada de loudnorm.
 // Retorna la ruta de la carpeta con los archivos generados
-func (f *ffmpegServiceImp) ConvertToHLS(ctx context.Context, inputPath, outputDir string) (string, error) {
+func (f *ffmpegServiceImp) ConvertToHLS(ctx context.Context, inputPath, outputDir string, options HLSOptions) (string, error) {
 	ctx, cancel := context.WithTimeout(ctx, f.hlsTimeout)
 	defer cancel()
 
-	outputPath := filepath.Join(outputDir, "output.m3u8")
+	cmd := exec.CommandContext(ctx, "ffmpeg", convertToHLSArgs(inputPath, outputDir, options, f.threads)...)
 
-	cmd := exec.CommandContext(ctx, "ffmpeg",
+	output, err := cmd.CombinedOutput()
+	if ctx.Err() == context.DeadlineExceeded {
+		return "", fmt.Errorf("ffmpeg HLS timeout después de %v", f.hlsTimeout)
+	}
+	if err != nil {
+		return "", fmt.Errorf("ffmpeg HLS error: %w, output: %s", err, string(output))
+	}
+
+	if options.Encryption != nil {
+		if err := EncryptHLS(outputDir, options.Encryption); err != nil {
+			return "", fmt.Errorf("error cifrando los segmentos: %w", err)
+		}
+	}
+
+	return outputDir, nil
+}
+
+// convertToHLSArgs arma los argumentos de ffmpeg de ConvertToHLS
+func convertToHLSArgs(inputPath, outputDir string, options HLSOptions, threads int) []string {
+	args := []string{"-i", inputPath}
+	if options.Watermark != nil {
+		args = append(args, "-i", options.Watermark.ImageURL)
+	}
+	args = append(args, "-threads", strconv.Itoa(threads))
+
+	if options.Watermark != nil {
+		args = append(args, "-filter_complex", watermarkFilter(options.Watermark), "-map", "[v]")
+		if len(options.AudioStreams) <= 1 {
+			args = append(args, "-map", "0:a?")
+		}
+		args = append(args,
+			"-c:v", "libx264",
+			"-preset", "veryfast",
+			"-crf", "23",
+		)
+	} else {
+		args = append(args, "-c:v", "copy")
+	}
+
+	if len(options.AudioStreams) > 1 {
+		args = append(args, separateAudioArgs(outputDir, options, threads)...)
+	} else {
+		if options.Loudness != nil {
+			args = append(args,
+				"-af", loudnormFilter(options.Loudness),
+				"-c:a", "aac",
+				"-b:a", "128k",
+				"-ar", "48000",
+			)
+		} else {
+			args = append(args, "-c:a", "copy")
+		}
+
+		args = append(args,
+			"-start_number", "0",
+			"-hls_time", "10",
+			"-hls_list_size", "0",
+			"-f", "hls",
+			filepath.Join(outputDir, MuxedPlaylistName),
+		)
+	}
+
+	return args
+}
+
+// separateAudioArgs arma las salidas de ConvertToHLS cuando el archivo trae varias pistas
+// de audio: el video sin audio en VideoPlaylistName y cada pista en su propio playlist AAC.
+// La medición de loudnorm es de la primera pista y solo se aplica a ella. -threads vale
+// por salida, así que cada pista lo repite.
+func separateAudioArgs(outputDir string, options HLSOptions, threads int) []string {
+	var args []string
+	if options.Watermark == nil {
+		args = append(args, "-map", "0:v:0")
+	}
+	args = append(args, "-an")
+	args = append(args, hlsOutputArgs(outputDir, VideoPlaylistName, "video_%d.ts")...)
+
+	for _, stream := range options.AudioStreams {
+		args = append(args, "-map", fmt.Sprintf("0:a:%d", stream.Index), "-threads", strconv.Itoa(threads))
+		if stream.Index == 0 && options.Loudness != nil {
+			args = append(args, "-af", loudnormFilter(options.Loudness))
+		}
+		args = append(args,
+			"-c:a", "aac",
+			"-b:a", strconv.Itoa(AudioTrackBitrate),
+			"-ar", "48000",
+			"-ac", "2",
+		)
+		args = append(args, hlsOutputArgs(outputDir, AudioPlaylistName(stream.Index), fmt.Sprintf("audio_%d_%%d.ts", stream.Index))...)
+	}
+
+	return args
+}
+
+// hlsOutputArgs son las opciones de una salida HLS VOD con segmentos de 10s
+func hlsOutputArgs(outputDir, playlistName, segmentPattern string) []string {
+	return []string{
+		"-start_number", "0",
+		"-hls_time", "10",
+		"-hls_list_size", "0",
+		"-hls_segment_filename", filepath.Join(outputDir, segmentPattern),
+		"-f", "hls",
+		filepath.Join(outputDir, playlistName),
+	}
+}
+
+// ConvertAudioToHLS convierte un audio (mp3, m4a, wav, flac) a HLS solo de audio en AAC,
+// normalizado con la segunda pasada de loudnorm si loudness no es nil
+// Retorna la ruta de la carpeta con los archivos generados
+func (f *ffmpegServiceImp) ConvertAudioToHLS(ctx context.Context, inputPath, outputDir string, loudness *LoudnessMeasurement) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, f.hlsTimeout)
+	defer cancel()
+
+	outputPath := filepath.Join(outputDir, MuxedPlaylistName)
+
+	// -vn descarta la carátula que traen muchos mp3 y m4a como stream de video
+	args := []string{
 		"-i", inputPath,
-		"-c", "copy",
+		"-threads", strconv.Itoa(f.threads),
+		"-vn",
+	}
+	if loudness != nil {
+		args = append(args, "-af", loudnormFilter(loudness), "-ar", "48000")
+	}
+	args = append(args,
+		"-c:a", "aac",
+		"-b:a", "128k",
+		"-ac", "2",
 		"-start_number", "0",
 		"-hls_time", "10",
 		"-hls_list_size", "0",
@@ -51,17 +331,187 @@ func (f *ffmpegServiceImp) ConvertToHLS(ctx context.Context, inputPath, outputDi
 		outputPath,
 	)
 
+	cmd := exec.CommandContext(ctx, "ffmpeg", args...)
+
 	output, err := cmd.CombinedOutput()
 	if ctx.Err() == context.DeadlineExceeded {
-		return "", fmt.Errorf("ffmpeg HLS timeout después de %v", f.hlsTimeout)
+		return "", fmt.Errorf("ffmpeg audio HLS timeout después de %v", f.hlsTimeout)
 	}
 	if err != nil {
-		return "", fmt.Errorf("ffmpeg HLS error: %w, output: %s", err, string(output))
+		return "", fmt.Errorf("ffmpeg audio HLS error: %w, output: %s", err, string(output))
 	}
 
 	return outputDir, nil
 }
 
+// CutClip recorta de start a end segundos del video fuente (su playlist HLS) a outputPath.
+// Sin accurate copia los streams y el corte empieza en el keyframe anterior a start;
+// con accurate recodifica para cortar en el frame exacto. Un outputPath .m4a recorta solo el audio.
+func (f *ffmpegServiceImp) CutClip(ctx context.Context, sourceURL, outputPath string, start, end float64, accurate bool) error {
+	ctx, cancel := context.WithTimeout(ctx, f.hlsTimeout)
+	defer cancel()
+
+	audioOnly := strings.ToLower(filepath.Ext(outputPath)) == ".m4a"
+
+	args := []string{"-ss", strconv.FormatFloat(start, 'f', 3, 64)}
+	args = append(args, inputArgs(sourceURL)...)
+	args = append(args,
+		"-t", strconv.FormatFloat(end-start, 'f', 3, 64),
+		"-threads", strconv.Itoa(f.threads),
+	)
+	if audioOnly {
+		args = append(args, "-vn", "-map", "0:a")
+	} else {
+		args = append(args, "-map", "0:v", "-map", "0:a?")
+	}
+
+	switch {
+	case !accurate:
+		args = append(args, "-c", "copy", "-avoid_negative_ts", "make_zero")
+	case audioOnly:
+		args = append(args, "-c:a", "aac", "-b:a", "128k")
+	default:
+		args = append(args,
+			"-c:v", "libx264",
+			"-preset", "veryfast",
+			"-crf", "20",
+			"-c:a", "aac",
+			"-b:a", "128k",
+		)
+	}
+	args = append(args, "-movflags", "+faststart", "-y", outputPath)
+
+	cmd := exec.CommandContext(ctx, "ffmpeg", args...)
+
+	output, err := cmd.CombinedOutput()
+	if ctx.Err() == context.DeadlineExceeded {
+		return fmt.Errorf("ffmpeg clip timeout después de %v", f.hlsTimeout)
+	}
+	if err != nil {
+		return fmt.Errorf("ffmpeg clip error: %w, output: %s", err, string(output))
+	}
+
+	return nil
+}
+
+// MeasureLoudness corre la primera pasada de loudnorm (EBU R128) sobre el audio del archivo.
+// Retorna nil sin error si el archivo no tiene audio o es silencio, porque no hay nada que normalizar.
+func (f *ffmpegServiceImp) MeasureLoudness(ctx context.Context, inputPath string) (*LoudnessMeasurement, error) {
+	hasAudio, err := f.hasAudioStream(ctx, inputPath)
+	if err != nil {
+		return nil, err
+	}
+	if !hasAudio {
+		return nil, nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, f.hlsTimeout)
+	defer cancel()
+
+	// Se mide la primera pista: con varias pistas es la única que se normaliza
+	cmd := exec.CommandContext(ctx, "ffmpeg",
+		"-hide_banner",
+		"-nostats",
+		"-i", inputPath,
+		"-threads", strconv.Itoa(f.threads),
+		"-map", "0:a:0",
+		"-af", loudnormFilter(nil),
+		"-f", "null",
+		"-",
+	)
+
+	output, err := cmd.CombinedOutput()
+	if ctx.Err() == context.DeadlineExceeded {
+		return nil, fmt.Errorf("ffmpeg loudnorm timeout después de %v", f.hlsTimeout)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("ffmpeg loudnorm error: %w, output: %s", err, string(output))
+	}
+
+	return parseLoudnessOutput(string(output))
+}
+
+// parseLoudnessOutput lee la medición que loudnorm imprime en JSON al final de la salida.
+// Retorna nil si no se puede normalizar: un audio en silencio mide -inf.
+func parseLoudnessOutput(text string) (*LoudnessMeasurement, error) {
+	start := strings.LastIndex(text, "{")
+	end := strings.LastIndex(text, "}")
+	if start == -1 || end < start {
+		return nil, fmt.Errorf("ffmpeg loudnorm no imprimió la medición, output: %s", text)
+	}
+
+	var measurement LoudnessMeasurement
+	if err := json.Unmarshal([]byte(text[start:end+1]), &measurement); err != nil {
+		return nil, fmt.Errorf("error parseando la medición de loudnorm: %w", err)
+	}
+
+	if !measurement.valid() {
+		return nil, nil
+	}
+
+	return &measurement, nil
+}
+
+// hasAudioStream indica si el archivo tiene al menos un stream de audio
+func (f *ffmpegServiceImp) hasAudioStream(ctx context.Context, inputPath string) (bool, error) {
+	streams, err := f.ProbeAudioStreams(ctx, inputPath)
+	if err != nil {
+		return false, err
+	}
+
+	return len(streams) > 0, nil
+}
+
+// ProbeAudioStreams lista los streams de audio del archivo con su idioma (tag language,
+// "und" si no tiene) y su título, en el orden en que ffmpeg los numera
+func (f *ffmpegServiceImp) ProbeAudioStreams(ctx context.Context, inputPath string) ([]AudioStream, error) {
+	ctx, cancel := context.WithTimeout(ctx, f.probeTimeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, "ffprobe",
+		"-v", "quiet",
+		"-select_streams", "a",
+		"-show_entries", "stream=index:stream_tags=language,title",
+		"-of", "json",
+		inputPath,
+	)
+
+	output, err := cmd.Output()
+	if ctx.Err() == context.DeadlineExceeded {
+		return nil, fmt.Errorf("ffprobe timeout después de %v", f.probeTimeout)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("ffprobe error: %w", err)
+	}
+
+	var probe struct {
+		Streams []struct {
+			Tags struct {
+				Language string `json:"language"`
+				Title    string `json:"title"`
+			} `json:"tags"`
+		} `json:"streams"`
+	}
+	if err := json.Unmarshal(output, &probe); err != nil {
+		return nil, fmt.Errorf("error parseando ffprobe: %w", err)
+	}
+
+	streams := make([]AudioStream, 0, len(probe.Streams))
+	for i, stream := range probe.Streams {
+		language := strings.TrimSpace(stream.Tags.Language)
+		if language == "" {
+			language = "und"
+		}
+		streams = append(streams, AudioStream{
+			Index:    i,
+			Language: language,
+			Title:    strings.TrimSpace(stream.Tags.Title),
+		})
+	}
+
+	return streams, nil
+}
+
 // ffprobeOutput estructura para parsear la salida JSON de ffprobe
 type ffprobeOutput struct {
 	Format struct {
@@ -102,23 +552,26 @@ func (f *ffmpegServiceImp) ExtractDuration(ctx context.Context, videoPath string
 	return formatDuration(seconds), nil
 }
 
-// GenerateThumbnail genera una miniatura WebP del video
+// GenerateThumbnail genera una miniatura WebP del frame en el segundo at
 // Retorna la ruta del archivo thumbnail generado
-func (f *ffmpegServiceImp) GenerateThumbnail(ctx context.Context, videoPath, outputDir string) (string, error) {
+func (f *ffmpegServiceImp) GenerateThumbnail(ctx context.Context, videoPath, outputDir string, at float64) (string, error) {
 	ctx, cancel := context.WithTimeout(ctx, f.thumbnailTimeout)
 	defer cancel()
 
 	thumbnailPath := filepath.Join(outputDir, "thumbnail.webp")
 
-	cmd := exec.CommandContext(ctx, "ffmpeg",
-		"-ss", "00:00:08",
-		"-i", videoPath,
+	args := []string{"-ss", strconv.FormatFloat(at, 'f', 3, 64)}
+	args = append(args, inputArgs(videoPath)...)
+	args = append(args,
 		"-frames:v", "1",
+		"-threads", strconv.Itoa(f.threads),
 		"-vf", "scale=480:-1",
 		"-y",
 		thumbnailPath,
 	)
 
+	cmd := exec.CommandContext(ctx, "ffmpeg", args...)
+
 	output, err := cmd.CombinedOutput()
 	if ctx.Err() == context.DeadlineExceeded {
 		return "", fmt.Errorf("ffmpeg thumbnail timeout después de %v", f.thumbnailTimeout)
@@ -130,6 +583,178 @@ func (f *ffmpegServiceImp) GenerateThumbnail(ctx context.Context, videoPath, out
 	return thumbnailPath, nil
 }
 
+// GenerateStoryboard genera un sprite WebP con StoryboardColumns x StoryboardRows
+// frames repartidos a lo largo del video
+// Retorna la ruta del archivo storyboard generado
+func (f *ffmpegServiceImp) GenerateStoryboard(ctx context.Context, videoPath, outputDir string, durationSeconds float64) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, f.storyboardTimeout)
+	defer cancel()
+
+	storyboardPath := filepath.Join(outputDir, "storyboard.webp")
+
+	// Un frame cada interval segundos para llenar la grilla
+	interval := durationSeconds / float64(StoryboardColumns*StoryboardRows)
+	if interval < 1 {
+		interval = 1
+	}
+
+	filter := fmt.Sprintf("fps=1/%s,scale=160:-1,tile=%dx%d",
+		strconv.FormatFloat(interval, 'f', 3, 64), StoryboardColumns, StoryboardRows)
+
+	args := append(inputArgs(videoPath),
+		"-threads", strconv.Itoa(f.threads),
+		"-vf", filter,
+		"-frames:v", "1",
+		"-y",
+		storyboardPath,
+	)
+
+	cmd := exec.CommandContext(ctx, "ffmpeg", args...)
+
+	output, err := cmd.CombinedOutput()
+	if ctx.Err() == context.DeadlineExceeded {
+		return "", fmt.Errorf("ffmpeg storyboard timeout después de %v", f.storyboardTimeout)
+	}
+	if err != nil {
+		return "", fmt.Errorf("ffmpeg storyboard error: %w, output: %s", err, string(output))
+	}
+
+	return storyboardPath, nil
+}
+
+// GeneratePreview genera una vista previa WebP animada con PreviewSegments fragmentos
+// tomados del medio de tramos iguales del video, sin audio y a baja resolución
+// Retorna la ruta del archivo preview generado
+func (f *ffmpegServiceImp) GeneratePreview(ctx context.Context, videoPath, outputDir string, durationSeconds float64) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, f.previewTimeout)
+	defer cancel()
+
+	previewPath := filepath.Join(outputDir, "preview.webp")
+
+	// Cada tramo mide al menos dos fragmentos para que no se solapen en los clips cortos
+	interval := durationSeconds / PreviewSegments
+	if interval < 2*PreviewSegmentSeconds {
+		interval = 2 * PreviewSegmentSeconds
+	}
+
+	intervalArg := strconv.FormatFloat(interval, 'f', 3, 64)
+	startArg := strconv.FormatFloat(interval/2, 'f', 3, 64)
+	endArg := strconv.FormatFloat(interval/2+PreviewSegmentSeconds, 'f', 3, 64)
+	filter := fmt.Sprintf("select='between(mod(t,%s),%s,%s)',setpts=N/FRAME_RATE/TB,fps=10,scale=320:-2",
+		intervalArg, startArg, endArg)
+
+	args := append(inputArgs(videoPath),
+		"-threads", strconv.Itoa(f.threads),
+		"-vf", filter,
+		"-an",
+		"-t", strconv.FormatFloat(PreviewSegments*PreviewSegmentSeconds, 'f', 3, 64),
+		"-c:v", "libwebp",
+		"-loop", "0",
+		"-q:v", "50",
+		"-y",
+		previewPath,
+	)
+
+	cmd := exec.CommandContext(ctx, "ffmpeg", args...)
+
+	output, err := cmd.CombinedOutput()
+	if ctx.Err() == context.DeadlineExceeded {
+		return "", fmt.Errorf("ffmpeg preview timeout después de %v", f.previewTimeout)
+	}
+	if err != nil {
+		return "", fmt.Errorf("ffmpeg preview error: %w, output: %s", err, string(output))
+	}
+
+	return previewPath, nil
+}
+
+// ResizeImage escala y recorta una imagen al tamaño exacto width x height y la guarda
+// en outputPath (el formato sale de la extensión, ej: .webp)
+func (f *ffmpegServiceImp) ResizeImage(ctx context.Context, imagePath, outputPath string, width, height int) error {
+	ctx, cancel := context.WithTimeout(ctx, f.thumbnailTimeout)
+	defer cancel()
+
+	// Llenar el tamaño pedido manteniendo la proporción y recortar lo que sobra
+	filter := fmt.Sprintf("scale=%d:%d:force_original_aspect_ratio=increase,crop=%d:%d",
+		width, height, width, height)
+
+	cmd := exec.CommandContext(ctx, "ffmpeg",
+		"-i", imagePath,
+		"-threads", strconv.Itoa(f.threads),
+		"-vf", filter,
+		"-frames:v", "1",
+		"-y",
+		outputPath,
+	)
+
+	output, err := cmd.CombinedOutput()
+	if ctx.Err() == context.DeadlineExceeded {
+		return fmt.Errorf("ffmpeg resize timeout después de %v", f.thumbnailTimeout)
+	}
+	if err != nil {
+		return fmt.Errorf("ffmpeg resize error: %w, output: %s", err, string(output))
+	}
+
+	return nil
+}
+
+// GenerateWaveform genera una imagen WebP con la forma de onda del audio, usada como miniatura
+// Retorna la ruta del archivo generado
+func (f *ffmpegServiceImp) GenerateWaveform(ctx context.Context, audioPath, outputDir string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, f.storyboardTimeout)
+	defer cancel()
+
+	waveformPath := filepath.Join(outputDir, "waveform.webp")
+
+	cmd := exec.CommandContext(ctx, "ffmpeg",
+		"-i", audioPath,
+		"-threads", strconv.Itoa(f.threads),
+		"-filter_complex", "aformat=channel_layouts=mono,showwavespic=s=1280x720:colors=0x3ea6ff",
+		"-frames:v", "1",
+		"-y",
+		waveformPath,
+	)
+
+	output, err := cmd.CombinedOutput()
+	if ctx.Err() == context.DeadlineExceeded {
+		return "", fmt.Errorf("ffmpeg waveform timeout después de %v", f.storyboardTimeout)
+	}
+	if err != nil {
+		return "", fmt.Errorf("ffmpeg waveform error: %w, output: %s", err, string(output))
+	}
+
+	return waveformPath, nil
+}
+
+// ExtractAudioFile junta el audio AAC de las renditions HLS en un solo archivo M4A,
+// sin recodificar, para los clientes de podcast que no reproducen HLS
+// Retorna la ruta del archivo generado
+func (f *ffmpegServiceImp) ExtractAudioFile(ctx context.Context, audioPath, outputDir string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, f.hlsTimeout)
+	defer cancel()
+
+	audioFilePath := filepath.Join(outputDir, "audio.m4a")
+
+	cmd := exec.CommandContext(ctx, "ffmpeg",
+		"-i", audioPath,
+		"-vn",
+		"-c:a", "copy",
+		"-movflags", "+faststart",
+		"-y",
+		audioFilePath,
+	)
+
+	output, err := cmd.CombinedOutput()
+	if ctx.Err() == context.DeadlineExceeded {
+		return "", fmt.Errorf("ffmpeg audio file timeout después de %v", f.hlsTimeout)
+	}
+	if err != nil {
+		return "", fmt.Errorf("ffmpeg audio file error: %w, output: %s", err, string(output))
+	}
+
+	return audioFilePath, nil
+}
+
 // formatDuration formatea segundos a formato legible (ej: "1:30" o "45s")
 func formatDuration(seconds float64) string {
 	if seconds < 60 {
@@ -141,3 +766,31 @@ func formatDuration(seconds float64) string {
 
 	return fmt.Sprintf("%.0f:%.0f", minutes, remainingSeconds)
 }
+
+// DurationSeconds convierte una duración generada por formatDuration ("1:30" o "45s") a segundos.
+// Retorna 0 si el formato no es válido.
+func DurationSeconds(duration string) float64 {
+	if strings.HasSuffix(duration, "s") {
+		seconds, err := strconv.ParseFloat(strings.TrimSuffix(duration, "s"), 64)
+		if err != nil {
+			return 0
+		}
+		return seconds
+	}
+
+	parts := strings.Split(duration, ":")
+	if len(parts) != 2 {
+		return 0
+	}
+
+	minutes, err := strconv.ParseFloat(parts[0], 64)
+	if err != nil {
+		return 0
+	}
+	seconds, err := strconv.ParseFloat(parts[1], 64)
+	if err != nil {
+		return 0
+	}
+
+	return minutes*60 + seconds
+}
@@ -0,0 +1,213 @@
+package services
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/unbot2313/go-streaming-service/internal/models"
+)
+
+// argAfter retorna el valor que sigue a la primera aparición de flag en args
+func argAfter(t *testing.T, args []string, flag string) string {
+	t.Helper()
+
+	for i := 0; i < len(args)-1; i++ {
+		if args[i] == flag {
+			return args[i+1]
+		}
+	}
+	t.Fatalf("%s not found in %v", flag, args)
+	return ""
+}
+
+func validMeasurement() *LoudnessMeasurement {
+	return &LoudnessMeasurement{
+		InputI:       "-23.54",
+		InputTP:      "-7.96",
+		InputLRA:     "0.00",
+		InputThresh:  "-34.17",
+		TargetOffset: "-0.42",
+	}
+}
+
+func TestLoudnormFilter(t *testing.T) {
+	missingOffset := validMeasurement()
+	missingOffset.TargetOffset = ""
+	silent := validMeasurement()
+	silent.InputI = "-inf"
+	invalidPeak := validMeasurement()
+	invalidPeak.InputTP = "loud"
+
+	tests := []struct {
+		name     string
+		measured *LoudnessMeasurement
+		want     string
+	}{
+		{"first pass", nil, "loudnorm=I=-16:TP=-1.5:LRA=11:print_format=json"},
+		{"second pass", validMeasurement(), "loudnorm=I=-16:TP=-1.5:LRA=11:measured_I=-23.54:measured_TP=-7.96:measured_LRA=0.00:measured_thresh=-34.17:offset=-0.42:linear=true"},
+		{"missing value falls back to one pass", missingOffset, "loudnorm=I=-16:TP=-1.5:LRA=11"},
+		{"-inf falls back to one pass", silent, "loudnorm=I=-16:TP=-1.5:LRA=11"},
+		{"non numeric value falls back to one pass", invalidPeak, "loudnorm=I=-16:TP=-1.5:LRA=11"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := loudnormFilter(tt.measured); got != tt.want {
+				t.Errorf("expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestParseLoudnessOutput(t *testing.T) {
+	output := `[Parsed_loudnorm_0 @ 0x5581] 
+{
+	"input_i" : "-23.54",
+	"input_tp" : "-7.96",
+	"input_lra" : "0.00",
+	"input_thresh" : "-34.17",
+	"output_i" : "-16.01",
+	"target_offset" : "-0.42"
+}
+`
+	measured, err := parseLoudnessOutput(output)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if measured == nil || *measured != *validMeasurement() {
+		t.Errorf("unexpected measurement: %+v", measured)
+	}
+
+	silent := strings.Replace(output, `"-23.54"`, `"-inf"`, 1)
+	if measured, err := parseLoudnessOutput(silent); err != nil || measured != nil {
+		t.Errorf("expected no measurement for silent audio, got %+v, %v", measured, err)
+	}
+
+	missing := strings.Replace(output, `"target_offset" : "-0.42"`, `"output_lra" : "1.0"`, 1)
+	if measured, err := parseLoudnessOutput(missing); err != nil || measured != nil {
+		t.Errorf("expected no measurement when a value is missing, got %+v, %v", measured, err)
+	}
+
+	if _, err := parseLoudnessOutput("Error opening input"); err == nil {
+		t.Error("expected error when loudnorm prints no measurement")
+	}
+}
+
+func TestConvertToHLSArgs_Loudness(t *testing.T) {
+	args := convertToHLSArgs("in.mp4", "out", HLSOptions{Loudness: validMeasurement()}, 2)
+
+	if got := argAfter(t, args, "-af"); got != loudnormFilter(validMeasurement()) {
+		t.Errorf("unexpected -af %q", got)
+	}
+	if got := argAfter(t, args, "-c:a"); got != "aac" {
+		t.Errorf("expected audio to be re-encoded, got -c:a %q", got)
+	}
+
+	args = convertToHLSArgs("in.mp4", "out", HLSOptions{}, 2)
+	for _, arg := range args {
+		if arg == "-af" {
+			t.Fatalf("expected no audio filter without loudness, got %v", args)
+		}
+	}
+	if got := argAfter(t, args, "-c:a"); got != "copy" {
+		t.Errorf("expected audio to be copied, got -c:a %q", got)
+	}
+}
+
+func TestConvertToHLSArgs_LoudnessMultipleAudioStreams(t *testing.T) {
+	options := HLSOptions{
+		Loudness:     validMeasurement(),
+		AudioStreams: []AudioStream{{Index: 0}, {Index: 1}},
+	}
+	args := convertToHLSArgs("in.mp4", "out", options, 3)
+
+	// Solo la primera pista se midió, así que solo ella se normaliza
+	filters := 0
+	threads := 0
+	output := ""
+	for i, arg := range args {
+		switch arg {
+		case "-map":
+			output = args[i+1]
+		case "-af":
+			filters++
+			if output != "0:a:0" {
+				t.Errorf("expected loudnorm on the first audio stream, got it on %s", output)
+			}
+		case "-threads":
+			threads++
+			if args[i+1] != "3" {
+				t.Errorf("expected -threads 3, got %s", args[i+1])
+			}
+		}
+	}
+	if filters != 1 {
+		t.Errorf("expected 1 loudnorm filter, got %d", filters)
+	}
+	// Una para la salida de video y una por pista de audio
+	if threads != 3 {
+		t.Errorf("expected -threads on each of the 3 outputs, got %d", threads)
+	}
+}
+
+func TestWatermarkFilter(t *testing.T) {
+	tests := []struct {
+		position string
+		overlay  string
+	}{
+		{models.WatermarkTopLeft, "overlay=x=H*0.03:y=H*0.03:format=auto"},
+		{models.WatermarkTopRight, "overlay=x=W-w-H*0.03:y=H*0.03:format=auto"},
+		{models.WatermarkBottomLeft, "overlay=x=H*0.03:y=H-h-H*0.03:format=auto"},
+		{models.WatermarkBottomRight, "overlay=x=W-w-H*0.03:y=H-h-H*0.03:format=auto"},
+		{models.WatermarkCenter, "overlay=x=(W-w)/2:y=(H-h)/2:format=auto"},
+		// Una posición desconocida usa la de por defecto
+		{"middle", "overlay=x=W-w-H*0.03:y=H-h-H*0.03:format=auto"},
+		{"", "overlay=x=W-w-H*0.03:y=H-h-H*0.03:format=auto"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.position, func(t *testing.T) {
+			filter := watermarkFilter(&models.Watermark{Position: tt.position, Opacity: 0.8, Scale: 0.15})
+
+			want := "[1:v]format=rgba,colorchannelmixer=aa=0.8[wm];[wm][0:v]scale2ref=w=main_w*0.15:h=ow/a[logo][base];[base][logo]" + tt.overlay + ",format=yuv420p[v]"
+			if filter != want {
+				t.Errorf("expected %q, got %q", want, filter)
+			}
+		})
+	}
+}
+
+func TestWatermarkFilter_OpacityAndScale(t *testing.T) {
+	filter := watermarkFilter(&models.Watermark{Position: models.WatermarkCenter, Opacity: 1, Scale: 0.05})
+
+	if !strings.Contains(filter, "colorchannelmixer=aa=1[wm]") {
+		t.Errorf("expected full opacity in %q", filter)
+	}
+	if !strings.Contains(filter, "scale2ref=w=main_w*0.05:h=ow/a") {
+		t.Errorf("expected 5%% width in %q", filter)
+	}
+}
+
+func TestConvertToHLSArgs_Watermark(t *testing.T) {
+	watermark := &models.Watermark{ImageURL: "https://storage/wm.png", Position: models.WatermarkTopLeft, Opacity: 0.5, Scale: 0.2}
+	args := convertToHLSArgs("in.mp4", "out", HLSOptions{Watermark: watermark}, 2)
+
+	// El PNG es la segunda entrada: el filtro la usa como [1:v]
+	if args[0] != "-i" || args[1] != "in.mp4" || args[2] != "-i" || args[3] != watermark.ImageURL {
+		t.Errorf("unexpected inputs %v", args[:4])
+	}
+	if got := argAfter(t, args, "-filter_complex"); got != watermarkFilter(watermark) {
+		t.Errorf("unexpected -filter_complex %q", got)
+	}
+	if got := argAfter(t, args, "-map"); got != "[v]" {
+		t.Errorf("expected the filtered video to be mapped, got %q", got)
+	}
+	if got := argAfter(t, args, "-c:v"); got != "libx264" {
+		t.Errorf("expected video to be re-encoded, got -c:v %q", got)
+	}
+
+	args = convertToHLSArgs("in.mp4", "out", HLSOptions{}, 2)
+	if got := argAfter(t, args, "-c:v"); got != "copy" {
+		t.Errorf("expected video to be copied without watermark, got -c:v %q", got)
+	}
+}
@@ -0,0 +1,475 @@
+package services
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/unbot2313/go-streaming-service/config"
+	"github.com/unbot2313/go-streaming-service/internal/models"
+	"github.com/unbot2313/go-streaming-service/internal/services/storage"
+)
+
+var (
+	// ErrEncryptionNotConfigured indica que falta HLS_KEY_ENCRYPTION_KEY para cifrar videos
+	ErrEncryptionNotConfigured = errors.New("el cifrado de videos no está configurado (HLS_KEY_ENCRYPTION_KEY)")
+	// ErrVideoKeyNotFound indica que la clave no existe o no es de ese video
+	ErrVideoKeyNotFound = errors.New("clave no encontrada")
+)
+
+// HLSKey es una clave AES-128 recién generada y la URI con la que el player la pide
+type HLSKey struct {
+	Id  string
+	Key []byte
+	URI string
+}
+
+// HLSEncryption cifra los segmentos de un video con AES-128. NewKey genera y guarda
+// cada clave; con RotationSegments > 0 se usa una clave nueva cada esa cantidad de segmentos.
+type HLSEncryption struct {
+	NewKey           func() (*HLSKey, error)
+	RotationSegments int
+}
+
+type HLSKeyService interface {
+	Encryption(videoId string) (*HLSEncryption, error)
+	GetKey(videoId, keyId string) ([]byte, error)
+	PrepareSource(ctx context.Context, videoId, sourceURL string) (string, func(), error)
+}
+
+type hlsKeyServiceImp struct {
+	client *http.Client
+}
+
+func NewHLSKeyService() HLSKeyService {
+	return &hlsKeyServiceImp{
+		client: &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// HLSEncryptionEnabled indica si está configurada la clave para cifrar videos
+func HLSEncryptionEnabled() bool {
+	return config.GetConfig().HLSKeyEncryptionKey != ""
+}
+
+// Encryption prepara el cifrado de las renditions de un video. Cada clave se guarda
+// cifrada en Postgres al generarse, antes de que las renditions se suban al storage.
+func (s *hlsKeyServiceImp) Encryption(videoId string) (*HLSEncryption, error) {
+	if !HLSEncryptionEnabled() {
+		return nil, ErrEncryptionNotConfigured
+	}
+
+	cfg := config.GetConfig()
+
+	return &HLSEncryption{
+		RotationSegments: cfg.HLSKeyRotationSegments,
+		NewKey: func() (*HLSKey, error) {
+			key := make([]byte, 16)
+			if _, err := rand.Read(key); err != nil {
+				return nil, err
+			}
+
+			sealed, err := sealVideoKey(key)
+			if err != nil {
+				return nil, err
+			}
+
+			db, err := config.GetDB()
+			if err != nil {
+				return nil, err
+			}
+
+			videoKey := &models.VideoKey{
+				Id:           uuid.New().String(),
+				VideoID:      videoId,
+				EncryptedKey: sealed,
+			}
+			if err := db.Create(videoKey).Error; err != nil {
+				return nil, err
+			}
+
+			return &HLSKey{
+				Id:  videoKey.Id,
+				Key: key,
+				URI: fmt.Sprintf("%s/api/v1/streaming/%s/key?kid=%s", cfg.PublicAPIURL, videoId, videoKey.Id),
+			}, nil
+		},
+	}, nil
+}
+
+// GetKey retorna la clave AES-128 descifrada de un video
+func (s *hlsKeyServiceImp) GetKey(videoId, keyId string) ([]byte, error) {
+	if !HLSEncryptionEnabled() {
+		return nil, ErrEncryptionNotConfigured
+	}
+
+	db, err := config.GetDB()
+	if err != nil {
+		return nil, err
+	}
+
+	var videoKey models.VideoKey
+	if err := db.Where("id = ? AND video_id = ?", keyId, videoId).First(&videoKey).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrVideoKeyNotFound
+		}
+		return nil, err
+	}
+
+	return openVideoKey(videoKey.EncryptedKey)
+}
+
+// PrepareSource retorna la fuente que ffmpeg tiene que leer para un video. Si no está
+// cifrado es sourceURL. Si lo está, es una copia local de sus playlists en la que los
+// segmentos apuntan al storage y cada EXT-X-KEY a un archivo con la clave ya descifrada:
+// las claves se leen de Postgres sin pasar por la API y no salen de la carpeta temporal.
+// cleanup borra la copia y siempre se puede llamar.
+func (s *hlsKeyServiceImp) PrepareSource(ctx context.Context, videoId, sourceURL string) (string, func(), error) {
+	noop := func() {}
+
+	db, err := config.GetDB()
+	if err != nil {
+		return "", noop, err
+	}
+
+	var video models.VideoModel
+	if err := db.Unscoped().Select("id", "encrypted").Where("id = ?", videoId).First(&video).Error; err != nil {
+		return "", noop, err
+	}
+	if !video.Encrypted {
+		return sourceURL, noop, nil
+	}
+
+	dir, err := os.MkdirTemp("", "hls-source-"+videoId+"-")
+	if err != nil {
+		return "", noop, err
+	}
+	cleanup := func() { os.RemoveAll(dir) }
+
+	source := &localHLSSource{service: s, ctx: ctx, videoId: videoId, dir: dir, keys: make(map[string]string)}
+	path, err := source.playlist(sourceURL, 0)
+	if err != nil {
+		cleanup()
+		return "", noop, err
+	}
+
+	return path, cleanup, nil
+}
+
+// localHLSSource arma la copia local de los playlists de un video cifrado
+type localHLSSource struct {
+	service   *hlsKeyServiceImp
+	ctx       context.Context
+	videoId   string
+	dir       string
+	keys      map[string]string // kid -> archivo de la clave
+	playlists int
+}
+
+// playlist descarga un playlist, reescribe sus URIs y lo guarda en la carpeta temporal.
+// Los playlists de las variantes y pistas de audio de un master se copian igual.
+func (l *localHLSSource) playlist(playlistURL string, depth int) (string, error) {
+	if depth > 1 {
+		return "", fmt.Errorf("playlist anidado demasiado profundo: %s", playlistURL)
+	}
+
+	base, err := url.Parse(playlistURL)
+	if err != nil {
+		return "", fmt.Errorf("URL del playlist inválida: %w", err)
+	}
+
+	content, err := l.fetch(playlistURL)
+	if err != nil {
+		return "", err
+	}
+
+	var out bytes.Buffer
+	variant := false
+	for _, line := range strings.Split(strings.TrimRight(string(content), "\n"), "\n") {
+		line = strings.TrimRight(line, "\r")
+
+		switch {
+		case strings.HasPrefix(line, "#EXT-X-KEY:"):
+			if line, err = l.localKey(line); err != nil {
+				return "", err
+			}
+		case strings.HasPrefix(line, "#EXT-X-MEDIA:"):
+			if line, err = l.replaceURI(base, line, func(ref string) (string, error) { return l.playlist(ref, depth+1) }); err != nil {
+				return "", err
+			}
+		case strings.HasPrefix(line, "#EXT-X-MAP:"), strings.HasPrefix(line, "#EXT-X-I-FRAME-STREAM-INF:"):
+			line = resolveURIAttribute(base, line)
+		case strings.HasPrefix(line, "#EXT-X-STREAM-INF:"):
+			variant = true
+		case line != "" && !strings.HasPrefix(line, "#"):
+			ref, err := url.Parse(line)
+			if err != nil {
+				return "", fmt.Errorf("URI inválida en el playlist: %w", err)
+			}
+			line = base.ResolveReference(ref).String()
+			if variant {
+				if line, err = l.playlist(line, depth+1); err != nil {
+					return "", err
+				}
+				variant = false
+			}
+		}
+
+		out.WriteString(line)
+		out.WriteString("\n")
+	}
+
+	l.playlists++
+	path := filepath.Join(l.dir, fmt.Sprintf("playlist-%d.m3u8", l.playlists))
+	if err := os.WriteFile(path, out.Bytes(), 0600); err != nil {
+		return "", err
+	}
+
+	return path, nil
+}
+
+// replaceURI reemplaza el atributo URI de un tag por lo que retorne replace con la URI absoluta
+func (l *localHLSSource) replaceURI(base *url.URL, line string, replace func(ref string) (string, error)) (string, error) {
+	match := uriAttributePattern.FindStringSubmatchIndex(line)
+	if match == nil {
+		return line, nil
+	}
+
+	ref, err := url.Parse(line[match[2]:match[3]])
+	if err != nil {
+		return "", fmt.Errorf("URI inválida en el playlist: %w", err)
+	}
+
+	replaced, err := replace(base.ResolveReference(ref).String())
+	if err != nil {
+		return "", err
+	}
+
+	return line[:match[2]] + replaced + line[match[3]:], nil
+}
+
+// localKey cambia la URI de un EXT-X-KEY por un archivo con la clave descifrada.
+// Solo acepta claves del mismo video; la URI es la del endpoint de claves de la API.
+func (l *localHLSSource) localKey(line string) (string, error) {
+	return l.replaceURI(&url.URL{}, line, func(ref string) (string, error) {
+		keyURL, err := url.Parse(ref)
+		if err != nil {
+			return "", err
+		}
+
+		kid := keyURL.Query().Get("kid")
+		if kid == "" || !strings.HasSuffix(keyURL.Path, "/streaming/"+l.videoId+"/key") {
+			return "", fmt.Errorf("clave de otro video en el playlist: %s", ref)
+		}
+
+		if path, ok := l.keys[kid]; ok {
+			return path, nil
+		}
+
+		key, err := l.service.GetKey(l.videoId, kid)
+		if err != nil {
+			return "", err
+		}
+
+		path := filepath.Join(l.dir, fmt.Sprintf("key-%d.key", len(l.keys)+1))
+		if err := os.WriteFile(path, key, 0600); err != nil {
+			return "", err
+		}
+		l.keys[kid] = path
+
+		return path, nil
+	})
+}
+
+// fetch descarga un playlist del storage; no lleva credenciales
+func (l *localHLSSource) fetch(playlistURL string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(l.ctx, http.MethodGet, playlistURL, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := l.service.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error leyendo el playlist: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("error leyendo el playlist: status %d", resp.StatusCode)
+	}
+
+	return io.ReadAll(io.LimitReader(resp.Body, maxPlaylistSize))
+}
+
+// videoKeyCipher es el AES-256-GCM con el que se cifran las claves en Postgres
+func videoKeyCipher() (cipher.AEAD, error) {
+	masterKey, err := base64.StdEncoding.DecodeString(config.GetConfig().HLSKeyEncryptionKey)
+	if err != nil {
+		return nil, err
+	}
+
+	block, err := aes.NewCipher(masterKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
+
+// sealVideoKey cifra una clave; el nonce va al principio del resultado
+func sealVideoKey(key []byte) ([]byte, error) {
+	gcm, err := videoKeyCipher()
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+
+	return gcm.Seal(nonce, nonce, key, nil), nil
+}
+
+// openVideoKey descifra una clave guardada con sealVideoKey
+func openVideoKey(sealed []byte) ([]byte, error) {
+	gcm, err := videoKeyCipher()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(sealed) < gcm.NonceSize() {
+		return nil, errors.New("clave cifrada inválida")
+	}
+
+	return gcm.Open(nil, sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():], nil)
+}
+
+// EncryptHLS cifra con AES-128-CBC los segmentos de todos los media playlists de dir y
+// les agrega los EXT-X-KEY. Los playlists comparten las claves: el segmento i de cada uno
+// usa la clave i / RotationSegments. El IV es el número de secuencia del segmento, como
+// indica la especificación cuando EXT-X-KEY no tiene IV.
+func EncryptHLS(dir string, encryption *HLSEncryption) error {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+
+	keys := make(map[int]*HLSKey)
+	keyFor := func(slot int) (*HLSKey, error) {
+		if key, ok := keys[slot]; ok {
+			return key, nil
+		}
+		key, err := encryption.NewKey()
+		if err != nil {
+			return nil, err
+		}
+		keys[slot] = key
+		return key, nil
+	}
+
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".m3u8") || entry.Name() == storage.MasterPlaylistName {
+			continue
+		}
+		if err := encryptPlaylist(dir, entry.Name(), encryption.RotationSegments, keyFor); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// encryptPlaylist cifra los segmentos de un media playlist y lo reescribe con los EXT-X-KEY
+func encryptPlaylist(dir, name string, rotation int, keyFor func(slot int) (*HLSKey, error)) error {
+	playlistPath := filepath.Join(dir, name)
+	content, err := os.ReadFile(playlistPath)
+	if err != nil {
+		return err
+	}
+
+	var out bytes.Buffer
+	var key *HLSKey
+	mediaSequence := 0
+	segment := 0
+
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+
+		switch {
+		case strings.HasPrefix(line, "#EXT-X-MEDIA-SEQUENCE:"):
+			mediaSequence, _ = strconv.Atoi(strings.TrimPrefix(line, "#EXT-X-MEDIA-SEQUENCE:"))
+		case strings.HasPrefix(line, "#EXTINF"):
+			slot := 0
+			if rotation > 0 {
+				slot = segment / rotation
+			}
+			if key == nil || (rotation > 0 && segment%rotation == 0) {
+				key, err = keyFor(slot)
+				if err != nil {
+					return err
+				}
+				fmt.Fprintf(&out, "#EXT-X-KEY:METHOD=AES-128,URI=\"%s\"\n", key.URI)
+			}
+		case line != "" && !strings.HasPrefix(line, "#"):
+			if key == nil {
+				return fmt.Errorf("segmento %s sin #EXTINF en %s", line, name)
+			}
+			if err := encryptSegment(filepath.Join(dir, line), key.Key, mediaSequence+segment); err != nil {
+				return err
+			}
+			segment++
+		}
+
+		out.WriteString(line)
+		out.WriteString("\n")
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+
+	return os.WriteFile(playlistPath, out.Bytes(), 0644)
+}
+
+// encryptSegment cifra un segmento en el lugar con AES-128-CBC y padding PKCS#7
+func encryptSegment(path string, key []byte, sequence int) error {
+	plain, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return err
+	}
+
+	padding := aes.BlockSize - len(plain)%aes.BlockSize
+	plain = append(plain, bytes.Repeat([]byte{byte(padding)}, padding)...)
+
+	iv := make([]byte, aes.BlockSize)
+	binary.BigEndian.PutUint64(iv[8:], uint64(sequence))
+
+	encrypted := make([]byte, len(plain))
+	cipher.NewCBCEncrypter(block, iv).CryptBlocks(encrypted, plain)
+
+	return os.WriteFile(path, encrypted, 0644)
+}
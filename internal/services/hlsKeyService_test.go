@@ -0,0 +1,206 @@
+package services
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"encoding/binary"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/unbot2313/go-streaming-service/internal/services/storage"
+)
+
+// hlsFixture es un media playlist de prueba con el contenido original de sus segmentos
+type hlsFixture struct {
+	name          string
+	mediaSequence int
+	segments      map[string][]byte
+	order         []string
+}
+
+// writeHLSFixture escribe el playlist y sus segmentos en dir
+func writeHLSFixture(t *testing.T, dir, name string, mediaSequence, count int) *hlsFixture {
+	t.Helper()
+
+	fixture := &hlsFixture{name: name, mediaSequence: mediaSequence, segments: make(map[string][]byte)}
+
+	var playlist strings.Builder
+	fmt.Fprintf(&playlist, "#EXTM3U\n#EXT-X-VERSION:3\n#EXT-X-TARGETDURATION:4\n#EXT-X-MEDIA-SEQUENCE:%d\n", mediaSequence)
+	for i := 0; i < count; i++ {
+		segment := fmt.Sprintf("%s_%03d.ts", strings.TrimSuffix(name, ".m3u8"), i)
+		// Largos que no son múltiplos del bloque para probar el padding
+		content := bytes.Repeat([]byte(segment), 3+i)
+		if err := os.WriteFile(filepath.Join(dir, segment), content, 0644); err != nil {
+			t.Fatal(err)
+		}
+		fixture.segments[segment] = content
+		fixture.order = append(fixture.order, segment)
+		fmt.Fprintf(&playlist, "#EXTINF:4.000000,\n%s\n", segment)
+	}
+	playlist.WriteString("#EXT-X-ENDLIST\n")
+
+	if err := os.WriteFile(filepath.Join(dir, name), []byte(playlist.String()), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	return fixture
+}
+
+// decryptHLSSegment descifra un segmento con AES-128-CBC y quita el padding PKCS#7
+func decryptHLSSegment(t *testing.T, encrypted, key []byte, sequence int) []byte {
+	t.Helper()
+
+	if len(encrypted) == 0 || len(encrypted)%aes.BlockSize != 0 {
+		t.Fatalf("encrypted segment has invalid length %d", len(encrypted))
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	iv := make([]byte, aes.BlockSize)
+	binary.BigEndian.PutUint64(iv[8:], uint64(sequence))
+
+	plain := make([]byte, len(encrypted))
+	cipher.NewCBCDecrypter(block, iv).CryptBlocks(plain, encrypted)
+
+	padding := int(plain[len(plain)-1])
+	if padding < 1 || padding > aes.BlockSize || !bytes.Equal(plain[len(plain)-padding:], bytes.Repeat([]byte{byte(padding)}, padding)) {
+		t.Fatalf("invalid PKCS#7 padding %d", padding)
+	}
+
+	return plain[:len(plain)-padding]
+}
+
+func TestEncryptHLS(t *testing.T) {
+	tests := []struct {
+		name     string
+		rotation int
+		// slot de clave de cada segmento; los dos playlists tienen 5 segmentos
+		slots []int
+	}{
+		{"single key per rendition", 0, []int{0, 0, 0, 0, 0}},
+		{"rotation every 2 segments", 2, []int{0, 0, 1, 1, 2}},
+		{"rotation every segment", 1, []int{0, 1, 2, 3, 4}},
+		{"rotation longer than the playlist", 10, []int{0, 0, 0, 0, 0}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			fixtures := []*hlsFixture{
+				writeHLSFixture(t, dir, "720p.m3u8", 0, 5),
+				writeHLSFixture(t, dir, "audio_eng.m3u8", 7, 5),
+			}
+
+			master := []byte("#EXTM3U\n#EXT-X-STREAM-INF:BANDWIDTH=2800000\n720p.m3u8\n")
+			if err := os.WriteFile(filepath.Join(dir, storage.MasterPlaylistName), master, 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			var keys []*HLSKey
+			encryption := &HLSEncryption{
+				RotationSegments: tt.rotation,
+				NewKey: func() (*HLSKey, error) {
+					id := fmt.Sprintf("key-%d", len(keys))
+					key := &HLSKey{
+						Id:  id,
+						Key: bytes.Repeat([]byte{byte(len(keys) + 1)}, 16),
+						URI: "https://api.example.com/api/v1/streaming/video-1/key?kid=" + id,
+					}
+					keys = append(keys, key)
+					return key, nil
+				},
+			}
+
+			if err := EncryptHLS(dir, encryption); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			// Los playlists comparten las claves: una por slot
+			if want := tt.slots[len(tt.slots)-1] + 1; len(keys) != want {
+				t.Fatalf("expected %d keys, got %d", want, len(keys))
+			}
+
+			for _, fixture := range fixtures {
+				content, err := os.ReadFile(filepath.Join(dir, fixture.name))
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				// Cada EXT-X-KEY va justo antes del #EXTINF del primer segmento de su slot
+				lines := strings.Split(strings.TrimSpace(string(content)), "\n")
+				segment := 0
+				var current *HLSKey
+				for i, line := range lines {
+					if !strings.HasPrefix(line, "#EXTINF") {
+						if strings.HasPrefix(line, "#EXT-X-KEY:") && (i+1 >= len(lines) || !strings.HasPrefix(lines[i+1], "#EXTINF")) {
+							t.Errorf("%s: EXT-X-KEY not followed by a segment at line %d", fixture.name, i)
+						}
+						continue
+					}
+
+					slot := tt.slots[segment]
+					newSlot := segment == 0 || tt.slots[segment-1] != slot
+					hasKey := i > 0 && strings.HasPrefix(lines[i-1], "#EXT-X-KEY:")
+					if hasKey != newSlot {
+						t.Errorf("%s: segment %d: expected EXT-X-KEY %v, got %v", fixture.name, segment, newSlot, hasKey)
+					}
+					if hasKey {
+						want := fmt.Sprintf("#EXT-X-KEY:METHOD=AES-128,URI=\"%s\"", keys[slot].URI)
+						if lines[i-1] != want {
+							t.Errorf("%s: segment %d: expected %q, got %q", fixture.name, segment, want, lines[i-1])
+						}
+						current = keys[slot]
+					}
+					if current != keys[slot] {
+						t.Errorf("%s: segment %d is played with the wrong key", fixture.name, segment)
+					}
+					segment++
+				}
+				if segment != len(fixture.order) {
+					t.Fatalf("%s: expected %d segments, got %d", fixture.name, len(fixture.order), segment)
+				}
+
+				// Cada segmento se descifra con su clave y el IV igual a su número de secuencia
+				for i, name := range fixture.order {
+					encrypted, err := os.ReadFile(filepath.Join(dir, name))
+					if err != nil {
+						t.Fatal(err)
+					}
+					plain := decryptHLSSegment(t, encrypted, keys[tt.slots[i]].Key, fixture.mediaSequence+i)
+					if !bytes.Equal(plain, fixture.segments[name]) {
+						t.Errorf("%s: segment %s does not decrypt to the original", fixture.name, name)
+					}
+				}
+			}
+
+			// El master playlist no se toca
+			content, err := os.ReadFile(filepath.Join(dir, storage.MasterPlaylistName))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(content, master) {
+				t.Errorf("master playlist was modified: %q", content)
+			}
+		})
+	}
+}
+
+func TestEncryptHLS_KeyError(t *testing.T) {
+	dir := t.TempDir()
+	writeHLSFixture(t, dir, "720p.m3u8", 0, 2)
+
+	encryption := &HLSEncryption{
+		NewKey: func() (*HLSKey, error) { return nil, fmt.Errorf("db down") },
+	}
+
+	if err := EncryptHLS(dir, encryption); err == nil {
+		t.Fatal("expected an error")
+	}
+}
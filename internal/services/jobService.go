@@ -1,6 +1,7 @@
 package services
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
 
@@ -9,11 +10,22 @@ import (
 	"gorm.io/gorm"
 )
 
+// ErrIdempotencyKeyInUse indica que el usuario ya creó un job con el mismo Idempotency-Key
+var ErrIdempotencyKeyInUse = errors.New("ya existe un job con el mismo Idempotency-Key")
+
 type JobService interface {
 	CreateJob(job *models.Job) (*models.JobModel, error)
+	CreateJobWithTask(job *models.Job, task []byte) (*models.JobModel, error)
 	GetJobByID(jobId string) (*models.JobModel, error)
 	UpdateJobStatus(jobId, status, errorMsg string) error
 	UpdateJobCompleted(jobId, videoID string) error
+	ClaimJob(jobId, workerId string) (bool, error)
+	UpdateJobStage(jobId, stage, m3u8FileURL string) error
+	UpdateJobLoudness(jobId string, loudness models.Loudness) error
+	UpdateJobAudioTracks(jobId string, tracks []models.AudioTrack) error
+	FindJobByIdempotencyKey(userId, idempotencyKey string) (*models.JobModel, error)
+	SetJobPriority(jobId string, priority int) (*models.JobModel, error)
+	CountPendingByPriority() (map[int]int, error)
 }
 
 type jobServiceImp struct{}
@@ -45,7 +57,99 @@ func (service *jobServiceImp) CreateJob(job *models.Job) (*models.JobModel, erro
 	return &jobModel, nil
 }
 
-func (service *jobServiceImp) GetJobByID(jobId string) (*models.JobModel, error) {
+// CreateJobWithTask crea el job y su tarea para la cola de video dentro de la misma transacción.
+// Si cualquiera de los dos falla no se guarda nada, y el relay del outbox
+// se encarga de publicar la tarea en RabbitMQ.
+// Si el job no trae prioridad se calcula con su duración y el plan del usuario.
+func (service *jobServiceImp) CreateJobWithTask(job *models.Job, task []byte) (*models.JobModel, error) {
+	db, err := config.GetDB()
+	if err != nil {
+		return nil, err
+	}
+
+	jobModel := models.JobModel{
+		Job: *job,
+	}
+
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if job.EncodingProfile != "" {
+			if _, err := findEncodingProfile(tx, job.EncodingProfile); err != nil {
+				return err
+			}
+		}
+
+		if jobModel.Priority == 0 {
+			var user models.User
+			if err := tx.Select("plan").Where("id = ?", job.UserID).First(&user).Error; err != nil {
+				return err
+			}
+			jobModel.Priority = JobPriority(DurationSeconds(job.Duration), user.Plan)
+		}
+
+		if err := tx.Create(&jobModel).Error; err != nil {
+			return err
+		}
+
+		return enqueueOutboxMessage(tx, config.GetConfig().RabbitMQVideoQueue, task, jobModel.Priority)
+	})
+
+	if errors.Is(err, gorm.ErrDuplicatedKey) {
+		if job.IdempotencyKey != "" {
+			return nil, ErrIdempotencyKeyInUse
+		}
+		return nil, fmt.Errorf("ya existe un job con el id %s", job.Id)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &jobModel, nil
+}
+
+// JobPriority calcula la prioridad de un job en la cola de video: los videos cortos
+// pasan antes que los largos y el plan pro suma prioridad. El máximo (QueueMaxPriority)
+// queda reservado para que un administrador adelante un job.
+func JobPriority(durationSeconds float64, plan string) int {
+	priority := 4
+	switch {
+	case durationSeconds <= 0:
+		// Sin duración conocida se usa la prioridad normal
+	case durationSeconds <= 60:
+		priority = 6
+	case durationSeconds <= 10*60:
+		priority = 4
+	case durationSeconds <= 60*60:
+		priority = 2
+	default:
+		priority = 1
+	}
+
+	if plan == models.UserPlanPro {
+		priority += 3
+	}
+
+	return priority
+}
+
+// clampJobPriority limita una prioridad al rango de las colas (0 a QueueMaxPriority).
+// El outbox la guarda como int y el broker la recibe como uint8: una negativa daría la vuelta al máximo.
+func clampJobPriority(priority int) int {
+	if priority < 0 {
+		return 0
+	}
+	if priority > QueueMaxPriority {
+		return QueueMaxPriority
+	}
+	return priority
+}
+
+// SetJobPriority cambia la prioridad de un job, limitada al rango de las colas. Si sigue
+// "pending" se vuelve a publicar su tarea con la nueva prioridad: la copia anterior se
+// descarta al llegar al worker porque el job ya no se puede reclamar.
+func (service *jobServiceImp) SetJobPriority(jobId string, priority int) (*models.JobModel, error) {
+	priority = clampJobPriority(priority)
+
 	db, err := config.GetDB()
 	if err != nil {
 		return nil, err
@@ -53,12 +157,82 @@ func (service *jobServiceImp) GetJobByID(jobId string) (*models.JobModel, error)
 
 	var job models.JobModel
 
-	dbCtx := db.Where("id = ?", jobId).First(&job)
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.JobModel{}).Where("id = ?", jobId).Update("priority", priority).Error; err != nil {
+			return err
+		}
 
-	if errors.Is(dbCtx.Error, gorm.ErrRecordNotFound) {
+		if err := tx.Where("id = ?", jobId).First(&job).Error; err != nil {
+			return err
+		}
+
+		if job.Status != "pending" {
+			return nil
+		}
+
+		task, err := json.Marshal(job.Task())
+		if err != nil {
+			return err
+		}
+
+		return enqueueOutboxMessage(tx, config.GetConfig().RabbitMQVideoQueue, task, priority)
+	})
+
+	if errors.Is(err, gorm.ErrRecordNotFound) {
 		return nil, fmt.Errorf("job con id %s no encontrado", jobId)
 	}
 
+	if err != nil {
+		return nil, err
+	}
+
+	return &job, nil
+}
+
+// CountPendingByPriority cuenta los jobs en "pending" por prioridad
+func (service *jobServiceImp) CountPendingByPriority() (map[int]int, error) {
+	db, err := config.GetDB()
+	if err != nil {
+		return nil, err
+	}
+
+	var rows []struct {
+		Priority int
+		Count    int
+	}
+
+	if err := db.Model(&models.JobModel{}).
+		Select("priority, COUNT(*) AS count").
+		Where("status = ?", "pending").
+		Group("priority").
+		Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	counts := make(map[int]int, len(rows))
+	for _, row := range rows {
+		counts[row.Priority] = row.Count
+	}
+
+	return counts, nil
+}
+
+// FindJobByIdempotencyKey busca el job creado por el usuario con ese Idempotency-Key.
+// Retorna nil sin error si no existe.
+func (service *jobServiceImp) FindJobByIdempotencyKey(userId, idempotencyKey string) (*models.JobModel, error) {
+	db, err := config.GetDB()
+	if err != nil {
+		return nil, err
+	}
+
+	var job models.JobModel
+
+	dbCtx := db.Where("user_id = ? AND idempotency_key = ?", userId, idempotencyKey).First(&job)
+
+	if errors.Is(dbCtx.Error, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+
 	if dbCtx.Error != nil {
 		return nil, dbCtx.Error
 	}
@@ -66,12 +240,30 @@ func (service *jobServiceImp) GetJobByID(jobId string) (*models.JobModel, error)
 	return &job, nil
 }
 
-func (service *jobServiceImp) UpdateJobStatus(jobId, status, errorMsg string) error {
+func (service *jobServiceImp) GetJobByID(jobId string) (*models.JobModel, error) {
 	db, err := config.GetDB()
 	if err != nil {
-		return err
+		return nil, err
+	}
+
+	var job models.JobModel
+
+	dbCtx := db.Where("id = ?", jobId).First(&job)
+
+	if errors.Is(dbCtx.Error, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("job con id %s no encontrado", jobId)
 	}
 
+	if dbCtx.Error != nil {
+		return nil, dbCtx.Error
+	}
+
+	return &job, nil
+}
+
+// UpdateJobStatus cambia el estado del job. Si pasa a "failed" sin intentos disponibles
+// notifica job.failed a los webhooks; si todavía se va a reintentar, no avisa nada.
+func (service *jobServiceImp) UpdateJobStatus(jobId, status, errorMsg string) error {
 	updates := map[string]interface{}{
 		"status": status,
 	}
@@ -80,37 +272,137 @@ func (service *jobServiceImp) UpdateJobStatus(jobId, status, errorMsg string) er
 		updates["error_message"] = errorMsg
 	}
 
-	dbCtx := db.Model(&models.JobModel{}).Where("id = ?", jobId).Updates(updates)
-
-	if dbCtx.Error != nil {
-		return dbCtx.Error
-	}
-
-	if dbCtx.RowsAffected == 0 {
-		return fmt.Errorf("job con id %s no encontrado", jobId)
+	event := ""
+	if status == "failed" {
+		event = models.WebhookEventJobFailed
 	}
 
-	return nil
+	return updateJob(jobId, updates, event)
 }
 
 func (service *jobServiceImp) UpdateJobCompleted(jobId, videoID string) error {
+	return updateJob(jobId, map[string]interface{}{
+		"status":   "completed",
+		"video_id": videoID,
+	}, models.WebhookEventJobCompleted)
+}
+
+// ClaimJob pasa el job a "processing", lo asigna al worker y cuenta el intento, solo si
+// está "pending" (o "failed" con intentos disponibles, para los reintentos de la cola).
+// Es un update condicional: si la tarea se entregó dos veces, solo un worker la reclama.
+// Retorna false si el job ya está en proceso, completado o sin intentos.
+// El worker asignado es el que el reaper revisa si deja de enviar heartbeats.
+func (service *jobServiceImp) ClaimJob(jobId, workerId string) (bool, error) {
 	db, err := config.GetDB()
 	if err != nil {
-		return err
+		return false, err
 	}
 
-	dbCtx := db.Model(&models.JobModel{}).Where("id = ?", jobId).Updates(map[string]interface{}{
-		"status":   "completed",
-		"video_id": videoID,
+	claimed := false
+
+	err = db.Transaction(func(tx *gorm.DB) error {
+		dbCtx := tx.Model(&models.JobModel{}).
+			Where("id = ?", jobId).
+			Where("status = ? OR (status = ? AND attempts < ?)", "pending", "failed", config.GetConfig().WorkerMaxJobAttempts).
+			Updates(map[string]interface{}{
+				"status":        "processing",
+				"worker_id":     workerId,
+				"error_message": "",
+				"attempts":      gorm.Expr("attempts + 1"),
+			})
+
+		if dbCtx.Error != nil {
+			return dbCtx.Error
+		}
+
+		if dbCtx.RowsAffected == 0 {
+			return nil
+		}
+		claimed = true
+
+		var job models.JobModel
+		if err := tx.Where("id = ?", jobId).First(&job).Error; err != nil {
+			return err
+		}
+
+		return dispatchWebhookEvent(tx, job.UserID, models.WebhookEventJobProcessing, jobEventData(&job))
 	})
 
-	if dbCtx.Error != nil {
-		return dbCtx.Error
+	return claimed, err
+}
+
+// UpdateJobStage registra la etapa completada del pipeline y la URL del playlist subido
+func (service *jobServiceImp) UpdateJobStage(jobId, stage, m3u8FileURL string) error {
+	return updateJob(jobId, map[string]interface{}{
+		"stage":         stage,
+		"m3u8_file_url": m3u8FileURL,
+	}, "")
+}
+
+// UpdateJobLoudness guarda la sonoridad medida al transcodificar, para que un reintento
+// que no repite ffmpeg la pueda guardar en el video
+func (service *jobServiceImp) UpdateJobLoudness(jobId string, loudness models.Loudness) error {
+	return updateJob(jobId, map[string]interface{}{
+		"loudness_integrated": loudness.Integrated,
+		"loudness_true_peak":  loudness.TruePeak,
+	}, "")
+}
+
+// UpdateJobAudioTracks guarda las pistas de audio detectadas al transcodificar, para que un
+// reintento que no repite ffmpeg las pueda guardar en el video
+func (service *jobServiceImp) UpdateJobAudioTracks(jobId string, tracks []models.AudioTrack) error {
+	data, err := json.Marshal(tracks)
+	if err != nil {
+		return err
 	}
 
-	if dbCtx.RowsAffected == 0 {
-		return fmt.Errorf("job con id %s no encontrado", jobId)
+	return updateJob(jobId, map[string]interface{}{
+		"audio_tracks": string(data),
+	}, "")
+}
+
+// updateJob aplica los cambios al job y, si event no está vacío, crea las entregas
+// de webhook del dueño del job en la misma transacción
+func updateJob(jobId string, updates map[string]interface{}, event string) error {
+	db, err := config.GetDB()
+	if err != nil {
+		return err
 	}
 
-	return nil
+	return db.Transaction(func(tx *gorm.DB) error {
+		dbCtx := tx.Model(&models.JobModel{}).Where("id = ?", jobId).Updates(updates)
+
+		if dbCtx.Error != nil {
+			return dbCtx.Error
+		}
+
+		if dbCtx.RowsAffected == 0 {
+			return fmt.Errorf("job con id %s no encontrado", jobId)
+		}
+
+		if event == "" {
+			return nil
+		}
+
+		var job models.JobModel
+		if err := tx.Where("id = ?", jobId).First(&job).Error; err != nil {
+			return err
+		}
+
+		event := jobEventToDispatch(event, &job, config.GetConfig().WorkerMaxJobAttempts)
+		if event == "" {
+			return nil
+		}
+
+		return dispatchWebhookEvent(tx, job.UserID, event, jobEventData(&job))
+	})
+}
+
+// jobEventToDispatch retorna el evento a notificar después de actualizar el job, o "" si no
+// hay que avisar: un fallo con intentos disponibles se reintenta (ClaimJob) y no es definitivo.
+func jobEventToDispatch(event string, job *models.JobModel, maxAttempts int) string {
+	if event == models.WebhookEventJobFailed && job.Attempts < maxAttempts {
+		return ""
+	}
+	return event
 }
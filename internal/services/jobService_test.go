@@ -0,0 +1,94 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/unbot2313/go-streaming-service/internal/models"
+)
+
+func TestJobEventToDispatch(t *testing.T) {
+	tests := []struct {
+		name     string
+		event    string
+		attempts int
+		want     string
+	}{
+		{"retryable failure sends nothing", models.WebhookEventJobFailed, 1, ""},
+		{"failure before the last attempt sends nothing", models.WebhookEventJobFailed, 2, ""},
+		{"failure on the last attempt", models.WebhookEventJobFailed, 3, models.WebhookEventJobFailed},
+		{"failure after the last attempt", models.WebhookEventJobFailed, 4, models.WebhookEventJobFailed},
+		{"completion is always sent", models.WebhookEventJobCompleted, 1, models.WebhookEventJobCompleted},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			job := &models.JobModel{Job: models.Job{Status: "failed", Attempts: tt.attempts}}
+			if got := jobEventToDispatch(tt.event, job, 3); got != tt.want {
+				t.Errorf("expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestJobPriority(t *testing.T) {
+	tests := []struct {
+		name     string
+		duration float64
+		plan     string
+		want     int
+	}{
+		{"unknown duration", 0, models.UserPlanFree, 4},
+		{"short video", 45, models.UserPlanFree, 6},
+		{"one minute", 60, models.UserPlanFree, 6},
+		{"medium video", 5 * 60, models.UserPlanFree, 4},
+		{"long video", 30 * 60, models.UserPlanFree, 2},
+		{"very long video", 3 * 60 * 60, models.UserPlanFree, 1},
+		{"unknown plan counts as free", 45, "", 6},
+		{"pro short video", 45, models.UserPlanPro, 9},
+		{"pro unknown duration", 0, models.UserPlanPro, 7},
+		{"pro very long video", 3 * 60 * 60, models.UserPlanPro, 4},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := JobPriority(tt.duration, tt.plan)
+			if got != tt.want {
+				t.Errorf("expected priority %d, got %d", tt.want, got)
+			}
+			// El máximo queda para los administradores
+			if got >= QueueMaxPriority {
+				t.Errorf("priority %d reaches the admin-only maximum %d", got, QueueMaxPriority)
+			}
+		})
+	}
+}
+
+func TestClampJobPriority(t *testing.T) {
+	tests := []struct {
+		priority int
+		want     int
+	}{
+		{-1, 0},
+		{-300, 0},
+		{0, 0},
+		{5, 5},
+		{QueueMaxPriority, QueueMaxPriority},
+		{QueueMaxPriority + 1, QueueMaxPriority},
+		{255, QueueMaxPriority},
+		{1000, QueueMaxPriority},
+	}
+
+	for _, tt := range tests {
+		if got := clampJobPriority(tt.priority); got != tt.want {
+			t.Errorf("priority %d: expected %d, got %d", tt.priority, tt.want, got)
+		}
+	}
+}
+
+func TestClampPriority(t *testing.T) {
+	for priority, want := range map[uint8]uint8{0: 0, 7: 7, QueueMaxPriority: QueueMaxPriority, QueueMaxPriority + 1: QueueMaxPriority, 255: QueueMaxPriority} {
+		if got := clampPriority(priority); got != want {
+			t.Errorf("priority %d: expected %d, got %d", priority, want, got)
+		}
+	}
+}
@@ -0,0 +1,356 @@
+package services
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/unbot2313/go-streaming-service/config"
+)
+
+const (
+	// jwtKeysReloadInterval es cada cuánto se relee JWT_KEYS_DIR para tomar la clave nueva tras una rotación
+	jwtKeysReloadInterval = time.Minute
+	// jwtKeysMinReload evita releer el directorio en cada token con un kid desconocido
+	jwtKeysMinReload = 5 * time.Second
+	// jwtKeyIDLayout es el formato de los kid que genera la rotación; ordenan igual que las fechas
+	jwtKeyIDLayout = "20060102T150405Z"
+	// jwtRSAKeyBits es el tamaño de las claves RSA nuevas
+	jwtRSAKeyBits = 2048
+)
+
+// ErrNoSigningKey indica que JWT_KEYS_DIR no tiene ninguna clave del algoritmo configurado
+var ErrNoSigningKey = errors.New("no hay ninguna clave de firma en JWT_KEYS_DIR, genera una con make rotate-jwt-key")
+
+// JWK es la clave pública de un kid en formato JSON Web Key (RFC 7517)
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+}
+
+// JWKSet es el documento de /.well-known/jwks.json
+type JWKSet struct {
+	Keys []JWK `json:"keys"`
+}
+
+type jwtKey struct {
+	id      string
+	method  jwt.SigningMethod
+	private crypto.Signer
+}
+
+// jwtKeyring guarda las claves de JWT_KEYS_DIR. Firma con la más nueva del algoritmo configurado
+// y valida con cualquiera de ellas, así los tokens firmados con una clave rotada siguen sirviendo.
+type jwtKeyring struct {
+	mu       sync.Mutex
+	keys     map[string]*jwtKey
+	signing  *jwtKey
+	loadedAt time.Time
+}
+
+var jwtKeys = &jwtKeyring{}
+
+// signToken firma claims con la clave activa: el secreto en HS256 o la clave más nueva con su kid
+func signToken(claims jwt.MapClaims) (string, error) {
+	cfg := config.GetConfig()
+
+	if cfg.JWTSigningAlgorithm == "HS256" {
+		return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(cfg.JWTSecretKey))
+	}
+
+	key, err := jwtKeys.signingKey()
+	if err != nil {
+		return "", err
+	}
+
+	token := jwt.NewWithClaims(key.method, claims)
+	token.Header["kid"] = key.id
+
+	return token.SignedString(key.private)
+}
+
+// parseToken valida la firma de un token con la clave de su kid, o con el secreto si no trae kid
+func parseToken(tokenString string) (*jwt.Token, error) {
+	return jwt.Parse(tokenString, verificationKey, jwt.WithValidMethods([]string{"HS256", "RS256", "EdDSA"}))
+}
+
+func verificationKey(token *jwt.Token) (interface{}, error) {
+	keyId, ok := token.Header["kid"].(string)
+	if !ok {
+		// Tokens HS256, incluidos los emitidos antes de pasar a RS256/EdDSA
+		secret := config.GetConfig().JWTSecretKey
+		if _, isHMAC := token.Method.(*jwt.SigningMethodHMAC); !isHMAC || secret == "" {
+			return nil, fmt.Errorf("método de firma inesperado: %v", token.Header["alg"])
+		}
+		return []byte(secret), nil
+	}
+
+	key, err := jwtKeys.find(keyId)
+	if err != nil {
+		return nil, err
+	}
+	if token.Method.Alg() != key.method.Alg() {
+		return nil, fmt.Errorf("el kid %s no firma con %v", keyId, token.Header["alg"])
+	}
+
+	return key.private.Public(), nil
+}
+
+// JWKS retorna las claves públicas con las que se validan los tokens
+func JWKS() (*JWKSet, error) {
+	keys, err := jwtKeys.all()
+	if err != nil {
+		return nil, err
+	}
+
+	set := &JWKSet{Keys: []JWK{}}
+	for _, key := range keys {
+		jwk := JWK{Kid: key.id, Use: "sig", Alg: key.method.Alg()}
+
+		switch public := key.private.Public().(type) {
+		case *rsa.PublicKey:
+			jwk.Kty = "RSA"
+			jwk.N = base64.RawURLEncoding.EncodeToString(public.N.Bytes())
+			jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(public.E)).Bytes())
+		case ed25519.PublicKey:
+			jwk.Kty = "OKP"
+			jwk.Crv = "Ed25519"
+			jwk.X = base64.RawURLEncoding.EncodeToString(public)
+		}
+
+		set.Keys = append(set.Keys, jwk)
+	}
+
+	return set, nil
+}
+
+// GenerateJWTKey crea una clave nueva en dir y retorna su kid. Pasa a ser la de firma
+// cuando cada instancia relee el directorio (a lo sumo jwtKeysReloadInterval).
+func GenerateJWTKey(dir, algorithm string) (string, error) {
+	var private crypto.Signer
+	var err error
+
+	switch algorithm {
+	case "RS256":
+		private, err = rsa.GenerateKey(rand.Reader, jwtRSAKeyBits)
+	case "EdDSA":
+		_, private, err = ed25519.GenerateKey(rand.Reader)
+	default:
+		return "", fmt.Errorf("algoritmo no soportado para rotar claves: %s", algorithm)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	der, err := x509.MarshalPKCS8PrivateKey(private)
+	if err != nil {
+		return "", err
+	}
+
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return "", err
+	}
+
+	keyId := time.Now().UTC().Format(jwtKeyIDLayout)
+	file, err := os.OpenFile(filepath.Join(dir, keyId+".pem"), os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	if err := pem.Encode(file, &pem.Block{Type: "PRIVATE KEY", Bytes: der}); err != nil {
+		return "", err
+	}
+
+	return keyId, nil
+}
+
+// PruneJWTKeys borra las claves reemplazadas hace más de retention (más lo que tardan las
+// instancias en tomar la clave nueva): los tokens que firmaron ya vencieron.
+// Las claves con un kid que no es una fecha se dejan, se manejan a mano.
+func PruneJWTKeys(dir string, retention time.Duration) ([]string, error) {
+	keyIds, err := jwtKeyIDs(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var removed []string
+	for i := 0; i < len(keyIds)-1; i++ {
+		replacedAt, err := time.Parse(jwtKeyIDLayout, keyIds[i+1])
+		if err != nil {
+			continue
+		}
+		if _, err := time.Parse(jwtKeyIDLayout, keyIds[i]); err != nil {
+			continue
+		}
+		if time.Since(replacedAt) <= retention+jwtKeysReloadInterval {
+			break
+		}
+
+		if err := os.Remove(filepath.Join(dir, keyIds[i]+".pem")); err != nil {
+			return removed, err
+		}
+		removed = append(removed, keyIds[i])
+	}
+
+	return removed, nil
+}
+
+// jwtKeyIDs lista los kid de dir, del más viejo al más nuevo
+func jwtKeyIDs(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var keyIds []string
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".pem" {
+			continue
+		}
+		keyIds = append(keyIds, strings.TrimSuffix(entry.Name(), ".pem"))
+	}
+	sort.Strings(keyIds)
+
+	return keyIds, nil
+}
+
+func (k *jwtKeyring) signingKey() (*jwtKey, error) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	if err := k.loadIfStale(jwtKeysReloadInterval); err != nil {
+		return nil, err
+	}
+	if k.signing == nil {
+		return nil, ErrNoSigningKey
+	}
+
+	return k.signing, nil
+}
+
+func (k *jwtKeyring) find(keyId string) (*jwtKey, error) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	if err := k.loadIfStale(jwtKeysReloadInterval); err != nil {
+		return nil, err
+	}
+
+	// Un kid desconocido puede ser una clave que otra instancia generó recién
+	key, ok := k.keys[keyId]
+	if !ok {
+		if err := k.loadIfStale(jwtKeysMinReload); err != nil {
+			return nil, err
+		}
+		key, ok = k.keys[keyId]
+	}
+	if !ok {
+		return nil, fmt.Errorf("kid desconocido: %s", keyId)
+	}
+
+	return key, nil
+}
+
+func (k *jwtKeyring) all() ([]*jwtKey, error) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	if err := k.loadIfStale(jwtKeysReloadInterval); err != nil {
+		return nil, err
+	}
+
+	keys := make([]*jwtKey, 0, len(k.keys))
+	for _, key := range k.keys {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].id > keys[j].id })
+
+	return keys, nil
+}
+
+// loadIfStale relee JWT_KEYS_DIR si pasó más de maxAge desde la última lectura; se llama con mu tomado
+func (k *jwtKeyring) loadIfStale(maxAge time.Duration) error {
+	if k.keys != nil && time.Since(k.loadedAt) < maxAge {
+		return nil
+	}
+
+	cfg := config.GetConfig()
+
+	keyIds, err := jwtKeyIDs(cfg.JWTKeysDir)
+	if err != nil {
+		return fmt.Errorf("error al leer JWT_KEYS_DIR: %v", err)
+	}
+
+	keys := make(map[string]*jwtKey, len(keyIds))
+	var signing *jwtKey
+	for _, keyId := range keyIds {
+		key, err := loadJWTKey(cfg.JWTKeysDir, keyId)
+		if err != nil {
+			slog.Warn("skipping invalid JWT key", slog.String("kid", keyId), slog.Any("error", err))
+			continue
+		}
+		keys[keyId] = key
+
+		// Los kid están ordenados: la última clave del algoritmo configurado es la más nueva
+		if key.method.Alg() == cfg.JWTSigningAlgorithm {
+			signing = key
+		}
+	}
+
+	k.keys = keys
+	k.signing = signing
+	k.loadedAt = time.Now()
+
+	return nil
+}
+
+func loadJWTKey(dir, keyId string) (*jwtKey, error) {
+	data, err := os.ReadFile(filepath.Join(dir, keyId+".pem"))
+	if err != nil {
+		return nil, err
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("el archivo no tiene un bloque PEM")
+	}
+
+	private, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+
+	switch private := private.(type) {
+	case *rsa.PrivateKey:
+		return &jwtKey{id: keyId, method: jwt.SigningMethodRS256, private: private}, nil
+	case ed25519.PrivateKey:
+		return &jwtKey{id: keyId, method: jwt.SigningMethodEdDSA, private: private}, nil
+	default:
+		return nil, fmt.Errorf("tipo de clave no soportado: %T", private)
+	}
+}
@@ -0,0 +1,67 @@
+package mailer
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/unbot2313/go-streaming-service/config"
+)
+
+// Message es un email de texto plano
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer define la interfaz genérica para enviar emails
+type Mailer interface {
+	// Send envía el mensaje; retorna error si no se pudo entregar al servidor o al sink
+	Send(ctx context.Context, message Message) error
+}
+
+// NewMailer crea el Mailer configurado en MAILER_TYPE
+func NewMailer() Mailer {
+	cfg := config.GetConfig()
+
+	switch cfg.MailerType {
+	case "smtp":
+		return NewSMTPMailer()
+	case "file":
+		return NewFileMailer(cfg.MailFileDir)
+	default:
+		return NewLogMailer()
+	}
+}
+
+// buildMessage arma el mensaje RFC 5322 que se envía por SMTP o se guarda como .eml
+func buildMessage(from string, message Message) ([]byte, error) {
+	// Un salto de línea en un header permitiría inyectar otros headers
+	for _, value := range []string{from, message.To, message.Subject} {
+		if strings.ContainsAny(value, "\r\n") {
+			return nil, fmt.Errorf("header de email inválido: %q", value)
+		}
+	}
+
+	domain := "localhost"
+	if at := strings.LastIndex(from, "@"); at >= 0 {
+		domain = from[at+1:]
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "From: %s\r\n", from)
+	fmt.Fprintf(&buf, "To: %s\r\n", message.To)
+	fmt.Fprintf(&buf, "Subject: %s\r\n", message.Subject)
+	fmt.Fprintf(&buf, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	fmt.Fprintf(&buf, "Message-ID: <%s@%s>\r\n", uuid.New().String(), domain)
+	buf.WriteString("MIME-Version: 1.0\r\n")
+	buf.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	buf.WriteString("\r\n")
+	buf.WriteString(strings.ReplaceAll(strings.ReplaceAll(message.Body, "\r\n", "\n"), "\n", "\r\n"))
+
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,56 @@
+package mailer
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/unbot2313/go-streaming-service/config"
+)
+
+// LogMailer implementa Mailer escribiendo los emails en el log, para desarrollo
+type LogMailer struct{}
+
+// NewLogMailer crea una nueva instancia de LogMailer
+func NewLogMailer() Mailer {
+	return &LogMailer{}
+}
+
+// Send loguea el mensaje completo, links incluidos
+func (m *LogMailer) Send(ctx context.Context, message Message) error {
+	slog.Info("email not sent (MAILER_TYPE=log)",
+		slog.String("to", message.To),
+		slog.String("subject", message.Subject),
+		slog.String("body", message.Body),
+	)
+	return nil
+}
+
+// FileMailer implementa Mailer guardando cada email como un archivo .eml en un directorio
+type FileMailer struct {
+	dir  string
+	from string
+}
+
+// NewFileMailer crea una nueva instancia de FileMailer
+func NewFileMailer(dir string) Mailer {
+	return &FileMailer{dir: dir, from: config.GetConfig().MailFrom}
+}
+
+// Send guarda el mensaje en <dir>/<fecha>-<id>.eml; se puede abrir con cualquier cliente de correo
+func (m *FileMailer) Send(ctx context.Context, message Message) error {
+	data, err := buildMessage(m.from, message)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(m.dir, 0o755); err != nil {
+		return err
+	}
+
+	name := time.Now().UTC().Format("20060102T150405.000000000Z") + "-" + uuid.New().String() + ".eml"
+	return os.WriteFile(filepath.Join(m.dir, name), data, 0o600)
+}
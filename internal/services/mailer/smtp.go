@@ -0,0 +1,100 @@
+package mailer
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"net/smtp"
+	"strconv"
+	"time"
+
+	"github.com/unbot2313/go-streaming-service/config"
+)
+
+// smtpTimeout limita cuánto puede tardar un envío si el contexto no tiene deadline
+const smtpTimeout = 30 * time.Second
+
+// SMTPMailer implementa Mailer enviando por SMTP, con STARTTLS si el servidor lo ofrece
+type SMTPMailer struct {
+	host     string
+	port     int
+	user     string
+	password string
+	from     string
+}
+
+// NewSMTPMailer crea una nueva instancia de SMTPMailer
+func NewSMTPMailer() Mailer {
+	cfg := config.GetConfig()
+
+	return &SMTPMailer{
+		host:     cfg.SMTPHost,
+		port:     cfg.SMTPPort,
+		user:     cfg.SMTPUser,
+		password: cfg.SMTPPassword,
+		from:     cfg.MailFrom,
+	}
+}
+
+// Send envía el mensaje al servidor SMTP
+func (m *SMTPMailer) Send(ctx context.Context, message Message) error {
+	data, err := buildMessage(m.from, message)
+	if err != nil {
+		return err
+	}
+
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, smtpTimeout)
+		defer cancel()
+	}
+
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort(m.host, strconv.Itoa(m.port)))
+	if err != nil {
+		return err
+	}
+	deadline, _ := ctx.Deadline()
+	conn.SetDeadline(deadline)
+
+	client, err := smtp.NewClient(conn, m.host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer client.Close()
+
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		if err := client.StartTLS(&tls.Config{ServerName: m.host}); err != nil {
+			return err
+		}
+	}
+
+	// smtp.PlainAuth se niega a mandar la contraseña sin TLS, salvo a localhost
+	if m.user != "" {
+		if err := client.Auth(smtp.PlainAuth("", m.user, m.password, m.host)); err != nil {
+			return err
+		}
+	}
+
+	if err := client.Mail(m.from); err != nil {
+		return err
+	}
+	if err := client.Rcpt(message.To); err != nil {
+		return err
+	}
+
+	writer, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := writer.Write(data); err != nil {
+		writer.Close()
+		return err
+	}
+	if err := writer.Close(); err != nil {
+		return err
+	}
+
+	return client.Quit()
+}
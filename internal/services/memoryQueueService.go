@@ -0,0 +1,297 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/unbot2313/go-streaming-service/config"
+)
+
+const (
+	// MemoryQueueCapacity es el máximo de mensajes en espera por cola en memoria.
+	// Si se llena, Publish falla y el outbox vuelve a intentarlo más tarde.
+	MemoryQueueCapacity = 1000
+	// DeadLetterSuffix se agrega al nombre de la cola para formar su dead letter queue
+	DeadLetterSuffix = ".dlq"
+)
+
+// memoryMessage es un mensaje en una cola en memoria, con headers como los de RabbitMQ
+type memoryMessage struct {
+	body     []byte
+	priority uint8
+	headers  map[string]interface{}
+}
+
+// memoryQueue tiene un canal por prioridad. ready recibe una señal por cada mensaje
+// encolado: quien la toma tiene garantizado un mensaje y lo saca del canal de mayor prioridad.
+type memoryQueue struct {
+	lanes     [QueueMaxPriority + 1]chan memoryMessage
+	ready     chan struct{}
+	consumers int
+}
+
+// next saca el mensaje de mayor prioridad; solo se llama tras tomar una señal de ready
+func (q *memoryQueue) next() memoryMessage {
+	for {
+		for priority := QueueMaxPriority; priority >= 0; priority-- {
+			select {
+			case msg := <-q.lanes[priority]:
+				return msg
+			default:
+			}
+		}
+	}
+}
+
+// MemoryQueueServiceImp implementa RabbitMQService con canales de Go, sin broker.
+// Pensado para tests y para correr todo en un solo proceso (QUEUE_TYPE=memory):
+// los mensajes no sobreviven a un reinicio.
+type MemoryQueueServiceImp struct {
+	mu     sync.Mutex
+	queues map[string]*memoryQueue
+
+	// retryDelay es la espera antes de republicar un mensaje fallido (RetryDelay)
+	retryDelay time.Duration
+
+	stopping       chan struct{}
+	stopOnce       sync.Once
+	consumers      sync.WaitGroup
+	handlersCtx    context.Context
+	cancelHandlers context.CancelFunc
+}
+
+// NewMemoryQueueService crea una cola en memoria vacía
+func NewMemoryQueueService() RabbitMQService {
+	handlersCtx, cancelHandlers := context.WithCancel(context.Background())
+
+	return &MemoryQueueServiceImp{
+		queues:         make(map[string]*memoryQueue),
+		retryDelay:     RetryDelay,
+		stopping:       make(chan struct{}),
+		handlersCtx:    handlersCtx,
+		cancelHandlers: cancelHandlers,
+	}
+}
+
+// NewQueueService crea el backend de colas elegido con QUEUE_TYPE
+func NewQueueService() RabbitMQService {
+	if config.GetConfig().QueueType == "memory" {
+		return NewMemoryQueueService()
+	}
+	return NewRabbitMQService()
+}
+
+// queue retorna una cola, creándola si no existe
+func (m *MemoryQueueServiceImp) queue(queueName string) *memoryQueue {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	q, ok := m.queues[queueName]
+	if !ok {
+		q = &memoryQueue{ready: make(chan struct{}, MemoryQueueCapacity*(QueueMaxPriority+1))}
+		for i := range q.lanes {
+			q.lanes[i] = make(chan memoryMessage, MemoryQueueCapacity)
+		}
+		m.queues[queueName] = q
+	}
+	return q
+}
+
+// Connect no hace nada: no hay broker al que conectarse
+func (m *MemoryQueueServiceImp) Connect() error {
+	slog.Info("using in-memory queue")
+	return nil
+}
+
+// Close no hace nada: las colas viven mientras viva el proceso
+func (m *MemoryQueueServiceImp) Close() {}
+
+// Publish agrega un mensaje a la cola. Falla si la cola está llena.
+func (m *MemoryQueueServiceImp) Publish(queueName string, message []byte, props MessageProperties) error {
+	return m.enqueue(queueName, memoryMessage{body: message, priority: clampPriority(props.Priority)})
+}
+
+// enqueue agrega un mensaje al canal de su prioridad sin bloquear
+func (m *MemoryQueueServiceImp) enqueue(queueName string, msg memoryMessage) error {
+	q := m.queue(queueName)
+
+	select {
+	case q.lanes[msg.priority] <- msg:
+		q.ready <- struct{}{}
+		slog.Info("message published",
+			slog.String("queue", queueName),
+			slog.Int("priority", int(msg.priority)),
+			slog.String("body", string(msg.body)),
+		)
+		return nil
+	default:
+		return fmt.Errorf("la cola en memoria %s está llena", queueName)
+	}
+}
+
+// Inspect retorna los mensajes en espera por prioridad y los consumidores de una cola
+func (m *MemoryQueueServiceImp) Inspect(queueName string) (*QueueInfo, error) {
+	q := m.queue(queueName)
+
+	m.mu.Lock()
+	info := &QueueInfo{Consumers: q.consumers, ByPriority: make(map[uint8]int)}
+	m.mu.Unlock()
+
+	for priority, lane := range q.lanes {
+		if depth := len(lane); depth > 0 {
+			info.ByPriority[uint8(priority)] = depth
+			info.Messages += depth
+		}
+	}
+
+	return info, nil
+}
+
+// Consume procesa los mensajes de una cola con hasta concurrency handlers en paralelo.
+// Los reintentos usan el header x-retry-count igual que en RabbitMQ; al agotarlos
+// el mensaje pasa a la dead letter queue <cola>.dlq.
+func (m *MemoryQueueServiceImp) Consume(queueName string, concurrency int, handler MessageHandler) error {
+	q := m.queue(queueName)
+
+	m.mu.Lock()
+	q.consumers += concurrency
+	m.mu.Unlock()
+
+	slog.Info("worker waiting for messages",
+		slog.String("queue", queueName),
+		slog.Int("concurrency", concurrency),
+	)
+
+	for i := 0; i < concurrency; i++ {
+		m.consumers.Add(1)
+		go func() {
+			defer m.consumers.Done()
+			for {
+				// Dar prioridad al apagado sobre los mensajes en espera
+				select {
+				case <-m.stopping:
+					return
+				default:
+				}
+
+				select {
+				case <-m.stopping:
+					return
+				case <-q.ready:
+					m.handleMessage(queueName, q.next(), handler)
+				}
+			}
+		}()
+	}
+
+	return nil
+}
+
+// handleMessage procesa un mensaje y decide si descartarlo, reintentarlo o mandarlo a la dead letter queue
+func (m *MemoryQueueServiceImp) handleMessage(queueName string, msg memoryMessage, handler MessageHandler) {
+	retryCount := memoryRetryCount(msg.headers)
+	slog.Info("message received",
+		slog.String("queue", queueName),
+		slog.Int("attempt", retryCount+1),
+		slog.Int("max_retries", MaxRetries),
+	)
+
+	err := handler(m.handlersCtx, msg.body)
+	if err == nil {
+		slog.Info("message processed successfully")
+		return
+	}
+
+	if m.handlersCtx.Err() != nil {
+		// El worker se apagó antes de terminar: devolver el mensaje sin consumir un reintento
+		slog.Warn("worker shutting down, returning message to queue",
+			slog.String("queue", queueName),
+			slog.Any("error", err),
+		)
+		if err := m.enqueue(queueName, msg); err != nil {
+			slog.Error("could not return message to queue", slog.String("queue", queueName), slog.Any("error", err))
+		}
+		return
+	}
+
+	if retryCount >= MaxRetries-1 {
+		// Máximo de reintentos alcanzado: mover a la dead letter queue
+		slog.Warn("max retries reached, moving message to dead letter queue",
+			slog.String("queue", queueName),
+			slog.Int("max_retries", MaxRetries),
+		)
+		deadLetter := memoryMessage{
+			body:     msg.body,
+			priority: msg.priority,
+			headers: map[string]interface{}{
+				"x-retry-count":       retryCount,
+				"x-first-death-queue": queueName,
+				"x-death-reason":      err.Error(),
+			},
+		}
+		if err := m.enqueue(queueName+DeadLetterSuffix, deadLetter); err != nil {
+			slog.Error("could not move message to dead letter queue", slog.String("queue", queueName), slog.Any("error", err))
+		}
+		return
+	}
+
+	slog.Warn("error processing message, retrying",
+		slog.Any("error", err),
+		slog.String("retry_delay", m.retryDelay.String()),
+		slog.Int("attempt", retryCount+2),
+		slog.Int("max_retries", MaxRetries),
+	)
+
+	// Republicar con el contador incrementado tras retryDelay, sin ocupar el handler
+	retry := memoryMessage{
+		body:     msg.body,
+		priority: msg.priority,
+		headers:  map[string]interface{}{"x-retry-count": retryCount + 1},
+	}
+	time.AfterFunc(m.retryDelay, func() {
+		if err := m.enqueue(queueName, retry); err != nil {
+			slog.Error("could not republish message", slog.String("queue", queueName), slog.Any("error", err))
+		}
+	})
+}
+
+// Shutdown deja de tomar mensajes nuevos y espera a que terminen los que están en curso.
+// Si ctx vence antes, cancela el contexto de los handlers y los mensajes vuelven a la cola.
+func (m *MemoryQueueServiceImp) Shutdown(ctx context.Context) error {
+	m.stopOnce.Do(func() { close(m.stopping) })
+
+	done := make(chan struct{})
+	go func() {
+		m.consumers.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		slog.Info("all in-flight messages finished")
+		return nil
+	case <-ctx.Done():
+	}
+
+	slog.Warn("shutdown deadline reached, cancelling in-flight handlers")
+	m.cancelHandlers()
+
+	select {
+	case <-done:
+	case <-time.After(HandlerCancelGrace):
+		slog.Error("handlers did not stop after cancellation")
+	}
+
+	return ctx.Err()
+}
+
+// memoryRetryCount extrae el contador de reintentos de los headers del mensaje
+func memoryRetryCount(headers map[string]interface{}) int {
+	if count, ok := headers["x-retry-count"].(int); ok {
+		return count
+	}
+	return 0
+}
@@ -0,0 +1,180 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// newTestMemoryQueue crea una cola en memoria con reintentos casi inmediatos y la apaga al terminar el test
+func newTestMemoryQueue(t *testing.T) *MemoryQueueServiceImp {
+	t.Helper()
+
+	m := NewMemoryQueueService().(*MemoryQueueServiceImp)
+	m.retryDelay = time.Millisecond
+	t.Cleanup(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		m.Shutdown(ctx)
+	})
+	return m
+}
+
+// waitFor espera hasta que cond se cumpla o falla el test
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("timed out waiting for condition")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestMemoryQueue_PublishConsume(t *testing.T) {
+	m := newTestMemoryQueue(t)
+
+	received := make(chan string, 1)
+	if err := m.Consume("videos", 1, func(ctx context.Context, message []byte) error {
+		received <- string(message)
+		return nil
+	}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := m.Publish("videos", []byte(`{"job_id":"job-1"}`), MessageProperties{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	select {
+	case body := <-received:
+		if body != `{"job_id":"job-1"}` {
+			t.Errorf("unexpected body %q", body)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("message was not consumed")
+	}
+}
+
+func TestMemoryQueue_PriorityLanes(t *testing.T) {
+	m := newTestMemoryQueue(t)
+
+	// Se publican antes de consumir para que el orden dependa solo de la prioridad
+	for _, msg := range []struct {
+		body     string
+		priority uint8
+	}{
+		{"low", 1},
+		{"admin", QueueMaxPriority},
+		{"pro", 5},
+		{"low-2", 1},
+	} {
+		if err := m.Publish("videos", []byte(msg.body), MessageProperties{Priority: msg.priority}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	info, err := m.Inspect("videos")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if info.Messages != 4 || info.ByPriority[1] != 2 || info.ByPriority[QueueMaxPriority] != 1 {
+		t.Errorf("unexpected queue depth: %+v", info)
+	}
+
+	var mu sync.Mutex
+	var order []string
+	m.Consume("videos", 1, func(ctx context.Context, message []byte) error {
+		mu.Lock()
+		order = append(order, string(message))
+		mu.Unlock()
+		return nil
+	})
+
+	waitFor(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(order) == 4
+	})
+
+	expected := []string{"admin", "pro", "low", "low-2"}
+	for i := range expected {
+		if order[i] != expected[i] {
+			t.Fatalf("expected order %v, got %v", expected, order)
+		}
+	}
+}
+
+func TestMemoryQueue_RetryIncrementsHeader(t *testing.T) {
+	m := newTestMemoryQueue(t)
+
+	m.handleMessage("videos", memoryMessage{body: []byte("task"), priority: 3}, func(ctx context.Context, message []byte) error {
+		return errors.New("ffmpeg failed")
+	})
+
+	q := m.queue("videos")
+	var retry memoryMessage
+	select {
+	case <-q.ready:
+		retry = q.next()
+	case <-time.After(2 * time.Second):
+		t.Fatal("message was not republished")
+	}
+
+	if got := memoryRetryCount(retry.headers); got != 1 {
+		t.Errorf("expected x-retry-count 1, got %d", got)
+	}
+	if retry.priority != 3 || string(retry.body) != "task" {
+		t.Errorf("retry should keep body and priority, got %q with priority %d", retry.body, retry.priority)
+	}
+}
+
+func TestMemoryQueue_DeadLetterAfterMaxRetries(t *testing.T) {
+	m := newTestMemoryQueue(t)
+
+	var mu sync.Mutex
+	attempts := 0
+	m.Consume("videos", 1, func(ctx context.Context, message []byte) error {
+		mu.Lock()
+		attempts++
+		mu.Unlock()
+		return errors.New("ffmpeg failed")
+	})
+
+	if err := m.Publish("videos", []byte("task"), MessageProperties{Priority: 2}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	waitFor(t, func() bool {
+		info, _ := m.Inspect("videos" + DeadLetterSuffix)
+		return info.Messages == 1
+	})
+
+	mu.Lock()
+	if attempts != MaxRetries {
+		t.Errorf("expected %d attempts, got %d", MaxRetries, attempts)
+	}
+	mu.Unlock()
+
+	dlq := m.queue("videos" + DeadLetterSuffix)
+	<-dlq.ready
+	dead := dlq.next()
+	if string(dead.body) != "task" || dead.priority != 2 {
+		t.Errorf("unexpected dead letter %q with priority %d", dead.body, dead.priority)
+	}
+	if dead.headers["x-first-death-queue"] != "videos" || dead.headers["x-death-reason"] != "ffmpeg failed" {
+		t.Errorf("unexpected dead letter headers: %v", dead.headers)
+	}
+	if got := memoryRetryCount(dead.headers); got != MaxRetries-1 {
+		t.Errorf("expected x-retry-count %d, got %d", MaxRetries-1, got)
+	}
+
+	// La cola original queda vacía: el mensaje no se reintenta más
+	if info, _ := m.Inspect("videos"); info.Messages != 0 {
+		t.Errorf("expected empty queue, got %d messages", info.Messages)
+	}
+}
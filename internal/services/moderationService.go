@@ -0,0 +1,238 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/unbot2313/go-streaming-service/config"
+	"github.com/unbot2313/go-streaming-service/internal/models"
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrUserNotFound indica que el usuario no existe
+	ErrUserNotFound = errors.New("usuario no encontrado")
+	// ErrVideoNotFound indica que el video no existe
+	ErrVideoNotFound = errors.New("video no encontrado")
+	// ErrAdminAlreadyExists indica que ya hay un administrador y no hace falta el bootstrap
+	ErrAdminAlreadyExists = errors.New("ya existe un administrador")
+)
+
+type PaginatedUsers struct {
+	Data     []*models.User `json:"data"`
+	Page     int            `json:"page"`
+	PageSize int            `json:"page_size"`
+	Total    int64          `json:"total"`
+}
+
+// ModerationService agrupa las operaciones de moderadores y administradores sobre cualquier usuario o video
+type ModerationService interface {
+	ListUsers(page, pageSize int) (*PaginatedUsers, error)
+	SetUserSuspended(userId string, suspended bool) (*models.User, error)
+	SetUserRole(userId, role string) (*models.User, error)
+	ListVideos(page, pageSize int) (*PaginatedVideos, error)
+	SetVideoSuspended(videoId string, suspended bool) (*models.VideoModel, error)
+	BootstrapAdmin(username string) (*models.User, error)
+}
+
+type moderationServiceImp struct{}
+
+func NewModerationService() ModerationService {
+	return &moderationServiceImp{}
+}
+
+// ListUsers lista todos los usuarios, incluidos los suspendidos, del más nuevo al más viejo
+func (s *moderationServiceImp) ListUsers(page, pageSize int) (*PaginatedUsers, error) {
+	db, err := config.GetDB()
+	if err != nil {
+		return nil, err
+	}
+
+	var total int64
+	if err := db.Model(&models.User{}).Count(&total).Error; err != nil {
+		return nil, err
+	}
+
+	var users []*models.User
+	if err := db.Order("created_at DESC").Limit(pageSize).Offset((page - 1) * pageSize).Find(&users).Error; err != nil {
+		return nil, err
+	}
+
+	return &PaginatedUsers{
+		Data:     users,
+		Page:     page,
+		PageSize: pageSize,
+		Total:    total,
+	}, nil
+}
+
+// SetUserSuspended suspende o reactiva una cuenta. Al suspenderla se cierran todas sus sesiones
+// y se invalidan sus access tokens: el usuario no puede renovarlas ni volver a iniciar sesión.
+func (s *moderationServiceImp) SetUserSuspended(userId string, suspended bool) (*models.User, error) {
+	db, err := config.GetDB()
+	if err != nil {
+		return nil, err
+	}
+
+	if !suspended {
+		return updateUser(db, userId, map[string]interface{}{"suspended_at": nil})
+	}
+
+	var user *models.User
+	err = db.Transaction(func(tx *gorm.DB) error {
+		user, err = updateUser(tx, userId, map[string]interface{}{"suspended_at": time.Now()})
+		if err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", userId).Delete(&models.Session{}).Error; err != nil {
+			return err
+		}
+		return bumpTokenVersion(tx, userId)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	forgetTokenVersion(userId)
+
+	return user, nil
+}
+
+// SetUserRole cambia el rol de un usuario. Sus access tokens se invalidan para que el rol
+// anterior no siga vigente; el nuevo se aplica en el próximo refresh.
+func (s *moderationServiceImp) SetUserRole(userId, role string) (*models.User, error) {
+	if !models.IsValidUserRole(role) {
+		return nil, fmt.Errorf("rol inválido: %s", role)
+	}
+
+	db, err := config.GetDB()
+	if err != nil {
+		return nil, err
+	}
+
+	var user *models.User
+	err = db.Transaction(func(tx *gorm.DB) error {
+		user, err = updateUser(tx, userId, map[string]interface{}{"role": role})
+		if err != nil {
+			return err
+		}
+		return bumpTokenVersion(tx, userId)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	forgetTokenVersion(userId)
+
+	return user, nil
+}
+
+// ListVideos lista todos los videos, incluidos los programados y los suspendidos
+func (s *moderationServiceImp) ListVideos(page, pageSize int) (*PaginatedVideos, error) {
+	db, err := config.GetDB()
+	if err != nil {
+		return nil, err
+	}
+
+	var total int64
+	if err := db.Model(&models.VideoModel{}).Count(&total).Error; err != nil {
+		return nil, err
+	}
+
+	var videos []*models.VideoModel
+	if err := db.Order("created_at DESC").Limit(pageSize).Offset((page - 1) * pageSize).Find(&videos).Error; err != nil {
+		return nil, err
+	}
+
+	return &PaginatedVideos{
+		Data:     videos,
+		Page:     page,
+		PageSize: pageSize,
+		Total:    total,
+	}, nil
+}
+
+// SetVideoSuspended suspende o reactiva un video. Un video suspendido deja de aparecer en
+// los listados y solo su dueño puede pedir sus claves.
+func (s *moderationServiceImp) SetVideoSuspended(videoId string, suspended bool) (*models.VideoModel, error) {
+	db, err := config.GetDB()
+	if err != nil {
+		return nil, err
+	}
+
+	var suspendedAt *time.Time
+	if suspended {
+		now := time.Now()
+		suspendedAt = &now
+	}
+
+	result := db.Model(&models.VideoModel{}).Where("id = ?", videoId).Update("suspended_at", suspendedAt)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, ErrVideoNotFound
+	}
+
+	var video models.VideoModel
+	if err := db.Where("id = ?", videoId).First(&video).Error; err != nil {
+		return nil, err
+	}
+
+	return &video, nil
+}
+
+// BootstrapAdmin convierte en administrador al usuario username. Solo funciona mientras
+// no exista ningún administrador; después los roles se cambian desde /admin/users.
+func (s *moderationServiceImp) BootstrapAdmin(username string) (*models.User, error) {
+	db, err := config.GetDB()
+	if err != nil {
+		return nil, err
+	}
+
+	var user *models.User
+	err = db.Transaction(func(tx *gorm.DB) error {
+		var admins int64
+		if err := tx.Model(&models.User{}).Where("role = ?", models.UserRoleAdmin).Count(&admins).Error; err != nil {
+			return err
+		}
+		if admins > 0 {
+			return ErrAdminAlreadyExists
+		}
+
+		var target models.User
+		if err := tx.Where("username = ?", username).First(&target).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrUserNotFound
+			}
+			return err
+		}
+
+		user, err = updateUser(tx, target.Id, map[string]interface{}{"role": models.UserRoleAdmin})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+// updateUser aplica updates al usuario y lo retorna actualizado
+func updateUser(db *gorm.DB, userId string, updates map[string]interface{}) (*models.User, error) {
+	result := db.Model(&models.User{}).Where("id = ?", userId).Updates(updates)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, ErrUserNotFound
+	}
+
+	var user models.User
+	if err := db.Where("id = ?", userId).First(&user).Error; err != nil {
+		return nil, err
+	}
+
+	return &user, nil
+}
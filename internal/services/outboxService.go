@@ -0,0 +1,220 @@
+package services
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/unbot2313/go-streaming-service/config"
+	"github.com/unbot2313/go-streaming-service/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	// OutboxPollInterval es cada cuánto el relay busca mensajes pendientes
+	OutboxPollInterval = 1 * time.Second
+	// OutboxBatchSize es el máximo de mensajes que se publican por ciclo
+	OutboxBatchSize = 50
+	// OutboxRetention es el tiempo que se conservan los mensajes ya enviados
+	OutboxRetention = 7 * 24 * time.Hour
+	// outboxPublishTimeout es lo máximo que tarda una publicación (el timeout de Publish);
+	// el lease de un lote alcanza para publicarlo entero
+	outboxPublishTimeout = 5 * time.Second
+)
+
+// PublishFunc publica un mensaje en una cola y retorna error si el broker no lo confirmó
+type PublishFunc func(queueName string, payload []byte, props MessageProperties) error
+
+type OutboxService interface {
+	Enqueue(queueName string, payload []byte) error
+	RelayPending(batchSize int, publish PublishFunc) (int, error)
+	PurgeSent(olderThan time.Duration) (int64, error)
+}
+
+type outboxServiceImp struct{}
+
+func NewOutboxService() OutboxService {
+	return &outboxServiceImp{}
+}
+
+// enqueueOutboxMessage inserta un mensaje en el outbox usando la transacción recibida.
+// Lo usan los servicios que necesitan escribir su cambio y el mensaje de forma atómica.
+func enqueueOutboxMessage(tx *gorm.DB, queueName string, payload []byte, priority int) error {
+	message := models.OutboxMessage{
+		Id:       uuid.New().String(),
+		Queue:    queueName,
+		Payload:  payload,
+		Priority: priority,
+		Status:   models.OutboxStatusPending,
+	}
+
+	return tx.Create(&message).Error
+}
+
+// Enqueue guarda un mensaje en el outbox fuera de cualquier otra transacción
+func (s *outboxServiceImp) Enqueue(queueName string, payload []byte) error {
+	db, err := config.GetDB()
+	if err != nil {
+		return err
+	}
+
+	return enqueueOutboxMessage(db, queueName, payload, 0)
+}
+
+// RelayPending publica los mensajes pendientes en orden de creación.
+// Los mensajes se reclaman en una transacción corta que les pone un lease, así varias
+// instancias de la API pueden correr el relay sin tomar los mismos y ninguna conexión
+// queda ocupada mientras se espera la confirmación del broker. Si el relay muere con el
+// lease tomado el mensaje se vuelve a publicar al vencer; ClaimJob tolera el duplicado.
+// Se detiene en el primer error para no desordenar la cola si el broker está caído.
+func (s *outboxServiceImp) RelayPending(batchSize int, publish PublishFunc) (int, error) {
+	db, err := config.GetDB()
+	if err != nil {
+		return 0, err
+	}
+
+	messages, err := claimOutboxMessages(db, batchSize, time.Duration(batchSize+1)*outboxPublishTimeout)
+	if err != nil {
+		return 0, err
+	}
+
+	sent := 0
+	for i, message := range messages {
+		props := MessageProperties{Priority: uint8(message.Priority)}
+		if publishErr := publish(message.Queue, message.Payload, props); publishErr != nil {
+			slog.Warn("outbox publish failed",
+				slog.String("outbox_id", message.Id),
+				slog.String("queue", message.Queue),
+				slog.Any("error", publishErr),
+			)
+			if err := db.Model(&models.OutboxMessage{}).Where("id = ?", message.Id).Updates(map[string]interface{}{
+				"attempts":     gorm.Expr("attempts + 1"),
+				"last_error":   publishErr.Error(),
+				"locked_until": nil,
+			}).Error; err != nil {
+				return sent, err
+			}
+			return sent, releaseOutboxMessages(db, messages[i+1:])
+		}
+
+		if err := db.Model(&models.OutboxMessage{}).Where("id = ?", message.Id).Updates(map[string]interface{}{
+			"status":       models.OutboxStatusSent,
+			"attempts":     gorm.Expr("attempts + 1"),
+			"sent_at":      time.Now(),
+			"locked_until": nil,
+		}).Error; err != nil {
+			return sent, err
+		}
+		sent++
+	}
+
+	return sent, nil
+}
+
+// claimOutboxMessages reclama hasta batchSize mensajes pendientes sin lease vigente y
+// les pone un lease de now+lease. Las filas se bloquean con SKIP LOCKED solo mientras dura la transacción.
+func claimOutboxMessages(db *gorm.DB, batchSize int, lease time.Duration) ([]models.OutboxMessage, error) {
+	var messages []models.OutboxMessage
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ? AND (locked_until IS NULL OR locked_until < ?)", models.OutboxStatusPending, now).
+			Order("created_at ASC").
+			Limit(batchSize).
+			Find(&messages).Error; err != nil {
+			return err
+		}
+		if len(messages) == 0 {
+			return nil
+		}
+
+		return tx.Model(&models.OutboxMessage{}).
+			Where("id IN ?", outboxMessageIds(messages)).
+			Update("locked_until", now.Add(lease)).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return messages, nil
+}
+
+// releaseOutboxMessages quita el lease de los mensajes que no se llegaron a publicar
+func releaseOutboxMessages(db *gorm.DB, messages []models.OutboxMessage) error {
+	if len(messages) == 0 {
+		return nil
+	}
+
+	return db.Model(&models.OutboxMessage{}).
+		Where("id IN ?", outboxMessageIds(messages)).
+		Update("locked_until", nil).Error
+}
+
+func outboxMessageIds(messages []models.OutboxMessage) []string {
+	ids := make([]string, len(messages))
+	for i := range messages {
+		ids[i] = messages[i].Id
+	}
+	return ids
+}
+
+// PurgeSent elimina los mensajes enviados hace más de olderThan
+func (s *outboxServiceImp) PurgeSent(olderThan time.Duration) (int64, error) {
+	db, err := config.GetDB()
+	if err != nil {
+		return 0, err
+	}
+
+	result := db.Where("status = ? AND sent_at < ?", models.OutboxStatusSent, time.Now().Add(-olderThan)).
+		Delete(&models.OutboxMessage{})
+
+	return result.RowsAffected, result.Error
+}
+
+// OutboxRelay publica periódicamente en RabbitMQ los mensajes pendientes del outbox
+type OutboxRelay struct {
+	outboxService   OutboxService
+	rabbitMQService RabbitMQService
+}
+
+func NewOutboxRelay(outboxService OutboxService, rabbitMQService RabbitMQService) *OutboxRelay {
+	return &OutboxRelay{
+		outboxService:   outboxService,
+		rabbitMQService: rabbitMQService,
+	}
+}
+
+// Start corre el relay hasta que se cancele el contexto
+func (r *OutboxRelay) Start(ctx context.Context) {
+	pollTicker := time.NewTicker(OutboxPollInterval)
+	defer pollTicker.Stop()
+
+	purgeTicker := time.NewTicker(time.Hour)
+	defer purgeTicker.Stop()
+
+	slog.Info("outbox relay started")
+
+	for {
+		select {
+		case <-ctx.Done():
+			slog.Info("outbox relay stopped")
+			return
+		case <-pollTicker.C:
+			sent, err := r.outboxService.RelayPending(OutboxBatchSize, r.rabbitMQService.Publish)
+			if err != nil {
+				slog.Error("error relaying outbox messages", slog.Any("error", err))
+				continue
+			}
+			if sent > 0 {
+				slog.Info("outbox messages relayed", slog.Int("count", sent))
+			}
+		case <-purgeTicker.C:
+			if _, err := r.outboxService.PurgeSent(OutboxRetention); err != nil {
+				slog.Error("error purging outbox messages", slog.Any("error", err))
+			}
+		}
+	}
+}
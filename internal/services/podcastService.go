@@ -0,0 +1,132 @@
+package services
+
+import (
+	"encoding/xml"
+	"errors"
+	"time"
+
+	"github.com/unbot2313/go-streaming-service/config"
+	"github.com/unbot2313/go-streaming-service/internal/models"
+	"gorm.io/gorm"
+)
+
+// PodcastFeedLimit es la cantidad máxima de episodios del feed, del más reciente al más antiguo
+const PodcastFeedLimit = 100
+
+// ErrPodcastNotFound indica que no existe el usuario del feed pedido
+var ErrPodcastNotFound = errors.New("el usuario del podcast no existe")
+
+type PodcastService interface {
+	BuildFeed(username, feedURL string) ([]byte, error)
+}
+
+type podcastServiceImp struct{}
+
+func NewPodcastService() PodcastService {
+	return &podcastServiceImp{}
+}
+
+// Estructuras del feed RSS 2.0
+type rssFeed struct {
+	XMLName xml.Name   `xml:"rss"`
+	Version string     `xml:"version,attr"`
+	Atom    string     `xml:"xmlns:atom,attr"`
+	Channel rssChannel `xml:"channel"`
+}
+
+type rssChannel struct {
+	Title         string      `xml:"title"`
+	Link          string      `xml:"link"`
+	Description   string      `xml:"description"`
+	AtomLink      rssAtomLink `xml:"atom:link"`
+	LastBuildDate string      `xml:"lastBuildDate,omitempty"`
+	Items         []rssItem   `xml:"item"`
+}
+
+type rssAtomLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr"`
+	Type string `xml:"type,attr"`
+}
+
+type rssItem struct {
+	Title       string       `xml:"title"`
+	Description string       `xml:"description,omitempty"`
+	GUID        rssGUID      `xml:"guid"`
+	PubDate     string       `xml:"pubDate"`
+	Enclosure   rssEnclosure `xml:"enclosure"`
+}
+
+type rssGUID struct {
+	IsPermaLink string `xml:"isPermaLink,attr"`
+	Value       string `xml:",chardata"`
+}
+
+type rssEnclosure struct {
+	URL    string `xml:"url,attr"`
+	Length int64  `xml:"length,attr"`
+	Type   string `xml:"type,attr"`
+}
+
+// BuildFeed arma el feed RSS 2.0 con los audios publicados del usuario. Solo incluye
+// los episodios cuyo archivo M4A ya se generó, porque es la URL del enclosure.
+func (s *podcastServiceImp) BuildFeed(username, feedURL string) ([]byte, error) {
+	db, err := config.GetDB()
+	if err != nil {
+		return nil, err
+	}
+
+	var user models.User
+	if err := db.Select("id", "username").Where("username = ?", username).First(&user).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrPodcastNotFound
+		}
+		return nil, err
+	}
+
+	var episodes []models.VideoModel
+	if err := db.Scopes(publishedVideosScope).
+		Where("user_id = ? AND media_type = ? AND audio_file_url <> ''", user.Id, models.MediaTypeAudio).
+		Order("published_at DESC").
+		Limit(PodcastFeedLimit).
+		Find(&episodes).Error; err != nil {
+		return nil, err
+	}
+
+	channel := rssChannel{
+		Title:       user.Username,
+		Link:        feedURL,
+		Description: "Podcast de " + user.Username,
+		AtomLink:    rssAtomLink{Href: feedURL, Rel: "self", Type: "application/rss+xml"},
+		Items:       make([]rssItem, 0, len(episodes)),
+	}
+
+	for _, episode := range episodes {
+		channel.Items = append(channel.Items, rssItem{
+			Title:       episode.Title,
+			Description: episode.Description,
+			GUID:        rssGUID{IsPermaLink: "false", Value: episode.Id},
+			PubDate:     episode.PublishedAt.Format(time.RFC1123Z),
+			Enclosure: rssEnclosure{
+				URL:    episode.AudioFileURL,
+				Length: episode.AudioFileSize,
+				Type:   "audio/mp4",
+			},
+		})
+	}
+
+	if len(episodes) > 0 {
+		channel.LastBuildDate = episodes[0].PublishedAt.Format(time.RFC1123Z)
+	}
+
+	feed, err := xml.MarshalIndent(rssFeed{
+		Version: "2.0",
+		Atom:    "http://www.w3.org/2005/Atom",
+		Channel: channel,
+	}, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	return append([]byte(xml.Header), feed...), nil
+}
@@ -0,0 +1,77 @@
+package services
+
+import (
+	"sort"
+
+	"github.com/unbot2313/go-streaming-service/config"
+	"github.com/unbot2313/go-streaming-service/internal/models"
+)
+
+type QueueStatsService interface {
+	ListQueues() ([]models.QueueStats, error)
+}
+
+type queueStatsServiceImp struct {
+	queueService RabbitMQService
+	jobService   JobService
+}
+
+func NewQueueStatsService(queueService RabbitMQService, jobService JobService) QueueStatsService {
+	return &queueStatsServiceImp{
+		queueService: queueService,
+		jobService:   jobService,
+	}
+}
+
+// ListQueues retorna el estado de las colas de video y thumbnails con su profundidad por prioridad.
+// RabbitMQ no informa los mensajes por prioridad: para la cola de video se usan los jobs
+// "pending" de cada prioridad, y las tareas de thumbnails siempre se publican con prioridad 0.
+func (s *queueStatsServiceImp) ListQueues() ([]models.QueueStats, error) {
+	cfg := config.GetConfig()
+
+	var stats []models.QueueStats
+
+	for _, queueName := range []string{cfg.RabbitMQVideoQueue, cfg.RabbitMQThumbnailQueue} {
+		info, err := s.queueService.Inspect(queueName)
+		if err != nil {
+			return nil, err
+		}
+
+		byPriority := make(map[int]int)
+		switch {
+		case info.ByPriority != nil:
+			for priority, messages := range info.ByPriority {
+				byPriority[int(priority)] = messages
+			}
+		case queueName == cfg.RabbitMQVideoQueue:
+			if byPriority, err = s.jobService.CountPendingByPriority(); err != nil {
+				return nil, err
+			}
+		case info.Messages > 0:
+			byPriority[0] = info.Messages
+		}
+
+		stats = append(stats, models.QueueStats{
+			Queue:      queueName,
+			Messages:   info.Messages,
+			Consumers:  info.Consumers,
+			Priorities: priorityDepths(byPriority),
+		})
+	}
+
+	return stats, nil
+}
+
+// priorityDepths ordena los mensajes por prioridad de mayor a menor
+func priorityDepths(byPriority map[int]int) []models.QueuePriorityDepth {
+	depths := make([]models.QueuePriorityDepth, 0, len(byPriority))
+	for priority, messages := range byPriority {
+		depths = append(depths, models.QueuePriorityDepth{Priority: priority, Messages: messages})
+	}
+
+	sort.Slice(depths, func(i, j int) bool {
+		return depths[i].Priority > depths[j].Priority
+	})
+
+	return depths
+}
@@ -2,10 +2,13 @@ package services
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
+	"sync"
 	"time"
 
+	"github.com/google/uuid"
 	"github.com/unbot2313/go-streaming-service/config"
 	amqp "github.com/rabbitmq/amqp091-go"
 )
@@ -15,29 +18,61 @@ const (
 	MaxRetries = 3
 	// RetryDelay es el tiempo de espera entre reintentos
 	RetryDelay = 5 * time.Second
+	// HandlerCancelGrace es lo que se espera a que los handlers terminen tras cancelarlos
+	HandlerCancelGrace = 30 * time.Second
+	// QueueMaxPriority es la prioridad máxima de las colas (x-max-priority).
+	// Los mensajes con mayor prioridad se entregan antes que los que esperan en la cola.
+	QueueMaxPriority = 10
 )
 
+// MessageProperties son las propiedades con las que se publica un mensaje
+type MessageProperties struct {
+	// Priority va de 0 a QueueMaxPriority; los valores mayores se recortan
+	Priority uint8
+}
+
+// QueueInfo es el estado de una cola en el broker
+type QueueInfo struct {
+	Messages  int
+	Consumers int
+	// ByPriority tiene los mensajes en espera por prioridad, o nil si el broker no lo informa
+	ByPriority map[uint8]int
+}
+
 // MessageHandler es una función que procesa un mensaje recibido
-// Retorna error si el procesamiento falla (el mensaje será reenviado)
-type MessageHandler func(message []byte) error
+// Retorna error si el procesamiento falla (el mensaje será reenviado).
+// El contexto se cancela si el worker se apaga antes de que termine.
+type MessageHandler func(ctx context.Context, message []byte) error
 
 // RabbitMQService define la interfaz para comunicarse con RabbitMQ
 type RabbitMQService interface {
 	Connect() error
 	Close()
-	Publish(queueName string, message []byte) error
-	Consume(queueName string, handler MessageHandler) error
+	Publish(queueName string, message []byte, props MessageProperties) error
+	Consume(queueName string, concurrency int, handler MessageHandler) error
+	Shutdown(ctx context.Context) error
+	Inspect(queueName string) (*QueueInfo, error)
 }
 
 // RabbitMQServiceImp es la implementación del servicio
 type RabbitMQServiceImp struct {
 	connection *amqp.Connection
 	channel    *amqp.Channel
+
+	consumerTags   []string
+	consumers      sync.WaitGroup
+	handlersCtx    context.Context
+	cancelHandlers context.CancelFunc
 }
 
 // NewRabbitMQService crea una nueva instancia del servicio
 func NewRabbitMQService() RabbitMQService {
-	return &RabbitMQServiceImp{}
+	handlersCtx, cancelHandlers := context.WithCancel(context.Background())
+
+	return &RabbitMQServiceImp{
+		handlersCtx:    handlersCtx,
+		cancelHandlers: cancelHandlers,
+	}
 }
 
 // logError registra errores sin detener la ejecución
@@ -49,6 +84,21 @@ func logError(err error, msg string) bool {
 	return false
 }
 
+// queueArguments son los argumentos con que se declaran todas las colas.
+// Publisher y consumer deben declararlas igual: RabbitMQ rechaza redeclarar
+// una cola existente con otros argumentos.
+func queueArguments() amqp.Table {
+	return amqp.Table{"x-max-priority": int32(QueueMaxPriority)}
+}
+
+// clampPriority limita la prioridad al máximo de las colas
+func clampPriority(priority uint8) uint8 {
+	if priority > QueueMaxPriority {
+		return QueueMaxPriority
+	}
+	return priority
+}
+
 // getRetryCount extrae el contador de reintentos de los headers del mensaje
 func getRetryCount(headers amqp.Table) int {
 	if headers == nil {
@@ -86,6 +136,11 @@ func (r *RabbitMQServiceImp) Connect() error {
 	}
 	r.channel = ch
 
+	// Activar publisher confirms: el broker confirma cada mensaje que persiste
+	if err := ch.Confirm(false); err != nil {
+		return fmt.Errorf("error al activar publisher confirms: %w", err)
+	}
+
 	slog.Info("connected to RabbitMQ")
 	return nil
 }
@@ -101,16 +156,16 @@ func (r *RabbitMQServiceImp) Close() {
 	slog.Info("RabbitMQ connection closed")
 }
 
-// Publish envía un mensaje a una cola con persistencia
-func (r *RabbitMQServiceImp) Publish(queueName string, message []byte) error {
+// Publish envía un mensaje a una cola con persistencia y espera la confirmación del broker
+func (r *RabbitMQServiceImp) Publish(queueName string, message []byte, props MessageProperties) error {
 	// Declarar la cola durable (sobrevive reinicios de RabbitMQ)
 	queue, err := r.channel.QueueDeclare(
-		queueName, // nombre
-		true,      // durable: la cola sobrevive al reinicio del servidor
-		false,     // autoDelete: NO se elimina cuando no hay consumidores
-		false,     // exclusive: NO es exclusiva de esta conexión
-		false,     // noWait: esperar confirmación del servidor
-		nil,       // arguments: sin argumentos extra
+		queueName,        // nombre
+		true,             // durable: la cola sobrevive al reinicio del servidor
+		false,            // autoDelete: NO se elimina cuando no hay consumidores
+		false,            // exclusive: NO es exclusiva de esta conexión
+		false,            // noWait: esperar confirmación del servidor
+		queueArguments(), // arguments: cola con prioridades (x-max-priority)
 	)
 	if logError(err, "Error al declarar la cola") {
 		return err
@@ -121,7 +176,7 @@ func (r *RabbitMQServiceImp) Publish(queueName string, message []byte) error {
 	defer cancel()
 
 	// Publicar mensaje con persistencia
-	err = r.channel.PublishWithContext(
+	confirmation, err := r.channel.PublishWithDeferredConfirmWithContext(
 		ctx,
 		"",         // exchange: usamos el exchange por defecto
 		queue.Name, // routing key: nombre de la cola
@@ -130,6 +185,7 @@ func (r *RabbitMQServiceImp) Publish(queueName string, message []byte) error {
 		amqp.Publishing{
 			DeliveryMode: amqp.Persistent, // Mensaje persistente (guardado en disco)
 			ContentType:  "text/plain",
+			Priority:     clampPriority(props.Priority),
 			Body:         message,
 		},
 	)
@@ -137,105 +193,193 @@ func (r *RabbitMQServiceImp) Publish(queueName string, message []byte) error {
 		return err
 	}
 
+	// Esperar el ack del broker: sin él no hay garantía de que el mensaje se guardó
+	acked, err := confirmation.WaitContext(ctx)
+	if logError(err, "Error esperando confirmación del broker") {
+		return err
+	}
+	if !acked {
+		return errors.New("el broker rechazó el mensaje (nack)")
+	}
+
 	slog.Info("message published",
 		slog.String("queue", queueName),
+		slog.Int("priority", int(clampPriority(props.Priority))),
 		slog.String("body", string(message)),
 	)
 	return nil
 }
 
-// Consume escucha mensajes de una cola y los procesa con el handler proporcionado
+// Inspect retorna los mensajes en espera y los consumidores de una cola.
+// RabbitMQ no informa cuántos mensajes hay por prioridad, así que ByPriority es nil.
+func (r *RabbitMQServiceImp) Inspect(queueName string) (*QueueInfo, error) {
+	// Declarar en vez de declarar en modo pasivo: si la cola no existe, un declare
+	// pasivo cierra el canal que también usa el relay para publicar
+	queue, err := r.channel.QueueDeclare(queueName, true, false, false, false, queueArguments())
+	if err != nil {
+		return nil, err
+	}
+
+	return &QueueInfo{
+		Messages:  queue.Messages,
+		Consumers: queue.Consumers,
+	}, nil
+}
+
+// Consume escucha mensajes de una cola y los procesa con el handler proporcionado,
+// usando hasta concurrency handlers en paralelo.
 // El handler debe retornar nil si el procesamiento fue exitoso, o error si falló
 // Si el handler falla, el mensaje será reenviado a otro worker (Nack)
-func (r *RabbitMQServiceImp) Consume(queueName string, handler MessageHandler) error {
+func (r *RabbitMQServiceImp) Consume(queueName string, concurrency int, handler MessageHandler) error {
 	// Declarar la cola durable (debe coincidir con el publisher)
 	queue, err := r.channel.QueueDeclare(
 		queueName,
-		true,  // durable: la cola sobrevive al reinicio del servidor
-		false, // autoDelete
-		false, // exclusive
-		false, // noWait
-		nil,   // arguments
+		true,             // durable: la cola sobrevive al reinicio del servidor
+		false,            // autoDelete
+		false,            // exclusive
+		false,            // noWait
+		queueArguments(), // arguments: debe coincidir con el publisher
 	)
 	if logError(err, "Error al declarar la cola") {
 		return err
 	}
 
-	// Configurar QoS: solo recibir 1 mensaje a la vez
+	// Configurar QoS: recibir tantos mensajes como handlers en paralelo
 	// Esto distribuye el trabajo equitativamente entre workers
 	err = r.channel.Qos(
-		1,     // prefetch count: procesar 1 mensaje a la vez
-		0,     // prefetch size: sin límite de bytes
-		false, // global: aplica solo a este consumer
+		concurrency, // prefetch count: un mensaje por handler
+		0,           // prefetch size: sin límite de bytes
+		false,       // global: aplica solo a este consumer
 	)
 	if logError(err, "Error al configurar QoS") {
 		return err
 	}
 
 	// Registrar consumidor con acknowledgment manual
+	consumerTag := queueName + "-" + uuid.New().String()
 	messages, err := r.channel.Consume(
-		queue.Name, // cola
-		"",         // consumer: nombre vacío = generado automáticamente
-		false,      // autoAck: FALSE - confirmaremos manualmente después de procesar
-		false,      // exclusive: NO exclusivo (permite múltiples workers)
-		false,      // noLocal: permitir mensajes del mismo conexión
-		false,      // noWait: esperar confirmación
-		nil,        // arguments
+		queue.Name,  // cola
+		consumerTag, // consumer: tag propio para poder cancelarlo en el apagado
+		false,       // autoAck: FALSE - confirmaremos manualmente después de procesar
+		false,       // exclusive: NO exclusivo (permite múltiples workers)
+		false,       // noLocal: permitir mensajes del mismo conexión
+		false,       // noWait: esperar confirmación
+		nil,         // arguments
 	)
 	if logError(err, "Error al registrar consumidor") {
 		return err
 	}
+	r.consumerTags = append(r.consumerTags, consumerTag)
 
-	slog.Info("worker waiting for messages", slog.String("queue", queueName))
+	slog.Info("worker waiting for messages",
+		slog.String("queue", queueName),
+		slog.Int("concurrency", concurrency),
+	)
 
-	// Escuchar mensajes en un goroutine
-	go func() {
-		for msg := range messages {
-			retryCount := getRetryCount(msg.Headers)
-			slog.Info("message received",
-				slog.String("queue", queueName),
-				slog.Int("attempt", retryCount+1),
+	// Escuchar mensajes con N goroutines; terminan cuando se cancela el consumidor
+	for i := 0; i < concurrency; i++ {
+		r.consumers.Add(1)
+		go func() {
+			defer r.consumers.Done()
+			for msg := range messages {
+				r.handleDelivery(queueName, msg, handler)
+			}
+		}()
+	}
+
+	return nil
+}
+
+// handleDelivery procesa un mensaje y decide si confirmarlo, reintentarlo o devolverlo a la cola
+func (r *RabbitMQServiceImp) handleDelivery(queueName string, msg amqp.Delivery, handler MessageHandler) {
+	retryCount := getRetryCount(msg.Headers)
+	slog.Info("message received",
+		slog.String("queue", queueName),
+		slog.Int("attempt", retryCount+1),
+		slog.Int("max_retries", MaxRetries),
+	)
+
+	// Procesar el mensaje con el handler
+	err := handler(r.handlersCtx, msg.Body)
+
+	if err != nil && r.handlersCtx.Err() != nil {
+		// El worker se apagó antes de terminar: devolver el mensaje a la cola
+		// sin consumir un reintento para que lo tome otro worker
+		slog.Warn("worker shutting down, returning message to queue",
+			slog.String("queue", queueName),
+			slog.Any("error", err),
+		)
+		msg.Nack(false, true)
+		return
+	}
+
+	if err != nil {
+		if retryCount >= MaxRetries-1 {
+			// Máximo de reintentos alcanzado, descartar mensaje
+			slog.Warn("max retries reached, discarding message",
 				slog.Int("max_retries", MaxRetries),
 			)
+			msg.Ack(false)
+		} else {
+			// Reintentar: Ack el mensaje actual y republicar con contador incrementado
+			slog.Warn("error processing message, retrying",
+				slog.Any("error", err),
+				slog.String("retry_delay", RetryDelay.String()),
+				slog.Int("attempt", retryCount+2),
+				slog.Int("max_retries", MaxRetries),
+			)
+			msg.Ack(false)
 
-			// Procesar el mensaje con el handler
-			err := handler(msg.Body)
-
-			if err != nil {
-				if retryCount >= MaxRetries-1 {
-					// Máximo de reintentos alcanzado, descartar mensaje
-					slog.Warn("max retries reached, discarding message",
-						slog.Int("max_retries", MaxRetries),
-					)
-					msg.Ack(false)
-				} else {
-					// Reintentar: Ack el mensaje actual y republicar con contador incrementado
-					slog.Warn("error processing message, retrying",
-						slog.Any("error", err),
-						slog.String("retry_delay", RetryDelay.String()),
-						slog.Int("attempt", retryCount+2),
-						slog.Int("max_retries", MaxRetries),
-					)
-					msg.Ack(false)
-
-					// Esperar antes de reintentar
-					time.Sleep(RetryDelay)
-
-					// Republicar con retry count incrementado
-					r.republishWithRetry(queueName, msg.Body, retryCount+1)
-				}
-			} else {
-				slog.Info("message processed successfully")
-				msg.Ack(false)
-			}
+			// Esperar antes de reintentar
+			time.Sleep(RetryDelay)
+
+			// Republicar con retry count incrementado
+			r.republishWithRetry(queueName, msg.Body, retryCount+1, msg.Priority)
 		}
+	} else {
+		slog.Info("message processed successfully")
+		msg.Ack(false)
+	}
+}
+
+// Shutdown deja de consumir mensajes nuevos y espera a que terminen los que están en curso.
+// Si ctx vence antes, cancela el contexto de los handlers: los mensajes
+// que no alcanzaron a terminar se devuelven a la cola con Nack.
+func (r *RabbitMQServiceImp) Shutdown(ctx context.Context) error {
+	for _, tag := range r.consumerTags {
+		if err := r.channel.Cancel(tag, false); err != nil {
+			slog.Error("error cancelling consumer", slog.String("consumer", tag), slog.Any("error", err))
+		}
+	}
+
+	done := make(chan struct{})
+	go func() {
+		r.consumers.Wait()
+		close(done)
 	}()
 
-	return nil
+	select {
+	case <-done:
+		slog.Info("all in-flight messages finished")
+		return nil
+	case <-ctx.Done():
+	}
+
+	slog.Warn("shutdown deadline reached, cancelling in-flight handlers")
+	r.cancelHandlers()
+
+	select {
+	case <-done:
+	case <-time.After(HandlerCancelGrace):
+		slog.Error("handlers did not stop after cancellation")
+	}
+
+	return ctx.Err()
 }
 
-// republishWithRetry republica un mensaje con el contador de reintentos incrementado
-func (r *RabbitMQServiceImp) republishWithRetry(queueName string, body []byte, retryCount int) error {
+// republishWithRetry republica un mensaje con el contador de reintentos incrementado,
+// conservando su prioridad
+func (r *RabbitMQServiceImp) republishWithRetry(queueName string, body []byte, retryCount int, priority uint8) error {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
@@ -248,6 +392,7 @@ func (r *RabbitMQServiceImp) republishWithRetry(queueName string, body []byte, r
 		amqp.Publishing{
 			DeliveryMode: amqp.Persistent,
 			ContentType:  "application/json",
+			Priority:     priority,
 			Headers: amqp.Table{
 				"x-retry-count": int32(retryCount),
 			},
@@ -76,7 +76,7 @@ func (m *MinIOStorage) UploadFolder(ctx context.Context, localFolder string) (Up
 		// Construir URL del archivo
 		fileURL := fmt.Sprintf("http://%s/%s/%s", m.endpoint, m.bucketName, objectName)
 
-		if strings.HasSuffix(file.Name(), ".m3u8") {
+		if isMainPlaylist(file.Name(), m3u8FileURL) {
 			m3u8FileURL = fileURL
 		}
 
@@ -98,6 +98,24 @@ func (m *MinIOStorage) UploadFolder(ctx context.Context, localFolder string) (Up
 	}, nil
 }
 
+// UploadFile sube un único archivo a MinIO
+func (m *MinIOStorage) UploadFile(ctx context.Context, localPath, objectName, contentType string) (string, error) {
+	_, err := m.client.FPutObject(
+		ctx,
+		m.bucketName,
+		objectName,
+		localPath,
+		minio.PutObjectOptions{ContentType: contentType},
+	)
+	if err != nil {
+		return "", fmt.Errorf("error subiendo %s a MinIO: %w", objectName, err)
+	}
+
+	slog.Info("MinIO uploaded", slog.String("object", objectName))
+
+	return fmt.Sprintf("http://%s/%s/%s", m.endpoint, m.bucketName, objectName), nil
+}
+
 // DeleteFolder elimina todos los objetos dentro de una carpeta en MinIO
 func (m *MinIOStorage) DeleteFolder(ctx context.Context, folderName string) error {
 	slog.Info("MinIO deleting objects", slog.String("folder", folderName))
@@ -71,7 +71,7 @@ func (s *S3Storage) UploadFolder(ctx context.Context, localFolder string) (Uploa
 			return UploadResult{BaseFolder: baseFolder}, err
 		}
 
-		if strings.HasSuffix(file.Name(), ".m3u8") {
+		if isMainPlaylist(file.Name(), m3u8FileURL) {
 			m3u8FileURL = result.Location
 		}
 
@@ -91,6 +91,27 @@ func (s *S3Storage) UploadFolder(ctx context.Context, localFolder string) (Uploa
 	}, nil
 }
 
+// UploadFile sube un único archivo a S3
+func (s *S3Storage) UploadFile(ctx context.Context, localPath, objectName, contentType string) (string, error) {
+	f, err := os.Open(localPath)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	result, err := s.uploader.Upload(ctx, &s3.PutObjectInput{
+		Bucket:      aws.String(s.bucketName),
+		Key:         aws.String(objectName),
+		Body:        f,
+		ContentType: aws.String(contentType),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return result.Location, nil
+}
+
 // DeleteFolder elimina todos los objetos dentro de una carpeta en S3
 func (s *S3Storage) DeleteFolder(ctx context.Context, folderName string) error {
 	slog.Info("S3 deleting objects", slog.String("folder", folderName))
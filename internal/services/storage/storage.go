@@ -2,10 +2,24 @@ package storage
 
 import (
 	"context"
+	"strings"
 
 	"github.com/unbot2313/go-streaming-service/config"
 )
 
+// MasterPlaylistName es el master playlist que agrupa las renditions de un video.
+// Si la carpeta lo tiene, UploadFolder lo retorna como el playlist del video.
+const MasterPlaylistName = "master.m3u8"
+
+// isMainPlaylist indica si el archivo subido es el playlist que se retorna en M3u8FileURL:
+// el master playlist o, si no hay, el primero que se subió
+func isMainPlaylist(fileName, current string) bool {
+	if !strings.HasSuffix(fileName, ".m3u8") {
+		return false
+	}
+	return current == "" || fileName == MasterPlaylistName
+}
+
 // UploadResult contiene las URLs de los archivos importantes después de subir
 type UploadResult struct {
 	M3u8FileURL  string
@@ -24,6 +38,9 @@ type StorageService interface {
 	// UploadFolder sube todos los archivos de una carpeta local al storage
 	UploadFolder(ctx context.Context, localFolder string) (UploadResult, error)
 
+	// UploadFile sube un único archivo local con el nombre de objeto indicado y retorna su URL
+	UploadFile(ctx context.Context, localPath, objectName, contentType string) (string, error)
+
 	// DeleteFolder elimina todos los objetos dentro de una carpeta en el storage
 	DeleteFolder(ctx context.Context, folderName string) error
 
@@ -55,6 +55,7 @@ func (s *tagServiceImp) FindVideosByTag(tagName string, page, pageSize int) (*Pa
 	if err := db.Model(&models.VideoModel{}).
 		Joins("JOIN video_tags ON video_tags.video_model_id = videos.id").
 		Where("video_tags.tag_id = ?", tag.Id).
+		Scopes(publishedVideosScope).
 		Count(&total).Error; err != nil {
 		return nil, err
 	}
@@ -65,6 +66,7 @@ func (s *tagServiceImp) FindVideosByTag(tagName string, page, pageSize int) (*Pa
 	if err := db.Preload("Tags").
 		Joins("JOIN video_tags ON video_tags.video_model_id = videos.id").
 		Where("video_tags.tag_id = ?", tag.Id).
+		Scopes(publishedVideosScope).
 		Order("videos.created_at DESC").
 		Limit(pageSize).
 		Offset(offset).
@@ -0,0 +1,449 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image"
+	_ "image/jpeg"
+	_ "image/png"
+	"io"
+	"log/slog"
+	"math"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/unbot2313/go-streaming-service/config"
+	"github.com/unbot2313/go-streaming-service/internal/models"
+	"github.com/unbot2313/go-streaming-service/internal/services/storage"
+	"gorm.io/gorm"
+)
+
+const (
+	// DefaultThumbnailAt es el segundo del que se toma la miniatura generada automáticamente
+	DefaultThumbnailAt = 8.0
+
+	// MaxThumbnailUploadSize es el tamaño máximo de una miniatura subida por el dueño
+	MaxThumbnailUploadSize = 5 * 1024 * 1024
+	// MaxThumbnailDimension es el ancho o alto máximo de una miniatura subida
+	MaxThumbnailDimension = 8192
+
+	// ThumbnailCandidateCount es la cantidad de frames candidatos de un video;
+	// los clips de menos de ShortClipSeconds tienen MinThumbnailCandidates
+	ThumbnailCandidateCount = 5
+	MinThumbnailCandidates  = 3
+	ShortClipSeconds        = 30.0
+)
+
+// ThumbnailSize es un tamaño estándar en el que se guarda una miniatura subida
+type ThumbnailSize struct {
+	Width  int
+	Height int
+}
+
+// ThumbnailSizes van de mayor a menor. Una imagen más chica que un tamaño no se
+// agranda: se guarda solo en los tamaños que entran y la mayor es la miniatura principal.
+var ThumbnailSizes = []ThumbnailSize{
+	{Width: 1280, Height: 720},
+	{Width: 640, Height: 360},
+	{Width: 320, Height: 180},
+}
+
+// ErrInvalidThumbnail indica que la imagen subida no se puede usar como miniatura
+var ErrInvalidThumbnail = errors.New("miniatura inválida")
+
+type ThumbnailService interface {
+	RequestThumbnail(video *models.VideoModel, at float64) error
+	UploadThumbnail(ctx context.Context, video *models.VideoModel, image io.Reader) (*models.VideoModel, error)
+	ProcessTask(ctx context.Context, task models.ThumbnailTask) error
+}
+
+type thumbnailServiceImp struct {
+	storageService storage.StorageService
+	ffmpegService  FFmpegService
+	filesService   FilesService
+	hlsKeyService  HLSKeyService
+}
+
+func NewThumbnailService(storageService storage.StorageService, ffmpegService FFmpegService, filesService FilesService, hlsKeyService HLSKeyService) ThumbnailService {
+	return &thumbnailServiceImp{
+		storageService: storageService,
+		ffmpegService:  ffmpegService,
+		filesService:   filesService,
+		hlsKeyService:  hlsKeyService,
+	}
+}
+
+// defaultThumbnailTasks son las tareas que se encolan cuando un video termina de procesarse
+func defaultThumbnailTasks(video *models.VideoModel) []models.ThumbnailTask {
+	return []models.ThumbnailTask{
+		{VideoID: video.Id, Folder: video.Folder(), Kind: models.ThumbnailKindThumbnail, SourceURL: video.VideoUrl, Duration: video.Duration, At: DefaultThumbnailAt},
+		{VideoID: video.Id, Folder: video.Folder(), Kind: models.ThumbnailKindStoryboard, SourceURL: video.VideoUrl, Duration: video.Duration},
+		candidatesTask(video),
+	}
+}
+
+// videoThumbnailTasks son las tareas por defecto más las que habilita el perfil de codificación del video
+func videoThumbnailTasks(tx *gorm.DB, video *models.VideoModel) ([]models.ThumbnailTask, error) {
+	if video.IsAudio() {
+		return audioThumbnailTasks(video), nil
+	}
+
+	tasks := defaultThumbnailTasks(video)
+
+	profile, err := findEncodingProfile(tx, video.EncodingProfile)
+	if errors.Is(err, ErrEncodingProfileNotFound) {
+		return tasks, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if profile.Preview {
+		tasks = append(tasks, models.ThumbnailTask{VideoID: video.Id, Folder: video.Folder(), Kind: models.ThumbnailKindPreview, SourceURL: video.VideoUrl, Duration: video.Duration})
+	}
+
+	return tasks, nil
+}
+
+// audioThumbnailTasks reemplazan a las de video en los uploads solo de audio:
+// la forma de onda hace de miniatura y el M4A es el enclosure del feed de podcast
+func audioThumbnailTasks(video *models.VideoModel) []models.ThumbnailTask {
+	return []models.ThumbnailTask{
+		{VideoID: video.Id, Folder: video.Folder(), Kind: models.ThumbnailKindWaveform, SourceURL: video.VideoUrl, Duration: video.Duration},
+		{VideoID: video.Id, Folder: video.Folder(), Kind: models.ThumbnailKindAudioFile, SourceURL: video.VideoUrl, Duration: video.Duration},
+	}
+}
+
+// candidatesTask genera los frames que el dueño puede elegir como miniatura
+func candidatesTask(video *models.VideoModel) models.ThumbnailTask {
+	return models.ThumbnailTask{VideoID: video.Id, Folder: video.Folder(), Kind: models.ThumbnailKindCandidates, SourceURL: video.VideoUrl, Duration: video.Duration}
+}
+
+// enqueueThumbnailTasks guarda las tareas en el outbox usando la transacción recibida
+func enqueueThumbnailTasks(tx *gorm.DB, tasks []models.ThumbnailTask) error {
+	queueName := config.GetConfig().RabbitMQThumbnailQueue
+
+	for _, task := range tasks {
+		payload, err := json.Marshal(task)
+		if err != nil {
+			return err
+		}
+
+		if err := enqueueOutboxMessage(tx, queueName, payload, 0); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// RequestThumbnail encola la regeneración de la miniatura en el segundo at.
+// La pide el dueño, así que reemplaza también una miniatura subida.
+func (s *thumbnailServiceImp) RequestThumbnail(video *models.VideoModel, at float64) error {
+	db, err := config.GetDB()
+	if err != nil {
+		return err
+	}
+
+	return enqueueThumbnailTasks(db, []models.ThumbnailTask{{
+		VideoID:   video.Id,
+		Folder:    video.Folder(),
+		Kind:      models.ThumbnailKindThumbnail,
+		SourceURL: video.VideoUrl,
+		Duration:  video.Duration,
+		At:        at,
+		Requested: true,
+	}})
+}
+
+// UploadThumbnail valida una imagen JPEG o PNG subida por el dueño, la guarda en WebP en
+// los tamaños estándar y la usa como miniatura del video. Las miniaturas subidas van fuera
+// de la carpeta de renditions, así sobreviven al reemplazo de la fuente del video.
+func (s *thumbnailServiceImp) UploadThumbnail(ctx context.Context, video *models.VideoModel, image io.Reader) (*models.VideoModel, error) {
+	workDir, err := os.MkdirTemp("", "thumbnail-upload-"+video.Id+"-")
+	if err != nil {
+		return nil, fmt.Errorf("error al crear la carpeta temporal: %w", err)
+	}
+	defer s.filesService.RemoveFolder(workDir)
+
+	sourcePath := filepath.Join(workDir, "source")
+	width, height, err := saveThumbnailUpload(image, sourcePath)
+	if err != nil {
+		return nil, err
+	}
+
+	// Un nombre distinto por subida para que las CDN no sigan sirviendo la anterior
+	prefix := fmt.Sprintf("thumbnails/%s/custom-%d", video.Id, time.Now().UnixMilli())
+
+	sizes := make(map[string]string)
+	mainURL := ""
+	for _, size := range ThumbnailSizes {
+		if size.Width > width || size.Height > height {
+			continue
+		}
+
+		name := fmt.Sprintf("%dx%d", size.Width, size.Height)
+		outputPath := filepath.Join(workDir, name+".webp")
+		if err := s.ffmpegService.ResizeImage(ctx, sourcePath, outputPath, size.Width, size.Height); err != nil {
+			return nil, err
+		}
+
+		fileURL, err := s.storageService.UploadFile(ctx, outputPath, prefix+"-"+name+".webp", "image/webp")
+		if err != nil {
+			return nil, err
+		}
+
+		sizes[name] = fileURL
+		if mainURL == "" {
+			mainURL = fileURL
+		}
+	}
+
+	db, err := config.GetDB()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := db.Model(video).
+		Select("thumbnail_url", "custom_thumbnail", "thumbnail_sizes").
+		Updates(&models.VideoModel{ThumbnailURL: mainURL, CustomThumbnail: true, ThumbnailSizes: sizes}).Error; err != nil {
+		return nil, err
+	}
+
+	video.ThumbnailURL = mainURL
+	video.CustomThumbnail = true
+	video.ThumbnailSizes = sizes
+
+	return video, nil
+}
+
+// saveThumbnailUpload guarda la imagen en path y valida tamaño, formato y dimensiones.
+// Retorna el ancho y alto de la imagen.
+func saveThumbnailUpload(upload io.Reader, path string) (int, int, error) {
+	file, err := os.Create(path)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer file.Close()
+
+	written, err := io.Copy(file, io.LimitReader(upload, MaxThumbnailUploadSize+1))
+	if err != nil {
+		return 0, 0, err
+	}
+	if written > MaxThumbnailUploadSize {
+		return 0, 0, fmt.Errorf("%w: la imagen supera los %d MB", ErrInvalidThumbnail, MaxThumbnailUploadSize/(1024*1024))
+	}
+
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return 0, 0, err
+	}
+
+	imageConfig, format, err := image.DecodeConfig(file)
+	if err != nil || (format != "jpeg" && format != "png") {
+		return 0, 0, fmt.Errorf("%w: la imagen debe ser JPEG o PNG", ErrInvalidThumbnail)
+	}
+
+	smallest := ThumbnailSizes[len(ThumbnailSizes)-1]
+	if imageConfig.Width < smallest.Width || imageConfig.Height < smallest.Height {
+		return 0, 0, fmt.Errorf("%w: la imagen debe medir al menos %dx%d", ErrInvalidThumbnail, smallest.Width, smallest.Height)
+	}
+	if imageConfig.Width > MaxThumbnailDimension || imageConfig.Height > MaxThumbnailDimension {
+		return 0, 0, fmt.Errorf("%w: la imagen no puede medir más de %dx%d", ErrInvalidThumbnail, MaxThumbnailDimension, MaxThumbnailDimension)
+	}
+
+	return imageConfig.Width, imageConfig.Height, nil
+}
+
+// ProcessTask genera el recurso pedido a partir del playlist HLS, lo sube
+// a la carpeta del video en el storage y guarda la URL en el video
+func (s *thumbnailServiceImp) ProcessTask(ctx context.Context, task models.ThumbnailTask) error {
+	workDir, err := os.MkdirTemp("", "thumbnail-"+task.VideoID+"-")
+	if err != nil {
+		return fmt.Errorf("error al crear la carpeta temporal: %w", err)
+	}
+	defer s.filesService.RemoveFolder(workDir)
+
+	// Las tareas anteriores al reemplazo de fuentes no traen carpeta: usan la del id del video
+	folder := task.Folder
+	if folder == "" {
+		folder = task.VideoID
+	}
+
+	// Las renditions cifradas se leen de una copia local con las claves ya descifradas
+	source, cleanup, err := s.hlsKeyService.PrepareSource(ctx, task.VideoID, task.SourceURL)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+	task.SourceURL = source
+
+	var localPath, objectName, column string
+	contentType := "image/webp"
+
+	switch task.Kind {
+	case models.ThumbnailKindThumbnail:
+		at := frameAt(task.At, DurationSeconds(task.Duration))
+		localPath, err = s.ffmpegService.GenerateThumbnail(ctx, task.SourceURL, workDir, at)
+		objectName = folder + "/" + thumbnailObjectName(task.At)
+		column = "thumbnail_url"
+	case models.ThumbnailKindStoryboard:
+		localPath, err = s.ffmpegService.GenerateStoryboard(ctx, task.SourceURL, workDir, DurationSeconds(task.Duration))
+		objectName = folder + "/storyboard.webp"
+		column = "storyboard_url"
+	case models.ThumbnailKindPreview:
+		localPath, err = s.ffmpegService.GeneratePreview(ctx, task.SourceURL, workDir, DurationSeconds(task.Duration))
+		objectName = folder + "/preview.webp"
+		column = "preview_url"
+	case models.ThumbnailKindWaveform:
+		localPath, err = s.ffmpegService.GenerateWaveform(ctx, task.SourceURL, workDir)
+		objectName = folder + "/waveform.webp"
+		column = "thumbnail_url"
+	case models.ThumbnailKindAudioFile:
+		localPath, err = s.ffmpegService.ExtractAudioFile(ctx, task.SourceURL, workDir)
+		objectName = folder + "/audio.m4a"
+		column = "audio_file_url"
+		contentType = "audio/mp4"
+	case models.ThumbnailKindCandidates:
+		return s.processCandidates(ctx, task, folder, workDir)
+	default:
+		return fmt.Errorf("tipo de tarea de thumbnail desconocido: %s", task.Kind)
+	}
+	if err != nil {
+		return err
+	}
+
+	fileURL, err := s.storageService.UploadFile(ctx, localPath, objectName, contentType)
+	if err != nil {
+		return err
+	}
+
+	db, err := config.GetDB()
+	if err != nil {
+		return err
+	}
+
+	// Solo se actualiza si el video sigue usando esa carpeta: si se reemplazó su fuente
+	// mientras la tarea estaba en cola, el recurso generado es de la versión anterior
+	query := db.Model(&models.VideoModel{}).
+		Where("id = ? AND COALESCE(NULLIF(storage_folder, ''), id) = ?", task.VideoID, folder)
+	updates := map[string]interface{}{column: fileURL}
+
+	switch task.Kind {
+	case models.ThumbnailKindAudioFile:
+		// El enclosure del feed RSS necesita el tamaño del archivo
+		info, err := os.Stat(localPath)
+		if err != nil {
+			return err
+		}
+		updates["audio_file_size"] = info.Size()
+	case models.ThumbnailKindWaveform:
+		// La forma de onda tampoco pisa la miniatura que subió el dueño
+		query = query.Where("custom_thumbnail = ?", false)
+	case models.ThumbnailKindThumbnail:
+		if task.Requested {
+			// El frame elegido por el dueño reemplaza a la miniatura subida
+			updates["custom_thumbnail"] = false
+			updates["thumbnail_sizes"] = nil
+		} else {
+			// Las miniaturas automáticas no pisan la que subió el dueño
+			query = query.Where("custom_thumbnail = ?", false)
+		}
+	}
+
+	result := query.Updates(updates)
+	if result.Error != nil {
+		return result.Error
+	}
+
+	// El video pudo borrarse o cambiar de fuente mientras la tarea estaba en cola
+	if result.RowsAffected == 0 {
+		slog.Warn("video not found or source replaced for thumbnail task", slog.String("video_id", task.VideoID), slog.String("kind", task.Kind))
+	}
+
+	return nil
+}
+
+// processCandidates genera los frames candidatos repartidos a lo largo del video,
+// los sube a la carpeta del video y los guarda para que el dueño elija uno
+func (s *thumbnailServiceImp) processCandidates(ctx context.Context, task models.ThumbnailTask, folder, workDir string) error {
+	times := candidateTimes(DurationSeconds(task.Duration))
+	if len(times) == 0 {
+		return fmt.Errorf("no se pueden generar candidatos sin la duración del video")
+	}
+
+	candidates := make([]models.ThumbnailCandidate, 0, len(times))
+	for i, at := range times {
+		localPath, err := s.ffmpegService.GenerateThumbnail(ctx, task.SourceURL, workDir, at)
+		if err != nil {
+			return err
+		}
+
+		objectName := fmt.Sprintf("%s/candidates/candidate-%d.webp", folder, i+1)
+		fileURL, err := s.storageService.UploadFile(ctx, localPath, objectName, "image/webp")
+		if err != nil {
+			return err
+		}
+
+		candidates = append(candidates, models.ThumbnailCandidate{At: at, URL: fileURL})
+	}
+
+	db, err := config.GetDB()
+	if err != nil {
+		return err
+	}
+
+	result := db.Model(&models.VideoModel{}).
+		Where("id = ? AND COALESCE(NULLIF(storage_folder, ''), id) = ?", task.VideoID, folder).
+		Select("thumbnail_candidates").
+		Updates(&models.VideoModel{ThumbnailCandidates: candidates})
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		slog.Warn("video not found or source replaced for thumbnail task", slog.String("video_id", task.VideoID), slog.String("kind", task.Kind))
+	}
+
+	return nil
+}
+
+// candidateTimes reparte los candidatos a lo largo del video sin tomar el primer ni el último frame
+func candidateTimes(durationSeconds float64) []float64 {
+	if durationSeconds <= 0 {
+		return nil
+	}
+
+	count := ThumbnailCandidateCount
+	if durationSeconds < ShortClipSeconds {
+		count = MinThumbnailCandidates
+	}
+
+	times := make([]float64, count)
+	for i := range times {
+		at := durationSeconds * float64(i+1) / float64(count+1)
+		times[i] = math.Round(at*1000) / 1000
+	}
+	return times
+}
+
+// frameAt evita pedir un frame fuera del video: en los clips más cortos que at
+// (ej: la miniatura automática del segundo 8) se usa la mitad del clip
+func frameAt(at, durationSeconds float64) float64 {
+	if durationSeconds > 0 && at >= durationSeconds {
+		return durationSeconds / 2
+	}
+	return at
+}
+
+// thumbnailObjectName usa un nombre distinto por segundo para que las CDN
+// no sigan sirviendo la miniatura anterior al regenerarla
+func thumbnailObjectName(at float64) string {
+	if at == DefaultThumbnailAt {
+		return "thumbnail.webp"
+	}
+
+	return fmt.Sprintf("thumbnail-%d.webp", int64(at*1000))
+}
@@ -0,0 +1,192 @@
+package services
+
+import (
+	"errors"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/unbot2313/go-streaming-service/config"
+	"github.com/unbot2313/go-streaming-service/internal/models"
+	"gorm.io/gorm"
+)
+
+// tokenRevocationSweepInterval es cada cuánto se limpian del cache las entradas vencidas
+const tokenRevocationSweepInterval = time.Minute
+
+// tokenRevocationCache guarda en memoria lo que ya se consultó en Postgres: los jti revocados
+// hasta que vence el token, y los no revocados y las versiones de token por TOKEN_REVOCATION_CACHE_TTL.
+// Las revocaciones de esta instancia se ven al instante; las de otras, cuando vence el cache.
+type tokenRevocationCache struct {
+	mu         sync.Mutex
+	revoked    map[string]time.Time
+	notRevoked map[string]time.Time
+	versions   map[string]cachedTokenVersion
+	lastSweep  time.Time
+}
+
+type cachedTokenVersion struct {
+	version int
+	until   time.Time
+}
+
+var revocationCache = &tokenRevocationCache{
+	revoked:    make(map[string]time.Time),
+	notRevoked: make(map[string]time.Time),
+	versions:   make(map[string]cachedTokenVersion),
+}
+
+// isAccessTokenRevoked indica si el access token del usuario fue revocado, por su jti o
+// porque la versión de tokens del usuario cambió después de emitirlo
+func isAccessTokenRevoked(user *models.User, claims *models.AuthClaims) (bool, error) {
+	if claims.TokenID != "" {
+		revoked, err := isTokenIDRevoked(claims.TokenID)
+		if err != nil || revoked {
+			return revoked, err
+		}
+	}
+
+	version, err := currentTokenVersion(user.Id)
+	if err != nil {
+		return false, err
+	}
+
+	return user.TokenVersion < version, nil
+}
+
+func isTokenIDRevoked(jti string) (bool, error) {
+	now := time.Now()
+
+	revocationCache.mu.Lock()
+	if _, ok := revocationCache.revoked[jti]; ok {
+		revocationCache.mu.Unlock()
+		return true, nil
+	}
+	if until, ok := revocationCache.notRevoked[jti]; ok && now.Before(until) {
+		revocationCache.mu.Unlock()
+		return false, nil
+	}
+	revocationCache.mu.Unlock()
+
+	db, err := config.GetDB()
+	if err != nil {
+		return false, err
+	}
+
+	var revokedToken models.RevokedToken
+	err = db.Where("jti = ?", jti).First(&revokedToken).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return false, err
+	}
+
+	revocationCache.mu.Lock()
+	defer revocationCache.mu.Unlock()
+	revocationCache.sweep(now)
+
+	if err == nil {
+		revocationCache.revoked[jti] = revokedToken.ExpiresAt
+		return true, nil
+	}
+
+	if ttl := config.GetConfig().TokenRevocationCacheTTL; ttl > 0 {
+		revocationCache.notRevoked[jti] = now.Add(ttl)
+	}
+	return false, nil
+}
+
+// currentTokenVersion retorna la versión de tokens vigente del usuario.
+// Un usuario borrado cuenta como con todos sus tokens revocados.
+func currentTokenVersion(userId string) (int, error) {
+	now := time.Now()
+
+	revocationCache.mu.Lock()
+	if cached, ok := revocationCache.versions[userId]; ok && now.Before(cached.until) {
+		revocationCache.mu.Unlock()
+		return cached.version, nil
+	}
+	revocationCache.mu.Unlock()
+
+	db, err := config.GetDB()
+	if err != nil {
+		return 0, err
+	}
+
+	var user models.User
+	if err := db.Select("id", "token_version").Where("id = ?", userId).First(&user).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return math.MaxInt, nil
+		}
+		return 0, err
+	}
+
+	if ttl := config.GetConfig().TokenRevocationCacheTTL; ttl > 0 {
+		revocationCache.mu.Lock()
+		revocationCache.versions[userId] = cachedTokenVersion{version: user.TokenVersion, until: now.Add(ttl)}
+		revocationCache.mu.Unlock()
+	}
+
+	return user.TokenVersion, nil
+}
+
+// revokeAccessToken guarda el jti como revocado hasta que vence el token
+func revokeAccessToken(db *gorm.DB, jti, userId string, expiresAt time.Time) error {
+	if jti == "" {
+		return nil
+	}
+
+	// Los tokens vencidos ya no pasan la validación: sus filas no hacen falta
+	if err := db.Where("expires_at < ?", time.Now()).Delete(&models.RevokedToken{}).Error; err != nil {
+		return err
+	}
+
+	revokedToken := &models.RevokedToken{Jti: jti, UserID: userId, ExpiresAt: expiresAt}
+	if err := db.Where(models.RevokedToken{Jti: jti}).FirstOrCreate(revokedToken).Error; err != nil {
+		return err
+	}
+
+	revocationCache.mu.Lock()
+	revocationCache.revoked[jti] = expiresAt
+	delete(revocationCache.notRevoked, jti)
+	revocationCache.mu.Unlock()
+
+	return nil
+}
+
+// bumpTokenVersion invalida todos los access tokens emitidos hasta ahora para el usuario.
+// Se usa dentro de una transacción: después del commit hay que llamar a forgetTokenVersion,
+// si no otra petición podría volver a cachear la versión anterior antes de que se confirme.
+func bumpTokenVersion(db *gorm.DB, userId string) error {
+	return db.Model(&models.User{}).Where("id = ?", userId).
+		Update("token_version", gorm.Expr("token_version + 1")).Error
+}
+
+// forgetTokenVersion saca del cache la versión del usuario para que se vuelva a leer de la DB
+func forgetTokenVersion(userId string) {
+	revocationCache.mu.Lock()
+	delete(revocationCache.versions, userId)
+	revocationCache.mu.Unlock()
+}
+
+// sweep borra las entradas vencidas; se llama con mu tomado
+func (c *tokenRevocationCache) sweep(now time.Time) {
+	if now.Sub(c.lastSweep) < tokenRevocationSweepInterval {
+		return
+	}
+	c.lastSweep = now
+
+	for jti, expiresAt := range c.revoked {
+		if now.After(expiresAt) {
+			delete(c.revoked, jti)
+		}
+	}
+	for jti, until := range c.notRevoked {
+		if now.After(until) {
+			delete(c.notRevoked, jti)
+		}
+	}
+	for userId, cached := range c.versions {
+		if now.After(cached.until) {
+			delete(c.versions, userId)
+		}
+	}
+}
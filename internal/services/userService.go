@@ -127,7 +127,13 @@ func (service *UserServiceImp) UpdateEmail(userId, newEmail string) error {
 		return err
 	}
 
-	result := db.Model(&models.User{}).Where("id = ?", userId).Update("email", newEmail)
+	// Los tokens emitidos llevan el email anterior: se invalidan con la versión.
+	// El email nuevo queda sin verificar hasta que se confirme.
+	result := db.Model(&models.User{}).Where("id = ?", userId).Updates(map[string]interface{}{
+		"email":             newEmail,
+		"email_verified_at": nil,
+		"token_version":     gorm.Expr("token_version + 1"),
+	})
 
 	if errors.Is(result.Error, gorm.ErrDuplicatedKey) {
 		return fmt.Errorf("email already in use")
@@ -141,6 +147,8 @@ func (service *UserServiceImp) UpdateEmail(userId, newEmail string) error {
 		return fmt.Errorf("user with ID %s not found", userId)
 	}
 
+	forgetTokenVersion(userId)
+
 	return nil
 }
 
@@ -164,11 +172,22 @@ func (service *UserServiceImp) UpdatePassword(userId, currentPassword, newPasswo
 		return err
 	}
 
-	result := db.Model(&models.User{}).Where("id = ?", userId).Update("password", hashedPassword)
-	if result.Error != nil {
-		return result.Error
+	// Cambiar la contraseña cierra todas las sesiones e invalida los access tokens emitidos
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.User{}).Where("id = ?", userId).Update("password", hashedPassword).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", userId).Delete(&models.Session{}).Error; err != nil {
+			return err
+		}
+		return bumpTokenVersion(tx, userId)
+	})
+	if err != nil {
+		return err
 	}
 
+	forgetTokenVersion(userId)
+
 	return nil
 }
 
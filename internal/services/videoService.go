@@ -3,6 +3,7 @@ package services
 import (
 	"context"
 	"fmt"
+	"os"
 	"path/filepath"
 	"strings"
 
@@ -23,12 +24,36 @@ var validVideoExtensions = []string{
 	".mp4", ".webm", ".avi", ".mkv", ".mov", ".wmv", ".flv", ".3gp",
 }
 
+// validAudioExtensions son los uploads que se procesan como audio (podcasts)
+var validAudioExtensions = []string{
+	".mp3", ".m4a", ".wav", ".flac",
+}
+
+// MediaTypeFromFilename retorna el tipo de medio según la extensión del archivo,
+// o "" si la extensión no es de video ni de audio
+func MediaTypeFromFilename(filename string) string {
+	extension := strings.ToLower(filepath.Ext(filename))
+
+	for _, validExtension := range validVideoExtensions {
+		if validExtension == extension {
+			return models.MediaTypeVideo
+		}
+	}
+	for _, validExtension := range validAudioExtensions {
+		if validExtension == extension {
+			return models.MediaTypeAudio
+		}
+	}
+	return ""
+}
+
 type VideoService interface {
 	SaveVideo(ctx context.Context, c *gin.Context) (*models.Video, error)
-	FormatVideo(ctx context.Context, videoName string) (string, error)
+	FormatVideo(ctx context.Context, videoName string, options FormatOptions) (*FormatResult, error)
+	NewClip(source *models.VideoModel, start, end float64) *models.Video
+	CutClip(ctx context.Context, sourceURL, outputPath string, start, end float64, accurate bool) error
 	UploadFolder(ctx context.Context, folder string) (storage.UploadResult, error)
 	DeleteFolder(ctx context.Context, folderName string) error
-	GenerateThumbnail(ctx context.Context, videoPath, outputDir string) (string, error)
 	GetFilesService() FilesService
 	IsValidVideoExtension(c *gin.Context) bool
 }
@@ -42,16 +67,8 @@ func (vs *videoServiceImp) IsValidVideoExtension(c *gin.Context) bool {
 		return false // El archivo no existe o hubo un error
 	}
 
-	// Obtener la extensión del archivo en minúsculas
-	extension := strings.ToLower(filepath.Ext(file.Filename))
-
-	// Verificar si la extensión es válida
-	for _, validExtension := range validVideoExtensions {
-		if validExtension == extension {
-			return true
-		}
-	}
-	return false
+	// Verificar si la extensión es de video o de audio
+	return MediaTypeFromFilename(file.Filename) != ""
 }
 
 func (vs *videoServiceImp) GetFilesService() FilesService {
@@ -100,12 +117,73 @@ func (vs *videoServiceImp) SaveVideo(ctx context.Context, c *gin.Context) (*mode
 		LocalPath:   savePath,
 		UniqueName:  uniqueName,
 		Duration:    duration,
+		MediaType:   MediaTypeFromFilename(header.Filename),
 	}
 
 	return videoData, nil
 }
 
-func (vs *videoServiceImp) FormatVideo(ctx context.Context, videoName string) (string, error) {
+// NewClip prepara los datos de un video recortado de source entre start y end segundos.
+// El archivo local todavía no existe: el worker lo genera con CutClip antes de procesarlo.
+func (vs *videoServiceImp) NewClip(source *models.VideoModel, start, end float64) *models.Video {
+	id := uuid.New().String()
+
+	// El recorte de un audio se guarda como M4A para que se procese como audio
+	mediaType := source.MediaType
+	ext := ".mp4"
+	if source.IsAudio() {
+		ext = ".m4a"
+	} else {
+		mediaType = models.MediaTypeVideo
+	}
+	uniqueName := id + ext
+
+	return &models.Video{
+		Id:            id,
+		Title:         source.Title,
+		Description:   source.Description,
+		LocalPath:     filepath.Join(config.GetConfig().LocalStoragePath, uniqueName),
+		UniqueName:    uniqueName,
+		Duration:      formatDuration(end - start),
+		MediaType:     mediaType,
+		SourceVideoID: source.Id,
+	}
+}
+
+// CutClip genera en outputPath el archivo local de un recorte a partir del video fuente
+func (vs *videoServiceImp) CutClip(ctx context.Context, sourceURL, outputPath string, start, end float64, accurate bool) error {
+	if err := vs.FilesService.EnsureDir(filepath.Dir(outputPath)); err != nil {
+		return err
+	}
+
+	return vs.FFmpegService.CutClip(ctx, sourceURL, outputPath, start, end, accurate)
+}
+
+// FormatOptions son las opciones de procesamiento de un upload
+type FormatOptions struct {
+	// Loudnorm normaliza el volumen (EBU R128) según el perfil de codificación
+	Loudnorm bool
+	// Watermark es la marca de agua del usuario; nil si no tiene o el upload la omite
+	Watermark *models.Watermark
+	// Encryption cifra los segmentos con AES-128; nil si el video no se cifra
+	Encryption *HLSEncryption
+}
+
+// FormatResult es el resultado de transcodificar un upload
+type FormatResult struct {
+	// FilesPath es la carpeta local con los playlists y segmentos
+	FilesPath string
+	// Loudness es la sonoridad medida; vacía si el perfil no normaliza el volumen
+	Loudness models.Loudness
+	// AudioTracks son las pistas de audio del archivo, sin id ni video asignados
+	AudioTracks []models.AudioTrack
+}
+
+// FormatVideo convierte el archivo subido a HLS. Con Loudnorm primero mide la sonoridad
+// del audio (EBU R128) y la normaliza al transcodificar; retorna lo medido. La marca
+// de agua solo se aplica a los videos. Si el video trae varias pistas de audio, cada una
+// va en su propio playlist y se escribe un master playlist que las agrupa.
+func (vs *videoServiceImp) FormatVideo(ctx context.Context, videoName string, options FormatOptions) (*FormatResult, error) {
 	// Obtener el nombre del video sin la extensión
 	stringName := strings.Split(videoName, ".")
 
@@ -113,13 +191,85 @@ func (vs *videoServiceImp) FormatVideo(ctx context.Context, videoName string) (s
 	outputDir := saveFormatedVideoPath + stringName[0]
 	err := vs.FilesService.CreateFolder("static/temp/" + stringName[0])
 	if err != nil {
-		return "", fmt.Errorf("error al crear la carpeta: %w", err)
+		return nil, fmt.Errorf("error al crear la carpeta: %w", err)
 	}
 
 	videoPath := rawVideoPathFromWSL + videoName
 
+	// Primera pasada de loudnorm: sin audio o en silencio no hay medición y no se normaliza
+	var measurement *LoudnessMeasurement
+	if options.Loudnorm {
+		measurement, err = vs.FFmpegService.MeasureLoudness(ctx, videoPath)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var loudness models.Loudness
+	if measurement != nil {
+		loudness = measurement.Loudness()
+	}
+
+	// Los audios se transcodifican a HLS solo de audio
+	if MediaTypeFromFilename(videoName) == models.MediaTypeAudio {
+		filesPath, err := vs.FFmpegService.ConvertAudioToHLS(ctx, videoPath, outputDir, measurement)
+		if err != nil {
+			return nil, err
+		}
+		return &FormatResult{FilesPath: filesPath, Loudness: loudness}, nil
+	}
+
+	streams, err := vs.FFmpegService.ProbeAudioStreams(ctx, videoPath)
+	if err != nil {
+		return nil, err
+	}
+
 	// Usar FFmpegService para convertir a HLS
-	return vs.FFmpegService.ConvertToHLS(ctx, videoPath, outputDir)
+	filesPath, err := vs.FFmpegService.ConvertToHLS(ctx, videoPath, outputDir, HLSOptions{
+		Loudness:     measurement,
+		Watermark:    options.Watermark,
+		AudioStreams: streams,
+		Encryption:   options.Encryption,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	tracks := EmbeddedAudioTracks(streams)
+	if len(streams) > 1 {
+		if err := vs.writeMasterPlaylist(ctx, videoPath, filesPath, tracks); err != nil {
+			return nil, err
+		}
+	}
+
+	return &FormatResult{FilesPath: filesPath, Loudness: loudness, AudioTracks: tracks}, nil
+}
+
+// writeMasterPlaylist escribe el master playlist de un video con varias pistas de audio.
+// El BANDWIDTH se estima con el tamaño de los segmentos de video y la duración del archivo.
+func (vs *videoServiceImp) writeMasterPlaylist(ctx context.Context, videoPath, filesPath string, tracks []models.AudioTrack) error {
+	duration, err := vs.FFmpegService.ExtractDuration(ctx, videoPath)
+	if err != nil {
+		return err
+	}
+
+	entries, err := os.ReadDir(filesPath)
+	if err != nil {
+		return err
+	}
+	var segments []storage.ObjectInfo
+	for _, entry := range entries {
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		segments = append(segments, storage.ObjectInfo{Key: entry.Name(), Size: info.Size()})
+	}
+
+	bandwidth := VariantBandwidth(segments, VideoPlaylistName, DurationSeconds(duration))
+	master := BuildMasterPlaylist(VideoPlaylistName, bandwidth, tracks)
+
+	return os.WriteFile(filepath.Join(filesPath, storage.MasterPlaylistName), master, 0644)
 }
 
 func NewVideoService(storageService storage.StorageService, filesService FilesService, ffmpegService FFmpegService) VideoService {
@@ -146,8 +296,3 @@ func (vs *videoServiceImp) DeleteFolder(ctx context.Context, folderName string)
 	return vs.StorageService.DeleteFolder(ctx, folderName)
 }
 
-// GenerateThumbnail delega al FFmpegService para generar miniatura
-func (vs *videoServiceImp) GenerateThumbnail(ctx context.Context, videoPath, outputDir string) (string, error) {
-	return vs.FFmpegService.GenerateThumbnail(ctx, videoPath, outputDir)
-}
-
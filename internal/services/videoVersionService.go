@@ -0,0 +1,273 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/unbot2313/go-streaming-service/config"
+	"github.com/unbot2313/go-streaming-service/internal/models"
+	"github.com/unbot2313/go-streaming-service/internal/services/storage"
+	"gorm.io/gorm"
+)
+
+const (
+	// VersionPurgeInterval es cada cuánto el worker borra las versiones vencidas
+	VersionPurgeInterval = 1 * time.Hour
+	// VersionPurgeBatchSize es el máximo de versiones que se borran por ciclo
+	VersionPurgeBatchSize = 50
+)
+
+// ErrVersionExpired indica que la versión ya no se puede restaurar
+var ErrVersionExpired = errors.New("la versión venció y ya no se puede restaurar")
+
+type VideoVersionService interface {
+	ReplaceSource(videoId, folder, m3u8FileURL, duration string, loudness models.Loudness) error
+	ListVersions(videoId string) ([]models.VideoVersion, error)
+	FindVersionByID(versionId string) (*models.VideoVersion, error)
+	Rollback(version *models.VideoVersion) (*models.VideoModel, error)
+	PurgeExpired(ctx context.Context, batchSize int) (int, error)
+}
+
+type videoVersionServiceImp struct {
+	storageService storage.StorageService
+}
+
+func NewVideoVersionService(storageService storage.StorageService) VideoVersionService {
+	return &videoVersionServiceImp{
+		storageService: storageService,
+	}
+}
+
+// archiveCurrentSource guarda las renditions actuales del video como una versión
+// que se puede restaurar hasta que venza la retención
+func archiveCurrentSource(tx *gorm.DB, video *models.VideoModel) error {
+	version := models.VideoVersion{
+		Id:            uuid.New().String(),
+		VideoID:       video.Id,
+		StorageFolder: video.Folder(),
+		VideoUrl:      video.VideoUrl,
+		Duration:      video.Duration,
+		ThumbnailURL:  video.ThumbnailURL,
+		StoryboardURL: video.StoryboardURL,
+		PreviewURL:    video.PreviewURL,
+		AudioFileURL:  video.AudioFileURL,
+		AudioFileSize: video.AudioFileSize,
+		Loudness:      video.Loudness,
+		ExpiresAt:     time.Now().Add(config.GetConfig().SourceVersionRetention),
+	}
+
+	return tx.Create(&version).Error
+}
+
+// ReplaceSource cambia las renditions del video por las de la carpeta folder en una sola
+// transacción: las anteriores se guardan como versión y se encolan el thumbnail y el
+// storyboard de la nueva fuente. Si el video ya usa esa carpeta no hace nada, así
+// una tarea entregada dos veces no archiva la fuente nueva como versión.
+func (s *videoVersionServiceImp) ReplaceSource(videoId, folder, m3u8FileURL, duration string, loudness models.Loudness) error {
+	db, err := config.GetDB()
+	if err != nil {
+		return err
+	}
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		var video models.VideoModel
+		if err := tx.Where("id = ?", videoId).First(&video).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return fmt.Errorf("video with id %s not found", videoId)
+			}
+			return err
+		}
+
+		if video.Folder() == folder {
+			return nil
+		}
+
+		if err := archiveCurrentSource(tx, &video); err != nil {
+			return err
+		}
+
+		if err := tx.Model(&video).Updates(map[string]interface{}{
+			"video_url":           m3u8FileURL,
+			"duration":            duration,
+			"storage_folder":      folder,
+			"loudness_integrated": loudness.Integrated,
+			"loudness_true_peak":  loudness.TruePeak,
+		}).Error; err != nil {
+			return err
+		}
+		video.VideoUrl = m3u8FileURL
+		video.Duration = duration
+		video.StorageFolder = folder
+		video.Loudness = loudness
+
+		tasks, err := videoThumbnailTasks(tx, &video)
+		if err != nil {
+			return err
+		}
+
+		return enqueueThumbnailTasks(tx, tasks)
+	})
+}
+
+// ListVersions retorna las versiones restaurables del video, de la más reciente a la más antigua
+func (s *videoVersionServiceImp) ListVersions(videoId string) ([]models.VideoVersion, error) {
+	db, err := config.GetDB()
+	if err != nil {
+		return nil, err
+	}
+
+	var versions []models.VideoVersion
+	if err := db.Where("video_id = ? AND expires_at > ?", videoId, time.Now()).
+		Order("created_at DESC").
+		Find(&versions).Error; err != nil {
+		return nil, err
+	}
+
+	return versions, nil
+}
+
+func (s *videoVersionServiceImp) FindVersionByID(versionId string) (*models.VideoVersion, error) {
+	db, err := config.GetDB()
+	if err != nil {
+		return nil, err
+	}
+
+	var version models.VideoVersion
+	if err := db.Where("id = ?", versionId).First(&version).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("version with id %s not found", versionId)
+		}
+		return nil, err
+	}
+
+	return &version, nil
+}
+
+// Rollback vuelve a las renditions de una versión anterior. Las actuales se guardan
+// como una versión nueva, así el cambio también se puede deshacer.
+func (s *videoVersionServiceImp) Rollback(version *models.VideoVersion) (*models.VideoModel, error) {
+	if !version.ExpiresAt.After(time.Now()) {
+		return nil, ErrVersionExpired
+	}
+
+	db, err := config.GetDB()
+	if err != nil {
+		return nil, err
+	}
+
+	var video models.VideoModel
+
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("id = ?", version.VideoID).First(&video).Error; err != nil {
+			return err
+		}
+
+		if err := archiveCurrentSource(tx, &video); err != nil {
+			return err
+		}
+
+		updates := map[string]interface{}{
+			"video_url":           version.VideoUrl,
+			"duration":            version.Duration,
+			"storyboard_url":      version.StoryboardURL,
+			"preview_url":         version.PreviewURL,
+			"audio_file_url":      version.AudioFileURL,
+			"audio_file_size":     version.AudioFileSize,
+			"loudness_integrated": version.Loudness.Integrated,
+			"loudness_true_peak":  version.Loudness.TruePeak,
+			"storage_folder":      version.StorageFolder,
+		}
+		// Una miniatura subida por el dueño no depende de la fuente y se mantiene
+		if !video.CustomThumbnail {
+			updates["thumbnail_url"] = version.ThumbnailURL
+		}
+
+		if err := tx.Model(&video).Updates(updates).Error; err != nil {
+			return err
+		}
+		video.VideoUrl = version.VideoUrl
+		video.Duration = version.Duration
+		video.StoryboardURL = version.StoryboardURL
+		video.PreviewURL = version.PreviewURL
+		video.AudioFileURL = version.AudioFileURL
+		video.AudioFileSize = version.AudioFileSize
+		video.StorageFolder = version.StorageFolder
+		video.Loudness = version.Loudness
+		if !video.CustomThumbnail {
+			video.ThumbnailURL = version.ThumbnailURL
+		}
+
+		// Los candidatos guardados son de la fuente reemplazada (los audios no tienen)
+		if !video.IsAudio() {
+			if err := enqueueThumbnailTasks(tx, []models.ThumbnailTask{candidatesTask(&video)}); err != nil {
+				return err
+			}
+		}
+
+		// Otro rollback de la misma versión ya la restauró
+		dbCtx := tx.Delete(&models.VideoVersion{}, "id = ?", version.Id)
+		if dbCtx.Error != nil {
+			return dbCtx.Error
+		}
+		if dbCtx.RowsAffected == 0 {
+			return fmt.Errorf("version with id %s not found", version.Id)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &video, nil
+}
+
+// PurgeExpired borra del storage y de la base de datos las versiones vencidas.
+// Retorna cuántas versiones se borraron.
+func (s *videoVersionServiceImp) PurgeExpired(ctx context.Context, batchSize int) (int, error) {
+	db, err := config.GetDB()
+	if err != nil {
+		return 0, err
+	}
+
+	var expired []models.VideoVersion
+	if err := db.Where("expires_at <= ?", time.Now()).
+		Order("expires_at ASC").
+		Limit(batchSize).
+		Find(&expired).Error; err != nil {
+		return 0, err
+	}
+
+	purged := 0
+	for _, version := range expired {
+		// Nunca se borra del storage una carpeta que el video está usando
+		var inUse int64
+		if err := db.Model(&models.VideoModel{}).
+			Where("COALESCE(NULLIF(storage_folder, ''), id) = ?", version.StorageFolder).
+			Count(&inUse).Error; err != nil {
+			return purged, err
+		}
+
+		if inUse == 0 {
+			if err := s.storageService.DeleteFolder(ctx, version.StorageFolder+"/"); err != nil {
+				slog.Error("error deleting expired version folder",
+					slog.String("version_id", version.Id),
+					slog.String("folder", version.StorageFolder),
+					slog.Any("error", err),
+				)
+				continue
+			}
+		}
+
+		if err := db.Delete(&models.VideoVersion{}, "id = ?", version.Id).Error; err != nil {
+			return purged, err
+		}
+		purged++
+	}
+
+	return purged, nil
+}
@@ -0,0 +1,180 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/unbot2313/go-streaming-service/config"
+	"github.com/unbot2313/go-streaming-service/internal/models"
+	"github.com/unbot2313/go-streaming-service/internal/services/storage"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	// MaxWatermarkUploadSize es el tamaño máximo del PNG de una marca de agua
+	MaxWatermarkUploadSize = 2 * 1024 * 1024
+	// MaxWatermarkDimension es el ancho o alto máximo del PNG de una marca de agua
+	MaxWatermarkDimension = 4096
+
+	// DefaultWatermarkOpacity y DefaultWatermarkScale se usan si el usuario no los elige
+	DefaultWatermarkOpacity = 1.0
+	DefaultWatermarkScale   = 0.15
+)
+
+var (
+	// ErrInvalidWatermark indica que la imagen subida no se puede usar como marca de agua
+	ErrInvalidWatermark = errors.New("marca de agua inválida")
+	// ErrWatermarkNotFound indica que el usuario no tiene marca de agua
+	ErrWatermarkNotFound = errors.New("el usuario no tiene marca de agua")
+)
+
+type WatermarkService interface {
+	GetWatermark(userId string) (*models.Watermark, error)
+	SaveWatermark(ctx context.Context, watermark *models.Watermark, image io.Reader) (*models.Watermark, error)
+	DeleteWatermark(ctx context.Context, userId string) error
+}
+
+type watermarkServiceImp struct {
+	storageService storage.StorageService
+	filesService   FilesService
+}
+
+func NewWatermarkService(storageService storage.StorageService, filesService FilesService) WatermarkService {
+	return &watermarkServiceImp{
+		storageService: storageService,
+		filesService:   filesService,
+	}
+}
+
+// watermarkFolder es la carpeta del storage con el PNG de la marca de agua del usuario
+func watermarkFolder(userId string) string {
+	return "watermarks/" + userId + "/"
+}
+
+func (s *watermarkServiceImp) GetWatermark(userId string) (*models.Watermark, error) {
+	db, err := config.GetDB()
+	if err != nil {
+		return nil, err
+	}
+
+	var watermark models.Watermark
+	if err := db.Where("user_id = ?", userId).First(&watermark).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrWatermarkNotFound
+		}
+		return nil, err
+	}
+
+	return &watermark, nil
+}
+
+// SaveWatermark crea o actualiza la marca de agua del usuario. Si image no es nil
+// reemplaza el PNG; si es nil solo cambia posición, opacidad y escala, y el usuario
+// ya tiene que tener una imagen. Se aplica a los videos que se procesen desde ahora.
+func (s *watermarkServiceImp) SaveWatermark(ctx context.Context, watermark *models.Watermark, image io.Reader) (*models.Watermark, error) {
+	if image != nil {
+		imageURL, err := s.uploadWatermarkImage(ctx, watermark.UserID, image)
+		if err != nil {
+			return nil, err
+		}
+		watermark.ImageURL = imageURL
+	}
+
+	if watermark.ImageURL == "" {
+		return nil, ErrWatermarkNotFound
+	}
+
+	db, err := config.GetDB()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"image_url", "position", "opacity", "scale", "updated_at"}),
+	}).Create(watermark).Error; err != nil {
+		return nil, err
+	}
+
+	return s.GetWatermark(watermark.UserID)
+}
+
+// uploadWatermarkImage valida el PNG y lo sube al storage en lugar del anterior
+func (s *watermarkServiceImp) uploadWatermarkImage(ctx context.Context, userId string, upload io.Reader) (string, error) {
+	workDir, err := os.MkdirTemp("", "watermark-"+userId+"-")
+	if err != nil {
+		return "", fmt.Errorf("error al crear la carpeta temporal: %w", err)
+	}
+	defer s.filesService.RemoveFolder(workDir)
+
+	localPath := filepath.Join(workDir, "watermark.png")
+	if err := saveWatermarkUpload(upload, localPath); err != nil {
+		return "", err
+	}
+
+	if err := s.storageService.DeleteFolder(ctx, watermarkFolder(userId)); err != nil {
+		return "", err
+	}
+
+	// Un nombre distinto por subida para que las CDN no sigan sirviendo la anterior
+	objectName := fmt.Sprintf("%swatermark-%d.png", watermarkFolder(userId), time.Now().UnixMilli())
+	return s.storageService.UploadFile(ctx, localPath, objectName, "image/png")
+}
+
+// saveWatermarkUpload guarda la imagen en path y valida tamaño, formato y dimensiones
+func saveWatermarkUpload(upload io.Reader, path string) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	written, err := io.Copy(file, io.LimitReader(upload, MaxWatermarkUploadSize+1))
+	if err != nil {
+		return err
+	}
+	if written > MaxWatermarkUploadSize {
+		return fmt.Errorf("%w: la imagen supera los %d MB", ErrInvalidWatermark, MaxWatermarkUploadSize/(1024*1024))
+	}
+
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+
+	// Solo PNG, porque la transparencia del logo es lo que permite superponerlo
+	imageConfig, format, err := image.DecodeConfig(file)
+	if err != nil || format != "png" {
+		return fmt.Errorf("%w: la imagen debe ser PNG", ErrInvalidWatermark)
+	}
+
+	if imageConfig.Width > MaxWatermarkDimension || imageConfig.Height > MaxWatermarkDimension {
+		return fmt.Errorf("%w: la imagen no puede medir más de %dx%d", ErrInvalidWatermark, MaxWatermarkDimension, MaxWatermarkDimension)
+	}
+
+	return nil
+}
+
+// DeleteWatermark borra la marca de agua del usuario. Los videos ya procesados la conservan.
+func (s *watermarkServiceImp) DeleteWatermark(ctx context.Context, userId string) error {
+	db, err := config.GetDB()
+	if err != nil {
+		return err
+	}
+
+	dbCtx := db.Delete(&models.Watermark{}, "user_id = ?", userId)
+	if dbCtx.Error != nil {
+		return dbCtx.Error
+	}
+	if dbCtx.RowsAffected == 0 {
+		return ErrWatermarkNotFound
+	}
+
+	return s.storageService.DeleteFolder(ctx, watermarkFolder(userId))
+}
@@ -0,0 +1,428 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/unbot2313/go-streaming-service/config"
+	"github.com/unbot2313/go-streaming-service/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	// WebhookPollInterval es cada cuánto el worker busca entregas pendientes
+	WebhookPollInterval = 2 * time.Second
+	// WebhookBatchSize es el máximo de entregas que se envían por ciclo
+	WebhookBatchSize = 20
+	// WebhookRetryBase es la espera antes del primer reintento; se duplica en cada intento
+	WebhookRetryBase = 30 * time.Second
+	// WebhookRetryMax es la espera máxima entre reintentos
+	WebhookRetryMax = 1 * time.Hour
+)
+
+type WebhookService interface {
+	CreateSubscription(userId, url string, events []string) (*models.WebhookSubscription, error)
+	ListSubscriptions(userId string) ([]models.WebhookSubscription, error)
+	FindSubscriptionByID(subscriptionId string) (*models.WebhookSubscription, error)
+	DeleteSubscription(subscriptionId string) error
+	ListDeliveries(subscriptionId string) ([]models.WebhookDelivery, error)
+	FindDeliveryByID(deliveryId string) (*models.WebhookDelivery, error)
+	Redeliver(delivery *models.WebhookDelivery) (*models.WebhookDelivery, error)
+	DeliverPending(ctx context.Context, batchSize int) (int, error)
+}
+
+type webhookServiceImp struct {
+	client *http.Client
+}
+
+func NewWebhookService() WebhookService {
+	return &webhookServiceImp{
+		client: newWebhookClient(config.GetConfig().WebhookTimeout),
+	}
+}
+
+// SignWebhookPayload calcula la firma HMAC-SHA256 de "timestamp.body" con el secret de la suscripción.
+// Es el valor que se envía en el header X-Webhook-Signature (con el prefijo "sha256=").
+func SignWebhookPayload(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// webhookRetryDelay calcula el backoff exponencial según los intentos ya hechos
+func webhookRetryDelay(attempts int) time.Duration {
+	delay := WebhookRetryBase
+	for i := 1; i < attempts; i++ {
+		delay *= 2
+		if delay >= WebhookRetryMax {
+			return WebhookRetryMax
+		}
+	}
+	return delay
+}
+
+// dispatchWebhookEvent crea una entrega pendiente por cada suscripción del usuario al evento.
+// Se llama dentro de la transacción del cambio que origina el evento, igual que el outbox.
+func dispatchWebhookEvent(tx *gorm.DB, userId, event string, data interface{}) error {
+	var subscriptions []models.WebhookSubscription
+	if err := tx.Where("user_id = ?", userId).Find(&subscriptions).Error; err != nil {
+		return err
+	}
+
+	payload := models.WebhookEvent{
+		Id:        uuid.New().String(),
+		Event:     event,
+		CreatedAt: time.Now(),
+		Data:      data,
+	}
+
+	var body []byte
+	for _, subscription := range subscriptions {
+		if !subscription.Subscribed(event) {
+			continue
+		}
+
+		if body == nil {
+			var err error
+			if body, err = json.Marshal(payload); err != nil {
+				return err
+			}
+		}
+
+		delivery := models.WebhookDelivery{
+			Id:             uuid.New().String(),
+			SubscriptionID: subscription.Id,
+			UserID:         userId,
+			EventID:        payload.Id,
+			Event:          event,
+			Payload:        string(body),
+			Status:         models.WebhookDeliveryPending,
+			NextAttemptAt:  payload.CreatedAt,
+		}
+		if err := tx.Create(&delivery).Error; err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// jobEventData es el contenido de los eventos job.*
+func jobEventData(job *models.JobModel) map[string]interface{} {
+	return map[string]interface{}{
+		"job_id":        job.Id,
+		"video_id":      job.VideoID,
+		"status":        job.Status,
+		"error_message": job.ErrorMessage,
+	}
+}
+
+func (s *webhookServiceImp) CreateSubscription(userId, url string, events []string) (*models.WebhookSubscription, error) {
+	if err := validateWebhookURL(url); err != nil {
+		return nil, err
+	}
+
+	db, err := config.GetDB()
+	if err != nil {
+		return nil, err
+	}
+
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, err
+	}
+
+	subscription := models.WebhookSubscription{
+		Id:     uuid.New().String(),
+		UserID: userId,
+		URL:    url,
+		Secret: "whsec_" + hex.EncodeToString(secret),
+		Events: events,
+	}
+
+	if err := db.Create(&subscription).Error; err != nil {
+		return nil, err
+	}
+
+	return &subscription, nil
+}
+
+// ListSubscriptions retorna las suscripciones del usuario sin el secret
+func (s *webhookServiceImp) ListSubscriptions(userId string) ([]models.WebhookSubscription, error) {
+	db, err := config.GetDB()
+	if err != nil {
+		return nil, err
+	}
+
+	var subscriptions []models.WebhookSubscription
+	if err := db.Where("user_id = ?", userId).Order("created_at ASC").Find(&subscriptions).Error; err != nil {
+		return nil, err
+	}
+
+	for i := range subscriptions {
+		subscriptions[i].Secret = ""
+	}
+
+	return subscriptions, nil
+}
+
+func (s *webhookServiceImp) FindSubscriptionByID(subscriptionId string) (*models.WebhookSubscription, error) {
+	db, err := config.GetDB()
+	if err != nil {
+		return nil, err
+	}
+
+	var subscription models.WebhookSubscription
+	dbCtx := db.Where("id = ?", subscriptionId).First(&subscription)
+
+	if errors.Is(dbCtx.Error, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("webhook con id %s no encontrado", subscriptionId)
+	}
+
+	if dbCtx.Error != nil {
+		return nil, dbCtx.Error
+	}
+
+	subscription.Secret = ""
+
+	return &subscription, nil
+}
+
+func (s *webhookServiceImp) DeleteSubscription(subscriptionId string) error {
+	db, err := config.GetDB()
+	if err != nil {
+		return err
+	}
+
+	result := db.Where("id = ?", subscriptionId).Delete(&models.WebhookSubscription{})
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("webhook con id %s no encontrado", subscriptionId)
+	}
+
+	return nil
+}
+
+// ListDeliveries retorna el log de entregas de una suscripción, las más recientes primero
+func (s *webhookServiceImp) ListDeliveries(subscriptionId string) ([]models.WebhookDelivery, error) {
+	db, err := config.GetDB()
+	if err != nil {
+		return nil, err
+	}
+
+	var deliveries []models.WebhookDelivery
+	if err := db.Where("subscription_id = ?", subscriptionId).
+		Order("created_at DESC").
+		Limit(100).
+		Find(&deliveries).Error; err != nil {
+		return nil, err
+	}
+
+	return deliveries, nil
+}
+
+func (s *webhookServiceImp) FindDeliveryByID(deliveryId string) (*models.WebhookDelivery, error) {
+	db, err := config.GetDB()
+	if err != nil {
+		return nil, err
+	}
+
+	var delivery models.WebhookDelivery
+	dbCtx := db.Where("id = ?", deliveryId).First(&delivery)
+
+	if errors.Is(dbCtx.Error, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("entrega con id %s no encontrada", deliveryId)
+	}
+
+	if dbCtx.Error != nil {
+		return nil, dbCtx.Error
+	}
+
+	return &delivery, nil
+}
+
+// Redeliver crea una nueva entrega del mismo evento (mismo event id y payload).
+// La entrega original se conserva en el log.
+func (s *webhookServiceImp) Redeliver(delivery *models.WebhookDelivery) (*models.WebhookDelivery, error) {
+	db, err := config.GetDB()
+	if err != nil {
+		return nil, err
+	}
+
+	redelivery := models.WebhookDelivery{
+		Id:             uuid.New().String(),
+		SubscriptionID: delivery.SubscriptionID,
+		UserID:         delivery.UserID,
+		EventID:        delivery.EventID,
+		Event:          delivery.Event,
+		Payload:        delivery.Payload,
+		Status:         models.WebhookDeliveryPending,
+		NextAttemptAt:  time.Now(),
+	}
+
+	if err := db.Create(&redelivery).Error; err != nil {
+		return nil, err
+	}
+
+	return &redelivery, nil
+}
+
+// DeliverPending envía las entregas pendientes cuyo próximo intento ya venció.
+// Las filas se reclaman en una transacción corta (SKIP LOCKED) que corre su próximo intento
+// más allá de lo que puede tardar el lote, así varios workers no envían la misma entrega y
+// ninguna transacción queda abierta mientras se espera a los endpoints. Si el worker se cae
+// a mitad del lote, las entregas vuelven a estar disponibles cuando vence ese plazo.
+// Si el endpoint no responde 2xx se reprograma con backoff exponencial hasta
+// WEBHOOK_MAX_ATTEMPTS intentos, después queda como "failed".
+func (s *webhookServiceImp) DeliverPending(ctx context.Context, batchSize int) (int, error) {
+	db, err := config.GetDB()
+	if err != nil {
+		return 0, err
+	}
+
+	cfg := config.GetConfig()
+	deliveries, err := claimWebhookDeliveries(db, batchSize, time.Duration(batchSize+1)*cfg.WebhookTimeout)
+	if err != nil {
+		return 0, err
+	}
+
+	delivered := 0
+	for _, delivery := range deliveries {
+		updates := map[string]interface{}{}
+
+		var subscription models.WebhookSubscription
+		subErr := db.Where("id = ?", delivery.SubscriptionID).First(&subscription).Error
+
+		switch {
+		case errors.Is(subErr, gorm.ErrRecordNotFound):
+			// La suscripción se borró: no tiene sentido reintentar
+			updates["status"] = models.WebhookDeliveryFailed
+			updates["last_error"] = "la suscripción fue eliminada"
+		case subErr != nil:
+			// La entrega queda reclamada y se reintenta cuando vence el plazo
+			return delivered, subErr
+		default:
+			statusCode, sendErr := s.send(ctx, &subscription, &delivery)
+			updates["response_status"] = statusCode
+
+			if sendErr == nil {
+				updates["status"] = models.WebhookDeliveryDelivered
+				updates["last_error"] = ""
+				updates["delivered_at"] = time.Now()
+				delivered++
+				break
+			}
+
+			slog.Warn("webhook delivery failed",
+				slog.String("delivery_id", delivery.Id),
+				slog.String("event", delivery.Event),
+				slog.Int("attempt", delivery.Attempts),
+				slog.Any("error", sendErr),
+			)
+
+			updates["last_error"] = sendErr.Error()
+			if delivery.Attempts >= cfg.WebhookMaxAttempts {
+				updates["status"] = models.WebhookDeliveryFailed
+			} else {
+				updates["next_attempt_at"] = time.Now().Add(webhookRetryDelay(delivery.Attempts))
+			}
+		}
+
+		if err := db.Model(&models.WebhookDelivery{}).Where("id = ?", delivery.Id).Updates(updates).Error; err != nil {
+			return delivered, err
+		}
+	}
+
+	return delivered, nil
+}
+
+// claimWebhookDeliveries reclama hasta batchSize entregas vencidas: cuenta el intento y
+// mueve su próximo intento a now+lease para que otro worker no las tome mientras se envían
+func claimWebhookDeliveries(db *gorm.DB, batchSize int, lease time.Duration) ([]models.WebhookDelivery, error) {
+	var deliveries []models.WebhookDelivery
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ? AND next_attempt_at <= ?", models.WebhookDeliveryPending, now).
+			Order("next_attempt_at ASC").
+			Limit(batchSize).
+			Find(&deliveries).Error; err != nil {
+			return err
+		}
+		if len(deliveries) == 0 {
+			return nil
+		}
+
+		ids := make([]string, len(deliveries))
+		for i := range deliveries {
+			ids[i] = deliveries[i].Id
+			deliveries[i].Attempts++
+		}
+
+		return tx.Model(&models.WebhookDelivery{}).
+			Where("id IN ?", ids).
+			Updates(map[string]interface{}{
+				"attempts":        gorm.Expr("attempts + 1"),
+				"next_attempt_at": now.Add(lease),
+			}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return deliveries, nil
+}
+
+// send hace el POST firmado al endpoint de la suscripción y retorna el status HTTP
+func (s *webhookServiceImp) send(ctx context.Context, subscription *models.WebhookSubscription, delivery *models.WebhookDelivery) (int, error) {
+	// Las suscripciones creadas antes de validar las URLs también se revisan al enviar
+	if err := validateWebhookURL(subscription.URL); err != nil {
+		return 0, err
+	}
+
+	body := []byte(delivery.Payload)
+	timestamp := time.Now().Unix()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, subscription.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "go-streaming-service-webhooks")
+	req.Header.Set("X-Webhook-Id", delivery.EventID)
+	req.Header.Set("X-Webhook-Event", delivery.Event)
+	req.Header.Set("X-Webhook-Timestamp", strconv.FormatInt(timestamp, 10))
+	req.Header.Set("X-Webhook-Signature", "sha256="+SignWebhookPayload(subscription.Secret, timestamp, body))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("el endpoint respondió %d", resp.StatusCode)
+	}
+
+	return resp.StatusCode, nil
+}
@@ -0,0 +1,45 @@
+package services
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSignWebhookPayload(t *testing.T) {
+	// Vector calculado aparte con HMAC-SHA256("whsec_test", "1700000000.<body>")
+	body := []byte(`{"event":"job.completed"}`)
+	want := "51be9920773f454007b9aaf2ef84578604f287a1ad8b1cf6918458c66aac6bd8"
+
+	if got := SignWebhookPayload("whsec_test", 1700000000, body); got != want {
+		t.Errorf("expected signature %s, got %s", want, got)
+	}
+
+	// El timestamp es parte de lo firmado: otra hora da otra firma
+	if got := SignWebhookPayload("whsec_test", 1700000001, body); got == want {
+		t.Error("expected a different signature for a different timestamp")
+	}
+	if got := SignWebhookPayload("other_secret", 1700000000, body); got == want {
+		t.Error("expected a different signature for a different secret")
+	}
+}
+
+func TestWebhookRetryDelay(t *testing.T) {
+	tests := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{0, WebhookRetryBase},
+		{1, WebhookRetryBase},
+		{2, 2 * WebhookRetryBase},
+		{3, 4 * WebhookRetryBase},
+		{7, 64 * WebhookRetryBase},
+		{8, WebhookRetryMax},
+		{100, WebhookRetryMax},
+	}
+
+	for _, tt := range tests {
+		if got := webhookRetryDelay(tt.attempts); got != tt.want {
+			t.Errorf("attempts %d: expected %v, got %v", tt.attempts, tt.want, got)
+		}
+	}
+}
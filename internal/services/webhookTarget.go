@@ -0,0 +1,120 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"syscall"
+	"time"
+
+	"github.com/unbot2313/go-streaming-service/config"
+)
+
+// ErrWebhookURLNotAllowed indica que la URL del webhook apunta a un destino que no se puede usar
+var ErrWebhookURLNotAllowed = errors.New("la URL del webhook no está permitida")
+
+// blockedWebhookNetworks son rangos reservados que los métodos de net.IP no cubren
+var blockedWebhookNetworks = mustParseCIDRs(
+	"0.0.0.0/8",     // "esta red"
+	"100.64.0.0/10", // NAT de operador
+	"192.0.0.0/24",  // asignaciones del IETF
+	"198.18.0.0/15", // pruebas de rendimiento
+	"64:ff9b::/96",  // NAT64, puede traducir a direcciones IPv4 internas
+)
+
+func mustParseCIDRs(cidrs ...string) []*net.IPNet {
+	networks := make([]*net.IPNet, 0, len(cidrs))
+	for _, cidr := range cidrs {
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			panic(err)
+		}
+		networks = append(networks, network)
+	}
+	return networks
+}
+
+// isBlockedWebhookIP indica si la IP es de loopback, de una red privada, link-local
+// (incluida la metadata de la nube en 169.254.169.254) o no especificada
+func isBlockedWebhookIP(ip net.IP) bool {
+	if ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsInterfaceLocalMulticast() || ip.IsMulticast() {
+		return true
+	}
+	for _, network := range blockedWebhookNetworks {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// validateWebhookURL revisa el esquema y, si el host es una IP literal, que no sea interna.
+// Los hostnames se revisan al conectar (webhookDialControl), porque el DNS puede cambiar.
+func validateWebhookURL(rawURL string) error {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrWebhookURLNotAllowed, err)
+	}
+
+	switch parsed.Scheme {
+	case "https":
+	case "http":
+		if !config.GetConfig().WebhookAllowHTTP {
+			return fmt.Errorf("%w: solo se aceptan URLs https", ErrWebhookURLNotAllowed)
+		}
+	default:
+		return fmt.Errorf("%w: esquema %q no soportado", ErrWebhookURLNotAllowed, parsed.Scheme)
+	}
+
+	host := parsed.Hostname()
+	if host == "" || parsed.User != nil {
+		return fmt.Errorf("%w: host inválido", ErrWebhookURLNotAllowed)
+	}
+	if ip := net.ParseIP(host); ip != nil && isBlockedWebhookIP(ip) {
+		return fmt.Errorf("%w: %s es una dirección interna", ErrWebhookURLNotAllowed, host)
+	}
+
+	return nil
+}
+
+// webhookDialControl corre después de resolver el DNS y antes de conectar, así que revisa
+// la IP real del destino: un hostname que resuelve (o se re-vincula) a una IP interna no pasa
+func webhookDialControl(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+
+	ip := net.ParseIP(host)
+	if ip == nil || isBlockedWebhookIP(ip) {
+		return fmt.Errorf("%w: %s es una dirección interna", ErrWebhookURLNotAllowed, host)
+	}
+
+	return nil
+}
+
+// newWebhookClient crea el cliente HTTP de las entregas: no usa proxies, no sigue
+// redirecciones (un 3xx cuenta como fallo) y solo conecta a IPs públicas
+func newWebhookClient(timeout time.Duration) *http.Client {
+	dialer := &net.Dialer{
+		Timeout: timeout,
+		Control: webhookDialControl,
+	}
+
+	return &http.Client{
+		Timeout: timeout,
+		Transport: &http.Transport{
+			Proxy:               nil,
+			DialContext:         dialer.DialContext,
+			TLSHandshakeTimeout: timeout,
+			MaxIdleConns:        10,
+			IdleConnTimeout:     90 * time.Second,
+		},
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
@@ -0,0 +1,168 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/unbot2313/go-streaming-service/config"
+	"github.com/unbot2313/go-streaming-service/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// WorkerPruneAfter es el tiempo tras el cual un worker sin heartbeat se elimina del listado
+const WorkerPruneAfter = 24 * time.Hour
+
+// ReapResult resume lo que hizo el reaper en una pasada
+type ReapResult struct {
+	Requeued []models.JobModel
+	Failed   []models.JobModel
+}
+
+type WorkerService interface {
+	Heartbeat(worker *models.Worker) error
+	RemoveWorker(workerId string) error
+	ListWorkers() ([]models.Worker, error)
+	ReapStaleJobs() (*ReapResult, error)
+}
+
+type workerServiceImp struct{}
+
+func NewWorkerService() WorkerService {
+	return &workerServiceImp{}
+}
+
+// Heartbeat registra (o actualiza) el worker con la hora actual
+func (s *workerServiceImp) Heartbeat(worker *models.Worker) error {
+	db, err := config.GetDB()
+	if err != nil {
+		return err
+	}
+
+	worker.LastHeartbeatAt = time.Now()
+
+	return db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"hostname", "queue", "concurrency", "current_jobs", "last_heartbeat_at"}),
+	}).Create(worker).Error
+}
+
+// RemoveWorker elimina el registro del worker (apagado ordenado)
+func (s *workerServiceImp) RemoveWorker(workerId string) error {
+	db, err := config.GetDB()
+	if err != nil {
+		return err
+	}
+
+	return db.Where("id = ?", workerId).Delete(&models.Worker{}).Error
+}
+
+// ListWorkers retorna todos los workers conocidos indicando si siguen vivos
+func (s *workerServiceImp) ListWorkers() ([]models.Worker, error) {
+	db, err := config.GetDB()
+	if err != nil {
+		return nil, err
+	}
+
+	var workers []models.Worker
+	if err := db.Order("started_at ASC").Find(&workers).Error; err != nil {
+		return nil, err
+	}
+
+	deadline := time.Now().Add(-config.GetConfig().WorkerHeartbeatTTL)
+	for i := range workers {
+		workers[i].Alive = workers[i].LastHeartbeatAt.After(deadline)
+	}
+
+	return workers, nil
+}
+
+// ReapStaleJobs busca jobs en "processing" cuyo worker dejó de enviar heartbeats.
+// Si al job le quedan intentos vuelve a "pending" y se reencola por el outbox;
+// si no, se marca como "failed". Cada cambio usa un update condicional sobre
+// (status, worker_id), así varios reapers en paralelo no procesan el mismo job.
+func (s *workerServiceImp) ReapStaleJobs() (*ReapResult, error) {
+	db, err := config.GetDB()
+	if err != nil {
+		return nil, err
+	}
+
+	cfg := config.GetConfig()
+	deadline := time.Now().Add(-cfg.WorkerHeartbeatTTL)
+
+	var staleJobs []models.JobModel
+	if err := db.Model(&models.JobModel{}).
+		Joins("LEFT JOIN workers ON workers.id = jobs.worker_id").
+		Where("jobs.status = ?", "processing").
+		Where("workers.id IS NULL OR workers.last_heartbeat_at < ?", deadline).
+		Find(&staleJobs).Error; err != nil {
+		return nil, err
+	}
+
+	result := &ReapResult{}
+
+	for _, job := range staleJobs {
+		requeue := job.Attempts < cfg.WorkerMaxJobAttempts
+		errorMsg := fmt.Sprintf("worker %s dejó de responder (intento %d de %d)", job.WorkerID, job.Attempts, cfg.WorkerMaxJobAttempts)
+
+		updates := map[string]interface{}{
+			"status":        "failed",
+			"error_message": errorMsg,
+		}
+		if requeue {
+			updates["status"] = "pending"
+			updates["worker_id"] = ""
+		}
+
+		claimed := false
+		err := db.Transaction(func(tx *gorm.DB) error {
+			dbCtx := tx.Model(&models.JobModel{}).
+				Where("id = ? AND status = ? AND worker_id = ?", job.Id, "processing", job.WorkerID).
+				Updates(updates)
+			if dbCtx.Error != nil {
+				return dbCtx.Error
+			}
+
+			// Otro reaper (o el propio worker) ya cambió el job
+			if dbCtx.RowsAffected == 0 {
+				return nil
+			}
+			claimed = true
+
+			if !requeue {
+				job.Status = "failed"
+				job.ErrorMessage = errorMsg
+				return dispatchWebhookEvent(tx, job.UserID, models.WebhookEventJobFailed, jobEventData(&job))
+			}
+
+			task, err := json.Marshal(job.Task())
+			if err != nil {
+				return err
+			}
+
+			return enqueueOutboxMessage(tx, cfg.RabbitMQVideoQueue, task, job.Priority)
+		})
+		if err != nil {
+			return result, err
+		}
+
+		if !claimed {
+			continue
+		}
+
+		if requeue {
+			result.Requeued = append(result.Requeued, job)
+		} else {
+			result.Failed = append(result.Failed, job)
+		}
+	}
+
+	// Limpiar workers que llevan mucho tiempo sin reportarse
+	if err := db.Where("last_heartbeat_at < ?", time.Now().Add(-WorkerPruneAfter)).
+		Delete(&models.Worker{}).Error; err != nil {
+		return result, err
+	}
+
+	return result, nil
+}
@@ -0,0 +1,142 @@
+package worker
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/unbot2313/go-streaming-service/internal/models"
+	"github.com/unbot2313/go-streaming-service/internal/services"
+)
+
+const (
+	// ReaperInterval es cada cuánto el worker revisa jobs huérfanos de otros workers
+	ReaperInterval = 30 * time.Second
+)
+
+// heartbeat mantiene el registro de este worker en la tabla workers
+type heartbeat struct {
+	mu            sync.Mutex
+	worker        models.Worker
+	workerService services.WorkerService
+}
+
+// newHeartbeat crea el registro del worker con un id único por proceso
+func newHeartbeat(workerService services.WorkerService, queueName string, concurrency int) *heartbeat {
+	hostname, err := os.Hostname()
+	if err != nil {
+		hostname = "unknown"
+	}
+
+	return &heartbeat{
+		worker: models.Worker{
+			Id:          hostname + "-" + uuid.New().String()[:8],
+			Hostname:    hostname,
+			Queue:       queueName,
+			Concurrency: concurrency,
+			CurrentJobs: []string{},
+			StartedAt:   time.Now(),
+		},
+		workerService: workerService,
+	}
+}
+
+// ID retorna el identificador del worker
+func (h *heartbeat) ID() string {
+	return h.worker.Id
+}
+
+// StartJob agrega un job a los que están en curso y lo reporta de inmediato
+func (h *heartbeat) StartJob(jobId string) {
+	h.mu.Lock()
+	h.worker.CurrentJobs = append(h.worker.CurrentJobs, jobId)
+	h.mu.Unlock()
+
+	h.beat()
+}
+
+// FinishJob quita un job de los que están en curso y lo reporta de inmediato
+func (h *heartbeat) FinishJob(jobId string) {
+	h.mu.Lock()
+	jobs := make([]string, 0, len(h.worker.CurrentJobs))
+	for _, id := range h.worker.CurrentJobs {
+		if id != jobId {
+			jobs = append(jobs, id)
+		}
+	}
+	h.worker.CurrentJobs = jobs
+	h.mu.Unlock()
+
+	h.beat()
+}
+
+// beat envía un heartbeat con el estado actual
+func (h *heartbeat) beat() {
+	h.mu.Lock()
+	worker := h.worker
+	worker.CurrentJobs = append([]string{}, h.worker.CurrentJobs...)
+	h.mu.Unlock()
+
+	if err := h.workerService.Heartbeat(&worker); err != nil {
+		slog.Error("error sending heartbeat", slog.String("worker_id", worker.Id), slog.Any("error", err))
+	}
+}
+
+// Run envía heartbeats cada interval hasta que se cancele el contexto
+func (h *heartbeat) Run(ctx context.Context, interval time.Duration) {
+	h.beat()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			h.beat()
+		}
+	}
+}
+
+// runReaper reencola o marca como fallidos los jobs de workers sin heartbeat.
+// Si un job agota sus intentos se borra el video original del disco.
+func runReaper(ctx context.Context, workerService services.WorkerService, filesService services.FilesService) {
+	ticker := time.NewTicker(ReaperInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			result, err := workerService.ReapStaleJobs()
+			if err != nil {
+				slog.Error("error reaping stale jobs", slog.Any("error", err))
+			}
+			if result == nil {
+				continue
+			}
+
+			for _, job := range result.Requeued {
+				slog.Warn("stale job requeued",
+					slog.String("job_id", job.Id),
+					slog.String("worker_id", job.WorkerID),
+					slog.Int("attempts", job.Attempts),
+				)
+			}
+
+			for _, job := range result.Failed {
+				slog.Warn("stale job failed, max attempts reached",
+					slog.String("job_id", job.Id),
+					slog.String("worker_id", job.WorkerID),
+					slog.Int("attempts", job.Attempts),
+				)
+				filesService.RemoveFile(job.LocalPath)
+			}
+		}
+	}
+}
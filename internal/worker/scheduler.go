@@ -0,0 +1,42 @@
+package worker
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/unbot2313/go-streaming-service/internal/services"
+)
+
+const (
+	// PublishSchedulerInterval es cada cuánto se buscan videos programados que ya deben publicarse
+	PublishSchedulerInterval = 15 * time.Second
+	// PublishSchedulerBatchSize es el máximo de videos que se publican por ciclo
+	PublishSchedulerBatchSize = 50
+)
+
+// runPublishScheduler publica los videos programados cuando llega su publish_at.
+// Varios workers pueden correrlo a la vez: cada video lo publica uno solo.
+func runPublishScheduler(ctx context.Context, databaseVideoService services.DatabaseVideoService) {
+	ticker := time.NewTicker(PublishSchedulerInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			published, err := databaseVideoService.PublishDueVideos(PublishSchedulerBatchSize)
+			if err != nil {
+				slog.Error("error publishing scheduled videos", slog.Any("error", err))
+			}
+
+			for _, video := range published {
+				slog.Info("scheduled video published",
+					slog.String("video_id", video.Id),
+					slog.String("user_id", video.UserID),
+				)
+			}
+		}
+	}
+}
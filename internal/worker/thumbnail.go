@@ -0,0 +1,41 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+
+	"github.com/unbot2313/go-streaming-service/internal/models"
+)
+
+// processThumbnailTask procesa una tarea de la cola de thumbnails (WORKER_MODE=thumbnail o worker embebido)
+func (w *Worker) processThumbnailTask(ctx context.Context, message []byte) error {
+	var task models.ThumbnailTask
+	if err := json.Unmarshal(message, &task); err != nil {
+		slog.Error("error parsing thumbnail message", slog.Any("error", err))
+		return err
+	}
+
+	slog.Info("processing thumbnail task",
+		slog.String("video_id", task.VideoID),
+		slog.String("kind", task.Kind),
+	)
+
+	w.heartbeat.StartJob(task.VideoID)
+	defer w.heartbeat.FinishJob(task.VideoID)
+
+	if err := w.thumbnailService.ProcessTask(ctx, task); err != nil {
+		slog.Error("error processing thumbnail task",
+			slog.String("video_id", task.VideoID),
+			slog.String("kind", task.Kind),
+			slog.Any("error", err),
+		)
+		return err
+	}
+
+	slog.Info("thumbnail task completed",
+		slog.String("video_id", task.VideoID),
+		slog.String("kind", task.Kind),
+	)
+	return nil
+}
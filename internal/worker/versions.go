@@ -0,0 +1,32 @@
+package worker
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/unbot2313/go-streaming-service/internal/services"
+)
+
+// runVersionPurge borra periódicamente las versiones de fuentes reemplazadas
+// cuya retención venció, junto con sus renditions en el storage
+func runVersionPurge(ctx context.Context, videoVersionService services.VideoVersionService) {
+	ticker := time.NewTicker(services.VersionPurgeInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			purged, err := videoVersionService.PurgeExpired(ctx, services.VersionPurgeBatchSize)
+			if err != nil {
+				slog.Error("error purging expired video versions", slog.Any("error", err))
+			}
+
+			if purged > 0 {
+				slog.Info("expired video versions purged", slog.Int("count", purged))
+			}
+		}
+	}
+}
@@ -0,0 +1,265 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+
+	"github.com/unbot2313/go-streaming-service/internal/models"
+	"github.com/unbot2313/go-streaming-service/internal/services"
+)
+
+// failJob marca el job como fallido, salvo que el worker se esté apagando:
+// en ese caso vuelve a "pending" porque el mensaje se devuelve a la cola
+func (w *Worker) failJob(ctx context.Context, jobId string, errorMsg string) {
+	if errors.Is(ctx.Err(), context.Canceled) {
+		w.jobService.UpdateJobStatus(jobId, "pending", "")
+		return
+	}
+
+	w.jobService.UpdateJobStatus(jobId, "failed", errorMsg)
+}
+
+// formatOptions arma las opciones de transcodificación del job: el perfil de codificación
+// decide si se normaliza el volumen y se aplica la marca de agua del usuario, si tiene
+// una y el upload no pidió omitirla. Los videos cifrados generan sus claves al transcodificar.
+func (w *Worker) formatOptions(task models.VideoTask) (services.FormatOptions, error) {
+	var options services.FormatOptions
+
+	profile, err := w.encodingProfiles.GetProfile(task.EncodingProfile)
+	if err != nil {
+		return options, err
+	}
+	options.Loudnorm = profile.Loudnorm
+
+	if !task.SkipWatermark {
+		watermark, err := w.watermarkService.GetWatermark(task.UserID)
+		if err != nil && !errors.Is(err, services.ErrWatermarkNotFound) {
+			return options, err
+		}
+		options.Watermark = watermark
+	}
+
+	if task.Encrypt {
+		videoId := task.JobID
+		if task.ReplaceVideoID != "" {
+			videoId = task.ReplaceVideoID
+		}
+		encryption, err := w.hlsKeyService.Encryption(videoId)
+		if err != nil {
+			return options, err
+		}
+		options.Encryption = encryption
+	}
+
+	return options, nil
+}
+
+// cutClip genera el archivo local del clip a partir de las renditions actuales del video fuente
+func (w *Worker) cutClip(ctx context.Context, task models.VideoTask) error {
+	source, err := w.databaseVideoService.FindVideoByID(task.SourceVideoID)
+	if err != nil {
+		return err
+	}
+
+	// Si la fuente está cifrada, ffmpeg lee una copia local con las claves ya descifradas
+	sourceURL, cleanup, err := w.hlsKeyService.PrepareSource(ctx, source.Id, source.VideoUrl)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+
+	return w.videoService.CutClip(ctx, sourceURL, task.LocalPath, task.ClipStart, task.ClipEnd, task.ClipAccurate)
+}
+
+// saveAudioTracks guarda en el video las pistas de audio detectadas al transcodificar
+func (w *Worker) saveAudioTracks(ctx context.Context, videoId string, tracks []models.AudioTrack) error {
+	video, err := w.databaseVideoService.FindVideoByID(videoId)
+	if err != nil {
+		return err
+	}
+
+	return w.audioTrackService.SaveEmbeddedTracks(ctx, video, tracks)
+}
+
+// processVideoTask procesa una tarea de video recibida de la cola.
+// parent se cancela si el worker se apaga antes de que termine el procesamiento.
+func (w *Worker) processVideoTask(parent context.Context, message []byte) error {
+	// Crear contexto con timeout para todo el procesamiento
+	ctx, cancel := context.WithTimeout(parent, ProcessingTimeout)
+	defer cancel()
+
+	// 1. Parsear el mensaje JSON
+	var task models.VideoTask
+	if err := json.Unmarshal(message, &task); err != nil {
+		slog.Error("error parsing message", slog.Any("error", err))
+		return err
+	}
+
+	slog.Info("processing job",
+		slog.String("job_id", task.JobID),
+		slog.String("file", task.UniqueName),
+	)
+
+	// 2. Reclamar el job (pending -> processing) con un update condicional.
+	// RabbitMQ entrega al menos una vez: si otro worker ya lo tomó o ya se
+	// completó, se confirma el mensaje sin volver a procesarlo.
+	claimed, err := w.jobService.ClaimJob(task.JobID, w.heartbeat.ID())
+	if err != nil {
+		slog.Error("error claiming job", slog.String("job_id", task.JobID), slog.Any("error", err))
+		return err
+	}
+	if !claimed {
+		slog.Info("job already claimed or finished, skipping duplicate task", slog.String("job_id", task.JobID))
+		return nil
+	}
+	w.heartbeat.StartJob(task.JobID)
+	defer w.heartbeat.FinishJob(task.JobID)
+
+	job, err := w.jobService.GetJobByID(task.JobID)
+	if err != nil {
+		slog.Error("error loading job", slog.String("job_id", task.JobID), slog.Any("error", err))
+		w.failJob(ctx, task.JobID, "Error cargando job: "+err.Error())
+		return err
+	}
+
+	// 3 y 4. Convertir a HLS y subir a storage, salvo que un intento anterior ya lo haya hecho
+	m3u8FileURL := job.M3u8FileURL
+	loudness := job.Loudness
+	audioTracks := job.AudioTracks
+	if job.Stage != models.JobStageUploaded {
+		// Los clips no traen archivo subido: se recorta el video fuente antes de procesarlo
+		if task.SourceVideoID != "" {
+			slog.Info("cutting clip", slog.String("job_id", task.JobID), slog.String("source_video_id", task.SourceVideoID))
+			if err := w.cutClip(ctx, task); err != nil {
+				slog.Error("error cutting clip", slog.String("job_id", task.JobID), slog.Any("error", err))
+				w.failJob(ctx, task.JobID, "Error recortando el video: "+err.Error())
+				return err
+			}
+		}
+
+		options, err := w.formatOptions(task)
+		if err != nil {
+			slog.Error("error loading processing options", slog.String("job_id", task.JobID), slog.Any("error", err))
+			w.failJob(ctx, task.JobID, "Error cargando las opciones de procesamiento: "+err.Error())
+			return err
+		}
+
+		// 3. Convertir video a HLS (ffmpeg)
+		slog.Info("converting to HLS",
+			slog.String("file", task.UniqueName),
+			slog.Bool("loudnorm", options.Loudnorm),
+			slog.Bool("watermark", options.Watermark != nil),
+			slog.Bool("encrypt", options.Encryption != nil),
+		)
+		formatted, err := w.videoService.FormatVideo(ctx, task.UniqueName, options)
+		if err != nil {
+			slog.Error("error in FormatVideo", slog.String("job_id", task.JobID), slog.Any("error", err))
+			w.failJob(ctx, task.JobID, "Error convirtiendo video: "+err.Error())
+			return err
+		}
+		filesPath := formatted.FilesPath
+		defer w.filesService.RemoveFolder(filesPath) // Carpeta con .ts y .m3u8
+
+		loudness = formatted.Loudness
+		if err := w.jobService.UpdateJobLoudness(task.JobID, loudness); err != nil {
+			slog.Error("error saving loudness", slog.String("job_id", task.JobID), slog.Any("error", err))
+			w.failJob(ctx, task.JobID, "Error guardando la sonoridad: "+err.Error())
+			return err
+		}
+
+		audioTracks = formatted.AudioTracks
+		if err := w.jobService.UpdateJobAudioTracks(task.JobID, audioTracks); err != nil {
+			slog.Error("error saving audio tracks", slog.String("job_id", task.JobID), slog.Any("error", err))
+			w.failJob(ctx, task.JobID, "Error guardando las pistas de audio: "+err.Error())
+			return err
+		}
+
+		// 4. Subir a storage (S3 o MinIO según configuración)
+		slog.Info("uploading to storage", slog.String("job_id", task.JobID))
+		uploadResult, err := w.videoService.UploadFolder(ctx, filesPath)
+		if err != nil {
+			slog.Error("error uploading to storage", slog.String("job_id", task.JobID), slog.Any("error", err))
+			w.failJob(ctx, task.JobID, "Error subiendo a storage: "+err.Error())
+			return err
+		}
+		m3u8FileURL = uploadResult.M3u8FileURL
+
+		if err := w.jobService.UpdateJobStage(task.JobID, models.JobStageUploaded, m3u8FileURL); err != nil {
+			slog.Error("error updating job stage", slog.String("job_id", task.JobID), slog.Any("error", err))
+			w.failJob(ctx, task.JobID, "Error actualizando la etapa del job: "+err.Error())
+			return err
+		}
+	} else {
+		slog.Info("renditions already uploaded, skipping transcode", slog.String("job_id", task.JobID))
+	}
+
+	// 5. Guardar video en base de datos (si un intento anterior no lo guardó ya).
+	// El thumbnail y el storyboard se encolan junto con el video para el worker de thumbnails.
+	// Si el job reemplaza la fuente de un video existente, se cambian sus renditions por
+	// las nuevas en una sola transacción y las anteriores quedan como versión restaurable.
+	videoId := task.JobID
+	if task.ReplaceVideoID != "" {
+		videoId = task.ReplaceVideoID
+		slog.Info("replacing video source", slog.String("job_id", task.JobID), slog.String("video_id", videoId))
+		if err := w.videoVersionService.ReplaceSource(videoId, task.JobID, m3u8FileURL, task.Duration, loudness); err != nil {
+			slog.Error("error replacing video source", slog.String("job_id", task.JobID), slog.Any("error", err))
+			w.failJob(ctx, task.JobID, "Error reemplazando la fuente: "+err.Error())
+			w.videoService.DeleteFolder(ctx, task.JobID+"/")
+			w.jobService.UpdateJobStage(task.JobID, "", "")
+			return err
+		}
+	} else if _, err := w.databaseVideoService.FindVideoByID(task.JobID); err == nil {
+		slog.Info("video already saved, skipping", slog.String("job_id", task.JobID))
+	} else {
+		slog.Info("saving to database", slog.String("job_id", task.JobID))
+		videoData := &models.Video{
+			Id:              task.JobID, // Usamos el mismo ID del job para el video
+			Title:           task.Title,
+			Description:     task.Description,
+			Duration:        task.Duration,
+			M3u8FileURL:     m3u8FileURL,
+			PublishAt:       task.PublishAt,
+			EncodingProfile: task.EncodingProfile,
+			MediaType:       task.MediaType,
+			Loudness:        loudness,
+			SourceVideoID:   task.SourceVideoID,
+			Encrypted:       task.Encrypt,
+		}
+
+		if _, err := w.databaseVideoService.CreateVideo(videoData, task.UserID); err != nil {
+			slog.Error("error saving to database", slog.String("job_id", task.JobID), slog.Any("error", err))
+			w.failJob(ctx, task.JobID, "Error guardando en DB: "+err.Error())
+			// Borrar de storage si falla; el próximo intento vuelve a transcodificar
+			w.videoService.DeleteFolder(ctx, task.JobID+"/")
+			w.jobService.UpdateJobStage(task.JobID, "", "")
+			return err
+		}
+	}
+
+	// 6. Guardar las pistas de audio del archivo fuente; las dobladas se vuelven a muxear
+	// con las renditions nuevas si el job reemplazó la fuente
+	if len(audioTracks) > 0 {
+		if err := w.saveAudioTracks(ctx, videoId, audioTracks); err != nil {
+			slog.Error("error saving audio tracks", slog.String("job_id", task.JobID), slog.Any("error", err))
+			w.failJob(ctx, task.JobID, "Error guardando las pistas de audio: "+err.Error())
+			return err
+		}
+	}
+
+	// 7. Actualizar job a "completed"
+	if err := w.jobService.UpdateJobCompleted(task.JobID, videoId); err != nil {
+		slog.Error("error updating job to completed", slog.String("job_id", task.JobID), slog.Any("error", err))
+		// Sin esto el job queda en "processing" y el reintento lo descarta como duplicado
+		w.failJob(ctx, task.JobID, "Error completando el job: "+err.Error())
+		return err
+	}
+
+	// 8. Cleanup - Borrar el video original (la carpeta HLS se borra con el defer)
+	slog.Info("cleaning up local files", slog.String("job_id", task.JobID))
+	w.filesService.RemoveFile(task.LocalPath)
+
+	slog.Info("job completed", slog.String("job_id", task.JobID))
+	return nil
+}
@@ -0,0 +1,32 @@
+package worker
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/unbot2313/go-streaming-service/internal/services"
+)
+
+// runWebhookDelivery envía las entregas de webhook pendientes hasta que se cancele el contexto.
+// Varios workers pueden correrlo a la vez: cada entrega la toma uno solo.
+func runWebhookDelivery(ctx context.Context, webhookService services.WebhookService) {
+	ticker := time.NewTicker(services.WebhookPollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			delivered, err := webhookService.DeliverPending(ctx, services.WebhookBatchSize)
+			if err != nil {
+				slog.Error("error delivering webhooks", slog.Any("error", err))
+				continue
+			}
+			if delivered > 0 {
+				slog.Info("webhooks delivered", slog.Int("count", delivered))
+			}
+		}
+	}
+}
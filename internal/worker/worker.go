@@ -0,0 +1,176 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/unbot2313/go-streaming-service/config"
+	"github.com/unbot2313/go-streaming-service/internal/services"
+	"github.com/unbot2313/go-streaming-service/internal/services/storage"
+)
+
+const (
+	// ProcessingTimeout es el tiempo máximo para procesar un video completo
+	ProcessingTimeout = 30 * time.Minute
+
+	// ModeVideo consume la cola de transcodificación de videos
+	ModeVideo = "video"
+	// ModeThumbnail consume la cola de thumbnails y storyboards
+	ModeThumbnail = "thumbnail"
+)
+
+// Worker consume las colas de video y/o thumbnails, reporta heartbeats
+// y envía los webhooks pendientes. Lo usa el binario del consumer y,
+// en modo embebido (EMBEDDED_WORKER=true), el propio proceso de la API.
+type Worker struct {
+	queueService services.RabbitMQService
+	modes        []string
+	concurrency  int
+
+	jobService           services.JobService
+	videoService         services.VideoService
+	databaseVideoService services.DatabaseVideoService
+	filesService         services.FilesService
+	workerService        services.WorkerService
+	thumbnailService     services.ThumbnailService
+	webhookService       services.WebhookService
+	videoVersionService  services.VideoVersionService
+	encodingProfiles     services.EncodingProfileService
+	watermarkService     services.WatermarkService
+	audioTrackService    services.AudioTrackService
+	hlsKeyService        services.HLSKeyService
+
+	heartbeat      *heartbeat
+	stopBackground context.CancelFunc
+}
+
+// New crea un worker que consume de queueService las colas de los modos indicados
+func New(queueService services.RabbitMQService, modes ...string) *Worker {
+	cfg := config.GetConfig()
+
+	filesService := services.NewFilesService()
+	storageService := storage.NewStorageService()
+	ffmpegService := services.NewFFmpegService()
+	hlsKeyService := services.NewHLSKeyService()
+
+	w := &Worker{
+		queueService:         queueService,
+		modes:                modes,
+		concurrency:          cfg.WorkerConcurrency,
+		jobService:           services.NewJobService(),
+		videoService:         services.NewVideoService(storageService, filesService, ffmpegService),
+		databaseVideoService: services.NewDatabaseVideoService(),
+		filesService:         filesService,
+		workerService:        services.NewWorkerService(),
+		thumbnailService:     services.NewThumbnailService(storageService, ffmpegService, filesService, hlsKeyService),
+		webhookService:       services.NewWebhookService(),
+		videoVersionService:  services.NewVideoVersionService(storageService),
+		encodingProfiles:     services.NewEncodingProfileService(),
+		watermarkService:     services.NewWatermarkService(storageService, filesService),
+		audioTrackService:    services.NewAudioTrackService(storageService, filesService, ffmpegService, hlsKeyService),
+		hlsKeyService:        hlsKeyService,
+	}
+	w.heartbeat = newHeartbeat(w.workerService, strings.Join(w.queueNames(), ","), w.concurrency)
+
+	slog.Info("services initialized")
+	return w
+}
+
+// ID retorna el identificador con el que el worker se registra
+func (w *Worker) ID() string {
+	return w.heartbeat.ID()
+}
+
+// queueNames retorna las colas que consume el worker según sus modos
+func (w *Worker) queueNames() []string {
+	cfg := config.GetConfig()
+
+	names := make([]string, 0, len(w.modes))
+	for _, mode := range w.modes {
+		if mode == ModeThumbnail {
+			names = append(names, cfg.RabbitMQThumbnailQueue)
+		} else {
+			names = append(names, cfg.RabbitMQVideoQueue)
+		}
+	}
+	return names
+}
+
+// hasMode indica si el worker corre en el modo indicado
+func (w *Worker) hasMode(mode string) bool {
+	for _, m := range w.modes {
+		if m == mode {
+			return true
+		}
+	}
+	return false
+}
+
+// Start registra el worker, lanza las tareas de fondo y empieza a consumir las colas
+func (w *Worker) Start() error {
+	cfg := config.GetConfig()
+
+	// Las tareas de fondo siguen corriendo durante el drenado y se detienen en Stop
+	ctx, cancel := context.WithCancel(context.Background())
+	w.stopBackground = cancel
+
+	// Registrar el worker y enviar heartbeats
+	go w.heartbeat.Run(ctx, cfg.WorkerHeartbeatInterval)
+
+	// El reaper recupera jobs de video de workers caídos, el scheduler
+	// publica los videos programados y se borran las versiones vencidas
+	if w.hasMode(ModeVideo) {
+		go runReaper(ctx, w.workerService, w.filesService)
+		go runPublishScheduler(ctx, w.databaseVideoService)
+		go runVersionPurge(ctx, w.videoVersionService)
+	}
+
+	// Enviar los webhooks de los eventos de jobs y videos
+	go runWebhookDelivery(ctx, w.webhookService)
+
+	for _, mode := range w.modes {
+		queueName := cfg.RabbitMQVideoQueue
+		handler := services.MessageHandler(w.processVideoTask)
+		if mode == ModeThumbnail {
+			queueName = cfg.RabbitMQThumbnailQueue
+			handler = w.processThumbnailTask
+		}
+
+		if err := w.queueService.Consume(queueName, w.concurrency, handler); err != nil {
+			return fmt.Errorf("error consumiendo la cola %s: %w", queueName, err)
+		}
+	}
+
+	slog.Info("worker listening",
+		slog.String("queues", strings.Join(w.queueNames(), ",")),
+		slog.String("worker_id", w.ID()),
+		slog.Int("concurrency", w.concurrency),
+	)
+	return nil
+}
+
+// Stop deja de consumir y espera a los jobs en curso hasta timeout; los que no
+// terminen se devuelven a la cola para que los tome otro worker
+func (w *Worker) Stop(timeout time.Duration) {
+	slog.Info("draining in-flight jobs", slog.String("timeout", timeout.String()))
+
+	drainCtx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	if err := w.queueService.Shutdown(drainCtx); err != nil {
+		slog.Warn("drain deadline reached, unfinished jobs were returned to the queue", slog.Any("error", err))
+	}
+
+	if w.stopBackground != nil {
+		w.stopBackground()
+	}
+
+	if err := w.workerService.RemoveWorker(w.ID()); err != nil {
+		slog.Error("error removing worker", slog.String("worker_id", w.ID()), slog.Any("error", err))
+	}
+
+	slog.Info("worker stopped", slog.String("worker_id", w.ID()))
+}
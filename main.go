@@ -1,8 +1,15 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"log/slog"
 	"net/http"
+	"os"
+	"os/signal"
 	"strings"
+	"syscall"
+	"time"
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
@@ -20,6 +27,9 @@ import (
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 )
 
+// httpShutdownTimeout es lo que se espera a que terminen las requests en curso al apagar la API
+const httpShutdownTimeout = 30 * time.Second
+
 // @title Go Streaming Service API
 // @version 1.0
 // @description A streaming service API using Go and Gin framework, with Swagger documentation and ffmpeg integration.
@@ -31,6 +41,10 @@ import (
 // @in header
 // @name Authorization
 
+// @SecurityDefinitions.apikey APIKeyAuth
+// @in header
+// @name X-API-Key
+
 func main() {
 	// Configurar logger estructurado
 	logger.Setup()
@@ -43,7 +57,7 @@ func main() {
 	r.Use(cors.New(cors.Config{
 		AllowOrigins:     allowedOrigins,
 		AllowMethods:     []string{"GET", "POST", "PATCH", "DELETE", "OPTIONS"},
-		AllowHeaders:     []string{"Authorization", "Content-Type"},
+		AllowHeaders:     []string{"Authorization", "Content-Type", "X-API-Key"},
 		AllowCredentials: true,
 	}))
 
@@ -73,15 +87,22 @@ func main() {
 	// ejm: http://localhost:3003/static/index.html, se sirve /public/index.html
 	v1Group.Static("/static", "./static/temp")
 
+	// El contexto se cancela con SIGINT/SIGTERM para iniciar el apagado ordenado
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
 	// Inicializar los componentes de la aplicación
-	userController, authController, videoController, jobController, tagController, authService := app.InitializeComponents()
+	components := app.InitializeComponents(ctx)
 
 	// Configurar las rutas
-	routes.SetupRoutes(v1Group, userController, authController, videoController, jobController, tagController, authService)
+	routes.SetupRoutes(v1Group, components.Controllers, components.Services)
 	// Configurar la documentación de Swagger
 	r.GET("/docs/*any", ginSwagger.WrapHandler(swaggerfiles.Handler))
 
 
+	// Claves públicas de los tokens RS256/EdDSA, en la ruta estándar fuera de /api/v1
+	r.GET("/.well-known/jwks.json", components.Controllers.Auth.GetJWKS)
+
 	// Health check endpoints (fuera de /api/v1, sin auth ni rate limit)
 	r.GET("/health", func(c *gin.Context) {
 		c.JSON(http.StatusOK, gin.H{"status": "ok"})
@@ -105,7 +126,12 @@ func main() {
 		}
 		checks["database"] = "ok"
 
-		// Check RabbitMQ
+		// Check RabbitMQ (la cola en memoria no depende de un broker)
+		if cfg.QueueType == "memory" {
+			checks["queue"] = "memory"
+			c.JSON(http.StatusOK, gin.H{"status": "ready", "checks": checks})
+			return
+		}
 		rabbitService := services.NewRabbitMQService()
 		if err := rabbitService.Connect(); err != nil {
 			checks["rabbitmq"] = "unreachable"
@@ -118,6 +144,28 @@ func main() {
 		c.JSON(http.StatusOK, gin.H{"status": "ready", "checks": checks})
 	})
 
-	r.Run(":3003")
+	srv := &http.Server{Addr: ":3003", Handler: r}
+	go func() {
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			slog.Error("failed to start server", slog.Any("error", err))
+			os.Exit(1)
+		}
+	}()
+
+	// Esperar la señal de apagado
+	<-ctx.Done()
+	stop()
+
+	// Dejar de aceptar requests y esperar a las que están en curso
+	slog.Info("shutdown signal received")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), httpShutdownTimeout)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		slog.Warn("server shutdown deadline reached", slog.Any("error", err))
+	}
+
+	// Drenar el worker embebido y el relay del outbox antes de cerrar la cola
+	app.Shutdown(cfg.WorkerShutdownTimeout)
+	slog.Info("api stopped")
 
 }
\ No newline at end of file
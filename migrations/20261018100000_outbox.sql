@@ -0,0 +1,16 @@
+-- Create "outbox" table
+CREATE TABLE "outbox" (
+  "id" text NOT NULL,
+  "queue" character varying(100) NOT NULL,
+  "payload" bytea NOT NULL,
+  "status" character varying(20) NOT NULL DEFAULT 'pending',
+  "attempts" bigint NOT NULL DEFAULT 0,
+  "last_error" text NULL,
+  "created_at" timestamptz NULL,
+  "sent_at" timestamptz NULL,
+  PRIMARY KEY ("id")
+);
+-- Create index "idx_outbox_id" to table: "outbox"
+CREATE UNIQUE INDEX "idx_outbox_id" ON "outbox" ("id");
+-- Create index "idx_outbox_status" to table: "outbox"
+CREATE INDEX "idx_outbox_status" ON "outbox" ("status");
@@ -0,0 +1,18 @@
+-- Modify "jobs" table
+ALTER TABLE "jobs" ADD COLUMN "duration" text NULL, ADD COLUMN "worker_id" text NULL, ADD COLUMN "attempts" bigint NOT NULL DEFAULT 0;
+-- Create index "idx_jobs_worker_id" to table: "jobs"
+CREATE INDEX "idx_jobs_worker_id" ON "jobs" ("worker_id");
+-- Create "workers" table
+CREATE TABLE "workers" (
+  "id" text NOT NULL,
+  "hostname" character varying(255) NOT NULL,
+  "queue" character varying(100) NULL,
+  "current_job_id" text NULL,
+  "started_at" timestamptz NULL,
+  "last_heartbeat_at" timestamptz NULL,
+  PRIMARY KEY ("id")
+);
+-- Create index "idx_workers_id" to table: "workers"
+CREATE UNIQUE INDEX "idx_workers_id" ON "workers" ("id");
+-- Create index "idx_workers_last_heartbeat_at" to table: "workers"
+CREATE INDEX "idx_workers_last_heartbeat_at" ON "workers" ("last_heartbeat_at");
@@ -0,0 +1,2 @@
+-- Modify "workers" table
+ALTER TABLE "workers" DROP COLUMN "current_job_id", ADD COLUMN "concurrency" bigint NULL, ADD COLUMN "current_jobs" text NULL;
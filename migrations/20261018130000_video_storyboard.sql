@@ -0,0 +1,2 @@
+-- Modify "videos" table
+ALTER TABLE "videos" ADD COLUMN "storyboard_url" text NULL;
@@ -0,0 +1,41 @@
+-- Create "webhook_deliveries" table
+CREATE TABLE "webhook_deliveries" (
+  "id" text NOT NULL,
+  "subscription_id" text NOT NULL,
+  "user_id" text NOT NULL,
+  "event_id" text NOT NULL,
+  "event" character varying(50) NOT NULL,
+  "payload" text NOT NULL,
+  "status" character varying(20) NOT NULL DEFAULT 'pending',
+  "attempts" bigint NOT NULL DEFAULT 0,
+  "response_status" bigint NULL,
+  "last_error" text NULL,
+  "next_attempt_at" timestamptz NULL,
+  "delivered_at" timestamptz NULL,
+  "created_at" timestamptz NULL,
+  PRIMARY KEY ("id")
+);
+-- Create index "idx_webhook_deliveries_id" to table: "webhook_deliveries"
+CREATE UNIQUE INDEX "idx_webhook_deliveries_id" ON "webhook_deliveries" ("id");
+-- Create index "idx_webhook_deliveries_next_attempt_at" to table: "webhook_deliveries"
+CREATE INDEX "idx_webhook_deliveries_next_attempt_at" ON "webhook_deliveries" ("next_attempt_at");
+-- Create index "idx_webhook_deliveries_subscription_id" to table: "webhook_deliveries"
+CREATE INDEX "idx_webhook_deliveries_subscription_id" ON "webhook_deliveries" ("subscription_id");
+-- Create "webhook_subscriptions" table
+CREATE TABLE "webhook_subscriptions" (
+  "id" text NOT NULL,
+  "user_id" text NOT NULL,
+  "url" text NOT NULL,
+  "secret" text NOT NULL,
+  "events" text NOT NULL,
+  "created_at" timestamptz NULL,
+  "updated_at" timestamptz NULL,
+  "deleted_at" timestamptz NULL,
+  PRIMARY KEY ("id")
+);
+-- Create index "idx_webhook_subscriptions_deleted_at" to table: "webhook_subscriptions"
+CREATE INDEX "idx_webhook_subscriptions_deleted_at" ON "webhook_subscriptions" ("deleted_at");
+-- Create index "idx_webhook_subscriptions_id" to table: "webhook_subscriptions"
+CREATE UNIQUE INDEX "idx_webhook_subscriptions_id" ON "webhook_subscriptions" ("id");
+-- Create index "idx_webhook_subscriptions_user_id" to table: "webhook_subscriptions"
+CREATE INDEX "idx_webhook_subscriptions_user_id" ON "webhook_subscriptions" ("user_id");
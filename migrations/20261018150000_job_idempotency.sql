@@ -0,0 +1,4 @@
+-- Modify "jobs" table
+ALTER TABLE "jobs" ADD COLUMN "stage" character varying(20) NULL, ADD COLUMN "m3u8_file_url" text NULL, ADD COLUMN "idempotency_key" character varying(255) NULL;
+-- Create index "idx_jobs_user_idempotency_key" to table: "jobs"
+CREATE UNIQUE INDEX "idx_jobs_user_idempotency_key" ON "jobs" ("user_id", "idempotency_key") WHERE ((idempotency_key)::text <> ''::text);
@@ -0,0 +1,6 @@
+-- Modify "jobs" table
+ALTER TABLE "jobs" ADD COLUMN "priority" bigint NOT NULL DEFAULT 0;
+-- Modify "outbox" table
+ALTER TABLE "outbox" ADD COLUMN "priority" bigint NOT NULL DEFAULT 0;
+-- Modify "users" table
+ALTER TABLE "users" ADD COLUMN "plan" character varying(20) NOT NULL DEFAULT 'free';
@@ -0,0 +1,10 @@
+-- Modify "jobs" table
+ALTER TABLE "jobs" ADD COLUMN "publish_at" timestamptz NULL;
+-- Modify "videos" table
+ALTER TABLE "videos" ADD COLUMN "publish_at" timestamptz NULL, ADD COLUMN "published_at" timestamptz NULL;
+-- Create index "idx_videos_publish_at" to table: "videos"
+CREATE INDEX "idx_videos_publish_at" ON "videos" ("publish_at");
+-- Create index "idx_videos_published_at" to table: "videos"
+CREATE INDEX "idx_videos_published_at" ON "videos" ("published_at");
+-- Videos existentes quedan publicados desde su creación
+UPDATE "videos" SET "published_at" = "created_at" WHERE "published_at" IS NULL;
@@ -0,0 +1,25 @@
+-- Modify "jobs" table
+ALTER TABLE "jobs" ADD COLUMN "replace_video_id" text NULL;
+-- Create index "idx_jobs_replace_video_id" to table: "jobs"
+CREATE INDEX "idx_jobs_replace_video_id" ON "jobs" ("replace_video_id");
+-- Modify "videos" table
+ALTER TABLE "videos" ADD COLUMN "storage_folder" character varying(255) NULL;
+-- Create "video_versions" table
+CREATE TABLE "video_versions" (
+  "id" text NOT NULL,
+  "video_id" text NOT NULL,
+  "storage_folder" character varying(255) NOT NULL,
+  "video_url" text NOT NULL,
+  "duration" text NULL,
+  "thumbnail_url" text NULL,
+  "storyboard_url" text NULL,
+  "created_at" timestamptz NULL,
+  "expires_at" timestamptz NULL,
+  PRIMARY KEY ("id")
+);
+-- Create index "idx_video_versions_expires_at" to table: "video_versions"
+CREATE INDEX "idx_video_versions_expires_at" ON "video_versions" ("expires_at");
+-- Create index "idx_video_versions_id" to table: "video_versions"
+CREATE UNIQUE INDEX "idx_video_versions_id" ON "video_versions" ("id");
+-- Create index "idx_video_versions_video_id" to table: "video_versions"
+CREATE INDEX "idx_video_versions_video_id" ON "video_versions" ("video_id");
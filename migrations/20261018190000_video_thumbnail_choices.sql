@@ -0,0 +1,2 @@
+-- Modify "videos" table
+ALTER TABLE "videos" ADD COLUMN "custom_thumbnail" boolean NOT NULL DEFAULT false, ADD COLUMN "thumbnail_sizes" text NULL, ADD COLUMN "thumbnail_candidates" text NULL;
@@ -0,0 +1,17 @@
+-- Create "encoding_profiles" table
+CREATE TABLE "encoding_profiles" (
+  "name" character varying(50) NOT NULL,
+  "description" character varying(255) NULL,
+  "preview" boolean NOT NULL DEFAULT false,
+  "created_at" timestamptz NULL,
+  "updated_at" timestamptz NULL,
+  PRIMARY KEY ("name")
+);
+-- Perfil por defecto usado por los uploads que no eligen uno
+INSERT INTO "encoding_profiles" ("name", "description", "preview", "created_at", "updated_at") VALUES ('default', 'Perfil por defecto', true, now(), now());
+-- Modify "jobs" table
+ALTER TABLE "jobs" ADD COLUMN "encoding_profile" character varying(50) NULL;
+-- Modify "video_versions" table
+ALTER TABLE "video_versions" ADD COLUMN "preview_url" text NULL;
+-- Modify "videos" table
+ALTER TABLE "videos" ADD COLUMN "preview_url" text NULL, ADD COLUMN "encoding_profile" character varying(50) NULL;
@@ -0,0 +1,6 @@
+-- Modify "jobs" table
+ALTER TABLE "jobs" ADD COLUMN "media_type" character varying(10) NULL;
+-- Modify "video_versions" table
+ALTER TABLE "video_versions" ADD COLUMN "audio_file_url" text NULL, ADD COLUMN "audio_file_size" bigint NULL;
+-- Modify "videos" table
+ALTER TABLE "videos" ADD COLUMN "media_type" character varying(10) NOT NULL DEFAULT 'video', ADD COLUMN "audio_file_url" text NULL, ADD COLUMN "audio_file_size" bigint NULL;
@@ -0,0 +1,8 @@
+-- Modify "encoding_profiles" table
+ALTER TABLE "encoding_profiles" ADD COLUMN "loudnorm" boolean NOT NULL DEFAULT false;
+-- Modify "jobs" table
+ALTER TABLE "jobs" ADD COLUMN "loudness_integrated" numeric NULL, ADD COLUMN "loudness_true_peak" numeric NULL;
+-- Modify "video_versions" table
+ALTER TABLE "video_versions" ADD COLUMN "loudness_integrated" numeric NULL, ADD COLUMN "loudness_true_peak" numeric NULL;
+-- Modify "videos" table
+ALTER TABLE "videos" ADD COLUMN "loudness_integrated" numeric NULL, ADD COLUMN "loudness_true_peak" numeric NULL;
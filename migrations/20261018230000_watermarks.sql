@@ -0,0 +1,13 @@
+-- Modify "jobs" table
+ALTER TABLE "jobs" ADD COLUMN "skip_watermark" boolean NOT NULL DEFAULT false;
+-- Create "watermarks" table
+CREATE TABLE "watermarks" (
+  "user_id" text NOT NULL,
+  "image_url" text NOT NULL,
+  "position" character varying(20) NOT NULL DEFAULT 'bottom-right',
+  "opacity" numeric NOT NULL DEFAULT 1,
+  "scale" numeric NOT NULL DEFAULT 0.15,
+  "created_at" timestamptz NULL,
+  "updated_at" timestamptz NULL,
+  PRIMARY KEY ("user_id")
+);
@@ -0,0 +1,8 @@
+-- Modify "jobs" table
+ALTER TABLE "jobs" ADD COLUMN "source_video_id" text NULL, ADD COLUMN "clip_start" numeric NULL, ADD COLUMN "clip_end" numeric NULL, ADD COLUMN "clip_accurate" boolean NOT NULL DEFAULT false;
+-- Create index "idx_jobs_source_video_id" to table: "jobs"
+CREATE INDEX "idx_jobs_source_video_id" ON "jobs" ("source_video_id");
+-- Modify "videos" table
+ALTER TABLE "videos" ADD COLUMN "source_video_id" text NULL;
+-- Create index "idx_videos_source_video_id" to table: "videos"
+CREATE INDEX "idx_videos_source_video_id" ON "videos" ("source_video_id");
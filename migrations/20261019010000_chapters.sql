@@ -0,0 +1,14 @@
+-- Create "chapters" table
+CREATE TABLE "chapters" (
+  "id" text NOT NULL,
+  "video_id" text NOT NULL,
+  "start_seconds" numeric NOT NULL,
+  "title" character varying(100) NOT NULL,
+  "created_at" timestamptz NULL,
+  "updated_at" timestamptz NULL,
+  PRIMARY KEY ("id")
+);
+-- Create index "idx_chapters_id" to table: "chapters"
+CREATE UNIQUE INDEX "idx_chapters_id" ON "chapters" ("id");
+-- Create index "idx_chapters_video_start" to table: "chapters"
+CREATE UNIQUE INDEX "idx_chapters_video_start" ON "chapters" ("video_id", "start_seconds");
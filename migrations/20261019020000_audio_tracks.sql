@@ -0,0 +1,18 @@
+-- Modify "jobs" table
+ALTER TABLE "jobs" ADD COLUMN "audio_tracks" text NULL;
+-- Create "audio_tracks" table
+CREATE TABLE "audio_tracks" (
+  "id" text NOT NULL,
+  "video_id" text NOT NULL,
+  "language" character varying(35) NOT NULL,
+  "name" character varying(100) NOT NULL,
+  "source" character varying(10) NOT NULL,
+  "position" bigint NOT NULL DEFAULT 0,
+  "playlist_url" text NULL,
+  "created_at" timestamptz NULL,
+  PRIMARY KEY ("id")
+);
+-- Create index "idx_audio_tracks_id" to table: "audio_tracks"
+CREATE UNIQUE INDEX "idx_audio_tracks_id" ON "audio_tracks" ("id");
+-- Create index "idx_audio_tracks_video_id" to table: "audio_tracks"
+CREATE INDEX "idx_audio_tracks_video_id" ON "audio_tracks" ("video_id");
@@ -0,0 +1,16 @@
+-- Modify "jobs" table
+ALTER TABLE "jobs" ADD COLUMN "encrypt" boolean NOT NULL DEFAULT false;
+-- Modify "videos" table
+ALTER TABLE "videos" ADD COLUMN "encrypted" boolean NOT NULL DEFAULT false;
+-- Create "video_keys" table
+CREATE TABLE "video_keys" (
+  "id" text NOT NULL,
+  "video_id" text NOT NULL,
+  "encrypted_key" bytea NOT NULL,
+  "created_at" timestamptz NULL,
+  PRIMARY KEY ("id")
+);
+-- Create index "idx_video_keys_id" to table: "video_keys"
+CREATE UNIQUE INDEX "idx_video_keys_id" ON "video_keys" ("id");
+-- Create index "idx_video_keys_video_id" to table: "video_keys"
+CREATE INDEX "idx_video_keys_video_id" ON "video_keys" ("video_id");
@@ -0,0 +1,4 @@
+-- Modify "users" table
+ALTER TABLE "users" ADD COLUMN "role" character varying(20) NOT NULL DEFAULT 'user', ADD COLUMN "suspended_at" timestamptz NULL;
+-- Modify "videos" table
+ALTER TABLE "videos" ADD COLUMN "suspended_at" timestamptz NULL;
@@ -0,0 +1,23 @@
+-- Modify "users" table
+ALTER TABLE "users" DROP COLUMN "refresh_token";
+-- Create "sessions" table
+CREATE TABLE "sessions" (
+  "id" text NOT NULL,
+  "user_id" text NOT NULL,
+  "device_name" character varying(100) NULL,
+  "user_agent" character varying(255) NULL,
+  "ip" character varying(45) NULL,
+  "refresh_token_hash" character varying(64) NOT NULL,
+  "expires_at" timestamptz NOT NULL,
+  "last_used_at" timestamptz NULL,
+  "created_at" timestamptz NULL,
+  PRIMARY KEY ("id")
+);
+-- Create index "idx_sessions_expires_at" to table: "sessions"
+CREATE INDEX "idx_sessions_expires_at" ON "sessions" ("expires_at");
+-- Create index "idx_sessions_id" to table: "sessions"
+CREATE UNIQUE INDEX "idx_sessions_id" ON "sessions" ("id");
+-- Create index "idx_sessions_refresh_token_hash" to table: "sessions"
+CREATE UNIQUE INDEX "idx_sessions_refresh_token_hash" ON "sessions" ("refresh_token_hash");
+-- Create index "idx_sessions_user_id" to table: "sessions"
+CREATE INDEX "idx_sessions_user_id" ON "sessions" ("user_id");
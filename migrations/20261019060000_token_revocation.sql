@@ -0,0 +1,16 @@
+-- Modify "users" table
+ALTER TABLE "users" ADD COLUMN "token_version" bigint NOT NULL DEFAULT 0;
+-- Modify "sessions" table
+ALTER TABLE "sessions" ADD COLUMN "access_token_id" character varying(36) NULL;
+-- Create "revoked_tokens" table
+CREATE TABLE "revoked_tokens" (
+  "jti" character varying(36) NOT NULL,
+  "user_id" text NOT NULL,
+  "expires_at" timestamptz NOT NULL,
+  "created_at" timestamptz NULL,
+  PRIMARY KEY ("jti")
+);
+-- Create index "idx_revoked_tokens_expires_at" to table: "revoked_tokens"
+CREATE INDEX "idx_revoked_tokens_expires_at" ON "revoked_tokens" ("expires_at");
+-- Create index "idx_revoked_tokens_user_id" to table: "revoked_tokens"
+CREATE INDEX "idx_revoked_tokens_user_id" ON "revoked_tokens" ("user_id");
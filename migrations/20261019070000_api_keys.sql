@@ -0,0 +1,19 @@
+-- Create "api_keys" table
+CREATE TABLE "api_keys" (
+  "id" text NOT NULL,
+  "user_id" text NOT NULL,
+  "name" character varying(100) NOT NULL,
+  "prefix" character varying(16) NOT NULL,
+  "key_hash" character varying(64) NOT NULL,
+  "scopes" text NOT NULL,
+  "expires_at" timestamptz NOT NULL,
+  "last_used_at" timestamptz NULL,
+  "created_at" timestamptz NULL,
+  PRIMARY KEY ("id")
+);
+-- Create index "idx_api_keys_id" to table: "api_keys"
+CREATE UNIQUE INDEX "idx_api_keys_id" ON "api_keys" ("id");
+-- Create index "idx_api_keys_key_hash" to table: "api_keys"
+CREATE UNIQUE INDEX "idx_api_keys_key_hash" ON "api_keys" ("key_hash");
+-- Create index "idx_api_keys_user_id" to table: "api_keys"
+CREATE INDEX "idx_api_keys_user_id" ON "api_keys" ("user_id");
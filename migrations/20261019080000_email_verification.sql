@@ -0,0 +1,18 @@
+-- Modify "users" table
+ALTER TABLE "users" ADD COLUMN "email_verified_at" timestamptz NULL;
+-- Backfill: the accounts created before email verification count as verified
+UPDATE "users" SET "email_verified_at" = COALESCE("created_at", now()) WHERE "email_verified_at" IS NULL AND "email" IS NOT NULL;
+-- Create "email_tokens" table
+CREATE TABLE "email_tokens" (
+  "jti" character varying(36) NOT NULL,
+  "user_id" text NOT NULL,
+  "purpose" character varying(20) NOT NULL,
+  "expires_at" timestamptz NOT NULL,
+  "used_at" timestamptz NULL,
+  "created_at" timestamptz NULL,
+  PRIMARY KEY ("jti")
+);
+-- Create index "idx_email_tokens_expires_at" to table: "email_tokens"
+CREATE INDEX "idx_email_tokens_expires_at" ON "email_tokens" ("expires_at");
+-- Create index "idx_email_tokens_user_id" to table: "email_tokens"
+CREATE INDEX "idx_email_tokens_user_id" ON "email_tokens" ("user_id");